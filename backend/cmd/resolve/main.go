@@ -0,0 +1,118 @@
+// Command resolve prints the cluster/tenant names for a list of IDs, using the
+// same NameResolver as the server. IDs come from --ids or stdin, one per line.
+//
+//	resolve --ids 1379661944646413143,1372813089209061633
+//	cat ids.txt | resolve --output json
+//
+// Exits with status 1 if any ID could not be resolved.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+type result struct {
+	services.NameInfo
+	Error string `json:"error,omitempty"`
+}
+
+func main() {
+	idsFlag := flag.String("ids", "", "comma-separated IDs to resolve (default: read from stdin)")
+	output := flag.String("output", "tsv", "output format: tsv or json")
+	flag.Parse()
+
+	if *output != "tsv" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "unsupported --output %q, expected tsv or json\n", *output)
+		os.Exit(2)
+	}
+
+	_ = godotenv.Load()
+
+	ids, err := readIDs(*idsFlag, os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read IDs: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Only TiDB is needed; the SQLite database and its migrations are skipped
+	if err := db.InitTiDB(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to TiDB: %v\n", err)
+		os.Exit(2)
+	}
+
+	resolver := services.NewNameResolver(
+		services.WithLogger(slog.New(slog.NewTextHandler(os.Stderr, nil))),
+	)
+
+	failed := false
+	results := make([]result, 0, len(ids))
+	for _, id := range ids {
+		info, err := resolver.Resolve(id)
+		r := result{NameInfo: info}
+		if err != nil {
+			failed = true
+			r.Error = err.Error()
+			if !errors.Is(err, services.ErrIDNotFound) {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", id, err)
+			}
+		}
+		results = append(results, r)
+	}
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(results)
+	} else {
+		w := bufio.NewWriter(os.Stdout)
+		for _, r := range results {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.ID, r.Type, r.Name, r.TenantID, r.TenantName)
+		}
+		w.Flush()
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// readIDs returns the IDs from the --ids flag, or from r one per line if the
+// flag is empty. Blank lines and duplicates are dropped.
+func readIDs(flagValue string, r io.Reader) ([]string, error) {
+	var raw []string
+	if flagValue != "" {
+		raw = strings.Split(flagValue, ",")
+	} else {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			raw = append(raw, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]bool, len(raw))
+	ids := make([]string, 0, len(raw))
+	for _, id := range raw {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
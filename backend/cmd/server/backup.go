@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// runSubcommand runs the backup or restore subcommand named by args[0] and
+// returns its exit status, or -1 if args names no subcommand.
+//
+//	server backup [--dir ./backups]
+//	server restore [--force] ./backups/alerts_v2-20260101T030000Z.db
+func runSubcommand(args []string) int {
+	if len(args) == 0 {
+		return -1
+	}
+	switch args[0] {
+	case "backup":
+		return runBackup(args[1:])
+	case "restore":
+		return runRestore(args[1:])
+	}
+	return -1
+}
+
+// runBackup copies the live database to a timestamped file while the server
+// keeps running, and uploads it to AWS_S3_BACKUP_BUCKET if set
+func runBackup(args []string) int {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	dir := fs.String("dir", "", "directory of the backup files (default: BACKUP_DIR or ./backups)")
+	fs.Parse(args)
+
+	sqliteDB, err := gorm.Open(sqlite.Open(db.SQLitePath), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open %s: %v\n", db.SQLitePath, err)
+		return 1
+	}
+
+	svc := services.GetBackupService()
+	if *dir != "" {
+		svc.Dir = *dir
+	}
+	info, err := svc.Run(context.Background(), sqliteDB)
+	if info.Name != "" {
+		fmt.Printf("%s (%d bytes)\n", info.Name, info.SizeBytes)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backup failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runRestore replaces the database with a backup. The server must be stopped
+// first and started again afterwards by its supervisor (systemd, Kubernetes,
+// ...), since a running server keeps the replaced file open. A server
+// answering on PORT stops the restore unless --force is given, e.g. for
+// another server on the same port; a process with the database file open
+// stops it in any case (see db.Restore).
+func runRestore(args []string) int {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	force := fs.Bool("force", false, "restore even though a server answers on PORT, as long as no process has the database open")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: server restore [--force] BACKUP_FILE")
+		return 2
+	}
+
+	if !*force && serverRunning() {
+		fmt.Fprintln(os.Stderr, "the server is running: stop it before restoring, or pass --force")
+		return 1
+	}
+
+	previous, err := db.Restore(fs.Arg(0), db.SQLitePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "restore failed: %v\n", err)
+		return 1
+	}
+	if previous != "" {
+		fmt.Printf("previous database kept as %s\n", previous)
+	}
+	fmt.Printf("restored %s, start the server again to use it\n", fs.Arg(0))
+	return 0
+}
+
+// serverRunning reports whether a server answers /api/health on the local PORT
+func serverRunning() bool {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8818"
+	}
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get("http://localhost:" + port + "/api/health")
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -11,6 +15,10 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/nolouch/alerts-platform-v2/internal/api"
 	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/rbac"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+	"github.com/nolouch/alerts-platform-v2/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
@@ -21,30 +29,157 @@ func main() {
 		log.Println("✅ Loaded environment variables from .env file")
 	}
 
+	// "server backup" and "server restore" run instead of the server
+	if code := runSubcommand(os.Args[1:]); code >= 0 {
+		os.Exit(code)
+	}
+
+	// Export traces to OTEL_EXPORTER_OTLP_ENDPOINT, if set
+	shutdownTracing, err := tracing.Init(context.Background())
+	if err != nil {
+		log.Printf("⚠️  Tracing disabled: %v", err)
+		shutdownTracing = func(context.Context) error { return nil }
+	}
+
 	// Initialize Database
 	if err := db.Init(); err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
+	// Check both databases in the background; /api/health/db serves the last result
+	db.StartHealthChecks(30 * time.Second)
+
+	// Restore the name cache saved by the previous run and keep saving it
+	resolver := services.GetNameResolver()
+	if err := resolver.LoadCachedNames(db.DB); err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+	persistInterval := 10 * time.Minute
+	if v := os.Getenv("NAME_SERVICE_PERSIST_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			persistInterval = d
+		}
+	}
+	resolver.StartCachePersistence(db.DB, persistInterval)
+
+	// Parse and plan the cluster/tenant lookups once; they are prepared on
+	// first use instead if TiDB connects later
+	if db.TiDBReady() {
+		if err := resolver.PrepareStatements(context.Background()); err != nil {
+			log.Printf("⚠️  Name service prepared statements: %v", err)
+		}
+	}
+
+	// Warm the names of the noisiest (NAME_SERVICE_WARMUP_STRATEGY=frequency, the
+	// default), most recent (recency) or both (combined) alerting clusters in the background
+	warmUpStrategy := services.WarmUpFrequency
+	if v := os.Getenv("NAME_SERVICE_WARMUP_STRATEGY"); v != "" {
+		if s, err := services.ParseWarmUpStrategy(v); err == nil {
+			warmUpStrategy = s
+		} else {
+			log.Printf("⚠️  %v", err)
+		}
+	}
+	warmUpOpts := services.WarmUpOptions{Limit: 200, Window: time.Hour}
+	if v := os.Getenv("NAME_SERVICE_WARMUP_WINDOW"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			warmUpOpts.Window = d
+		}
+	}
+	go func() {
+		if err := resolver.WarmUp(warmUpStrategy, db.DB, warmUpOpts); err != nil {
+			log.Printf("⚠️  Name service warm-up: %v", err)
+		}
+	}()
+
+	// Load custom alert label validators and normalization rules before serving
+	// /api/alerts/validate
+	services.GetLabelSchema()
+	services.GetLabelNormalizer()
+
+	// Cancelled on shutdown to stop background jobs
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Pick up clusters/tenants renamed upstream
+	resolver.StartNameChangeWatcher(bgCtx, db.DB, 15*time.Minute)
+
+	// Evict clusters whose metadata changed in TiDB every CLUSTER_CHANGE_POLL_INTERVAL (default: 30s)
+	services.GetClusterChangePoller().Start(bgCtx)
+
+	// Copy clusters from TiDB into clusters_local every CLUSTER_SYNC_INTERVAL
+	// (default: 1h), and serve them from there while TiDB is down
+	services.GetClusterSyncer().Start(bgCtx, db.DB)
+
+	// Keep the provider/region -> cluster index used by the alert list fresh
+	services.GetClusterLocationIndex().Start(bgCtx, db.DB, 5*time.Minute)
+
+	// Purge soft-deleted alerts once they are older than ALERT_RETENTION_DAYS (default: 30)
+	retentionDays := 30
+	if v := os.Getenv("ALERT_RETENTION_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			retentionDays = n
+		}
+	}
+	services.StartAlertRetention(bgCtx, db.DB, time.Duration(retentionDays)*24*time.Hour, time.Hour)
+
+	// Auto-resolve alerts still open after STALE_ALERT_AFTER (default: 24h)
+	services.GetStaleAlertReaper().Start(bgCtx, db.DB)
+
+	// Escalate alerts left unacknowledged past their escalation policy's window
+	services.GetEscalationWorker().Start(bgCtx, db.DB)
+
+	// Back up the alerts database on BACKUP_CRON (default: nightly at 03:00)
+	if err := services.GetBackupService().Start(bgCtx, db.DB); err != nil {
+		log.Printf("⚠️  Scheduled backups disabled: %v", err)
+	}
+
+	// Forget the flap history of fingerprints that have been stable for FLAP_STABLE_AFTER
+	services.GetFlapDetector().Start(bgCtx)
+
+	// Raise a system alert when most name lookups miss over NAME_SERVICE_MISS_RATE_WINDOW
+	missRate := services.NewMissRateMonitorFromEnv(resolver)
+	missRate.OnHighMissRate(missRate.HighMissRateAlert(db.DB))
+	missRate.Start(bgCtx)
+
+	// Optionally re-warm them on a schedule, e.g. NAME_SERVICE_PRELOAD_CRON="*/30 * * * *"
+	if spec := os.Getenv("NAME_SERVICE_PRELOAD_CRON"); spec != "" {
+		if err := resolver.StartPreloadCron(bgCtx, db.DB, spec, warmUpStrategy, warmUpOpts); err != nil {
+			log.Printf("⚠️  Name service preload cron: %v", err)
+		}
+	}
+
 	r := gin.Default()
 
 	// CORS Configuration (Allow Frontend)
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Allow all for dev simplicity (ports change)
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Invalidation-Token", "X-Admin-Token", "traceparent", "tracestate"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
+	r.Use(api.GzipMiddleware())
+	r.Use(tracing.Middleware())
+	// 413 for bodies over MAX_REQUEST_BODY_BYTES, 504 for requests past their deadline
+	r.Use(api.BodyLimit())
+	r.Use(api.RequestTimeout())
 
 	// API Routes
-	v1 := r.Group("/api")
+	v1 := r.Group("/api", api.AuditMiddleware())
 	{
 		v1.GET("/health", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+			c.JSON(http.StatusOK, gin.H{"status": "ok", "tidb": db.TiDBReady()})
+		})
+		v1.GET("/health/db", func(c *gin.Context) {
+			c.JSON(http.StatusOK, db.LastHealthStatus())
 		})
 
+		v1.POST("/auth/token", api.IssueAuthToken)
+		v1.GET("/graphql", api.Authenticate(), api.GraphQL)
+		v1.POST("/graphql", api.Authenticate(), api.GraphQL)
+
 		// Components Endpoints
 		v1.GET("/categories", api.GetCategories)
 		v1.GET("/components", api.GetComponents)
@@ -53,9 +188,32 @@ func main() {
 		v1.PUT("/components/:name/rules", api.UpdateComponentRule)
 
 		// New Dashboard Route
-		v1.GET("/dashboard", api.GetDashboardData)
-		v1.GET("/dashboard/issues", api.GetDashboardIssues)
-		v1.POST("/issues/:id/mute", api.MuteIssue)
+		v1.GET("/dashboard", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.GetDashboardData)
+		v1.GET("/dashboard/issues", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.GetDashboardIssues)
+		v1.GET("/user/dashboard-config", api.Authenticate(), api.GetDashboardConfig)
+		v1.PUT("/user/dashboard-config", api.Authenticate(), api.PutDashboardConfig)
+		v1.POST("/issues/:id/mute", api.Authenticate(), api.Authorize(rbac.ActionAlertWrite), api.MuteIssue)
+		v1.GET("/clusters", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.GetClusters)
+		v1.GET("/alerts/summary", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.GetAlertSummary)
+		v1.GET("/heatmap", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.GetClusterHeatmap)
+		v1.GET("/alerts/search", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.SearchAlerts)
+		v1.GET("/alerts/trend", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.GetAlertTrend)
+		v1.GET("/alerts/top-noisy", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.GetTopNoisyAlerts)
+		v1.GET("/alerts/grouped", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.GetGroupedAlerts)
+		v1.GET("/alerts/export", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.ExportAlerts)
+		v1.GET("/alerts/stream", api.Authenticate(), api.StreamAlertsSSE)
+		v1.POST("/alerts/validate", api.AlertRateLimit(), api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.ValidateAlertLabels)
+		v1.POST("/alerts/diff", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.DiffAlerts)
+		v1.POST("/alerts/proto", api.AlertRateLimit(), api.Authenticate(), api.Authorize(rbac.ActionAlertWrite), api.IngestRemoteWrite)
+		v1.GET("/alerts/:id", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.GetAlert)
+		v1.DELETE("/alerts/:id", api.Authenticate(), api.Authorize(rbac.ActionAlertWrite), api.DeleteAlert)
+		v1.POST("/alerts/:id/ack", api.Authenticate(), api.Authorize(rbac.ActionAlertWrite), api.AckAlert)
+		v1.DELETE("/alerts/:id/ack", api.Authenticate(), api.Authorize(rbac.ActionAlertWrite), api.UnackAlert)
+		v1.GET("/alerts/:id/ack-history", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.GetAlertAckHistory)
+		v1.GET("/alerts/:id/notes", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.GetAlertNotes)
+		v1.POST("/alerts/:id/notes", api.Authenticate(), api.Authorize(rbac.ActionAlertWrite), api.CreateAlertNote)
+		v1.PATCH("/alerts/:id/notes/:note_id", api.Authenticate(), api.Authorize(rbac.ActionAlertWrite), api.UpdateAlertNote)
+		v1.DELETE("/alerts/:id/notes/:note_id", api.Authenticate(), api.Authorize(rbac.ActionAlertWrite), api.DeleteAlertNote)
 		// New Rules Notify Manager Routes
 		v1.GET("/rules-notify-manager", api.GetRulesNotifyConfig)
 		v1.PUT("/rules-notify-manager", api.UpdateRulesNotifyConfig)
@@ -64,8 +222,76 @@ func main() {
 
 		v1.GET("/tasks", api.HandleGetTasks)
 		v1.POST("/tasks", api.HandleCreateTask)
+
+		// Tenant plan aware notification routing
+		v1.GET("/routing-rules", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetRoutingRules)
+		v1.POST("/routing-rules", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.CreateRoutingRule)
+		v1.PUT("/routing-rules/:id", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.UpdateRoutingRule)
+		v1.DELETE("/routing-rules/:id", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.DeleteRoutingRule)
+
+		// Silences
+		v1.GET("/silences", api.Authenticate(), api.Authorize(rbac.ActionAlertRead), api.GetSilences)
+		v1.POST("/silences", api.Authenticate(), api.Authorize(rbac.ActionSilenceWrite), api.CreateSilence)
+		v1.DELETE("/silences/:id", api.Authenticate(), api.Authorize(rbac.ActionSilenceWrite), api.ExpireSilence)
+
+		// Webhook notification channels
+		v1.GET("/notification-channels", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetNotificationChannels)
+		v1.POST("/notification-channels", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.CreateNotificationChannel)
+		v1.PUT("/notification-channels/:id", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.UpdateNotificationChannel)
+		v1.DELETE("/notification-channels/:id", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.DeleteNotificationChannel)
+		v1.GET("/escalation-policies", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetEscalationPolicies)
+		v1.POST("/escalation-policies", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.CreateEscalationPolicy)
+		v1.DELETE("/escalation-policies/:id", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.DeleteEscalationPolicy)
+		v1.POST("/notifications/test", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.TestNotification)
+		v1.POST("/notifications/test-email", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.TestEmailNotification)
+
+		// Name service cache management (lifecycle webhooks, blue-green snapshots)
+		v1.POST("/cache/invalidate", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.InvalidateNameCache)
+		v1.GET("/cache/snapshot", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.ExportNameCacheSnapshot)
+		v1.POST("/cache/snapshot", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.ImportNameCacheSnapshot)
+		v1.POST("/admin/import/clusters", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.ImportClusterMetadata)
+		v1.POST("/admin/import/tenants", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.ImportTenantMetadata)
+		v1.GET("/admin/cache-consistency", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.CheckNameCacheConsistency)
+		v1.GET("/admin/resolved-names", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetResolvedNames)
+		v1.GET("/admin/name-resolver/config", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetNameResolverConfig)
+		v1.POST("/admin/name-resolver/config", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.UpdateNameResolverConfig)
+		v1.GET("/admin/stale-reaper/status", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetStaleReaperStatus)
+		v1.GET("/admin/sync/status", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetClusterSyncStatus)
+		v1.GET("/admin/quotas", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetQuotas)
+		v1.POST("/admin/quotas", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.SetQuota)
+		v1.DELETE("/admin/quotas/:cluster_id", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.DeleteQuota)
+		v1.GET("/admin/quotas/:cluster_id/usage", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetQuotaUsage)
+		v1.GET("/admin/fingerprint-config", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetFingerprintConfig)
+		v1.POST("/admin/fingerprint-config", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.UpdateFingerprintConfig)
+		v1.POST("/admin/reload-config", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.ReloadConfig)
+		v1.GET("/admin/version-filters", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetVersionFilters)
+		v1.GET("/admin/rbac/bindings", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetRoleBindings)
+		v1.POST("/admin/rbac/bindings", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.CreateRoleBinding)
+		v1.DELETE("/admin/rbac/bindings/:user_id/:role", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.DeleteRoleBinding)
+		v1.GET("/admin/audit-log", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetAuditLog)
+		v1.GET("/admin/backups", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetBackups)
+		v1.GET("/name-changes", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.GetNameChanges)
+		v1.POST("/name-changes/evict", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.EvictNameChanges)
+
+		// Operator debugging aids, off unless ENABLE_DEBUG_ENDPOINTS=true
+		if os.Getenv("ENABLE_DEBUG_ENDPOINTS") == "true" {
+			log.Println("⚠️  Debug endpoints enabled")
+			v1.GET("/debug/query", api.Authenticate(), api.Authorize(rbac.ActionAdmin), api.DebugAlertQuery)
+		}
 	}
 
+	// Live alert events over WebSocket
+	r.GET("/ws/alerts", api.Authenticate(), api.StreamAlerts)
+
+	// Single sign-on for the dashboard (OIDC_* settings)
+	r.GET("/auth/login", api.OIDCLogin)
+	r.GET("/auth/callback", api.OIDCCallback)
+	r.GET("/auth/logout", api.OIDCLogout)
+
+	// Name service metrics (Prometheus format)
+	r.GET("/metrics/name-service", gin.WrapH(promhttp.HandlerFor(services.NameServiceRegistry(), promhttp.HandlerOpts{})))
+	r.GET("/metrics", api.GetMetrics)
+
 	// Serve Frontend Static Files (for production/release)
 	// Only serves if "public" directory exists (created by release process)
 	if _, err := os.Stat("./public"); err == nil {
@@ -96,6 +322,54 @@ func main() {
 	host := os.Getenv("HOST")
 	addr := host + ":" + port
 
-	log.Printf("Server running on %s", addr)
-	r.Run(addr)
+	// Bound how long a client may take to send its request (default: 60s)
+	readTimeout := 60 * time.Second
+	if v := os.Getenv("HTTP_READ_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			readTimeout = d
+		}
+	}
+	srv := &http.Server{Addr: addr, Handler: r, ReadHeaderTimeout: 10 * time.Second, ReadTimeout: readTimeout}
+	go func() {
+		log.Printf("Server running on %s", addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server failed:", err)
+		}
+	}()
+
+	// Wait for SIGINT/SIGTERM, then drain requests and close the databases
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down server...")
+	stopBackground()
+	// Hijacked WebSocket connections are not tracked by srv.Shutdown
+	services.GetAlertBroadcaster().Close()
+
+	shutdownTimeout := 15 * time.Second
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			shutdownTimeout = d
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("⚠️  Server shutdown: %v", err)
+	}
+	if err := resolver.PersistCache(db.DB); err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+	if smtpNotifier := services.GetSMTPNotifier(); smtpNotifier != nil {
+		smtpNotifier.Close()
+	}
+	resolver.ClosePreparedStatements()
+	if err := db.Shutdown(ctx); err != nil {
+		log.Printf("⚠️  Database shutdown: %v", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("⚠️  Tracing shutdown: %v", err)
+	}
+	log.Println("Server stopped")
 }
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// exportChunkSize is the amount of output buffered before it is flushed to the client
+const exportChunkSize = 1 << 20
+
+// exportFields maps the columns available to ExportAlerts to their values.
+// Cluster and tenant names are resolved from the name cache.
+var exportFields = map[string]func(issue *models.Issue, names *exportNames) string{
+	"id":               func(i *models.Issue, _ *exportNames) string { return i.ID },
+	"title":            func(i *models.Issue, _ *exportNames) string { return i.Title },
+	"created":          func(i *models.Issue, _ *exportNames) string { return i.Created },
+	"priority":         func(i *models.Issue, _ *exportNames) string { return i.Priority },
+	"status":           func(i *models.Issue, _ *exportNames) string { return i.Status },
+	"alert_signature":  func(i *models.Issue, _ *exportNames) string { return i.AlertSignature },
+	"cluster_id":       func(i *models.Issue, _ *exportNames) string { return i.ClusterID },
+	"cluster_name":     func(i *models.Issue, n *exportNames) string { return n.name(i.ClusterID) },
+	"tenant_id":        func(i *models.Issue, _ *exportNames) string { return i.TenantID },
+	"tenant_name":      func(i *models.Issue, n *exportNames) string { return n.name(i.TenantID) },
+	"biz_type":         func(i *models.Issue, _ *exportNames) string { return i.BizType },
+	"component":        func(i *models.Issue, _ *exportNames) string { return i.ComponentName },
+	"source_component": func(i *models.Issue, _ *exportNames) string { return i.SourceComponent },
+	"alert_group":      func(i *models.Issue, _ *exportNames) string { return i.AlertGroup },
+	"labels":           func(i *models.Issue, _ *exportNames) string { return i.Labels },
+	"description":      func(i *models.Issue, _ *exportNames) string { return i.Description },
+	"dedup_key":        func(i *models.Issue, _ *exportNames) string { return i.DedupKey },
+}
+
+// defaultExportFields are exported when no fields parameter is given
+var defaultExportFields = []string{
+	"id", "created", "priority", "status", "title", "alert_signature",
+	"cluster_id", "cluster_name", "tenant_id", "tenant_name", "biz_type", "component",
+}
+
+// exportNames memoizes name lookups for one export
+type exportNames struct {
+	ctx      context.Context
+	resolver services.NameService
+	names    map[string]string
+}
+
+func (n *exportNames) name(id string) string {
+	if id == "" {
+		return ""
+	}
+	if name, ok := n.names[id]; ok {
+		return name
+	}
+	name := ""
+	if info, err := n.resolver.ResolveContext(n.ctx, id); err == nil && info.Name != id {
+		name = info.Name
+	}
+	n.names[id] = name
+	return name
+}
+
+// parseExportTime accepts RFC 3339, "2006-01-02 15:04:05" or "2006-01-02" (UTC)
+func parseExportTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// ExportAlerts streams alerts created between since (default: 30 days ago) and
+// until (default: now) as CSV with a header row or as NDJSON
+// (?format=ndjson). ?fields=id,created,cluster_name selects the columns.
+func ExportAlerts(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "ndjson" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or ndjson"})
+		return
+	}
+
+	fields := defaultExportFields
+	if f := c.Query("fields"); f != "" {
+		fields = strings.Split(f, ",")
+		for i, field := range fields {
+			fields[i] = strings.TrimSpace(field)
+			if _, ok := exportFields[fields[i]]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown field %q", fields[i])})
+				return
+			}
+		}
+	}
+
+	until := time.Now().UTC()
+	since := until.AddDate(0, 0, -30)
+	var err error
+	if s := c.Query("since"); s != "" {
+		if since, err = parseExportTime(s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if s := c.Query("until"); s != "" {
+		if until, err = parseExportTime(s); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	rows, err := requestDB(c).Model(&models.Issue{}).Scopes(tenantScope(c)).
+		Where("is_alert = 1 AND deleted_at IS NULL AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?",
+			since.Format("2006-01-02 15:04:05"), until.Format("2006-01-02 15:04:05")).
+		Order("created ASC").
+		Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to query alerts"})
+		return
+	}
+	defer rows.Close()
+
+	contentType := "text/csv; charset=utf-8"
+	if format == "ndjson" {
+		contentType = "application/x-ndjson"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="alerts-%s.%s"`, time.Now().UTC().Format("20060102-150405"), format))
+	c.Status(http.StatusOK)
+
+	// Rows are written to the client in chunks as the buffer fills up
+	out := bufio.NewWriterSize(c.Writer, exportChunkSize)
+	names := &exportNames{ctx: c.Request.Context(), resolver: services.GetNameService(), names: make(map[string]string)}
+
+	var csvWriter *csv.Writer
+	var encoder *json.Encoder
+	if format == "csv" {
+		csvWriter = csv.NewWriter(out)
+		csvWriter.Write(fields)
+	} else {
+		encoder = json.NewEncoder(out)
+	}
+
+	record := make([]string, len(fields))
+	for rows.Next() {
+		var issue models.Issue
+		if err := db.DB.ScanRows(rows, &issue); err != nil {
+			break
+		}
+
+		if csvWriter != nil {
+			for i, field := range fields {
+				record[i] = exportFields[field](&issue, names)
+			}
+			csvWriter.Write(record)
+		} else {
+			obj := make(map[string]string, len(fields))
+			for _, field := range fields {
+				obj[field] = exportFields[field](&issue, names)
+			}
+			if err := encoder.Encode(obj); err != nil {
+				break
+			}
+		}
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+	}
+	out.Flush()
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// GetGroupedAlerts returns the page of alerts selected by the dashboard issue
+// filters (see GetDashboardIssues), grouped by the comma-separated labels of
+// group_by (default: cluster_id,alertname)
+func GetGroupedAlerts(c *gin.Context) {
+	groupBy, err := services.ParseGroupBy(c.DefaultQuery("group_by", "cluster_id,alertname"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alerts, ok := dashboardIssues(c)
+	if !ok {
+		return
+	}
+
+	groups := services.NewGroupingService(requestDB(c)).Group(alerts, groupBy)
+	c.JSON(http.StatusOK, gin.H{
+		"group_by": groupBy,
+		"total":    len(alerts),
+		"groups":   groups,
+	})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetGroupedAlerts(t *testing.T) {
+	openTestDB(t)
+	useFakeNames(t)
+	seedIssue(t, "A-1", "2001", "1001", time.Hour)
+	seedIssue(t, "A-2", "2002", "1001", 2*time.Hour)
+	seedIssue(t, "A-3", "2001", "1001", 3*time.Hour)
+
+	r := gin.New()
+	r.GET("/api/alerts/grouped", GetGroupedAlerts)
+
+	var resp struct {
+		GroupBy []string `json:"group_by"`
+		Total   int      `json:"total"`
+		Groups  []struct {
+			Key    string            `json:"key"`
+			Labels map[string]string `json:"labels"`
+			Count  int               `json:"count"`
+		} `json:"groups"`
+	}
+	decodeJSON(t, mustServe(t, r, http.MethodGet, "/api/alerts/grouped", "", ""), &resp)
+	if len(resp.GroupBy) != 2 || resp.Total != 3 || len(resp.Groups) != 2 {
+		t.Fatalf("default grouping = %+v, want cluster_id,alertname with 2 groups of 3 alerts", resp)
+	}
+	if g := resp.Groups[0]; g.Key != "cluster_id=2001,alertname=[PROD] TiKV down" || g.Count != 2 || g.Labels["cluster_id"] != "2001" {
+		t.Errorf("largest group = %+v", g)
+	}
+
+	resp.Groups = nil
+	decodeJSON(t, mustServe(t, r, http.MethodGet, "/api/alerts/grouped?group_by=tenant_id", "", ""), &resp)
+	if len(resp.Groups) != 1 || resp.Groups[0].Count != 3 {
+		t.Errorf("by tenant_id = %+v, want one group of 3", resp.Groups)
+	}
+
+	if w := serve(r, http.MethodGet, "/api/alerts/grouped?group_by=,", "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("empty group_by: %d, want 400", w.Code)
+	}
+}
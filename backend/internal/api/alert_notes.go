@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// maxNoteLength bounds the Markdown body of a note, in bytes
+const maxNoteLength = 64 * 1024
+
+// AlertNoteRequest is the body of the note endpoints. Body is Markdown.
+type AlertNoteRequest struct {
+	Author string `json:"author"`
+	Body   string `json:"body" binding:"required"`
+}
+
+func (r *AlertNoteRequest) validate() error {
+	if strings.TrimSpace(r.Body) == "" {
+		return errors.New("body must not be empty")
+	}
+	if len(r.Body) > maxNoteLength {
+		return errors.New("body is too long")
+	}
+	return nil
+}
+
+// GetAlertNotes returns the notes of an alert, oldest first
+func GetAlertNotes(c *gin.Context) {
+	if !checkAlertTenant(c) {
+		return
+	}
+	var notes []models.AlertNote
+	if err := requestDB(c).Where("alert_id = ?", c.Param("id")).Order("note_id ASC").Find(&notes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load notes"})
+		return
+	}
+	c.JSON(http.StatusOK, notes)
+}
+
+// CreateAlertNote attaches a note to an alert
+func CreateAlertNote(c *gin.Context) {
+	var req AlertNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var issue models.Issue
+	if err := requestDB(c).Scopes(tenantScope(c)).Where("id = ? AND is_alert = 1 AND deleted_at IS NULL", c.Param("id")).First(&issue).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "alert not found"})
+		return
+	}
+
+	note := models.AlertNote{AlertID: issue.ID, Author: req.Author, Body: req.Body}
+	if err := requestDB(c).Create(&note).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create note"})
+		return
+	}
+	c.JSON(http.StatusCreated, note)
+}
+
+// UpdateAlertNote replaces the body of a note. The author is kept unless a new
+// one is given.
+func UpdateAlertNote(c *gin.Context) {
+	var req AlertNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !checkAlertTenant(c) {
+		return
+	}
+
+	var note models.AlertNote
+	if err := requestDB(c).Where("note_id = ? AND alert_id = ?", c.Param("note_id"), c.Param("id")).First(&note).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "note not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load note"})
+		return
+	}
+
+	note.Body = req.Body
+	if req.Author != "" {
+		note.Author = req.Author
+	}
+	if err := requestDB(c).Save(&note).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update note"})
+		return
+	}
+	c.JSON(http.StatusOK, note)
+}
+
+// DeleteAlertNote removes a note
+func DeleteAlertNote(c *gin.Context) {
+	if !checkAlertTenant(c) {
+		return
+	}
+	result := requestDB(c).Where("note_id = ? AND alert_id = ?", c.Param("note_id"), c.Param("id")).Delete(&models.AlertNote{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete note"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "note not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
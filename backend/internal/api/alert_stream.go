@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+const (
+	streamBufferSize = 64               // events buffered per connection before they are dropped
+	streamWriteWait  = 10 * time.Second // deadline for each write to the client
+	streamPongWait   = 60 * time.Second // the client must answer pings within this
+	streamPingPeriod = streamPongWait * 9 / 10
+)
+
+// sseHeartbeat keeps idle event streams open through load balancers
+var sseHeartbeat = 30 * time.Second
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	// Same policy as the CORS config: any origin
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// alertStreamFilter prunes events per connection. Empty fields match everything.
+type alertStreamFilter struct {
+	severities []string // lowercased
+	types      []string
+	clusterID  string
+	tenantID   string
+}
+
+func newAlertStreamFilter(c *gin.Context) alertStreamFilter {
+	split := func(v string) []string {
+		var out []string
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+
+	f := alertStreamFilter{
+		severities: split(c.Query("severity")),
+		types:      split(c.Query("type")),
+		clusterID:  c.Query("cluster_id"),
+		tenantID:   c.Query("tenant_id"),
+	}
+	// Tenant-scoped tokens only see their own tenant's alerts
+	if tenant := scopedTenant(c); tenant != "" {
+		f.tenantID = tenant
+	}
+	return f
+}
+
+func (f alertStreamFilter) match(e services.AlertEvent) bool {
+	if len(f.severities) > 0 && !containsFold(f.severities, e.Alert.Severity) {
+		return false
+	}
+	if len(f.types) > 0 && !containsFold(f.types, e.Type) {
+		return false
+	}
+	if f.clusterID != "" && e.Alert.ClusterID != f.clusterID {
+		return false
+	}
+	if f.tenantID != "" && e.Alert.TenantID != f.tenantID {
+		return false
+	}
+	return true
+}
+
+func containsFold(values []string, v string) bool {
+	for _, s := range values {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// StreamAlerts upgrades to a WebSocket and pushes a JSON AlertEvent message for
+// every new, resolved, silenced or (un)acknowledged alert matching the
+// severity, type, cluster_id and tenant_id query parameters
+func StreamAlerts(c *gin.Context) {
+	filter := newAlertStreamFilter(c)
+
+	conn, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already written the error response
+		return
+	}
+	defer conn.Close()
+
+	events, unsubscribe := services.GetAlertBroadcaster().Subscribe(streamBufferSize)
+	defer unsubscribe()
+
+	// The read loop handles pongs and notices when the client goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(streamPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(streamPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(streamPingPeriod)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait)); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				// The broadcaster was closed on shutdown
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+					time.Now().Add(streamWriteWait))
+				return
+			}
+			if !filter.match(event) {
+				continue
+			}
+			conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			if err := conn.WriteJSON(event); err != nil {
+				log.Printf("[WARN] Failed to write alert event to %s: %v\n", c.ClientIP(), err)
+				return
+			}
+		}
+	}
+}
+
+// StreamAlertsSSE is the Server-Sent Events alternative to StreamAlerts for
+// clients that cannot open WebSockets. Each matching event is sent as a
+// "data: <json>" message, with an "event: ping" heartbeat every 30 seconds.
+func StreamAlertsSSE(c *gin.Context) {
+	filter := newAlertStreamFilter(c)
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "streaming not supported"})
+		return
+	}
+
+	events, unsubscribe := services.GetAlertBroadcaster().Subscribe(streamBufferSize)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable nginx response buffering
+	c.Status(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, "event: ping\ndata: {}\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if !filter.match(event) {
+				continue
+			}
+			payload, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", payload); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
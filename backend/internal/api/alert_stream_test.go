@@ -0,0 +1,119 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// openEventStream connects to the SSE stream at path and waits until it has
+// subscribed to the broadcaster
+func openEventStream(t *testing.T, path string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	r := gin.New()
+	r.GET("/api/v1/alerts/stream", StreamAlertsSSE)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	broadcaster := services.GetAlertBroadcaster()
+	before := broadcaster.Subscribers()
+	resp, err := http.Get(srv.URL + path)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	waitFor(t, "the stream to subscribe", func() bool { return broadcaster.Subscribers() > before })
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// nextEvent reads the next event and returns its event name ("" for plain
+// data messages) and data
+func nextEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var name, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return name, data
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamAlertsSSE(t *testing.T) {
+	broadcaster := services.GetAlertBroadcaster()
+	before := broadcaster.Subscribers()
+	resp, events := openEventStream(t, "/api/v1/alerts/stream?severity=critical,major")
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Cache-Control = %q", cc)
+	}
+
+	broadcaster.Publish(services.AlertEventCreated, services.Alert{ID: "A-1", Severity: "Critical"})
+	broadcaster.Publish(services.AlertEventCreated, services.Alert{ID: "A-2", Severity: "Warning"})
+	broadcaster.Publish(services.AlertEventAcknowledged, services.Alert{ID: "A-1", Severity: "Critical"})
+	broadcaster.Publish(services.AlertEventResolved, services.Alert{ID: "A-3", Severity: "Major"})
+
+	// The Warning alert is filtered out
+	want := []struct{ typ, id string }{
+		{services.AlertEventCreated, "A-1"},
+		{services.AlertEventAcknowledged, "A-1"},
+		{services.AlertEventResolved, "A-3"},
+	}
+	for i, w := range want {
+		name, data := nextEvent(t, events)
+		var event services.AlertEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("event %d %q: %v", i, data, err)
+		}
+		if name != "" || event.Type != w.typ || event.Alert.ID != w.id {
+			t.Errorf("event %d = %q %+v, want %s of %s", i, name, event, w.typ, w.id)
+		}
+	}
+
+	// Disconnecting unsubscribes the stream
+	resp.Body.Close()
+	waitFor(t, "the stream to unsubscribe", func() bool { return broadcaster.Subscribers() == before })
+}
+
+func TestStreamAlertsSSEHeartbeat(t *testing.T) {
+	prev := sseHeartbeat
+	sseHeartbeat = 20 * time.Millisecond
+	t.Cleanup(func() { sseHeartbeat = prev })
+
+	_, events := openEventStream(t, "/api/v1/alerts/stream")
+	for i := 0; i < 2; i++ {
+		if name, data := nextEvent(t, events); name != "ping" || data != "{}" {
+			t.Errorf("heartbeat %d = %q %q, want ping", i, name, data)
+		}
+	}
+}
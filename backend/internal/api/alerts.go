@@ -0,0 +1,362 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// DeleteAlert soft-deletes an alert so it can be recovered until the retention
+// job purges it. With ?permanent=true the row is removed immediately, which
+// requires the admin token.
+func DeleteAlert(c *gin.Context) {
+	id := c.Param("id")
+
+	if c.Query("permanent") == "true" {
+		if !checkAdminToken(c) {
+			return
+		}
+		result := requestDB(c).Scopes(tenantScope(c)).Where("id = ? AND is_alert = 1", id).Delete(&models.Issue{})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete alert"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "alert not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": true, "permanent": true})
+		return
+	}
+
+	result := requestDB(c).Model(&models.Issue{}).Scopes(tenantScope(c)).
+		Where("id = ? AND is_alert = 1 AND deleted_at IS NULL", id).
+		Update("deleted_at", time.Now().UTC())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete alert"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "alert not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "permanent": false})
+}
+
+// checkAdminToken verifies the shared secret from ADMIN_API_TOKEN sent in the
+// X-Admin-Token header, writing an error response if it does not match
+func checkAdminToken(c *gin.Context) bool {
+	token := os.Getenv("ADMIN_API_TOKEN")
+	if token == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Admin operations are not configured"})
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Admin-Token")), []byte(token)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid admin token"})
+		return false
+	}
+	return true
+}
+
+// checkAlertTenant writes 404 unless the alert of the :id parameter belongs to
+// the tenant the caller is restricted to. Callers that see every tenant pass.
+func checkAlertTenant(c *gin.Context) bool {
+	tenant := scopedTenant(c)
+	if tenant == "" {
+		return true
+	}
+	var count int64
+	err := requestDB(c).Model(&models.Issue{}).Where("id = ? AND is_alert = 1 AND tenant_id = ?", c.Param("id"), tenant).Count(&count).Error
+	if err != nil || count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "alert not found"})
+		return false
+	}
+	return true
+}
+
+// acknowledgedColumn selects whether the latest acknowledgement of each issue is an ack
+const acknowledgedColumn = `COALESCE((SELECT a.action FROM acknowledgements a WHERE a.alert_id = issues.id ORDER BY a.id DESC LIMIT 1), '') = 'ack' AS acknowledged`
+
+// AlertDetailResponse is an alert with its current acknowledgement, if any, its
+// annotations and its notes
+type AlertDetailResponse struct {
+	models.Issue
+	Acknowledgement     *models.Acknowledgement `json:"acknowledgement"`
+	Annotations         map[string]string       `json:"annotations,omitempty"`          // raw templates
+	RenderedAnnotations map[string]string       `json:"rendered_annotations,omitempty"` // templates expanded at ingestion
+	Notes               []models.AlertNote      `json:"notes"`                          // oldest first
+	Flapping            bool                    `json:"flapping"`                       // the fingerprint is flapping now
+	FlapState           *services.FlapStatus    `json:"flap_state,omitempty"`           // transitions of the fingerprint
+}
+
+// AckRequest is the body of the ack and unack endpoints
+type AckRequest struct {
+	AckBy   string `json:"ack_by"`
+	Comment string `json:"comment"`
+}
+
+// GetAlert returns a single alert with its current acknowledgement and notes
+// embedded
+func GetAlert(c *gin.Context) {
+	var issue models.Issue
+	err := requestDB(c).Scopes(tenantScope(c)).Where("id = ? AND is_alert = 1 AND deleted_at IS NULL", c.Param("id")).First(&issue).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "alert not found"})
+		return
+	}
+
+	issues := []models.Issue{issue}
+	if err := services.NewSilenceService(requestDB(c)).MarkSilenced(issues, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load silences"})
+		return
+	}
+
+	resp := AlertDetailResponse{Issue: issues[0]}
+	if issue.Annotations != "" {
+		json.Unmarshal([]byte(issue.Annotations), &resp.Annotations)
+	}
+	if issue.RenderedAnnotations != "" {
+		json.Unmarshal([]byte(issue.RenderedAnnotations), &resp.RenderedAnnotations)
+	}
+	var latest models.Acknowledgement
+	if err := requestDB(c).Where("alert_id = ?", issue.ID).Order("id DESC").Limit(1).Find(&latest).Error; err == nil && latest.Action == "ack" {
+		resp.Acknowledged = true
+		resp.Acknowledgement = &latest
+	}
+	resp.Notes = []models.AlertNote{}
+	if err := requestDB(c).Where("alert_id = ?", issue.ID).Order("note_id ASC").Find(&resp.Notes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load notes"})
+		return
+	}
+	if issue.Fingerprint != "" {
+		flap := services.GetFlapDetector().Status(issue.Fingerprint)
+		resp.Flapping = flap.Flapping
+		resp.FlapState = &flap
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// AckAlert acknowledges an alert
+func AckAlert(c *gin.Context) {
+	recordAck(c, "ack", http.StatusCreated)
+}
+
+// UnackAlert withdraws an acknowledgement. The ack row is kept and an "unack"
+// entry is appended to the audit trail.
+func UnackAlert(c *gin.Context) {
+	recordAck(c, "unack", http.StatusOK)
+}
+
+// GetAlertAckHistory returns every ack/unack entry of an alert, oldest first
+func GetAlertAckHistory(c *gin.Context) {
+	if !checkAlertTenant(c) {
+		return
+	}
+	var history []models.Acknowledgement
+	if err := requestDB(c).Where("alert_id = ?", c.Param("id")).Order("id ASC").Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load acknowledgement history"})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+func recordAck(c *gin.Context, action string, status int) {
+	id := c.Param("id")
+
+	var req AckRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var issue models.Issue
+	if err := requestDB(c).Scopes(tenantScope(c)).Where("id = ? AND is_alert = 1 AND deleted_at IS NULL", id).First(&issue).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "alert not found"})
+		return
+	}
+
+	var latest models.Acknowledgement
+	requestDB(c).Where("alert_id = ?", id).Order("id DESC").Limit(1).Find(&latest)
+	if action == "ack" && latest.Action == "ack" {
+		c.JSON(http.StatusConflict, gin.H{"error": "alert is already acknowledged"})
+		return
+	}
+	if action == "unack" && latest.Action != "ack" {
+		c.JSON(http.StatusConflict, gin.H{"error": "alert is not acknowledged"})
+		return
+	}
+
+	entry := models.Acknowledgement{
+		AlertID: id,
+		Action:  action,
+		AckBy:   req.AckBy,
+		AckAt:   time.Now().UTC(),
+		Comment: req.Comment,
+	}
+	if err := requestDB(c).Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record acknowledgement"})
+		return
+	}
+
+	eventType := services.AlertEventAcknowledged
+	if action == "unack" {
+		eventType = services.AlertEventUnacknowledged
+	}
+	services.GetAlertBroadcaster().Publish(eventType, services.AlertFromIssue(&issue))
+	c.JSON(status, entry)
+}
+
+// SearchAlerts finds up to 100 alerts whose title, description, labels,
+// signature or notes contain the terms of q, newest first. Terms are ANDed; OR
+// separates alternatives.
+func SearchAlerts(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	issues, err := services.SearchAlerts(requestDB(c), q, scopedTenant(c), 100)
+	if errors.Is(err, services.ErrEmptySearchQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search alerts"})
+		return
+	}
+	c.JSON(http.StatusOK, issues)
+}
+
+// GetAlertTrend returns alert counts per time bucket, e.g.
+// ?window=7d&granularity=1h, optionally filtered by severity, cluster_id and
+// tenant_id. Tenant-scoped callers only count their own tenant's alerts.
+func GetAlertTrend(c *gin.Context) {
+	window, err := services.ParseTrendWindow(c.DefaultQuery("window", "7d"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenantID := c.Query("tenant_id")
+	if tenant := scopedTenant(c); tenant != "" {
+		tenantID = tenant
+	}
+	buckets, err := services.AlertTrend(requestDB(c), services.TrendQuery{
+		Window:      window,
+		Granularity: c.DefaultQuery("granularity", "1h"),
+		Severity:    c.Query("severity"),
+		ClusterID:   c.Query("cluster_id"),
+		TenantID:    tenantID,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedGranularity) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute alert trend"})
+		return
+	}
+	c.JSON(http.StatusOK, buckets)
+}
+
+// ValidateAlertRequest is an alert payload checked by ValidateAlertLabels
+type ValidateAlertRequest struct {
+	Labels map[string]string `json:"labels" binding:"required"`
+}
+
+// ValidateAlertLabels checks alert labels against the label schema so
+// producers can reject malformed payloads before sending them. Responds 400
+// with per-field errors when validation fails and 429 when the alert's
+// cluster is over its quota. Callers restricted to a tenant may only validate
+// alerts of that tenant.
+func ValidateAlertLabels(c *gin.Context) {
+	var req ValidateAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.Labels = services.Normalize(services.GetLabelNormalizer(), req.Labels)
+	if tenant := scopedTenant(c); tenant != "" {
+		alertTenant := req.Labels["o11y_tenant_id"]
+		if alertTenant == "" {
+			alertTenant = req.Labels["tenant_id"]
+		}
+		if alertTenant != tenant {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Alerts of other tenants cannot be validated"})
+			return
+		}
+	}
+	if severity, ok := req.Labels["severity"]; ok {
+		req.Labels["severity"] = services.GetSeverityMapper().Map(severity)
+	}
+	if errs := services.GetLabelSchema().Validate(req.Labels); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid alert labels", "errors": errs})
+		return
+	}
+
+	// Non-critical alerts of a cluster over its quota would be dropped on ingestion
+	usage, err := services.NewQuotaService(requestDB(c)).Check(req.Labels["cluster_id"], req.Labels["severity"])
+	if errors.Is(err, services.ErrQuotaExceeded) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "usage": usage})
+		return
+	}
+	if err != nil {
+		log.Printf("[WARN] Failed to check alert quota of cluster %s: %v\n", req.Labels["cluster_id"], err)
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": true})
+}
+
+// AlertDiffRequest selects the two points in time compared by DiffAlerts, as
+// UNIX timestamps. Window is the lookback in seconds that counts as firing
+// (default: 3600).
+type AlertDiffRequest struct {
+	Baseline int64 `json:"baseline" binding:"required"`
+	Current  int64 `json:"current" binding:"required"`
+	Window   int64 `json:"window"`
+}
+
+// DiffAlerts returns the alerts that are new, resolved and ongoing between two
+// points in time, matched by dedup key
+func DiffAlerts(c *gin.Context) {
+	var req AlertDiffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Window == 0 {
+		req.Window = 3600
+	}
+	if req.Window < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window must be positive"})
+		return
+	}
+
+	diff, err := services.DiffAlerts(requestDB(c), time.Unix(req.Baseline, 0), time.Unix(req.Current, 0), time.Duration(req.Window)*time.Second, scopedTenant(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+// GetStaleReaperStatus returns when the stale alert reaper last ran and how
+// many alerts it auto-resolved
+func GetStaleReaperStatus(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+	c.JSON(http.StatusOK, services.GetStaleAlertReaper().Status())
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+const (
+	// maxAuditBody is the most of a request body kept in the audit log
+	maxAuditBody = 8 << 10
+
+	// auditRedacted replaces the values of sensitiveAuditKeys
+	auditRedacted = "[REDACTED]"
+)
+
+// sensitiveAuditKeys are the (case-insensitive) substrings of JSON keys whose
+// values are not written to the audit log, e.g. webhook Authorization headers
+var sensitiveAuditKeys = []string{"password", "secret", "token", "authorization", "api_key", "routing_key"}
+
+// AuditMiddleware writes an audit_log row for every POST, PUT, PATCH and
+// DELETE request that matched a route, after the handler ran: the caller (see
+// currentUser, or "admin_token" for X-Admin-Token requests), the method and
+// route, the resource type and ID taken from the route, the first 8 KB of the
+// request body with secrets redacted, and the response status. Write failures
+// are logged and do not affect the response.
+func AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		defer func() {
+			route := c.FullPath()
+			if route == "" {
+				return
+			}
+			entry := models.AuditLog{
+				UserID:       auditUser(c),
+				Action:       c.Request.Method + " " + route,
+				ResourceType: auditResourceType(route),
+				RequestBody:  auditBody(body),
+				ResponseCode: c.Writer.Status(),
+				CreatedAt:    time.Now().UTC(),
+			}
+			if len(c.Params) > 0 {
+				entry.ResourceID = c.Params[0].Value
+			}
+			if err := db.DB.Create(&entry).Error; err != nil {
+				log.Printf("[WARN] Failed to write audit log for %s: %v\n", entry.Action, err)
+			}
+		}()
+		c.Next()
+	}
+}
+
+func auditUser(c *gin.Context) string {
+	if user, ok := currentUser(c); ok && user != anonymousUser {
+		return user
+	}
+	if c.GetHeader("X-Admin-Token") != "" {
+		return "admin_token"
+	}
+	user, _ := currentUser(c)
+	return user
+}
+
+// auditResourceType returns the first segment of route after /api (and
+// /api/admin), e.g. "alerts" for /api/alerts/:id/ack
+func auditResourceType(route string) string {
+	route = strings.TrimPrefix(route, "/api/")
+	route = strings.TrimPrefix(route, "admin/")
+	resource, _, _ := strings.Cut(route, "/")
+	return resource
+}
+
+// auditBody returns body for the audit log: redacted JSON when it is JSON and
+// fits in maxAuditBody, otherwise its first maxAuditBody bytes as a JSON string
+func auditBody(body []byte) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err == nil {
+		if redacted, err := json.Marshal(redactAuditValue(v)); err == nil {
+			body = redacted
+			if len(body) <= maxAuditBody {
+				return body
+			}
+		}
+	}
+	if len(body) > maxAuditBody {
+		body = body[:maxAuditBody]
+	}
+	raw, _ := json.Marshal(strings.ToValidUTF8(string(body), "�"))
+	return raw
+}
+
+func redactAuditValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isSensitiveAuditKey(key) {
+				v[key] = auditRedacted
+			} else {
+				v[key] = redactAuditValue(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactAuditValue(value)
+		}
+	}
+	return v
+}
+
+func isSensitiveAuditKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveAuditKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAuditLog pages through the audit log, newest first, optionally filtered
+// by user_id, resource_type and since (RFC 3339 or "2006-01-02[ 15:04:05]").
+// page_size defaults to 50, at most 500.
+func GetAuditLog(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+
+	query := requestDB(c).Model(&models.AuditLog{})
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if resourceType := c.Query("resource_type"); resourceType != "" {
+		query = query.Where("resource_type = ?", resourceType)
+	}
+	if s := c.Query("since"); s != "" {
+		since, err := parseExportTime(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("created_at >= ?", since)
+	}
+
+	var page, pageSize int
+	fmt.Sscanf(c.DefaultQuery("page", "1"), "%d", &page)
+	if page < 1 {
+		page = 1
+	}
+	fmt.Sscanf(c.DefaultQuery("page_size", "50"), "%d", &pageSize)
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	pageSize = min(pageSize, 500)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	entries := []models.AuditLog{}
+	if err := query.Order("created_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"entries":   entries,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
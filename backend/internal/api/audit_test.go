@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/rbac"
+)
+
+// auditRouter returns /api routes behind AuditMiddleware as cmd/server
+// registers them, with handlers answering status
+func auditRouter(status int) *gin.Engine {
+	r := gin.New()
+	api := r.Group("/api", AuditMiddleware())
+	respond := func(c *gin.Context) { c.Status(status) }
+	api.GET("/alerts", Authenticate(), respond)
+	api.PUT("/alerts/:id/ack", Authenticate(), respond)
+	api.POST("/admin/import/clusters", respond)
+	api.GET("/admin/audit-log", Authenticate(), Authorize(rbac.ActionAdmin), GetAuditLog)
+	return r
+}
+
+func TestAuditLogEntries(t *testing.T) {
+	sqlite := openTestDB(t)
+	useAuth(t)
+	r := auditRouter(http.StatusNoContent)
+	editor := signToken(t, "tenant-a", rbac.RoleEditor)
+
+	body := `{"comment":"on it","api_key":"k-1","webhook":{"headers":{"Authorization":"Bearer s-1"}}}`
+	if w := serve(r, http.MethodPut, "/api/alerts/A-1/ack", editor, body); w.Code != http.StatusNoContent {
+		t.Fatalf("ack: %d %s", w.Code, w.Body)
+	}
+	// Reads and unmatched routes are not recorded, rejected requests are
+	serve(r, http.MethodGet, "/api/alerts", editor, "")
+	serve(r, http.MethodPost, "/api/unknown", editor, "{}")
+	serve(r, http.MethodPut, "/api/alerts/A-2/ack", "", "")
+
+	req := newRequest(http.MethodPost, "/api/admin/import/clusters", strings.Repeat("x", maxAuditBody+10))
+	req.Header.Set("X-Admin-Token", "test-admin-token")
+	serveRequest(r, req)
+
+	var entries []models.AuditLog
+	if err := sqlite.Order("id").Find(&entries).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("%d audit entries, want 3: %+v", len(entries), entries)
+	}
+
+	ack := entries[0]
+	if ack.UserID != "editor@tenant-a.example.com" || ack.Action != "PUT /api/alerts/:id/ack" ||
+		ack.ResourceType != "alerts" || ack.ResourceID != "A-1" || ack.ResponseCode != http.StatusNoContent {
+		t.Errorf("ack entry = %+v", ack)
+	}
+	var recorded map[string]interface{}
+	if err := json.Unmarshal(ack.RequestBody, &recorded); err != nil {
+		t.Fatalf("request_body %s: %v", ack.RequestBody, err)
+	}
+	if recorded["comment"] != "on it" || recorded["api_key"] != auditRedacted ||
+		recorded["webhook"].(map[string]interface{})["headers"].(map[string]interface{})["Authorization"] != auditRedacted {
+		t.Errorf("request_body = %s, want the comment kept and secrets redacted", ack.RequestBody)
+	}
+
+	if unauthenticated := entries[1]; unauthenticated.UserID != "" || unauthenticated.ResourceID != "A-2" || unauthenticated.ResponseCode != http.StatusUnauthorized {
+		t.Errorf("unauthenticated entry = %+v, want no user and 401", unauthenticated)
+	}
+
+	imported := entries[2]
+	if imported.UserID != "admin_token" || imported.ResourceType != "import" || imported.ResourceID != "" {
+		t.Errorf("import entry = %+v", imported)
+	}
+	var truncated string
+	if err := json.Unmarshal(imported.RequestBody, &truncated); err != nil || len(truncated) != maxAuditBody {
+		t.Errorf("request_body of a large non-JSON body: %d bytes, %v, want its first %d as a string", len(truncated), err, maxAuditBody)
+	}
+}
+
+func TestGetAuditLog(t *testing.T) {
+	openTestDB(t)
+	useAuth(t)
+	r := auditRouter(http.StatusOK)
+	editor := signToken(t, "tenant-a", rbac.RoleEditor)
+	admin := signToken(t, "", roleAdmin)
+
+	for _, id := range []string{"A-1", "A-2", "A-3"} {
+		serve(r, http.MethodPut, "/api/alerts/"+id+"/ack", editor, "")
+	}
+	req := newRequest(http.MethodPost, "/api/admin/import/clusters", "[]")
+	req.Header.Set("X-Admin-Token", "test-admin-token")
+	serveRequest(r, req)
+
+	if w := serve(r, http.MethodGet, "/api/admin/audit-log", editor, ""); w.Code != http.StatusForbidden {
+		t.Errorf("audit log as an editor: %d, want 403", w.Code)
+	}
+
+	var page struct {
+		Entries []models.AuditLog `json:"entries"`
+		Total   int64             `json:"total"`
+	}
+	w := serve(r, http.MethodGet, "/api/admin/audit-log?resource_type=alerts&page_size=2", admin, "")
+	decodeJSON(t, w.Body.String(), &page)
+	if page.Total != 3 || len(page.Entries) != 2 || page.Entries[0].ResourceID != "A-3" {
+		t.Errorf("alerts page = %d of %d, first %+v, want the newest 2 of 3", len(page.Entries), page.Total, page.Entries)
+	}
+
+	w = serve(r, http.MethodGet, "/api/admin/audit-log?user_id=admin_token", admin, "")
+	decodeJSON(t, w.Body.String(), &page)
+	if page.Total != 1 || page.Entries[0].Action != "POST /api/admin/import/clusters" {
+		t.Errorf("entries of admin_token = %+v, want the import", page.Entries)
+	}
+
+	if w := serve(r, http.MethodGet, "/api/admin/audit-log?since=yesterday", admin, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid since: %d, want 400", w.Code)
+	}
+}
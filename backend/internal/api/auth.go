@@ -0,0 +1,189 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nolouch/alerts-platform-v2/internal/rbac"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultTokenTTL = time.Hour
+	maxTokenTTL     = 24 * time.Hour
+
+	// Gin context keys set by Authenticate
+	ctxTenantID = "auth_tenant_id"
+	ctxRole     = "auth_role"
+	ctxUser     = "auth_user"
+
+	// anonymousUser owns per-user settings when authentication is disabled
+	anonymousUser = "anonymous"
+
+	roleAdmin = "admin"
+)
+
+// AuthClaims are the claims of the JWTs issued by IssueAuthToken and of the
+// single sign-on sessions set by OIDCCallback
+type AuthClaims struct {
+	TenantID string   `json:"tenant_id,omitempty"`
+	Role     string   `json:"role,omitempty"`
+	Email    string   `json:"email,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// authSecret returns the HMAC key from AUTH_SECRET; authentication is disabled when empty
+func authSecret() []byte {
+	return []byte(os.Getenv("AUTH_SECRET"))
+}
+
+// Authenticate verifies the HS256 bearer token (or access_token query
+// parameter, or single sign-on session cookie) signed with AUTH_SECRET and
+// stores its tenant_id, role and user (email, or subject) in the context.
+// Tokens must carry a tenant_id or the admin role, except single sign-on
+// sessions, which name their user by email and see every tenant. When
+// AUTH_SECRET is unset requests pass through unauthenticated and see every
+// tenant.
+func Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := authSecret()
+		if len(secret) == 0 {
+			c.Next()
+			return
+		}
+
+		raw, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			// Browsers cannot set headers on WebSocket requests
+			raw = c.Query("access_token")
+		}
+		if raw == "" {
+			raw, _ = c.Cookie(sessionCookie)
+		}
+		if raw == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			return
+		}
+
+		var claims AuthClaims
+		_, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (interface{}, error) {
+			if t.Method != jwt.SigningMethodHS256 {
+				return nil, errors.New("unexpected signing method")
+			}
+			return secret, nil
+		})
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+		if claims.Role != roleAdmin && claims.TenantID == "" && claims.Email == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token has no tenant_id"})
+			return
+		}
+
+		c.Set(ctxTenantID, claims.TenantID)
+		c.Set(ctxRole, claims.Role)
+		if claims.Email != "" {
+			c.Set(ctxUser, claims.Email)
+		} else {
+			c.Set(ctxUser, claims.Subject)
+		}
+		c.Next()
+	}
+}
+
+// scopedTenant returns the tenant the caller is restricted to, or "" for admins
+// and unauthenticated deployments
+func scopedTenant(c *gin.Context) string {
+	if c.GetString(ctxRole) == roleAdmin {
+		return ""
+	}
+	return c.GetString(ctxTenantID)
+}
+
+// tenantScope is a gorm scope restricting a query of issues to the tenant the
+// caller is restricted to, if any
+func tenantScope(c *gin.Context) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if tenant := scopedTenant(c); tenant != "" {
+			return tx.Where("tenant_id = ?", tenant)
+		}
+		return tx
+	}
+}
+
+// currentUser returns the user the caller is signed in as. Without
+// authentication every caller is anonymousUser; tokens that name no user
+// have none.
+func currentUser(c *gin.Context) (string, bool) {
+	if len(authSecret()) == 0 {
+		return anonymousUser, true
+	}
+	user := c.GetString(ctxUser)
+	return user, user != ""
+}
+
+// IssueTokenRequest selects the claims of a token issued by IssueAuthToken
+type IssueTokenRequest struct {
+	TenantID   string `json:"tenant_id"`
+	User       string `json:"user"`        // subject; the user role_bindings and per-user settings apply to
+	Role       string `json:"role"`        // "", viewer, editor or admin
+	TTLSeconds int    `json:"ttl_seconds"` // default 3600, at most 86400
+}
+
+// IssueAuthToken issues a short-lived JWT for a tenant (or an admin token with
+// role "admin"), signed with AUTH_SECRET. Tenant tokens may carry the viewer or
+// editor role (see Authorize); the roles bound to the token's user apply on top
+// of it. Requires the admin token.
+func IssueAuthToken(c *gin.Context) {
+	if !checkAdminToken(c) {
+		return
+	}
+	secret := authSecret()
+	if len(secret) == 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Authentication is not configured"})
+		return
+	}
+
+	var req IssueTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Role != "" && !rbac.ValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be empty, viewer, editor or admin"})
+		return
+	}
+	if req.Role != roleAdmin && req.TenantID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tenant_id is required for non-admin tokens"})
+		return
+	}
+
+	ttl := defaultTokenTTL
+	if req.TTLSeconds > 0 {
+		ttl = min(time.Duration(req.TTLSeconds)*time.Second, maxTokenTTL)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, AuthClaims{
+		TenantID: req.TenantID,
+		Role:     req.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   req.User,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}).SignedString(secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": token, "expires_at": expiresAt.UTC()})
+}
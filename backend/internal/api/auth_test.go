@@ -0,0 +1,246 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/rbac"
+)
+
+// alertReadRouter registers the alert-reading routes as cmd/server does
+func alertReadRouter() *gin.Engine {
+	r := gin.New()
+	read := []gin.HandlerFunc{Authenticate(), Authorize(rbac.ActionAlertRead)}
+	write := []gin.HandlerFunc{Authenticate(), Authorize(rbac.ActionAlertWrite)}
+	route := func(method, path string, mw []gin.HandlerFunc, h gin.HandlerFunc) {
+		r.Handle(method, path, append(append([]gin.HandlerFunc{}, mw...), h)...)
+	}
+	route("GET", "/api/dashboard", read, GetDashboardData)
+	route("GET", "/api/alerts/summary", read, GetAlertSummary)
+	route("GET", "/api/heatmap", read, GetClusterHeatmap)
+	route("GET", "/api/alerts/search", read, SearchAlerts)
+	route("GET", "/api/alerts/trend", read, GetAlertTrend)
+	route("GET", "/api/alerts/top-noisy", read, GetTopNoisyAlerts)
+	route("GET", "/api/alerts/grouped", read, GetGroupedAlerts)
+	route("GET", "/api/alerts/export", read, ExportAlerts)
+	route("POST", "/api/alerts/diff", read, DiffAlerts)
+	route("POST", "/api/alerts/validate", read, ValidateAlertLabels)
+	route("GET", "/api/alerts/:id", read, GetAlert)
+	route("DELETE", "/api/alerts/:id", write, DeleteAlert)
+	route("POST", "/api/alerts/:id/ack", write, AckAlert)
+	route("GET", "/api/alerts/:id/ack-history", read, GetAlertAckHistory)
+	route("GET", "/api/alerts/:id/notes", read, GetAlertNotes)
+	route("POST", "/api/alerts/:id/notes", write, CreateAlertNote)
+	return r
+}
+
+func decodeJSON(t *testing.T, body string, v any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(body), v); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+}
+
+func TestAlertRoutesRequireAuthentication(t *testing.T) {
+	openTestDB(t)
+	useFakeNames(t)
+	useAuth(t)
+	r := alertReadRouter()
+
+	for _, path := range []string{
+		"/api/dashboard", "/api/alerts/summary", "/api/heatmap", "/api/alerts/trend",
+		"/api/alerts/top-noisy", "/api/alerts/search?q=x", "/api/alerts/export", "/api/alerts/A-1",
+	} {
+		if w := serve(r, "GET", path, "", ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("GET %s without a token: %d, want 401", path, w.Code)
+		}
+	}
+	if w := serve(r, "POST", "/api/alerts/diff", "", `{"baseline":1,"current":2}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("POST /api/alerts/diff without a token: %d, want 401", w.Code)
+	}
+	if w := serve(r, "POST", "/api/alerts/validate", "", `{"labels":{"severity":"critical","cluster_id":"10001"}}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("POST /api/alerts/validate without a token: %d, want 401", w.Code)
+	}
+}
+
+func TestValidateAlertLabelsTenantScope(t *testing.T) {
+	openTestDB(t)
+	useFakeNames(t)
+	useAuth(t)
+	r := alertReadRouter()
+
+	viewer := signToken(t, "1", rbac.RoleViewer)
+	admin := signToken(t, "", roleAdmin)
+	for _, tt := range []struct {
+		name, token, tenantLabel string
+		want                     int
+	}{
+		{"own tenant", viewer, `"o11y_tenant_id":"1"`, http.StatusOK},
+		{"own tenant as tenant_id", viewer, `"tenant_id":"1"`, http.StatusOK},
+		{"other tenant", viewer, `"o11y_tenant_id":"2"`, http.StatusForbidden},
+		{"no tenant", viewer, `"alertname":"TiKVDown"`, http.StatusForbidden},
+		{"admin, any tenant", admin, `"o11y_tenant_id":"2"`, http.StatusOK},
+	} {
+		body := `{"labels":{"severity":"critical","cluster_id":"10001",` + tt.tenantLabel + `}}`
+		if w := serve(r, "POST", "/api/alerts/validate", tt.token, body); w.Code != tt.want {
+			t.Errorf("%s: %d %s, want %d", tt.name, w.Code, w.Body, tt.want)
+		}
+	}
+}
+
+func TestAlertRoutesTenantScope(t *testing.T) {
+	openTestDB(t)
+	useFakeNames(t)
+	useAuth(t)
+	r := alertReadRouter()
+
+	seedIssue(t, "A-1", "c-a", "tenant-a", time.Hour)
+	seedIssue(t, "A-2", "c-a", "tenant-a", 2*time.Hour)
+	seedIssue(t, "B-1", "c-b", "tenant-b", time.Hour)
+
+	admin := signToken(t, "", roleAdmin)
+	tenant := signToken(t, "tenant-a", rbac.RoleViewer)
+
+	// count returns the number of alerts a caller sees through each route
+	counts := map[string]func(t *testing.T, token string) int{
+		"dashboard": func(t *testing.T, token string) int {
+			var resp DashboardDataResponse
+			decodeJSON(t, mustServe(t, r, "GET", "/api/dashboard", token, ""), &resp)
+			return int(resp.TotalAlerts.Current)
+		},
+		"summary": func(t *testing.T, token string) int {
+			var resp AlertSummaryResponse
+			decodeJSON(t, mustServe(t, r, "GET", "/api/alerts/summary", token, ""), &resp)
+			return resp.Total
+		},
+		"heatmap": func(t *testing.T, token string) int {
+			var resp struct {
+				Clusters []struct{ Total int } `json:"clusters"`
+			}
+			decodeJSON(t, mustServe(t, r, "GET", "/api/heatmap", token, ""), &resp)
+			total := 0
+			for _, row := range resp.Clusters {
+				total += row.Total
+			}
+			return total
+		},
+		"trend": func(t *testing.T, token string) int {
+			var buckets []struct{ Count int }
+			decodeJSON(t, mustServe(t, r, "GET", "/api/alerts/trend?window=1d&tenant_id=tenant-b", token, ""), &buckets)
+			total := 0
+			for _, b := range buckets {
+				total += b.Count
+			}
+			return total
+		},
+		"top-noisy": func(t *testing.T, token string) int {
+			var resp struct {
+				TotalOccurrences int `json:"total_occurrences"`
+			}
+			decodeJSON(t, mustServe(t, r, "GET", "/api/alerts/top-noisy?group_by=cluster_id", token, ""), &resp)
+			return resp.TotalOccurrences
+		},
+		"search": func(t *testing.T, token string) int {
+			var issues []struct{ ID string }
+			decodeJSON(t, mustServe(t, r, "GET", "/api/alerts/search?q=alert", token, ""), &issues)
+			return len(issues)
+		},
+		"grouped": func(t *testing.T, token string) int {
+			var resp struct{ Total int }
+			decodeJSON(t, mustServe(t, r, "GET", "/api/alerts/grouped?days=1", token, ""), &resp)
+			return resp.Total
+		},
+		"export": func(t *testing.T, token string) int {
+			body := mustServe(t, r, "GET", "/api/alerts/export?format=ndjson&fields=id", token, "")
+			return strings.Count(body, "\n")
+		},
+		"diff": func(t *testing.T, token string) int {
+			now := time.Now().Unix()
+			req := fmt.Sprintf(`{"baseline":%d,"current":%d,"window":10800}`, now-86400, now)
+			var resp struct {
+				New struct{ Count int } `json:"new"`
+			}
+			decodeJSON(t, mustServe(t, r, "POST", "/api/alerts/diff", token, req), &resp)
+			return resp.New.Count
+		},
+	}
+
+	// The trend route is asked for tenant-b, which a tenant token must not override
+	wantAdmin := map[string]int{"trend": 1}
+	wantTenant := map[string]int{"trend": 2}
+	for name, count := range counts {
+		t.Run(name, func(t *testing.T) {
+			want := 3
+			if n, ok := wantAdmin[name]; ok {
+				want = n
+			}
+			if got := count(t, admin); got != want {
+				t.Errorf("admin sees %d alerts, want %d", got, want)
+			}
+			want = 2
+			if n, ok := wantTenant[name]; ok {
+				want = n
+			}
+			if got := count(t, tenant); got != want {
+				t.Errorf("tenant-a sees %d alerts, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestAlertDetailTenantScope(t *testing.T) {
+	openTestDB(t)
+	useFakeNames(t)
+	useAuth(t)
+	r := alertReadRouter()
+
+	seedIssue(t, "A-1", "c-a", "tenant-a", time.Hour)
+	seedIssue(t, "B-1", "c-b", "tenant-b", time.Hour)
+
+	admin := signToken(t, "", roleAdmin)
+	viewer := signToken(t, "tenant-a", rbac.RoleViewer)
+	editor := signToken(t, "tenant-a", rbac.RoleEditor)
+
+	for _, path := range []string{"/api/alerts/B-1", "/api/alerts/B-1/ack-history", "/api/alerts/B-1/notes"} {
+		if w := serve(r, "GET", path, admin, ""); w.Code != http.StatusOK {
+			t.Errorf("admin GET %s: %d %s, want 200", path, w.Code, w.Body)
+		}
+		if w := serve(r, "GET", path, viewer, ""); w.Code != http.StatusNotFound {
+			t.Errorf("tenant-a GET %s: %d %s, want 404", path, w.Code, w.Body)
+		}
+	}
+	if w := serve(r, "GET", "/api/alerts/A-1", viewer, ""); w.Code != http.StatusOK {
+		t.Errorf("tenant-a GET its own alert: %d %s, want 200", w.Code, w.Body)
+	}
+
+	if w := serve(r, "POST", "/api/alerts/B-1/ack", editor, `{"ack_by":"ann"}`); w.Code != http.StatusNotFound {
+		t.Errorf("tenant-a acked a tenant-b alert: %d %s, want 404", w.Code, w.Body)
+	}
+	if w := serve(r, "POST", "/api/alerts/B-1/notes", editor, `{"body":"mine now"}`); w.Code != http.StatusNotFound {
+		t.Errorf("tenant-a annotated a tenant-b alert: %d %s, want 404", w.Code, w.Body)
+	}
+	if w := serve(r, "DELETE", "/api/alerts/B-1", editor, ""); w.Code != http.StatusNotFound {
+		t.Errorf("tenant-a deleted a tenant-b alert: %d %s, want 404", w.Code, w.Body)
+	}
+	if w := serve(r, "POST", "/api/alerts/A-1/ack", editor, `{"ack_by":"ann"}`); w.Code != http.StatusCreated {
+		t.Errorf("tenant-a ack of its own alert: %d %s, want 201", w.Code, w.Body)
+	}
+	if w := serve(r, "POST", "/api/alerts/A-1/notes", viewer, `{"body":"note"}`); w.Code != http.StatusForbidden {
+		t.Errorf("viewer annotated an alert: %d %s, want 403", w.Code, w.Body)
+	}
+}
+
+// mustServe runs the request and returns the body, failing the test unless it is 200
+func mustServe(t *testing.T, r http.Handler, method, path, token, body string) string {
+	t.Helper()
+	w := serve(r, method, path, token, body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s %s: %d %s", method, path, w.Code, w.Body)
+	}
+	return w.Body.String()
+}
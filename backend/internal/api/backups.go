@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// GetBackups lists the backup files of the alerts database, newest first,
+// with the backup schedule and S3 bucket
+func GetBackups(c *gin.Context) {
+	svc := services.GetBackupService()
+	backups, err := svc.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	bucket := ""
+	if svc.S3 != nil {
+		bucket = svc.S3.Bucket
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"backups":   backups,
+		"dir":       svc.Dir,
+		"schedule":  svc.Schedule,
+		"s3_bucket": bucket,
+	})
+}
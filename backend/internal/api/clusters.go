@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// ClusterResponse is a cluster of the catalog served by GetClusters
+type ClusterResponse struct {
+	ClusterID        string    `json:"cluster_id"`
+	ClusterName      string    `json:"cluster_name"`
+	TenantID         string    `json:"tenant_id"`
+	TenantName       string    `json:"tenant_name"`
+	DeployType       string    `json:"deploy_type"`
+	Version          string    `json:"version"`
+	ClusterLifecycle string    `json:"cluster_lifecycle"`
+	CreationDuration string    `json:"creation_duration"`
+	TenantPlan       string    `json:"tenant_plan"`
+	Provider         string    `json:"provider"`
+	Region           string    `json:"region"`
+	ProjectID        string    `json:"project_id"`
+	OrgID            string    `json:"org_id"`
+	ClusterType      string    `json:"cluster_type"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// GetClusters pages through the known clusters, most recently updated first.
+// Filters: provider, region, deploy_type, cluster_lifecycle and tenant_plan;
+// pagination: page and page_size (default 50, at most 500). The total number
+// of matches is in the X-Total-Count header.
+func GetClusters(c *gin.Context) {
+	filter := services.ClusterFilter{
+		Provider:         c.Query("provider"),
+		Region:           c.Query("region"),
+		DeployType:       c.Query("deploy_type"),
+		ClusterLifecycle: c.Query("cluster_lifecycle"),
+		TenantPlan:       c.Query("tenant_plan"),
+	}
+
+	var page, pageSize int
+	fmt.Sscanf(c.DefaultQuery("page", "1"), "%d", &page)
+	if page < 1 {
+		page = 1
+	}
+	fmt.Sscanf(c.DefaultQuery("page_size", "50"), "%d", &pageSize)
+	if pageSize < 1 {
+		pageSize = 50
+	}
+	pageSize = min(pageSize, 500)
+
+	clusters, total, source, err := services.GetNameResolver().ListClusters(requestDB(c), filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data := make([]ClusterResponse, 0, len(clusters))
+	for _, info := range clusters {
+		data = append(data, ClusterResponse{
+			ClusterID:        info.ClusterID,
+			ClusterName:      info.ClusterName,
+			TenantID:         info.TenantID,
+			TenantName:       info.TenantName,
+			DeployType:       info.DeployType,
+			Version:          info.Version,
+			ClusterLifecycle: info.ClusterLifecycle,
+			CreationDuration: info.CreationDuration,
+			TenantPlan:       info.TenantPlan,
+			Provider:         info.Provider,
+			Region:           info.Region,
+			ProjectID:        info.ProjectID,
+			OrgID:            info.OrgID,
+			ClusterType:      info.ClusterType,
+			CreatedAt:        info.CreatedAt,
+			UpdatedAt:        info.UpdatedAt,
+		})
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	c.JSON(http.StatusOK, gin.H{
+		"clusters":  data,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+		"source":    source,
+	})
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+func TestGetClusters(t *testing.T) {
+	openTestDB(t)
+	useNameResolver(t)
+	for i := 0; i < 5; i++ {
+		provider := "aws"
+		if i%2 == 1 {
+			provider = "gcp"
+		}
+		cluster := models.LocalCluster{
+			ClusterID: fmt.Sprintf("300%d", i),
+			TenantID:  "1001",
+			Provider:  provider,
+			UpdatedAt: time.Date(2026, 1, 1+i, 0, 0, 0, 0, time.UTC),
+		}
+		if err := db.DB.Create(&cluster).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := gin.New()
+	r.GET("/api/clusters", GetClusters)
+
+	type page struct {
+		Clusters []ClusterResponse `json:"clusters"`
+		Total    int64             `json:"total"`
+		Page     int               `json:"page"`
+		PageSize int               `json:"page_size"`
+		Source   string            `json:"source"`
+	}
+	for _, tc := range []struct {
+		query, totalHeader string
+		ids                []string
+		pageSize           int
+	}{
+		{"", "5", []string{"3004", "3003", "3002", "3001", "3000"}, 50},
+		{"?provider=aws", "3", []string{"3004", "3002", "3000"}, 50},
+		{"?provider=gcp&page=2&page_size=1", "2", []string{"3001"}, 1},
+		{"?page_size=1000", "5", []string{"3004", "3003", "3002", "3001", "3000"}, 500},
+		{"?region=eu-west-1", "0", nil, 50},
+	} {
+		w := serve(r, http.MethodGet, "/api/clusters"+tc.query, "", "")
+		var resp page
+		decodeJSON(t, w.Body.String(), &resp)
+		var ids []string
+		for _, c := range resp.Clusters {
+			ids = append(ids, c.ClusterID)
+		}
+		if w.Code != http.StatusOK || w.Header().Get("X-Total-Count") != tc.totalHeader || fmt.Sprint(ids) != fmt.Sprint(tc.ids) ||
+			resp.PageSize != tc.pageSize || resp.Source != "sqlite" || resp.Clusters == nil {
+			t.Errorf("%q: %d X-Total-Count %q, %v page size %d from %s, want %s %v page size %d",
+				tc.query, w.Code, w.Header().Get("X-Total-Count"), ids, resp.PageSize, resp.Source, tc.totalHeader, tc.ids, tc.pageSize)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,7 +11,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/nolouch/alerts-platform-v2/internal/db"
 	"github.com/nolouch/alerts-platform-v2/internal/models"
 	"github.com/nolouch/alerts-platform-v2/internal/services"
 	"gopkg.in/yaml.v3"
@@ -133,12 +133,12 @@ func GetComponents(c *gin.Context) {
 	var componentNames []string
 
 	// 1. Try querying distinct components from component_stats
-	db.DB.Model(&models.ComponentStat{}).Distinct("component").Pluck("component", &componentNames)
+	requestDB(c).Model(&models.ComponentStat{}).Distinct("component").Pluck("component", &componentNames)
 
 	// 2. If empty, fallback to scanning issues table
 	if len(componentNames) == 0 {
 		var rawComponents []string
-		db.DB.Model(&models.Issue{}).
+		requestDB(c).Model(&models.Issue{}).
 			Where("is_alert = ?", true).
 			Order("created DESC").
 			Limit(5000).
@@ -221,7 +221,7 @@ func GetComponents(c *gin.Context) {
 	// Check if we need to add a single "old-rules" component for Resilience
 	// This aggregates ALL issues with empty stability_governance AND (biz_type NOT LIKE '%nextgen%')
 	var countEmpty int64
-	db.DB.Model(&models.Issue{}).
+	requestDB(c).Model(&models.Issue{}).
 		Where("is_alert = ? AND (stability_governance = '' OR stability_governance IS NULL) AND (biz_type NOT LIKE '%nextgen%')", true).
 		Count(&countEmpty)
 
@@ -262,14 +262,24 @@ func calcCompChange(curr, prev int64) (float64, string) {
 	return change, trend
 }
 
-func resolveNameInfo(componentName, id string) services.NameInfo {
+func resolveNameInfo(ctx context.Context, names services.NameService, componentName, id string) services.NameInfo {
 	if getCategory(componentName) == "Serverless" {
 		return services.NameInfo{ID: id, Name: id}
 	}
-	info, _ := services.GetNameResolver().Resolve(id)
+	info, _ := names.ResolveContext(ctx, id)
 	return info
 }
 
+// requestNameService returns the name service for one request. The resolver
+// is wrapped in a request cache, as handlers resolve the same IDs many times.
+func requestNameService() services.NameService {
+	svc := services.GetNameService()
+	if nr, ok := svc.(*services.NameResolver); ok {
+		return services.NewRequestCache(nr)
+	}
+	return svc
+}
+
 // GetComponentStats returns aggregate stats
 func GetComponentStats(c *gin.Context) {
 	name := c.Param("name")
@@ -366,13 +376,13 @@ func GetComponentStats(c *gin.Context) {
 
 	// 1. Total Alerts (Current & Previous)
 	var currTotal int64
-	db.DB.Model(&models.Issue{}).
+	requestDB(c).Model(&models.Issue{}).
 		Where("is_alert = ? AND components LIKE ?"+envCondition+categoryCondition+stabilityCondition+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?",
 			true, componentFilter, startDate, endDate).
 		Count(&currTotal)
 
 	var prevTotal int64
-	db.DB.Model(&models.Issue{}).
+	requestDB(c).Model(&models.Issue{}).
 		Where("is_alert = ? AND components LIKE ?"+envCondition+categoryCondition+stabilityCondition+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?",
 			true, componentFilter, prevStartDate, prevEndDate).
 		Count(&prevTotal)
@@ -389,13 +399,13 @@ func GetComponentStats(c *gin.Context) {
 
 	// 1.5 Rate Stats (Current)
 	var currFake int64
-	db.DB.Model(&models.Issue{}).
+	requestDB(c).Model(&models.Issue{}).
 		Where("is_alert = ? AND components LIKE ?"+envCondition+categoryCondition+stabilityCondition+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status = 'FAKE ALARM'",
 			true, componentFilter, startDate, endDate).
 		Count(&currFake)
 
 	var currHandled int64
-	db.DB.Model(&models.Issue{}).
+	requestDB(c).Model(&models.Issue{}).
 		Where("is_alert = ? AND components LIKE ?"+envCondition+categoryCondition+stabilityCondition+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status != 'Created'",
 			true, componentFilter, startDate, endDate).
 		Count(&currHandled)
@@ -405,13 +415,13 @@ func GetComponentStats(c *gin.Context) {
 
 	// 1.6 Rate Stats (Previous)
 	var prevFake int64
-	db.DB.Model(&models.Issue{}).
+	requestDB(c).Model(&models.Issue{}).
 		Where("is_alert = ? AND components LIKE ?"+envCondition+categoryCondition+stabilityCondition+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status = 'FAKE ALARM'",
 			true, componentFilter, prevStartDate, prevEndDate).
 		Count(&prevFake)
 
 	var prevHandled int64
-	db.DB.Model(&models.Issue{}).
+	requestDB(c).Model(&models.Issue{}).
 		Where("is_alert = ? AND components LIKE ?"+envCondition+categoryCondition+stabilityCondition+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status != 'Created'",
 			true, componentFilter, prevStartDate, prevEndDate).
 		Count(&prevHandled)
@@ -449,7 +459,7 @@ func GetComponentStats(c *gin.Context) {
 	}
 
 	trendData := []DailyTrend{}
-	db.DB.Raw(`
+	requestDB(c).Raw(`
 		SELECT 
 			`+dateSelect+`,
 			COUNT(*) as total_alerts,
@@ -466,7 +476,7 @@ func GetComponentStats(c *gin.Context) {
 
 	// 3. Recent Issues
 	recentIssues := []models.Issue{}
-	db.DB.Where("is_alert = ? AND components LIKE ? "+envCondition+categoryCondition+stabilityCondition, true, componentFilter).
+	requestDB(c).Where("is_alert = ? AND components LIKE ? "+envCondition+categoryCondition+stabilityCondition, true, componentFilter).
 		Order("created DESC").
 		Limit(10).
 		Find(&recentIssues)
@@ -481,13 +491,14 @@ func GetComponentStats(c *gin.Context) {
 		Trend      string  `json:"trend"`
 	}
 	tenants := []TenantCount{}
+	names, ctx := requestNameService(), c.Request.Context()
 
 	type TenantBasic struct {
 		TenantID string
 		Count    int
 	}
 	topTenants := []TenantBasic{}
-	db.DB.Raw(`
+	requestDB(c).Raw(`
 		SELECT tenant_id, COUNT(*) as count
 		FROM issues
 		WHERE is_alert = 1 
@@ -501,14 +512,14 @@ func GetComponentStats(c *gin.Context) {
 
 	for _, t := range topTenants {
 		var prevCount int64
-		db.DB.Model(&models.Issue{}).
+		requestDB(c).Model(&models.Issue{}).
 			Where("is_alert = 1 AND components LIKE ? "+envCondition+categoryCondition+stabilityCondition+" AND tenant_id = ? AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?",
 				componentFilter, t.TenantID, prevStartDate, prevEndDate).
 			Count(&prevCount)
 
 		change, trend := calcCompChange(int64(t.Count), prevCount)
 		// Resolve Name
-		nameInfo := resolveNameInfo(targetName, t.TenantID)
+		nameInfo := resolveNameInfo(ctx, names, targetName, t.TenantID)
 
 		tenants = append(tenants, TenantCount{
 			TenantID:   t.TenantID,
@@ -537,7 +548,7 @@ func GetComponentStats(c *gin.Context) {
 		Count     int
 	}
 	topClusters := []ClusterBasic{}
-	db.DB.Raw(`
+	requestDB(c).Raw(`
 		SELECT cluster_id, COUNT(*) as count
 		FROM issues
 		WHERE is_alert = 1 
@@ -549,16 +560,17 @@ func GetComponentStats(c *gin.Context) {
 		LIMIT 10
 	`, componentFilter, startDate, endDate).Scan(&topClusters)
 
+	tx := requestDB(c)
 	for _, c := range topClusters {
 		var prevCount int64
-		db.DB.Model(&models.Issue{}).
+		tx.Model(&models.Issue{}).
 			Where("is_alert = 1 AND components LIKE ? "+envCondition+categoryCondition+stabilityCondition+" AND cluster_id = ? AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?",
 				componentFilter, c.ClusterID, prevStartDate, prevEndDate).
 			Count(&prevCount)
 
 		change, trend := calcCompChange(int64(c.Count), prevCount)
 
-		nameInfo := resolveNameInfo(targetName, c.ClusterID)
+		nameInfo := resolveNameInfo(ctx, names, targetName, c.ClusterID)
 
 		clusters = append(clusters, ClusterCount{
 			ClusterID:   c.ClusterID,
@@ -577,7 +589,7 @@ func GetComponentStats(c *gin.Context) {
 		Count     int    `json:"count"`
 	}
 	topRules := []RuleCount{}
-	db.DB.Raw(`
+	requestDB(c).Raw(`
 		SELECT alert_signature as signature, COUNT(*) as count
 		FROM issues
 		WHERE is_alert = 1 
@@ -599,7 +611,7 @@ func GetComponentStats(c *gin.Context) {
 	for _, issue := range recentIssues {
 		clusterName := ""
 		if issue.ClusterID != "" {
-			ni := resolveNameInfo(targetName, issue.ClusterID)
+			ni := resolveNameInfo(ctx, names, targetName, issue.ClusterID)
 			clusterName = ni.Name
 		}
 		recentIssuesEnriched = append(recentIssuesEnriched, IssueWithNames{
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+func TestComponentStatsNames(t *testing.T) {
+	openTestDB(t)
+	fake := useFakeNames(t)
+	fake.RegisterName("2001", services.NameInfo{Type: "cluster", Name: "prod-east", TenantName: "acme"})
+	fake.RegisterName("1001", services.NameInfo{Type: "tenant", Name: "acme"})
+	for _, id := range []string{"A-1", "A-2"} {
+		seedIssue(t, id, "2001", "1001", time.Hour)
+	}
+	seedIssue(t, "A-3", "9999", "1001", time.Hour)
+	db.DB.Model(&models.Issue{}).Where("1 = 1").Updates(map[string]any{
+		"components":           `["TiKV"]`,
+		"stability_governance": "storage",
+	})
+
+	r := gin.New()
+	r.GET("/api/components/:name/stats", GetComponentStats)
+
+	var resp struct {
+		TopTenants []struct {
+			TenantID   string `json:"tenant_id"`
+			TenantName string `json:"tenant_name"`
+		} `json:"top_tenants"`
+		TopClusters []struct {
+			ClusterID   string `json:"cluster_id"`
+			ClusterName string `json:"cluster_name"`
+			TenantName  string `json:"tenant_name"`
+		} `json:"top_clusters"`
+		RecentIssues []struct {
+			ID          string `json:"id"`
+			ClusterName string `json:"cluster_name"`
+		} `json:"recent_issues"`
+	}
+	decodeJSON(t, mustServe(t, r, http.MethodGet, "/api/components/TiKV/stats", "", ""), &resp)
+
+	if len(resp.TopTenants) != 1 || resp.TopTenants[0].TenantName != "acme" {
+		t.Errorf("top tenants = %+v, want acme", resp.TopTenants)
+	}
+	names := map[string][2]string{}
+	for _, c := range resp.TopClusters {
+		names[c.ClusterID] = [2]string{c.ClusterName, c.TenantName}
+	}
+	if names["2001"] != [2]string{"prod-east", "acme"} {
+		t.Errorf("cluster 2001 = %v, want prod-east of acme", names["2001"])
+	}
+	// Unknown IDs are shown as themselves
+	if names["9999"][0] != "9999" {
+		t.Errorf("cluster 9999 = %v, want its ID as the name", names["9999"])
+	}
+	if len(resp.RecentIssues) != 3 {
+		t.Fatalf("recent issues = %+v, want 3", resp.RecentIssues)
+	}
+	for _, issue := range resp.RecentIssues {
+		if issue.ID != "A-3" && issue.ClusterName != "prod-east" {
+			t.Errorf("issue %s cluster name = %q, want prod-east", issue.ID, issue.ClusterName)
+		}
+	}
+	// Once as a top cluster and twice for the recent issues
+	if n := fake.Lookups("2001"); n != 3 {
+		t.Errorf("cluster 2001 looked up %d times, want 3", n)
+	}
+}
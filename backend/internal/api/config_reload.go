@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// ReloadConfig re-reads the severity map and version filter configs without
+// restarting. An invalid config is rejected and the current one kept.
+func ReloadConfig(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+
+	mapper, err := services.ReloadSeverityMapper()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filters, err := services.ReloadVersionFilters()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"severity_map":    gin.H{"path": mapper.Path(), "entries": mapper.Len()},
+		"version_filters": gin.H{"path": filters.Path, "filters": len(filters.Filters)},
+	})
+}
+
+// GetVersionFilters returns the version filters suppressing known false
+// positives on ingestion
+func GetVersionFilters(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+	filters := services.GetVersionFilters()
+	list := filters.Filters
+	if list == nil {
+		list = []services.VersionFilter{}
+	}
+	c.JSON(http.StatusOK, gin.H{"path": filters.Path, "filters": list})
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+func TestReloadConfigSeverityMap(t *testing.T) {
+	openTestDB(t)
+	useNameResolver(t)
+	// Registered first so it runs after SEVERITY_MAP_CONFIG is restored
+	t.Cleanup(func() { services.ReloadSeverityMapper() })
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "severity_map.yaml")
+	t.Setenv("SEVERITY_MAP_CONFIG", path)
+	t.Setenv("VERSION_FILTER_CONFIG", filepath.Join(dir, "version_filters.yaml"))
+	t.Setenv("ADMIN_API_TOKEN", "admin-token")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := gin.New()
+	r.POST("/api/alerts/validate", ValidateAlertLabels)
+	r.POST("/api/admin/reload-config", ReloadConfig)
+	reload := func() (int, string) {
+		req := newRequest(http.MethodPost, "/api/admin/reload-config", "")
+		req.Header.Set("X-Admin-Token", "admin-token")
+		w := serveRequest(r, req)
+		return w.Code, w.Body.String()
+	}
+	validate := func(severity string) int {
+		return serve(r, http.MethodPost, "/api/alerts/validate", "", `{"labels": {"severity": "`+severity+`", "cluster_id": "2001"}}`).Code
+	}
+
+	write("P1: critical\nsev2: high\n")
+	if code, body := reload(); code != http.StatusOK {
+		t.Fatalf("reload: %d %s", code, body)
+	}
+	for severity, code := range map[string]int{
+		"P1": http.StatusOK, "SEV2": http.StatusOK, "Critical": http.StatusOK,
+		// Unknown severities become "unknown", which the schema rejects
+		"P3": http.StatusBadRequest,
+	} {
+		if got := validate(severity); got != code {
+			t.Errorf("severity %s: %d, want %d", severity, got, code)
+		}
+	}
+
+	// The reloaded map applies to the next alerts without a restart
+	write("P1: critical\nsev2: high\nP3: low\n")
+	var resp struct {
+		SeverityMap struct {
+			Path    string `json:"path"`
+			Entries int    `json:"entries"`
+		} `json:"severity_map"`
+	}
+	code, body := reload()
+	decodeJSON(t, body, &resp)
+	if code != http.StatusOK || resp.SeverityMap.Path != path || resp.SeverityMap.Entries != 6 {
+		t.Errorf("reload: %d %s", code, body)
+	}
+	if got := validate("p3"); got != http.StatusOK {
+		t.Errorf("P3 after reload: %d, want 200", got)
+	}
+
+	// An invalid config is rejected and the loaded map kept
+	write("P1: [critical\n")
+	if code, _ := reload(); code != http.StatusBadRequest {
+		t.Errorf("reload of an invalid config: %d, want 400", code)
+	}
+	if got := validate("P3"); got != http.StatusOK {
+		t.Errorf("P3 after a failed reload: %d, want 200", got)
+	}
+
+	req := newRequest(http.MethodPost, "/api/admin/reload-config", "")
+	if w := serveRequest(r, req); w.Code != http.StatusUnauthorized {
+		t.Errorf("reload without the admin token: %d, want 401", w.Code)
+	}
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"github.com/nolouch/alerts-platform-v2/internal/db"
 	"github.com/nolouch/alerts-platform-v2/internal/models"
 	"github.com/nolouch/alerts-platform-v2/internal/services"
+	"gorm.io/gorm"
 )
 
 // DashboardDataResponse matches the frontend expectation
@@ -144,6 +147,10 @@ func GetDashboardData(c *gin.Context) {
 	if tenantFilter != "" {
 		filterCondition += " AND tenant_id = '" + tenantFilter + "'"
 	}
+	// Tenant-scoped tokens only ever see their own tenant's alerts
+	if tenant := scopedTenant(c); tenant != "" {
+		filterCondition += " AND tenant_id = '" + strings.ReplaceAll(tenant, "'", "''") + "'"
+	}
 	if signatureFilter != "" {
 		filterCondition += " AND alert_signature = '" + signatureFilter + "'"
 	}
@@ -160,7 +167,7 @@ func GetDashboardData(c *gin.Context) {
 			NonProd  int
 			Critical int
 		}
-		db.DB.Raw(`
+		requestDB(c).Raw(`
 			SELECT
 				COUNT(*) as total,
 				SUM(CASE WHEN alert_signature LIKE '[PROD]%' THEN 1 ELSE 0 END) as prod,
@@ -175,23 +182,23 @@ func GetDashboardData(c *gin.Context) {
 
 	// 1.5 Rate Stats (Current)
 	var currFake int64
-	db.DB.Model(&models.Issue{}).
+	requestDB(c).Model(&models.Issue{}).
 		Where("is_alert = 1 "+envCondition+filterCondition+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status = 'FAKE ALARM'", startDate, endDate).
 		Count(&currFake)
 
 	var currHandled int64
-	db.DB.Model(&models.Issue{}).
+	requestDB(c).Model(&models.Issue{}).
 		Where("is_alert = 1 "+envCondition+filterCondition+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status != 'Created'", startDate, endDate).
 		Count(&currHandled)
 
 	// 1.6 Rate Stats (Previous)
 	var prevFake int64
-	db.DB.Model(&models.Issue{}).
+	requestDB(c).Model(&models.Issue{}).
 		Where("is_alert = 1 "+envCondition+filterCondition+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status = 'FAKE ALARM'", prevStartDate, prevEndDate).
 		Count(&prevFake)
 
 	var prevHandled int64
-	db.DB.Model(&models.Issue{}).
+	requestDB(c).Model(&models.Issue{}).
 		Where("is_alert = 1 "+envCondition+filterCondition+" AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? AND status != 'Created'", prevStartDate, prevEndDate).
 		Count(&prevHandled)
 
@@ -232,8 +239,8 @@ func GetDashboardData(c *gin.Context) {
 		TenantID string
 		Count    int
 	}
-	var topTenants []TenantBasic
-	db.DB.Raw(`
+	ctx := c.Request.Context()
+	topTenants, err := queryAll[TenantBasic](c, `
 		SELECT tenant_id, COUNT(*) as count
 		FROM issues
 		WHERE is_alert = 1 `+envCondition+filterCondition+` AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?
@@ -241,19 +248,22 @@ func GetDashboardData(c *gin.Context) {
 		GROUP BY tenant_id
 		ORDER BY count DESC
 		LIMIT 10
-	`, startDate, endDate).Scan(&topTenants)
+	`, startDate, endDate)
+	if err != nil {
+		log.Printf("[WARN] Failed to load top tenants: %v\n", err)
+	}
 
 	// For each top tenant, get previous stats and resolve name
 	for _, t := range topTenants {
 		var prevCount int64
-		db.DB.Model(&models.Issue{}).
+		requestDB(c).Model(&models.Issue{}).
 			Where("is_alert = 1 "+envCondition+filterCondition+" AND tenant_id = ? AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?", t.TenantID, prevStartDate, prevEndDate).
 			Count(&prevCount)
 
 		change, trend := calculateChange(t.Count, int(prevCount))
 
 		// Resolve Name
-		info, _ := services.GetNameResolver().Resolve(t.TenantID)
+		info, _ := services.GetNameService().ResolveContext(ctx, t.TenantID)
 
 		tenants = append(tenants, TenantCount{
 			TenantID:   t.TenantID,
@@ -272,8 +282,7 @@ func GetDashboardData(c *gin.Context) {
 		ClusterID string
 		Count     int
 	}
-	var topClusters []ClusterBasic
-	db.DB.Raw(`
+	topClusters, err := queryAll[ClusterBasic](c, `
 		SELECT cluster_id, COUNT(*) as count
 		FROM issues
 		WHERE is_alert = 1 `+envCondition+filterCondition+` AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?
@@ -281,18 +290,22 @@ func GetDashboardData(c *gin.Context) {
 		GROUP BY cluster_id
 		ORDER BY count DESC
 		LIMIT 10
-	`, startDate, endDate).Scan(&topClusters)
+	`, startDate, endDate)
+	if err != nil {
+		log.Printf("[WARN] Failed to load top clusters: %v\n", err)
+	}
 
+	tx := requestDB(c)
 	for _, c := range topClusters {
 		var prevCount int64
-		db.DB.Model(&models.Issue{}).
+		tx.Model(&models.Issue{}).
 			Where("is_alert = 1 "+envCondition+filterCondition+" AND cluster_id = ? AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?", c.ClusterID, prevStartDate, prevEndDate).
 			Count(&prevCount)
 
 		change, trend := calculateChange(c.Count, int(prevCount))
 
 		// Resolve Name
-		info, _ := services.GetNameResolver().Resolve(c.ClusterID)
+		info, _ := services.GetNameService().ResolveContext(ctx, c.ClusterID)
 
 		clusters = append(clusters, ClusterCount{
 			ClusterID:   c.ClusterID,
@@ -307,7 +320,7 @@ func GetDashboardData(c *gin.Context) {
 
 	// 3. Top Signatures (Current)
 	var signatures []SignatureCount
-	db.DB.Raw(`
+	requestDB(c).Raw(`
 		SELECT 
 			alert_signature as signature,
 			COUNT(*) as total_count,
@@ -323,7 +336,7 @@ func GetDashboardData(c *gin.Context) {
 
 	// 4. Top Components (Current)
 	var components []ComponentCount
-	db.DB.Raw(`
+	requestDB(c).Raw(`
 		SELECT 
 			CASE 
 				WHEN components IS NULL OR components = '[]' OR components = '' THEN 'No Component'
@@ -352,7 +365,7 @@ func GetDashboardData(c *gin.Context) {
 		dateSelect = "SUBSTR(REPLACE(created, ' UTC', ''), 1, 10) as date"
 	}
 
-	db.DB.Raw(`
+	requestDB(c).Raw(`
 		SELECT 
 			`+dateSelect+`,
 			COUNT(*) as total_alerts,
@@ -366,8 +379,10 @@ func GetDashboardData(c *gin.Context) {
 	`, startDate[:10], endDate[:10]).Scan(&trend)
 
 	// Priority Breakdown
-	var priorityCounts []PriorityCount
-	db.DB.Raw(`SELECT priority, COUNT(*) as count FROM issues WHERE is_alert=1 `+envCondition+filterCondition+` AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? GROUP BY priority`, startDate, endDate).Scan(&priorityCounts)
+	priorityCounts, err := queryAll[PriorityCount](c, `SELECT priority, COUNT(*) as count FROM issues WHERE is_alert=1 `+envCondition+filterCondition+` AND REPLACE(created, ' UTC', '') BETWEEN ? AND ? GROUP BY priority`, startDate, endDate)
+	if err != nil {
+		log.Printf("[WARN] Failed to load priority breakdown: %v\n", err)
+	}
 
 	// Build MetricStats
 	totalChange, totalTrend := calculateChange(currTotal, prevTotal)
@@ -400,6 +415,40 @@ func GetDashboardData(c *gin.Context) {
 
 // GetDashboardIssues returns a list of issues matching the dashboard filters
 func GetDashboardIssues(c *gin.Context) {
+	issues, ok := dashboardIssues(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, issues)
+}
+
+// dashboardIssues loads the page of alerts selected by the dashboard filters of
+// the request. On failure it writes the error response and returns false.
+func dashboardIssues(c *gin.Context) ([]models.Issue, bool) {
+	query, ok := dashboardIssuesQuery(c)
+	if !ok {
+		return nil, false
+	}
+	if query == nil {
+		return []models.Issue{}, true
+	}
+
+	var issues []models.Issue
+	query.Find(&issues)
+
+	if err := services.NewSilenceService(requestDB(c)).MarkSilenced(issues, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load silences"})
+		return nil, false
+	}
+
+	return issues, true
+}
+
+// dashboardIssuesQuery builds the query for the page of alerts selected by the
+// dashboard filters of the request, without running it. The query is nil when
+// no cluster matches the deploy_type, provider or region filters. On failure it
+// writes the error response and returns false.
+func dashboardIssuesQuery(c *gin.Context) (*gorm.DB, bool) {
 	daysStr := c.DefaultQuery("days", "30")
 	envStr := c.DefaultQuery("env", "all")
 	componentFilter := c.Query("component")
@@ -464,6 +513,10 @@ func GetDashboardIssues(c *gin.Context) {
 	if tenantFilter != "" {
 		filterCondition += " AND tenant_id = '" + tenantFilter + "'"
 	}
+	// Tenant-scoped tokens only ever see their own tenant's alerts
+	if tenant := scopedTenant(c); tenant != "" {
+		filterCondition += " AND tenant_id = '" + strings.ReplaceAll(tenant, "'", "''") + "'"
+	}
 	if signatureFilter != "" {
 		filterCondition += " AND alert_signature = '" + signatureFilter + "'"
 	}
@@ -471,6 +524,18 @@ func GetDashboardIssues(c *gin.Context) {
 		filterCondition += " AND cluster_id = '" + clusterFilter + "'"
 	}
 
+	// Label filters, e.g. label=region=us-west-2 (repeatable, all must match),
+	// are joined against the (key, value) index of alert_labels
+	var labelFilters [][2]string
+	for _, label := range c.QueryArray("label") {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok || key == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "label filters must be key=value"})
+			return nil, false
+		}
+		labelFilters = append(labelFilters, [2]string{key, value})
+	}
+
 	if category != "" {
 		switch category {
 		case "premium":
@@ -506,18 +571,116 @@ func GetDashboardIssues(c *gin.Context) {
 		filterCondition += " AND priority IN (" + strings.Join(quoted, ",") + ")"
 	}
 
-	var issues []models.Issue
-	db.DB.Model(&models.Issue{}).
-		Select("issues.*").
-		Joins("LEFT JOIN muted_issues ON muted_issues.issue_id = issues.id").
-		Where("muted_issues.issue_id IS NULL").
+	// Filter by cluster deploy type (e.g. nextgen-host, dedicated) using the
+	// cached cluster metadata, before pagination
+	if deployType := c.Query("deploy_type"); deployType != "" {
+		var clusterIDs []string
+		requestDB(c).Model(&models.Issue{}).
+			Distinct("cluster_id").
+			Where("is_alert = 1 "+envCondition+filterCondition+" AND cluster_id != '' AND REPLACE(issues.created, ' UTC', '') BETWEEN ? AND ?", startDate, endDate).
+			Pluck("cluster_id", &clusterIDs)
+
+		matching, err := clusterIDsWithDeployType(clusterIDs, deployType)
+		if err != nil {
+			log.Printf("[WARN] Deploy type filter failed: %v\n", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to resolve cluster deploy types"})
+			return nil, false
+		}
+		if len(matching) == 0 {
+			return nil, true
+		}
+		quoted := make([]string, len(matching))
+		for i, id := range matching {
+			quoted[i] = "'" + strings.ReplaceAll(id, "'", "''") + "'"
+		}
+		filterCondition += " AND cluster_id IN (" + strings.Join(quoted, ",") + ")"
+	}
+
+	// Filter by cloud provider and/or region through the cluster location index
+	provider, region := c.Query("provider"), c.Query("region")
+	if provider != "" || region != "" {
+		matching, err := services.GetClusterLocationIndex().ClusterIDs(requestDB(c), provider, region)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to filter by provider/region"})
+			return nil, false
+		}
+		if len(matching) == 0 {
+			return nil, true
+		}
+		quoted := make([]string, len(matching))
+		for i, id := range matching {
+			quoted[i] = "'" + strings.ReplaceAll(id, "'", "''") + "'"
+		}
+		filterCondition += " AND cluster_id IN (" + strings.Join(quoted, ",") + ")"
+	}
+
+	// Alerts suppressed by cluster lifecycle are hidden unless asked for
+	// (suppressed=true shows only them, suppressed=all shows everything)
+	switch c.Query("suppressed") {
+	case "true":
+		filterCondition += " AND issues.suppressed = 1"
+	case "all":
+	default:
+		filterCondition += " AND (issues.suppressed = 0 OR issues.suppressed IS NULL)"
+	}
+
+	// Collapse duplicates of the same alert to the latest one (collapse=false shows all)
+	if c.DefaultQuery("collapse", "true") != "false" {
+		filterCondition += " AND (issues.dedup_key = '' OR issues.dedup_key IS NULL OR issues.created = (SELECT MAX(d.created) FROM issues d WHERE d.dedup_key = issues.dedup_key))"
+	}
+
+	query := requestDB(c).Model(&models.Issue{}).
+		Select("issues.*, " + acknowledgedColumn).
+		Joins("LEFT JOIN muted_issues ON muted_issues.issue_id = issues.id")
+	for i, label := range labelFilters {
+		alias := fmt.Sprintf("label_%d", i)
+		query = query.Joins("INNER JOIN alert_labels "+alias+" ON "+alias+".alert_id = issues.id AND "+alias+".key = ? AND "+alias+".value = ?", label[0], label[1])
+	}
+
+	return query.Where("muted_issues.issue_id IS NULL").
+		Where("issues.deleted_at IS NULL").
 		Where("is_alert = 1 "+envCondition+filterCondition+" AND REPLACE(issues.created, ' UTC', '') BETWEEN ? AND ?", startDate, endDate).
 		Order("issues.created DESC").
 		Limit(pageSize).
-		Offset(offset).
-		Find(&issues)
+		Offset(offset), true
+}
 
-	c.JSON(http.StatusOK, issues)
+// clusterIDsWithDeployType returns the clusters whose deploy type matches
+// deployType (case-insensitive). The IDs are resolved in one batch; only
+// clusters cached without their deploy type are looked up one by one. IDs
+// that are not clusters never match. It fails if any lookup fails.
+func clusterIDsWithDeployType(clusterIDs []string, deployType string) ([]string, error) {
+	svc := services.GetNameService()
+	infos, errs := svc.ResolveBatch(clusterIDs)
+	for _, err := range errs {
+		if !errors.Is(err, services.ErrIDNotFound) {
+			return nil, err
+		}
+	}
+
+	details, _ := svc.(interface {
+		ResolveCluster(clusterID string) (*services.ClusterInfo, error)
+	})
+	var matching []string
+	for _, id := range clusterIDs {
+		info, ok := infos[id]
+		if !ok || info.Type != "cluster" {
+			continue
+		}
+		if info.DeployType == "" && details != nil {
+			cluster, err := details.ResolveCluster(id)
+			if err != nil && !errors.Is(err, services.ErrIDNotFound) {
+				return nil, err
+			}
+			if cluster != nil {
+				info.DeployType = cluster.DeployType
+			}
+		}
+		if strings.EqualFold(info.DeployType, deployType) {
+			matching = append(matching, id)
+		}
+	}
+	return matching, nil
 }
 
 // MuteIssue mutes an issue
@@ -533,10 +696,30 @@ func MuteIssue(c *gin.Context) {
 		Reason:  "User muted via dashboard",
 	}
 
-	if err := db.DB.Create(&muted).Error; err != nil {
+	if err := requestDB(c).Create(&muted).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mute issue"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
+
+// queryAll runs a raw query bounded by the request context and the default
+// SQLite query timeout and scans every row into a T
+func queryAll[T any](c *gin.Context, query string, args ...interface{}) ([]T, error) {
+	rows, err := db.QueryWithTimeout(c.Request.Context(), 0, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []T
+	for rows.Next() {
+		var row T
+		if err := db.DB.ScanRows(rows, &row); err != nil {
+			return results, err
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
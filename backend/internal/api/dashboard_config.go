@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// maxDashboardConfigSize bounds the body of PutDashboardConfig, in bytes
+const maxDashboardConfigSize = 64 * 1024
+
+// DashboardConfigResponse is the dashboard config of the current user.
+// Default is set when the system default is returned instead of a saved config.
+type DashboardConfigResponse struct {
+	UserID    string          `json:"user_id"`
+	Config    json.RawMessage `json:"config"`
+	UpdatedAt *time.Time      `json:"updated_at,omitempty"`
+	Default   bool            `json:"default"`
+}
+
+// GetDashboardConfig returns the dashboard config saved by the current user.
+// Without a saved config it returns 404, or the system default with
+// ?default=true.
+func GetDashboardConfig(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Token does not identify a user"})
+		return
+	}
+
+	config, err := services.NewDashboardConfigService(requestDB(c)).Get(user)
+	if errors.Is(err, services.ErrNoDashboardConfig) {
+		if c.Query("default") == "true" {
+			c.JSON(http.StatusOK, DashboardConfigResponse{UserID: user, Config: services.DefaultDashboardConfig(), Default: true})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load dashboard config"})
+		return
+	}
+	c.JSON(http.StatusOK, DashboardConfigResponse{UserID: user, Config: config.Config, UpdatedAt: &config.UpdatedAt})
+}
+
+// PutDashboardConfig replaces the dashboard config of the current user with
+// the JSON body, which must satisfy the embedded dashboard config schema
+func PutDashboardConfig(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Token does not identify a user"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxDashboardConfigSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(body) > maxDashboardConfigSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "dashboard config is too large"})
+		return
+	}
+
+	config, errs, err := services.NewDashboardConfigService(requestDB(c)).Put(user, body)
+	if len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dashboard config", "errors": errs})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save dashboard config"})
+		return
+	}
+	c.JSON(http.StatusOK, DashboardConfigResponse{UserID: user, Config: config.Config, UpdatedAt: &config.UpdatedAt})
+}
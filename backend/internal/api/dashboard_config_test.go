@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/rbac"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+func dashboardConfigRouter() *gin.Engine {
+	r := gin.New()
+	r.GET("/api/user/dashboard-config", Authenticate(), GetDashboardConfig)
+	r.PUT("/api/user/dashboard-config", Authenticate(), PutDashboardConfig)
+	return r
+}
+
+func TestDashboardConfigAPI(t *testing.T) {
+	openTestDB(t)
+	useAuth(t)
+	r := dashboardConfigRouter()
+	ops := signToken(t, "1001", rbac.RoleEditor)
+	dev := signToken(t, "1001", rbac.RoleViewer)
+	const path = "/api/user/dashboard-config"
+
+	if w := serve(r, http.MethodGet, path, ops, ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET without a saved config: %d, want 404", w.Code)
+	}
+	var defaults bytes.Buffer
+	json.Compact(&defaults, services.DefaultDashboardConfig())
+	var resp DashboardConfigResponse
+	decodeJSON(t, mustServe(t, r, http.MethodGet, path+"?default=true", ops, ""), &resp)
+	if !resp.Default || resp.UserID != "editor@1001.example.com" || resp.UpdatedAt != nil || string(resp.Config) != defaults.String() {
+		t.Errorf("GET ?default=true = %+v, want the system default", resp)
+	}
+
+	config := `{"panels":[{"id":"trend","width":6}],"default_time_range":"7d"}`
+	decodeJSON(t, mustServe(t, r, http.MethodPut, path, ops, config), &resp)
+	if resp.Default || resp.UpdatedAt == nil || string(resp.Config) != config {
+		t.Errorf("PUT = %+v", resp)
+	}
+
+	// The saved config wins over the default, and is the caller's only
+	for _, query := range []string{"", "?default=true"} {
+		resp = DashboardConfigResponse{}
+		decodeJSON(t, mustServe(t, r, http.MethodGet, path+query, ops, ""), &resp)
+		if resp.Default || string(resp.Config) != config {
+			t.Errorf("GET%s = %+v, want the saved config", query, resp)
+		}
+	}
+	if w := serve(r, http.MethodGet, path, dev, ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET as another user: %d, want 404", w.Code)
+	}
+
+	var invalid struct {
+		Errors []services.ValidationError `json:"errors"`
+	}
+	w := serve(r, http.MethodPut, path, ops, `{"default_time_range":"2h","panels":[{}]}`)
+	if err := json.Unmarshal(w.Body.Bytes(), &invalid); err != nil || w.Code != http.StatusBadRequest || len(invalid.Errors) != 2 {
+		t.Errorf("PUT of an invalid config: %d %s", w.Code, w.Body)
+	}
+	large := `{"timezone":"` + strings.Repeat("x", maxDashboardConfigSize) + `"}`
+	if w := serve(r, http.MethodPut, path, ops, large); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("PUT of %d bytes: %d, want 413", len(large), w.Code)
+	}
+	if w := serve(r, http.MethodPut, path, "", config); w.Code != http.StatusUnauthorized {
+		t.Errorf("PUT without a token: %d, want 401", w.Code)
+	}
+}
+
+func TestDashboardConfigIssuedToken(t *testing.T) {
+	openTestDB(t)
+	useAuth(t)
+	r := dashboardConfigRouter()
+	r.POST("/api/v1/auth/token", IssueAuthToken)
+
+	issue := func(req IssueTokenRequest) string {
+		body, _ := json.Marshal(req)
+		httpReq := newRequest(http.MethodPost, "/api/v1/auth/token", string(body))
+		httpReq.Header.Set("X-Admin-Token", "test-admin-token")
+		var resp struct {
+			Token string `json:"token"`
+		}
+		w := serveRequest(r, httpReq)
+		if w.Code != http.StatusOK {
+			t.Fatalf("issue %+v: %d %s", req, w.Code, w.Body)
+		}
+		decodeJSON(t, w.Body.String(), &resp)
+		return resp.Token
+	}
+
+	// The user of an issued token owns its config
+	token := issue(IssueTokenRequest{TenantID: "1001", User: "ops@acme.example.com", Role: rbac.RoleViewer})
+	mustServe(t, r, http.MethodPut, "/api/user/dashboard-config", token, `{"timezone":"UTC"}`)
+	var resp DashboardConfigResponse
+	decodeJSON(t, mustServe(t, r, http.MethodGet, "/api/user/dashboard-config", token, ""), &resp)
+	if resp.UserID != "ops@acme.example.com" || string(resp.Config) != `{"timezone":"UTC"}` {
+		t.Errorf("GET = %+v", resp)
+	}
+
+	// Tokens issued without a user have no config
+	anonymous := issue(IssueTokenRequest{TenantID: "1001"})
+	if w := serve(r, http.MethodGet, "/api/user/dashboard-config", anonymous, ""); w.Code != http.StatusForbidden {
+		t.Errorf("GET with a token without a user: %d, want 403", w.Code)
+	}
+}
+
+func TestDashboardConfigAnonymous(t *testing.T) {
+	openTestDB(t)
+	t.Setenv("AUTH_SECRET", "")
+	r := dashboardConfigRouter()
+
+	// Without authentication every caller shares one config
+	mustServe(t, r, http.MethodPut, "/api/user/dashboard-config", "", `{"timezone":"Asia/Shanghai"}`)
+	var resp DashboardConfigResponse
+	decodeJSON(t, mustServe(t, r, http.MethodGet, "/api/user/dashboard-config", "", ""), &resp)
+	if resp.UserID != anonymousUser || string(resp.Config) != `{"timezone":"Asia/Shanghai"}` {
+		t.Errorf("GET = %+v", resp)
+	}
+}
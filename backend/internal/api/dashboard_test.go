@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+func TestClusterIDsWithDeployType(t *testing.T) {
+	openTestDB(t)
+	tidb := openTestTiDB(t)
+	mustExec(t, tidb,
+		`INSERT INTO tenants (tenant_id, tenant_name) VALUES ('1001', 'acme')`,
+		`INSERT INTO clusters (cluster_id, cluster_name, tenant_id, deploy_type) VALUES
+			('2001', 'prod-east', '1001', 'dedicated'),
+			('2002', 'serverless-1', '1001', 'nextgen-host'),
+			('2003', 'prod-west', '1001', 'dedicated'),
+			('2004', 'prod-south', '1001', 'Dedicated')`,
+	)
+	nr := services.NewNameResolver()
+	useNameService(t, nr)
+
+	// 2003 is cached, and keeps its cached deploy type while the entry is valid
+	if _, err := nr.Resolve("2003"); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	mustExec(t, tidb, `UPDATE clusters SET deploy_type = 'nextgen-host' WHERE cluster_id = '2003'`)
+
+	// 2004 is cached without its deploy type, as restored from a name_cache row
+	// persisted before deploy types were
+	db.DB.Create(&models.NameCacheEntry{ID: "2004", Type: "cluster", Name: "prod-south", CachedAt: time.Now()})
+	if err := nr.LoadCachedNames(db.DB); err != nil {
+		t.Fatalf("LoadCachedNames: %v", err)
+	}
+
+	matching, err := clusterIDsWithDeployType([]string{"2001", "2002", "2003", "2004", "1001", "9999"}, "dedicated")
+	if err != nil {
+		t.Fatalf("clusterIDsWithDeployType: %v", err)
+	}
+	sort.Strings(matching)
+	want := []string{"2001", "2003", "2004"}
+	if len(matching) != len(want) || matching[0] != want[0] || matching[1] != want[1] || matching[2] != want[2] {
+		t.Errorf("matching = %v, want %v", matching, want)
+	}
+
+	matching, err = clusterIDsWithDeployType([]string{"2001", "2002", "2003"}, "nextgen-host")
+	if err != nil || len(matching) != 1 || matching[0] != "2002" {
+		t.Errorf("nextgen-host: %v, %v", matching, err)
+	}
+}
+
+// failingNames fails every batch lookup like a resolver with an open breaker
+type failingNames struct{ services.NameService }
+
+func (failingNames) ResolveBatch(ids []string) (map[string]services.NameInfo, []error) {
+	return map[string]services.NameInfo{}, []error{services.ErrCircuitOpen}
+}
+
+func TestDashboardIssuesDeployTypeFilter(t *testing.T) {
+	openTestDB(t)
+	fake := useFakeNames(t)
+	fake.RegisterName("2001", services.NameInfo{Type: "cluster", Name: "prod-east", DeployType: "dedicated"})
+	fake.RegisterName("2002", services.NameInfo{Type: "cluster", Name: "serverless-1", DeployType: "nextgen-host"})
+	seedIssue(t, "A-1", "2001", "1001", time.Hour)
+	seedIssue(t, "A-2", "2002", "1001", time.Hour)
+	seedIssue(t, "A-3", "2001", "1001", 2*time.Hour)
+
+	r := gin.New()
+	r.GET("/api/dashboard/issues", GetDashboardIssues)
+
+	w := serve(r, http.MethodGet, "/api/dashboard/issues?deploy_type=dedicated", "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var issues []models.Issue
+	json.Unmarshal(w.Body.Bytes(), &issues)
+	if len(issues) != 2 || issues[0].ID != "A-1" || issues[1].ID != "A-3" {
+		t.Errorf("issues = %+v, want A-1 and A-3", issues)
+	}
+	if fake.ResolveBatchCalls() != 1 || fake.ResolveCalls() != 0 {
+		t.Errorf("%d batch and %d single lookups, want one batch", fake.ResolveBatchCalls(), fake.ResolveCalls())
+	}
+
+	w = serve(r, http.MethodGet, "/api/dashboard/issues?deploy_type=byoc", "", "")
+	if w.Code != http.StatusOK || w.Body.String() != "[]" {
+		t.Errorf("no matching cluster: %d %s", w.Code, w.Body)
+	}
+
+	useNameService(t, failingNames{})
+	if w := serve(r, http.MethodGet, "/api/dashboard/issues?deploy_type=dedicated", "", ""); w.Code != http.StatusBadGateway {
+		t.Errorf("failed lookups: status %d, want 502", w.Code)
+	}
+}
+
+func TestDashboardIssuesSuppressedFilter(t *testing.T) {
+	openTestDB(t)
+	useFakeNames(t)
+	seedIssue(t, "A-1", "2001", "1001", time.Hour)
+	seedIssue(t, "A-2", "2001", "1001", 2*time.Hour)
+	db.DB.Model(&models.Issue{}).Where("id = ?", "A-2").Updates(map[string]any{"suppressed": true, "suppression_reason": "lifecycle"})
+
+	r := gin.New()
+	r.GET("/api/dashboard/issues", GetDashboardIssues)
+
+	for query, want := range map[string]string{
+		"":                 "A-1",
+		"?suppressed=true": "A-2",
+		"?suppressed=all":  "A-1 A-2",
+	} {
+		var issues []models.Issue
+		decodeJSON(t, mustServe(t, r, http.MethodGet, "/api/dashboard/issues"+query, "", ""), &issues)
+		var ids []string
+		for _, issue := range issues {
+			ids = append(ids, issue.ID)
+		}
+		if got := strings.Join(ids, " "); got != want {
+			t.Errorf("issues%s = %s, want %s", query, got, want)
+		}
+	}
+}
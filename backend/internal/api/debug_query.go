@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// DebugAlertQuery returns the SQL that GET /api/dashboard/issues would run for
+// the same query parameters, with its bind parameters, without running it.
+// Lookups needed to build the filters (deploy_type, provider, region) still
+// run. Only registered when ENABLE_DEBUG_ENDPOINTS=true, for admins.
+func DebugAlertQuery(c *gin.Context) {
+	query, ok := dashboardIssuesQuery(c)
+	if !ok {
+		return
+	}
+	if query == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"sql":  "",
+			"vars": []interface{}{},
+			"note": "no cluster matches the deploy_type, provider or region filters, so no query is run",
+		})
+		return
+	}
+
+	stmt := query.Session(&gorm.Session{DryRun: true}).Find(&[]models.Issue{}).Statement
+	sql := stmt.SQL.String()
+	vars := stmt.Vars
+	if vars == nil {
+		vars = []interface{}{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"sql":     sql,
+		"vars":    vars,
+		"explain": stmt.Dialector.Explain(sql, vars...), // vars inlined, for reading only
+	})
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDebugAlertQuery(t *testing.T) {
+	openTestDB(t)
+	useFakeNames(t)
+	seedIssue(t, "A-1", "2001", "1001", time.Hour)
+	r := gin.New()
+	r.GET("/api/debug/query", DebugAlertQuery)
+
+	type debugQuery struct {
+		SQL     string   `json:"sql"`
+		Vars    []string `json:"vars"`
+		Explain string   `json:"explain"`
+	}
+	get := func(query string) debugQuery {
+		t.Helper()
+		var resp debugQuery
+		decodeJSON(t, mustServe(t, r, http.MethodGet, "/api/debug/query"+query, "", ""), &resp)
+		return resp
+	}
+
+	base := get("")
+	for _, want := range []string{
+		"FROM `issues` LEFT JOIN muted_issues",
+		"(issues.suppressed = 0 OR issues.suppressed IS NULL)",
+		"issues.dedup_key = ''",
+		"BETWEEN ? AND ?",
+		"ORDER BY issues.created DESC LIMIT 50",
+	} {
+		if !strings.Contains(base.SQL, want) {
+			t.Errorf("default SQL lacks %q:\n%s", want, base.SQL)
+		}
+	}
+	if len(base.Vars) != 2 || !strings.Contains(base.Explain, base.Vars[0]) {
+		t.Errorf("default vars = %v, explain %s", base.Vars, base.Explain)
+	}
+	if start, err := time.Parse("2006-01-02 15:04:05", base.Vars[0]); err != nil || time.Since(start) < 29*24*time.Hour {
+		t.Errorf("default window starts at %s, want 30 days ago", base.Vars[0])
+	}
+
+	// Each filter changes the SQL it is about and nothing else
+	for query, tc := range map[string]struct{ adds, drops []string }{
+		"?tenant_id=1001":                {adds: []string{"tenant_id = '1001'"}},
+		"?cluster_id=2001&signature=Foo": {adds: []string{"cluster_id = '2001'", "alert_signature = 'Foo'"}},
+		"?priority=Critical,%20Major":    {adds: []string{"priority IN ('Critical','Major')"}},
+		"?env=prod":                      {adds: []string{"alert_signature LIKE '[PROD]%'"}},
+		"?category=premium":              {adds: []string{"biz_type LIKE '%nextgen%'"}},
+		"?metric_type=handled":           {adds: []string{"status != 'Created'"}},
+		"?page=3&page_size=20":           {adds: []string{"LIMIT 20 OFFSET 40"}, drops: []string{"LIMIT 50"}},
+		"?suppressed=true":               {adds: []string{"issues.suppressed = 1"}, drops: []string{"issues.suppressed = 0"}},
+		"?suppressed=all&collapse=false": {drops: []string{"suppressed", "dedup_key"}},
+		"?label=env=prod&label=team=tikv": {adds: []string{
+			"INNER JOIN alert_labels label_0 ON label_0.alert_id = issues.id AND label_0.key = ? AND label_0.value = ?",
+			"INNER JOIN alert_labels label_1",
+		}},
+	} {
+		got := get(query)
+		for _, want := range tc.adds {
+			if strings.Contains(base.SQL, want) || !strings.Contains(got.SQL, want) {
+				t.Errorf("%s: SQL does not add %q:\n%s", query, want, got.SQL)
+			}
+		}
+		for _, gone := range tc.drops {
+			if strings.Contains(got.SQL, gone) {
+				t.Errorf("%s: SQL still has %q:\n%s", query, gone, got.SQL)
+			}
+		}
+	}
+
+	labels := get("?label=env=prod&days=7")
+	if len(labels.Vars) != 4 || labels.Vars[0] != "env" || labels.Vars[1] != "prod" {
+		t.Errorf("label vars = %v, want env and prod before the window", labels.Vars)
+	}
+	if labels.Vars[2] <= base.Vars[0] {
+		t.Errorf("7 day window starts at %s, not after the 30 day one at %s", labels.Vars[2], base.Vars[0])
+	}
+
+	if w := serve(r, http.MethodGet, "/api/debug/query?label=env", "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed label filter: %d, want 400", w.Code)
+	}
+	// No cluster has the deploy type, so there is no query to show
+	if got := get("?deploy_type=serverless"); got.SQL != "" || len(got.Vars) != 0 {
+		t.Errorf("unmatched deploy_type = %+v, want no SQL", got)
+	}
+}
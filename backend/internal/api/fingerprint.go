@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// FingerprintConfig is the label keys alerts are fingerprinted by
+type FingerprintConfig struct {
+	GroupBy []string `json:"group_by" binding:"required"`
+}
+
+// GetFingerprintConfig returns the label keys alerts are fingerprinted by
+func GetFingerprintConfig(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+	c.JSON(http.StatusOK, FingerprintConfig{GroupBy: services.GetFingerprinter().GroupBy()})
+}
+
+// UpdateFingerprintConfig replaces the label keys alerts are fingerprinted by.
+// The change applies to alerts synced from now on and is not persisted.
+func UpdateFingerprintConfig(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+
+	var req FingerprintConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fingerprinter := services.GetFingerprinter()
+	if err := fingerprinter.SetGroupBy(req.GroupBy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, FingerprintConfig{GroupBy: fingerprinter.GroupBy()})
+}
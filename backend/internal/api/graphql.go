@@ -0,0 +1,370 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// maxGraphQLAlerts bounds the limit of the alerts query
+const maxGraphQLAlerts = 500
+
+// GraphQLRequest is the body of a POST /graphql request
+type GraphQLRequest struct {
+	Query         string                 `json:"query" binding:"required"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// GraphQL executes a query against the alert, cluster, tenant and name schema
+// so related data can be fetched in one round-trip. Tenant-scoped tokens only
+// see their own tenant's alerts.
+func GraphQL(c *gin.Context) {
+	var req GraphQLRequest
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), graphQLContextKey{}, &graphQLContext{
+		tenant: scopedTenant(c),
+		names:  newNameLoader(services.GetNameResolver()),
+	})
+	result := graphql.Do(graphql.Params{
+		Schema:         graphQLSchema(),
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+	c.JSON(http.StatusOK, result)
+}
+
+// graphQLContextKey stores the per-request *graphQLContext
+type graphQLContextKey struct{}
+
+type graphQLContext struct {
+	tenant string // tenant the caller is restricted to, "" for all
+	names  *nameLoader
+}
+
+func requestContext(p graphql.ResolveParams) *graphQLContext {
+	return p.Context.Value(graphQLContextKey{}).(*graphQLContext)
+}
+
+// nameLoader batches the name, cluster and tenant lookups of one request.
+// Resolvers queue IDs and return thunks; the executor runs the thunks only
+// after every field of a list has been visited, so the first thunk resolves
+// all queued names with a single ResolveBatch call. Cluster and tenant details
+// are fetched once per distinct ID.
+type nameLoader struct {
+	resolver *services.NameResolver
+
+	mu       sync.Mutex
+	pending  map[string]bool
+	names    map[string]services.NameInfo
+	clusters map[string]*services.ClusterInfo
+	tenants  map[string]*services.TenantInfo
+}
+
+func newNameLoader(resolver *services.NameResolver) *nameLoader {
+	return &nameLoader{
+		resolver: resolver,
+		pending:  make(map[string]bool),
+		names:    make(map[string]services.NameInfo),
+		clusters: make(map[string]*services.ClusterInfo),
+		tenants:  make(map[string]*services.TenantInfo),
+	}
+}
+
+// name queues id and returns a thunk resolving to its display name
+func (l *nameLoader) name(id string) func() (interface{}, error) {
+	if id == "" {
+		return func() (interface{}, error) { return nil, nil }
+	}
+	l.mu.Lock()
+	if _, ok := l.names[id]; !ok {
+		l.pending[id] = true
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if len(l.pending) > 0 {
+			ids := make([]string, 0, len(l.pending))
+			for pendingID := range l.pending {
+				ids = append(ids, pendingID)
+			}
+			l.pending = make(map[string]bool)
+
+			results, _ := l.resolver.ResolveBatch(ids)
+			for _, pendingID := range ids {
+				info, ok := results[pendingID]
+				if !ok {
+					info = services.NameInfo{ID: pendingID, Name: pendingID}
+				}
+				l.names[pendingID] = info
+			}
+		}
+		return l.names[id].Name, nil
+	}
+}
+
+// cluster returns the details of clusterID, or nil if it cannot be resolved
+func (l *nameLoader) cluster(clusterID string) *services.ClusterInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	info, ok := l.clusters[clusterID]
+	if !ok {
+		info, _ = l.resolver.ResolveCluster(clusterID)
+		l.clusters[clusterID] = info
+	}
+	return info
+}
+
+// tenant returns the details of tenantID, or nil if it cannot be resolved
+func (l *nameLoader) tenant(tenantID string) *services.TenantInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	info, ok := l.tenants[tenantID]
+	if !ok {
+		info, _ = l.resolver.ResolveTenant(tenantID)
+		l.tenants[tenantID] = info
+	}
+	return info
+}
+
+var (
+	schemaOnce sync.Once
+	schema     graphql.Schema
+)
+
+// graphQLSchema builds the schema on first use
+func graphQLSchema() graphql.Schema {
+	schemaOnce.Do(func() {
+		var err error
+		schema, err = newGraphQLSchema()
+		if err != nil {
+			panic("invalid GraphQL schema: " + err.Error())
+		}
+	})
+	return schema
+}
+
+func newGraphQLSchema() (graphql.Schema, error) {
+	// Fields without a resolver are read from the struct field of the same
+	// name (case-insensitively) or json tag
+	nameInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "NameInfo",
+		Fields: graphql.Fields{
+			"type":       &graphql.Field{Type: graphql.String},
+			"id":         &graphql.Field{Type: graphql.ID},
+			"name":       &graphql.Field{Type: graphql.String},
+			"tenantId":   &graphql.Field{Type: graphql.String},
+			"tenantName": &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	tenantInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TenantInfo",
+		Fields: graphql.Fields{
+			"tenantId":   &graphql.Field{Type: graphql.ID},
+			"tenantName": &graphql.Field{Type: graphql.String},
+			"kind":       &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	clusterInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ClusterInfo",
+		Fields: graphql.Fields{
+			"clusterId":        &graphql.Field{Type: graphql.ID},
+			"clusterName":      &graphql.Field{Type: graphql.String},
+			"tenantId":         &graphql.Field{Type: graphql.String},
+			"tenantName":       &graphql.Field{Type: graphql.String},
+			"deployType":       &graphql.Field{Type: graphql.String},
+			"version":          &graphql.Field{Type: graphql.String},
+			"clusterLifecycle": &graphql.Field{Type: graphql.String},
+			"tenantPlan":       &graphql.Field{Type: graphql.String},
+			"provider":         &graphql.Field{Type: graphql.String},
+			"region":           &graphql.Field{Type: graphql.String},
+			"projectId":        &graphql.Field{Type: graphql.String},
+			"orgId":            &graphql.Field{Type: graphql.String},
+			"clusterType":      &graphql.Field{Type: graphql.String},
+			"tenant": &graphql.Field{
+				Type: tenantInfoType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					c := p.Source.(*services.ClusterInfo)
+					return optionalTenant(requestContext(p).names, c.TenantID), nil
+				},
+			},
+		},
+	})
+
+	alertType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Alert",
+		Fields: graphql.Fields{
+			"id":             &graphql.Field{Type: graphql.ID},
+			"title":          &graphql.Field{Type: graphql.String},
+			"status":         &graphql.Field{Type: graphql.String},
+			"created":        &graphql.Field{Type: graphql.String},
+			"alertSignature": &graphql.Field{Type: graphql.String},
+			"dedupKey":       &graphql.Field{Type: graphql.String},
+			"clusterId":      &graphql.Field{Type: graphql.String},
+			"tenantId":       &graphql.Field{Type: graphql.String},
+			"severity": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*models.Issue).Priority, nil
+				},
+			},
+			"clusterName": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return requestContext(p).names.name(p.Source.(*models.Issue).ClusterID), nil
+				},
+			},
+			"tenantName": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return requestContext(p).names.name(p.Source.(*models.Issue).TenantID), nil
+				},
+			},
+			"cluster": &graphql.Field{
+				Type: clusterInfoType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return optionalCluster(requestContext(p).names, p.Source.(*models.Issue).ClusterID), nil
+				},
+			},
+			"tenant": &graphql.Field{
+				Type: tenantInfoType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return optionalTenant(requestContext(p).names, p.Source.(*models.Issue).TenantID), nil
+				},
+			},
+		},
+	})
+
+	alertFilterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "AlertFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"severity":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"status":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"clusterId": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"tenantId":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"days":      &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 30},
+			"limit":     &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 50},
+			"offset":    &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 0},
+		},
+	})
+
+	idArgs := graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}}
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"alerts": &graphql.Field{
+				Type:    graphql.NewList(alertType),
+				Args:    graphql.FieldConfigArgument{"filter": &graphql.ArgumentConfig{Type: alertFilterType}},
+				Resolve: resolveAlerts,
+			},
+			"cluster": &graphql.Field{
+				Type: clusterInfoType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return services.GetNameResolver().ResolveCluster(p.Args["id"].(string))
+				},
+			},
+			"tenant": &graphql.Field{
+				Type: tenantInfoType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return services.GetNameResolver().ResolveTenant(p.Args["id"].(string))
+				},
+			},
+			"nameResolve": &graphql.Field{
+				Type: nameInfoType,
+				Args: idArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					info, _ := services.GetNameService().Resolve(p.Args["id"].(string))
+					return info, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// resolveAlerts lists alerts matching the AlertFilter argument, newest first
+func resolveAlerts(p graphql.ResolveParams) (interface{}, error) {
+	filter, _ := p.Args["filter"].(map[string]interface{})
+	intArg := func(name string, def int) int {
+		if v, ok := filter[name].(int); ok {
+			return v
+		}
+		return def
+	}
+	days, limit, offset := intArg("days", 30), intArg("limit", 50), intArg("offset", 0)
+	if days <= 0 {
+		days = 30
+	}
+	if limit <= 0 || limit > maxGraphQLAlerts {
+		limit = maxGraphQLAlerts
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	startDate := time.Now().UTC().AddDate(0, 0, -days).Format("2006-01-02 15:04:05")
+	query := db.DB.WithContext(p.Context).Where("is_alert = 1 AND deleted_at IS NULL AND REPLACE(created, ' UTC', '') >= ?", startDate)
+	for arg, column := range map[string]string{"severity": "priority", "status": "status", "clusterId": "cluster_id", "tenantId": "tenant_id"} {
+		if v, _ := filter[arg].(string); v != "" {
+			query = query.Where(column+" = ?", v)
+		}
+	}
+	if tenant := requestContext(p).tenant; tenant != "" {
+		query = query.Where("tenant_id = ?", tenant)
+	}
+
+	var issues []*models.Issue
+	if err := query.Order("created DESC").Limit(limit).Offset(offset).Find(&issues).Error; err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// optionalCluster and optionalTenant return an untyped nil for unknown IDs so
+// the field resolves to null
+func optionalCluster(l *nameLoader, id string) interface{} {
+	if id == "" {
+		return nil
+	}
+	if info := l.cluster(id); info != nil {
+		return info
+	}
+	return nil
+}
+
+func optionalTenant(l *nameLoader, id string) interface{} {
+	if id == "" {
+		return nil
+	}
+	if info := l.tenant(id); info != nil {
+		return info
+	}
+	return nil
+}
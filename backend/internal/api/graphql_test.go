@@ -0,0 +1,274 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/rbac"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+func graphQLRouter() *gin.Engine {
+	r := gin.New()
+	r.GET("/api/v1/graphql", Authenticate(), GraphQL)
+	r.POST("/api/v1/graphql", Authenticate(), GraphQL)
+	return r
+}
+
+// graphQLQuery posts query and decodes its data into v, failing on errors
+func graphQLQuery(t *testing.T, r http.Handler, token, query string, v any) {
+	t.Helper()
+	body, _ := json.Marshal(GraphQLRequest{Query: query})
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	decodeJSON(t, mustServe(t, r, http.MethodPost, "/api/v1/graphql", token, string(body)), &resp)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("query errors: %+v", resp.Errors)
+	}
+	decodeJSON(t, string(resp.Data), v)
+}
+
+// typeRef is a field or argument type in an introspection result
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+func (r typeRef) String() string {
+	switch r.Kind {
+	case "NON_NULL":
+		return r.OfType.String() + "!"
+	case "LIST":
+		return "[" + r.OfType.String() + "]"
+	}
+	return r.Name
+}
+
+func TestGraphQLIntrospection(t *testing.T) {
+	openTestDB(t)
+	useNameResolver(t)
+	r := graphQLRouter()
+
+	type field struct {
+		Name string  `json:"name"`
+		Type typeRef `json:"type"`
+		Args []struct {
+			Name string  `json:"name"`
+			Type typeRef `json:"type"`
+		} `json:"args"`
+	}
+	var resp struct {
+		Schema struct {
+			QueryType struct {
+				Name   string  `json:"name"`
+				Fields []field `json:"fields"`
+			} `json:"queryType"`
+			MutationType *struct{ Name string } `json:"mutationType"`
+		} `json:"__schema"`
+		Alert struct {
+			Fields []field `json:"fields"`
+		} `json:"alert"`
+		Filter struct {
+			Kind        string  `json:"kind"`
+			InputFields []field `json:"inputFields"`
+		} `json:"filter"`
+	}
+	const typeFields = `kind name ofType { kind name ofType { kind name } }`
+	graphQLQuery(t, r, "", `{
+		__schema {
+			queryType { name fields { name type { `+typeFields+` } args { name type { `+typeFields+` } } } }
+			mutationType { name }
+		}
+		alert: __type(name: "Alert") { fields { name type { `+typeFields+` } } }
+		filter: __type(name: "AlertFilter") { kind inputFields { name type { `+typeFields+` } } }
+	}`, &resp)
+
+	if resp.Schema.QueryType.Name != "Query" || resp.Schema.MutationType != nil {
+		t.Errorf("query type %q, mutation type %v, want Query and none", resp.Schema.QueryType.Name, resp.Schema.MutationType)
+	}
+	queries := map[string]string{}
+	for _, f := range resp.Schema.QueryType.Fields {
+		sig := f.Type.String()
+		for _, arg := range f.Args {
+			sig = arg.Name + ": " + arg.Type.String() + " -> " + sig
+		}
+		queries[f.Name] = sig
+	}
+	want := map[string]string{
+		"alerts":      "filter: AlertFilter -> [Alert]",
+		"cluster":     "id: ID! -> ClusterInfo",
+		"tenant":      "id: ID! -> TenantInfo",
+		"nameResolve": "id: ID! -> NameInfo",
+	}
+	if len(queries) != len(want) {
+		t.Errorf("queries = %v, want %v", queries, want)
+	}
+	for name, sig := range want {
+		if queries[name] != sig {
+			t.Errorf("query %s = %q, want %q", name, queries[name], sig)
+		}
+	}
+
+	alertFields := map[string]string{}
+	for _, f := range resp.Alert.Fields {
+		alertFields[f.Name] = f.Type.String()
+	}
+	for name, typ := range map[string]string{
+		"id": "ID", "severity": "String", "clusterName": "String", "tenantName": "String",
+		"cluster": "ClusterInfo", "tenant": "TenantInfo",
+	} {
+		if alertFields[name] != typ {
+			t.Errorf("Alert.%s = %q, want %s", name, alertFields[name], typ)
+		}
+	}
+
+	var inputs []string
+	for _, f := range resp.Filter.InputFields {
+		inputs = append(inputs, f.Name+" "+f.Type.String())
+	}
+	sort.Strings(inputs)
+	wantInputs := []string{"clusterId String", "days Int", "limit Int", "offset Int", "severity String", "status String", "tenantId String"}
+	if resp.Filter.Kind != "INPUT_OBJECT" || len(inputs) != len(wantInputs) {
+		t.Fatalf("AlertFilter = %s %v, want an input object with %v", resp.Filter.Kind, inputs, wantInputs)
+	}
+	for i := range wantInputs {
+		if inputs[i] != wantInputs[i] {
+			t.Errorf("AlertFilter field %d = %q, want %q", i, inputs[i], wantInputs[i])
+		}
+	}
+
+	// Introspection also works over GET
+	w := serve(r, http.MethodGet, "/api/v1/graphql?query="+url.QueryEscape(`{ __schema { queryType { name } } }`), "", "")
+	if w.Code != http.StatusOK || !json.Valid(w.Body.Bytes()) {
+		t.Errorf("GET introspection: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestGraphQLAlerts(t *testing.T) {
+	openTestDB(t)
+	conn := openTestTiDB(t)
+	mustExec(t, conn,
+		`INSERT INTO tenants (tenant_id, tenant_name) VALUES ('1001', 'acme'), ('1002', 'globex')`,
+		`INSERT INTO clusters (cluster_id, cluster_name, tenant_id, deploy_type, region) VALUES
+			('2001', 'prod-east', '1001', 'dedicated', 'us-east-1'),
+			('2002', 'staging', '1002', 'dedicated', 'eu-west-1')`,
+	)
+	useNameResolver(t)
+	useAuth(t)
+	r := graphQLRouter()
+
+	seedIssue(t, "A-1", "2001", "1001", time.Hour)
+	seedIssue(t, "A-2", "2002", "1002", 2*time.Hour)
+	seedIssue(t, "A-3", "2001", "1001", 3*time.Hour)
+	seedIssue(t, "A-4", "9999", "1001", 4*time.Hour)
+
+	type alert struct {
+		ID          string `json:"id"`
+		Severity    string `json:"severity"`
+		ClusterName string `json:"clusterName"`
+		TenantName  string `json:"tenantName"`
+		Cluster     *struct {
+			Region string `json:"region"`
+			Tenant *struct {
+				TenantName string `json:"tenantName"`
+			} `json:"tenant"`
+		} `json:"cluster"`
+	}
+	const query = `{ alerts(filter: {days: 1}) { id severity clusterName tenantName cluster { region tenant { tenantName } } } }`
+	var resp struct {
+		Alerts []alert `json:"alerts"`
+	}
+	graphQLQuery(t, r, signToken(t, "", roleAdmin), query, &resp)
+
+	if len(resp.Alerts) != 4 {
+		t.Fatalf("alerts = %+v, want 4", resp.Alerts)
+	}
+	for _, a := range resp.Alerts {
+		switch a.ID {
+		case "A-1", "A-3":
+			if a.ClusterName != "prod-east" || a.TenantName != "acme" || a.Cluster == nil || a.Cluster.Region != "us-east-1" || a.Cluster.Tenant.TenantName != "acme" {
+				t.Errorf("%s = %+v", a.ID, a)
+			}
+		case "A-2":
+			if a.ClusterName != "staging" || a.TenantName != "globex" || a.Cluster == nil || a.Cluster.Tenant.TenantName != "globex" {
+				t.Errorf("%s = %+v", a.ID, a)
+			}
+		case "A-4":
+			// Unknown clusters show their ID and have no details
+			if a.ClusterName != "9999" || a.Cluster != nil || a.Severity != "Major" {
+				t.Errorf("%s = %+v", a.ID, a)
+			}
+		}
+	}
+
+	// Tenant-scoped tokens only see their own alerts, whatever the filter
+	resp.Alerts = nil
+	graphQLQuery(t, r, signToken(t, "1002", rbac.RoleViewer), `{ alerts(filter: {days: 1, tenantId: "1001"}) { id } }`, &resp)
+	if len(resp.Alerts) != 0 {
+		t.Errorf("tenant 1002 filtering on 1001 got %+v", resp.Alerts)
+	}
+	graphQLQuery(t, r, signToken(t, "1002", rbac.RoleViewer), `{ alerts(filter: {days: 1}) { id } }`, &resp)
+	if len(resp.Alerts) != 1 || resp.Alerts[0].ID != "A-2" {
+		t.Errorf("tenant 1002 alerts = %+v, want A-2", resp.Alerts)
+	}
+
+	var lookups struct {
+		Cluster struct {
+			ClusterName string `json:"clusterName"`
+		} `json:"cluster"`
+		NameResolve struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+		} `json:"nameResolve"`
+	}
+	graphQLQuery(t, r, signToken(t, "", roleAdmin), `{ cluster(id: "2002") { clusterName } nameResolve(id: "1001") { type name } }`, &lookups)
+	if lookups.Cluster.ClusterName != "staging" || lookups.NameResolve.Type != "tenant" || lookups.NameResolve.Name != "acme" {
+		t.Errorf("lookups = %+v", lookups)
+	}
+}
+
+func TestNameLoaderBatches(t *testing.T) {
+	conn := openTestTiDB(t)
+	mustExec(t, conn,
+		`INSERT INTO tenants (tenant_id, tenant_name) VALUES ('1001', 'acme')`,
+		`INSERT INTO clusters (cluster_id, cluster_name, tenant_id) VALUES ('2001', 'prod-east', '1001'), ('2002', 'staging', '1001')`,
+	)
+	l := newNameLoader(services.NewNameResolver())
+
+	thunks := map[string]func() (interface{}, error){}
+	for _, id := range []string{"2001", "2002", "1001", "9999", "2001"} {
+		thunks[id] = l.name(id)
+	}
+	if len(l.pending) != 4 {
+		t.Fatalf("pending = %v, want the 4 distinct IDs", l.pending)
+	}
+
+	// The first thunk resolves every queued ID
+	if name, _ := thunks["2002"](); name != "staging" {
+		t.Errorf("2002 = %v, want staging", name)
+	}
+	if len(l.pending) != 0 || len(l.names) != 4 {
+		t.Errorf("after the first thunk: pending %v, names %v", l.pending, l.names)
+	}
+	for id, want := range map[string]string{"2001": "prod-east", "1001": "acme", "9999": "9999"} {
+		if name, _ := thunks[id](); name != want {
+			t.Errorf("%s = %v, want %s", id, name, want)
+		}
+	}
+
+	// Resolved IDs are not queued again
+	l.name("2001")
+	if len(l.pending) != 0 {
+		t.Errorf("pending after a known ID = %v", l.pending)
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	gzipLevel = 5
+	// gzipMinSize is the smallest response worth compressing, about one TCP packet
+	gzipMinSize = 1400
+)
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, gzipLevel)
+		return w
+	},
+}
+
+// GzipMiddleware compresses responses of at least 1400 bytes for clients that
+// send Accept-Encoding: gzip. Smaller responses, streams (anything flushed
+// before it is done) and WebSocket upgrades are sent as is.
+func GzipMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.Request) || c.Request.Method == http.MethodHead || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		w := &gzipResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer w.finish()
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		c.Next()
+	}
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the first gzipMinSize bytes of a response to
+// decide whether to compress it. Once the buffer overflows it switches to
+// gzip; a flush or an explicit header write switches it to pass-through.
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+
+	buf         bytes.Buffer
+	gz          *gzip.Writer // set once compressing
+	passThrough bool
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(data)
+	case w.passThrough:
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf.Write(data)
+	if w.buf.Len() < gzipMinSize {
+		return len(data), nil
+	}
+	if err := w.start(); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow is used to send headers without a body, e.g. by c.Status, so
+// the response is not compressed
+func (w *gzipResponseWriter) WriteHeaderNow() {
+	if w.gz == nil && !w.passThrough {
+		w.stopBuffering()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	} else if !w.passThrough {
+		w.stopBuffering()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// start compresses the buffered bytes and everything written after them,
+// unless the handler already encoded the response or is streaming it
+func (w *gzipResponseWriter) start() error {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || strings.HasPrefix(h.Get("Content-Type"), "text/event-stream") {
+		w.stopBuffering()
+		return nil
+	}
+
+	h.Set("Content-Encoding", "gzip")
+	h.Del("Content-Length")
+	gz := gzipWriterPool.Get().(*gzip.Writer)
+	gz.Reset(w.ResponseWriter)
+	w.gz = gz
+	_, err := w.buf.WriteTo(gz)
+	return err
+}
+
+// stopBuffering sends the buffered bytes uncompressed and passes the rest through
+func (w *gzipResponseWriter) stopBuffering() {
+	w.passThrough = true
+	if w.buf.Len() > 0 {
+		w.buf.WriteTo(w.ResponseWriter)
+	}
+}
+
+// finish sends a response too small to compress, or ends the gzip stream
+func (w *gzipResponseWriter) finish() {
+	if w.gz == nil {
+		if !w.passThrough {
+			w.stopBuffering()
+		}
+		return
+	}
+	w.gz.Close()
+	gzipWriterPool.Put(w.gz)
+	w.gz = nil
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gzipServer serves a list of n alerts at /alerts?n=, a short event stream at
+// /stream and an empty 204 at /empty, behind GzipMiddleware
+func gzipServer(t *testing.T) *httptest.Server {
+	r := gin.New()
+	r.Use(GzipMiddleware())
+	r.GET("/alerts", func(c *gin.Context) {
+		var n int
+		fmt.Sscanf(c.Query("n"), "%d", &n)
+		alerts := make([]gin.H, n)
+		for i := range alerts {
+			alerts[i] = gin.H{"id": fmt.Sprintf("A-%d", i), "cluster_name": "prod-east", "tenant_name": "acme", "severity": "critical"}
+		}
+		c.JSON(http.StatusOK, alerts)
+	})
+	r.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		for i := 0; i < 100; i++ {
+			fmt.Fprintf(c.Writer, "data: %s\n\n", bytes.Repeat([]byte("x"), 100))
+		}
+	})
+	r.GET("/empty", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// getRaw fetches url with the given Accept-Encoding, without the client
+// decompressing the body
+func getRaw(t *testing.T, method, url, acceptEncoding string) (*http.Response, []byte) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read %s: %v", url, err)
+	}
+	return resp, body
+}
+
+func TestGzipMiddleware(t *testing.T) {
+	srv := gzipServer(t)
+
+	plainResp, plain := getRaw(t, http.MethodGet, srv.URL+"/alerts?n=200", "")
+	if enc := plainResp.Header.Get("Content-Encoding"); enc != "" || len(plain) < 10*gzipMinSize {
+		t.Fatalf("without Accept-Encoding: encoding %q, %d bytes", enc, len(plain))
+	}
+
+	// The writers are pooled, so later responses must compress as well
+	for i := 0; i < 3; i++ {
+		resp, body := getRaw(t, http.MethodGet, srv.URL+"/alerts?n=200", "deflate, gzip;q=0.8")
+		if resp.Header.Get("Content-Encoding") != "gzip" || resp.Header.Get("Vary") != "Accept-Encoding" {
+			t.Fatalf("with gzip: headers %v", resp.Header)
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("response %d is not gzip: %v", i, err)
+		}
+		decompressed, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("decompress response %d: %v", i, err)
+		}
+		if !bytes.Equal(decompressed, plain) {
+			t.Errorf("response %d decompresses to %d bytes that differ from the plain %d", i, len(decompressed), len(plain))
+		}
+		if len(body) >= len(plain)/2 {
+			t.Errorf("compressed %d bytes to %d", len(plain), len(body))
+		}
+	}
+
+	// Small responses, streams, empty responses and refused gzip are sent as is
+	for _, tc := range []struct{ method, path, acceptEncoding string }{
+		{http.MethodGet, "/alerts?n=2", "gzip"},
+		{http.MethodGet, "/stream", "gzip"},
+		{http.MethodGet, "/empty", "gzip"},
+		{http.MethodGet, "/alerts?n=200", "gzip;q=0"},
+		{http.MethodGet, "/alerts?n=200", "br"},
+		{http.MethodHead, "/alerts?n=200", "gzip"},
+	} {
+		resp, body := getRaw(t, tc.method, srv.URL+tc.path, tc.acceptEncoding)
+		if enc := resp.Header.Get("Content-Encoding"); enc != "" {
+			t.Errorf("%s %s with %q: Content-Encoding %q", tc.method, tc.path, tc.acceptEncoding, enc)
+		}
+		if bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+			t.Errorf("%s %s with %q: gzip body", tc.method, tc.path, tc.acceptEncoding)
+		}
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	for header, want := range map[string]bool{
+		"":                     false,
+		"gzip":                 true,
+		"GZIP":                 true,
+		"deflate, gzip;q=0.5":  true,
+		"gzip; q=0":            false,
+		"br, deflate":          false,
+		"x-gzip":               false,
+		"identity, gzip ; q=1": true,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", header)
+		if got := acceptsGzip(req); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMaxRequestBody  = 1 << 20
+	defaultReadTimeout     = 10 * time.Second
+	defaultMutationTimeout = 30 * time.Second
+)
+
+// bodyLimitOverrides are the routes taking bodies above the default limit;
+// their handlers enforce their own limits
+var bodyLimitOverrides = map[string]int64{
+	"/api/cache/snapshot":        maxSnapshotSize,
+	"/api/admin/import/clusters": maxSnapshotSize,
+	"/api/admin/import/tenants":  maxSnapshotSize,
+	"/api/alerts/proto":          maxRemoteWriteBody,
+}
+
+// noTimeoutRoutes stream their response for as long as the client stays
+var noTimeoutRoutes = map[string]bool{
+	"/api/alerts/stream": true,
+	"/api/alerts/export": true,
+	"/ws/alerts":         true,
+	// Walks the whole name cache
+	"/api/admin/cache-consistency": true,
+}
+
+// BodyLimit rejects POST, PUT and PATCH requests whose body is larger than
+// MAX_REQUEST_BODY_BYTES (default 1 MB) with 413. The body is read up front
+// through http.MaxBytesReader, so handlers never see a truncated one.
+func BodyLimit() gin.HandlerFunc {
+	limit := int64(defaultMaxRequestBody)
+	if n, err := strconv.ParseInt(os.Getenv("MAX_REQUEST_BODY_BYTES"), 10, 64); err == nil && n > 0 {
+		limit = n
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			c.Next()
+			return
+		}
+
+		maxBytes := limit
+		if n, ok := bodyLimitOverrides[c.FullPath()]; ok {
+			maxBytes = n
+		}
+		if c.Request.ContentLength > maxBytes {
+			abortTooLarge(c, maxBytes)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes))
+		c.Request.Body.Close()
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			abortTooLarge(c, maxBytes)
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+func abortTooLarge(c *gin.Context, limit int64) {
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":     "Request body too large",
+		"max_bytes": limit,
+	})
+}
+
+// RequestTimeout gives the context of each request a deadline of
+// REQUEST_MUTATION_TIMEOUT (default 30s) for POST, PUT, PATCH and DELETE and
+// REQUEST_READ_TIMEOUT (default 10s) for the rest. Handlers that use the
+// request context are cancelled then; a handler that has not responded by
+// then, or answers with a 5xx after it, gets 504. Streams and WebSocket
+// upgrades have no deadline.
+func RequestTimeout() gin.HandlerFunc {
+	readTimeout := envDuration("REQUEST_READ_TIMEOUT", defaultReadTimeout)
+	mutationTimeout := envDuration("REQUEST_MUTATION_TIMEOUT", defaultMutationTimeout)
+
+	return func(c *gin.Context) {
+		if noTimeoutRoutes[c.FullPath()] || c.GetHeader("Upgrade") != "" ||
+			strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+			c.Next()
+			return
+		}
+
+		timeout := readTimeout
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			timeout = mutationTimeout
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Writer = &timeoutResponseWriter{ResponseWriter: c.Writer, ctx: ctx}
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+		}
+	}
+}
+
+// requestDB returns db.DB bound to the request context, so queries are
+// cancelled when the client goes away or the RequestTimeout deadline passes.
+// Work that outlives the request uses db.DB.
+func requestDB(c *gin.Context) *gorm.DB {
+	return db.DB.WithContext(c.Request.Context())
+}
+
+// timeoutResponseWriter turns the 5xx a handler answers after the deadline of
+// ctx, typically caused by its cancelled queries, into a 504
+type timeoutResponseWriter struct {
+	gin.ResponseWriter
+	ctx context.Context
+}
+
+func (w *timeoutResponseWriter) WriteHeader(code int) {
+	if code >= 500 && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		code = http.StatusGatewayTimeout
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// envDuration parses the duration in the environment variable name, or
+// returns def if it is unset or invalid
+func envDuration(name string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(name)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
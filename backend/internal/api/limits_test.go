@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestBodyLimit(t *testing.T) {
+	t.Setenv("MAX_REQUEST_BODY_BYTES", "64")
+	r := gin.New()
+	r.Use(BodyLimit())
+	r.POST("/echo", func(c *gin.Context) {
+		var body map[string]string
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, body)
+	})
+
+	if w := serve(r, "POST", "/echo", "", `{"a":"b"}`); w.Code != http.StatusOK {
+		t.Errorf("small body: %d %s", w.Code, w.Body)
+	}
+	big := `{"a":"` + strings.Repeat("x", 100) + `"}`
+	if w := serve(r, "POST", "/echo", "", big); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("body over the limit: %d %s, want 413", w.Code, w.Body)
+	}
+
+	// Without a Content-Length the body is cut off while reading it
+	req := newRequest("POST", "/echo", big)
+	req.ContentLength = -1
+	if w := serveRequest(r, req); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("chunked body over the limit: %d %s, want 413", w.Code, w.Body)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	t.Setenv("REQUEST_READ_TIMEOUT", "50ms")
+	r := gin.New()
+	r.Use(RequestTimeout())
+	r.GET("/slow", func(c *gin.Context) {
+		time.Sleep(100 * time.Millisecond)
+	})
+	r.GET("/fast", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+
+	if w := serve(r, "GET", "/slow", "", ""); w.Code != http.StatusGatewayTimeout {
+		t.Errorf("slow handler: %d, want 504", w.Code)
+	}
+	if w := serve(r, "GET", "/fast", "", ""); w.Code != http.StatusOK {
+		t.Errorf("fast handler: %d, want 200", w.Code)
+	}
+}
+
+// Handler queries run with the request context, so the deadline cancels them
+func TestRequestTimeoutCancelsQueries(t *testing.T) {
+	sqliteDB := openTestDB(t)
+	useFakeNames(t)
+	t.Setenv("REQUEST_READ_TIMEOUT", "50ms")
+
+	// Every query blocks until its context is done
+	cancelled := make(chan error, 1)
+	err := sqliteDB.Callback().Query().Before("gorm:query").Register("test:block", func(tx *gorm.DB) {
+		select {
+		case <-tx.Statement.Context.Done():
+			tx.AddError(tx.Statement.Context.Err())
+			select {
+			case cancelled <- tx.Statement.Context.Err():
+			default:
+			}
+		case <-time.After(5 * time.Second):
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(RequestTimeout())
+	r.GET("/api/alerts/search", SearchAlerts)
+
+	start := time.Now()
+	w := serve(r, "GET", "/api/alerts/search?q=tikv", "", "")
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("search past the deadline: %d %s, want 504", w.Code, w.Body)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("search took %v; its query was not cancelled", elapsed)
+	}
+	select {
+	case err := <-cancelled:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("query ended with %v, want context.DeadlineExceeded", err)
+		}
+	default:
+		t.Error("the query did not see the request deadline")
+	}
+}
@@ -0,0 +1,299 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+	"github.com/prometheus/common/expfmt"
+	"gorm.io/gorm"
+)
+
+// maxSnapshotSize bounds the body accepted by ImportNameCacheSnapshot
+const maxSnapshotSize = 64 << 20
+
+// InvalidateNamesRequest lists the cluster/tenant IDs to evict from the name cache
+type InvalidateNamesRequest struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+// InvalidateNameCache evicts entries from the name resolver cache so lifecycle
+// events (create, delete, rename) take effect without waiting for the TTL.
+func InvalidateNameCache(c *gin.Context) {
+	if !checkCacheAdmin(c) {
+		return
+	}
+
+	var req InvalidateNamesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	evicted := services.GetNameResolver().Invalidate(req.IDs)
+	c.JSON(http.StatusOK, gin.H{"evicted": evicted})
+}
+
+// ExportNameCacheSnapshot returns the name cache as a gzipped JSON snapshot so a
+// new process can be warmed from the one it replaces
+func ExportNameCacheSnapshot(c *gin.Context) {
+	if !checkCacheAdmin(c) {
+		return
+	}
+
+	data, err := services.GetNameResolver().ExportSnapshot()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/gzip", data)
+}
+
+// ImportNameCacheSnapshot loads a snapshot produced by ExportNameCacheSnapshot
+func ImportNameCacheSnapshot(c *gin.Context) {
+	if !checkCacheAdmin(c) {
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSnapshotSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resolver := services.GetNameResolver()
+	if err := resolver.ImportSnapshot(data); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrSnapshotTooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "stats": resolver.GetCacheStats()})
+}
+
+// checkCacheAdmin guards the name cache endpoints. They are routed behind
+// Authorize(rbac.ActionAdmin), which lets everyone through when AUTH_SECRET is
+// unset; the invalidation token is required then.
+func checkCacheAdmin(c *gin.Context) bool {
+	return len(authSecret()) > 0 || checkCacheToken(c)
+}
+
+// checkCacheToken verifies the shared secret from NAME_SERVICE_INVALIDATION_TOKEN
+// sent in the X-Invalidation-Token header, writing an error response if it does
+// not match. The cache endpoints are disabled when the token is unset.
+func checkCacheToken(c *gin.Context) bool {
+	token := os.Getenv("NAME_SERVICE_INVALIDATION_TOKEN")
+	if token == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Cache management is not configured"})
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Invalidation-Token")), []byte(token)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid invalidation token"})
+		return false
+	}
+	return true
+}
+
+// ImportClusterMetadata upserts clusters from an uploaded CMDB export (multipart
+// field "file", JSON or CSV) and loads them into the name cache
+func ImportClusterMetadata(c *gin.Context) {
+	importMetadata(c, services.GetNameResolver().ImportClusters)
+}
+
+// ImportTenantMetadata upserts tenants from an uploaded CMDB export (multipart
+// field "file", JSON or CSV) and loads them into the name cache
+func ImportTenantMetadata(c *gin.Context) {
+	importMetadata(c, services.GetNameResolver().ImportTenants)
+}
+
+func importMetadata(c *gin.Context, importFn func(*gorm.DB, []map[string]string) (services.ImportResult, error)) {
+	if !checkCacheAdmin(c) {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing upload field 'file'"})
+		return
+	}
+
+	// Format comes from the "format" form field, or the file extension
+	format := c.PostForm("format")
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(fileHeader.Filename), ".")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	rows, err := services.ParseImportRows(file, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := importFn(requestDB(c), rows)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetNameChanges lists clusters and tenants renamed upstream since the unix
+// timestamp in ?since=, without changing the name cache
+func GetNameChanges(c *gin.Context) {
+	nameChanges(c, false)
+}
+
+// EvictNameChanges lists clusters and tenants renamed upstream since the unix
+// timestamp in ?since= and evicts them from the name cache
+func EvictNameChanges(c *gin.Context) {
+	nameChanges(c, true)
+}
+
+func nameChanges(c *gin.Context, evict bool) {
+	if !checkCacheAdmin(c) {
+		return
+	}
+
+	sinceUnix, err := strconv.ParseInt(c.Query("since"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since must be a unix timestamp"})
+		return
+	}
+
+	resolver := services.GetNameResolver()
+	changes, err := resolver.FindNameChanges(requestDB(c), time.Unix(sinceUnix, 0))
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if evict {
+		resolver.EvictNameChanges(requestDB(c), changes)
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
+// CheckNameCacheConsistency compares every live name cache entry with TiDB,
+// evicts the stale ones and returns them
+func CheckNameCacheConsistency(c *gin.Context) {
+	if !checkCacheAdmin(c) {
+		return
+	}
+
+	reports, err := services.GetNameResolver().ConsistencyCheck(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "inconsistencies": reports})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"count": len(reports), "inconsistencies": reports})
+}
+
+// GetResolvedNames pages through the valid name cache entries, sorted by ID,
+// for audits (limit defaults to 100, at most 1000)
+func GetResolvedNames(c *gin.Context) {
+	if !checkCacheAdmin(c) {
+		return
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+		return
+	}
+
+	names, total, err := services.GetNameResolver().ResolveAll(offset, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"names": names, "total": total, "offset": offset, "limit": limit})
+}
+
+// GetMetrics serves the name service Prometheus registry followed by the name
+// cache stats in the text exposition format
+func GetMetrics(c *gin.Context) {
+	families, err := services.NameServiceRegistry().Gather()
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	format := expfmt.NewFormat(expfmt.TypeTextPlain)
+	c.Header("Content-Type", string(format))
+	c.Status(http.StatusOK)
+	encoder := expfmt.NewEncoder(c.Writer, format)
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			return
+		}
+	}
+	services.GetNameResolver().WritePrometheusMetrics(c.Writer)
+}
+
+// GetClusterSyncStatus returns when clusters were last copied from TiDB into
+// clusters_local, how many, and when the next sync runs
+func GetClusterSyncStatus(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+	c.JSON(http.StatusOK, services.GetClusterSyncer().Status(requestDB(c)))
+}
+
+// NameResolverConfig is the runtime configuration of the name resolver;
+// omitted fields are left unchanged by UpdateNameResolverConfig
+type NameResolverConfig struct {
+	UseClusterView *bool `json:"use_cluster_view"`
+}
+
+// GetNameResolverConfig returns the runtime configuration of the name resolver
+func GetNameResolverConfig(c *gin.Context) {
+	if !checkCacheAdmin(c) {
+		return
+	}
+
+	useClusterView := services.GetNameResolver().ClusterView()
+	c.JSON(http.StatusOK, NameResolverConfig{UseClusterView: &useClusterView})
+}
+
+// UpdateNameResolverConfig changes the runtime configuration of the name
+// resolver without a restart, e.g. {"use_cluster_view": true} to resolve
+// clusters from the v_cluster_names view
+func UpdateNameResolverConfig(c *gin.Context) {
+	if !checkCacheAdmin(c) {
+		return
+	}
+
+	var req NameResolverConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resolver := services.GetNameResolver()
+	if req.UseClusterView != nil {
+		resolver.SetClusterView(*req.UseClusterView)
+	}
+	useClusterView := resolver.ClusterView()
+	c.JSON(http.StatusOK, NameResolverConfig{UseClusterView: &useClusterView})
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestInvalidateNameCache(t *testing.T) {
+	openTestDB(t)
+	tidb := openTestTiDB(t)
+	mustExec(t, tidb, `INSERT INTO clusters (cluster_id, cluster_name, tenant_id) VALUES ('2001', 'prod-east', '1001')`)
+	nr := useNameResolver(t)
+	t.Setenv("NAME_SERVICE_INVALIDATION_TOKEN", "s3cret")
+
+	r := gin.New()
+	r.POST("/api/cache/invalidate", InvalidateNameCache)
+	// invalidate evicts 2001 and the uncached 9999, returning the status and
+	// the number of entries evicted
+	invalidate := func(token string) (int, int) {
+		req := newRequest(http.MethodPost, "/api/cache/invalidate", `{"ids":["2001","9999"]}`)
+		if token != "" {
+			req.Header.Set("X-Invalidation-Token", token)
+		}
+		w := serveRequest(r, req)
+		var body struct{ Evicted int }
+		if w.Code == http.StatusOK {
+			decodeJSON(t, w.Body.String(), &body)
+		}
+		return w.Code, body.Evicted
+	}
+
+	if info, _ := nr.Resolve("2001"); info.Name != "prod-east" {
+		t.Fatalf("Resolve(2001) = %+v", info)
+	}
+	mustExec(t, tidb, `UPDATE clusters SET cluster_name = 'prod-east-2' WHERE cluster_id = '2001'`)
+	if info, _ := nr.Resolve("2001"); info.Name != "prod-east" {
+		t.Fatalf("Resolve(2001) before invalidation = %q, want the cached prod-east", info.Name)
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if code, _ := invalidate(token); code != http.StatusUnauthorized {
+			t.Errorf("token %q: status %d, want 401", token, code)
+		}
+	}
+	if info, _ := nr.Resolve("2001"); info.Name != "prod-east" {
+		t.Errorf("rejected invalidation evicted the entry: %q", info.Name)
+	}
+
+	if code, evicted := invalidate("s3cret"); code != http.StatusOK || evicted != 1 {
+		t.Fatalf("invalidate: status %d, %d evicted, want 200 and 1", code, evicted)
+	}
+	if info, _ := nr.Resolve("2001"); info.Name != "prod-east-2" {
+		t.Errorf("Resolve(2001) after invalidation = %q, want prod-east-2 from the database", info.Name)
+	}
+
+	t.Setenv("NAME_SERVICE_INVALIDATION_TOKEN", "")
+	if code, _ := invalidate("s3cret"); code != http.StatusServiceUnavailable {
+		t.Errorf("without a configured token: status %d, want 503", code)
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+	"gorm.io/gorm"
+)
+
+// GetNotificationChannels returns all webhook notification channels. Header
+// values are redacted (see services.RedactChannel), as in every channel
+// response.
+func GetNotificationChannels(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+	channels, err := services.GetNotificationService().ListChannels()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range channels {
+		channels[i] = services.RedactChannel(channels[i])
+	}
+	c.JSON(http.StatusOK, channels)
+}
+
+// CreateNotificationChannel adds a webhook notification channel
+func CreateNotificationChannel(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+	channel := models.NotificationChannel{Enabled: true}
+	if err := c.ShouldBindJSON(&channel); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	channel.ID = 0
+
+	if err := services.GetNotificationService().CreateChannel(&channel); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, services.RedactChannel(channel))
+}
+
+// UpdateNotificationChannel replaces the channel with the given id. Redacted
+// header values are kept.
+func UpdateNotificationChannel(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	channel := models.NotificationChannel{Enabled: true}
+	if err := c.ShouldBindJSON(&channel); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	channel.ID = uint(id)
+
+	if err := services.GetNotificationService().UpdateChannel(&channel); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "notification channel not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, services.RedactChannel(channel))
+}
+
+// DeleteNotificationChannel removes the channel with the given id
+func DeleteNotificationChannel(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := services.GetNotificationService().DeleteChannel(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "notification channel not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// TestNotificationRequest selects the channel to send a test message to
+type TestNotificationRequest struct {
+	ChannelID uint `json:"channel_id" binding:"required"`
+}
+
+// TestNotification sends a sample alert to a notification channel
+func TestNotification(c *gin.Context) {
+	var req TestNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.GetNotificationService().SendTest(req.ChannelID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "notification channel not found"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// TestEmailNotification sends a sample alert email through the configured SMTP server
+func TestEmailNotification(c *gin.Context) {
+	var req services.EmailRecipients
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.To) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to is required"})
+		return
+	}
+
+	smtpNotifier := services.GetSMTPNotifier()
+	if smtpNotifier == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "SMTP is not configured"})
+		return
+	}
+	if err := smtpNotifier.Send(services.TestAlert(), req); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
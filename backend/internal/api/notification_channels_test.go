@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/rbac"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// useNotificationService points the notification service at the test database
+func useNotificationService(t *testing.T) {
+	t.Helper()
+	svc := services.GetNotificationService()
+	prev := svc.DB
+	svc.DB = db.DB
+	t.Cleanup(func() { svc.DB = prev })
+}
+
+func channelRouter() *gin.Engine {
+	r := gin.New()
+	admin := []gin.HandlerFunc{Authenticate(), Authorize(rbac.ActionAdmin)}
+	r.GET("/api/notification-channels", append(admin, GetNotificationChannels)...)
+	r.POST("/api/notification-channels", append(admin, CreateNotificationChannel)...)
+	r.PUT("/api/notification-channels/:id", append(admin, UpdateNotificationChannel)...)
+	return r
+}
+
+func TestNotificationChannelsRedactHeaders(t *testing.T) {
+	openTestDB(t)
+	useNotificationService(t)
+	useAuth(t)
+	r := channelRouter()
+	admin := signToken(t, "", roleAdmin)
+
+	w := serve(r, "POST", "/api/notification-channels", admin,
+		`{"name":"ops","url":"https://hooks.example.com","headers":{"Authorization":"Bearer secret"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("create response leaks the header: %s", w.Body)
+	}
+	var created models.NotificationChannel
+	decodeJSON(t, w.Body.String(), &created)
+
+	body := mustServe(t, r, "GET", "/api/notification-channels", admin, "")
+	if strings.Contains(body, "secret") || !strings.Contains(body, services.RedactedHeaderValue) {
+		t.Errorf("list response does not redact the header: %s", body)
+	}
+
+	// Sending the redacted channel back keeps the stored header
+	created.Name = "ops-renamed"
+	update, _ := json.Marshal(created)
+	w = serve(r, "PUT", "/api/notification-channels/1", admin, string(update))
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "secret") {
+		t.Fatalf("update: %d %s", w.Code, w.Body)
+	}
+	var stored models.NotificationChannel
+	db.DB.First(&stored, created.ID)
+	if stored.Name != "ops-renamed" || !strings.Contains(string(stored.Headers), "Bearer secret") {
+		t.Errorf("stored channel = %s %s", stored.Name, stored.Headers)
+	}
+}
+
+func TestNotificationChannelsRequireAdmin(t *testing.T) {
+	openTestDB(t)
+	useNotificationService(t)
+	r := channelRouter()
+
+	useAuth(t)
+	if w := serve(r, "GET", "/api/notification-channels", signToken(t, "tenant-a", rbac.RoleEditor), ""); w.Code != http.StatusForbidden {
+		t.Errorf("editor: %d, want 403", w.Code)
+	}
+
+	// Without AUTH_SECRET the admin token is required
+	t.Setenv("AUTH_SECRET", "")
+	t.Setenv("ADMIN_API_TOKEN", "admin-token")
+	if w := serve(r, "GET", "/api/notification-channels", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("without the admin token: %d, want 401", w.Code)
+	}
+	req := newRequest("GET", "/api/notification-channels", "")
+	req.Header.Set("X-Admin-Token", "admin-token")
+	if w := serveRequest(r, req); w.Code != http.StatusOK {
+		t.Errorf("with the admin token: %d %s, want 200", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nolouch/alerts-platform-v2/internal/rbac"
+	"golang.org/x/oauth2"
+)
+
+const (
+	sessionCookie   = "alerts_session"
+	oidcStateCookie = "oidc_state"
+	oidcNonceCookie = "oidc_nonce"
+
+	sessionTTL   = 12 * time.Hour
+	oidcLoginTTL = 10 * time.Minute // how long a login may take at the identity provider
+)
+
+// oidcClient is the configured identity provider
+type oidcClient struct {
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	secure   bool // redirect URL is https, so cookies are marked Secure
+}
+
+var (
+	oidcMu       sync.Mutex
+	oidcInstance *oidcClient
+)
+
+// getOIDCClient discovers the provider at OIDC_ISSUER on first use. Failed
+// discoveries are retried on the next login.
+func getOIDCClient(c *gin.Context) (*oidcClient, error) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	if oidcInstance != nil {
+		return oidcInstance, nil
+	}
+
+	issuer, clientID := os.Getenv("OIDC_ISSUER"), os.Getenv("OIDC_CLIENT_ID")
+	redirectURL := os.Getenv("OIDC_REDIRECT_URL")
+	if issuer == "" || clientID == "" || redirectURL == "" || len(authSecret()) == 0 {
+		return nil, errors.New("single sign-on is not configured")
+	}
+	provider, err := oidc.NewProvider(c.Request.Context(), issuer)
+	if err != nil {
+		return nil, err
+	}
+	oidcInstance = &oidcClient{
+		oauth2: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  redirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "email", "profile", "groups"},
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: clientID}),
+		secure:   strings.HasPrefix(redirectURL, "https://"),
+	}
+	return oidcInstance, nil
+}
+
+// oidcClaims are the ID token claims used to build a session
+type oidcClaims struct {
+	Email         string   `json:"email"`
+	EmailVerified *bool    `json:"email_verified"`
+	Groups        []string `json:"groups"`
+}
+
+// oidcAdminGroups returns the comma-separated OIDC_ADMIN_GROUPS
+func oidcAdminGroups() []string {
+	return splitList(os.Getenv("OIDC_ADMIN_GROUPS"))
+}
+
+// oidcAdminDomains returns the comma-separated OIDC_ADMIN_DOMAINS, lower-cased
+// and without a leading "@"
+func oidcAdminDomains() []string {
+	var domains []string
+	for _, d := range splitList(os.Getenv("OIDC_ADMIN_DOMAINS")) {
+		domains = append(domains, strings.ToLower(strings.TrimPrefix(d, "@")))
+	}
+	return domains
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// isOIDCAdmin reports whether the user is in an OIDC_ADMIN_GROUPS group or has
+// an email in an OIDC_ADMIN_DOMAINS domain. Without an allowlist nobody is.
+func isOIDCAdmin(claims oidcClaims) bool {
+	if admins := oidcAdminGroups(); slices.ContainsFunc(claims.Groups, func(g string) bool {
+		return slices.Contains(admins, g)
+	}) {
+		return true
+	}
+	_, domain, ok := strings.Cut(claims.Email, "@")
+	return ok && slices.Contains(oidcAdminDomains(), strings.ToLower(domain))
+}
+
+// oidcRole returns the role of a signed-in user: admin for the users of
+// OIDC_ADMIN_GROUPS and OIDC_ADMIN_DOMAINS, otherwise the strongest role bound
+// to their email in role_bindings or the RBAC_DEFAULT_ROLE. "" means none.
+func oidcRole(c *gin.Context, claims oidcClaims) (string, error) {
+	if isOIDCAdmin(claims) {
+		return roleAdmin, nil
+	}
+	return rbac.NewAuthorizer(requestDB(c)).Role(claims.Email)
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// afterLoginURL is where the browser goes once signed in or out
+func afterLoginURL() string {
+	if u := os.Getenv("DASHBOARD_URL"); u != "" {
+		return u
+	}
+	return "/"
+}
+
+// OIDCLogin redirects the browser to the identity provider
+func OIDCLogin(c *gin.Context) {
+	client, err := getOIDCClient(c)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, int(oidcLoginTTL.Seconds()), "/auth", "", client.secure, true)
+	c.SetCookie(oidcNonceCookie, nonce, int(oidcLoginTTL.Seconds()), "/auth", "", client.secure, true)
+	c.Redirect(http.StatusFound, client.oauth2.AuthCodeURL(state, oidc.Nonce(nonce)))
+}
+
+// OIDCCallback exchanges the authorization code for an ID token, verifies it
+// and signs the user in with a session cookie holding an AUTH_SECRET JWT with
+// their email, groups and role (see oidcRole). Users without a role are
+// denied a session.
+func OIDCCallback(c *gin.Context) {
+	client, err := getOIDCClient(c)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	if errParam := c.Query("error"); errParam != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errParam, "description": c.Query("error_description")})
+		return
+	}
+	state, err := c.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid login state"})
+		return
+	}
+	nonce, _ := c.Cookie(oidcNonceCookie)
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, "/auth", "", client.secure, true)
+	c.SetCookie(oidcNonceCookie, "", -1, "/auth", "", client.secure, true)
+
+	token, err := client.oauth2.Exchange(c.Request.Context(), c.Query("code"))
+	if err != nil {
+		log.Printf("[WARN] OIDC code exchange failed: %v\n", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to exchange authorization code"})
+		return
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "No id_token in token response"})
+		return
+	}
+	idToken, err := client.verifier.Verify(c.Request.Context(), rawIDToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid ID token"})
+		return
+	}
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid ID token nonce"})
+		return
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if claims.Email == "" || (claims.EmailVerified != nil && !*claims.EmailVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "A verified email is required"})
+		return
+	}
+	role, err := oidcRole(c, claims)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if role == "" {
+		log.Printf("[WARN] %s has no role, sign-in denied\n", claims.Email)
+		c.JSON(http.StatusForbidden, gin.H{"error": "No role is granted to this user"})
+		return
+	}
+
+	now := time.Now()
+	session, err := jwt.NewWithClaims(jwt.SigningMethodHS256, AuthClaims{
+		Role:   role,
+		Email:  claims.Email,
+		Groups: claims.Groups,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   idToken.Subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(sessionTTL)),
+		},
+	}).SignedString(authSecret())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.SetCookie(sessionCookie, session, int(sessionTTL.Seconds()), "/", "", client.secure, true)
+	log.Printf("[INFO] %s signed in as %s\n", claims.Email, role)
+	c.Redirect(http.StatusFound, afterLoginURL())
+}
+
+// OIDCLogout clears the session cookie
+func OIDCLogout(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(sessionCookie, "", -1, "/", "", strings.HasPrefix(os.Getenv("OIDC_REDIRECT_URL"), "https://"), true)
+	c.Redirect(http.StatusFound, afterLoginURL())
+}
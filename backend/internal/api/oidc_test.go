@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/rbac"
+)
+
+func TestIsOIDCAdmin(t *testing.T) {
+	tests := []struct {
+		name, groups, domains string
+		claims                oidcClaims
+		want                  bool
+	}{
+		{"no allowlist", "", "", oidcClaims{Email: "ann@example.com", Groups: []string{"oncall"}}, false},
+		{"in an admin group", "sre, oncall", "", oidcClaims{Email: "ann@other.org", Groups: []string{"eng", "oncall"}}, true},
+		{"in no admin group", "oncall", "", oidcClaims{Email: "ann@example.com", Groups: []string{"eng"}}, false},
+		{"in an admin domain", "", "@Example.com", oidcClaims{Email: "ann@EXAMPLE.COM"}, true},
+		{"in a subdomain", "", "example.com", oidcClaims{Email: "ann@mail.example.com"}, false},
+		{"domain as a suffix", "", "example.com", oidcClaims{Email: "ann@badexample.com"}, false},
+		{"email without a domain", "", "example.com", oidcClaims{Email: "example.com"}, false},
+		{"either allowlist", "oncall", "example.com", oidcClaims{Email: "ann@example.com", Groups: []string{"eng"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OIDC_ADMIN_GROUPS", tt.groups)
+			t.Setenv("OIDC_ADMIN_DOMAINS", tt.domains)
+			if got := isOIDCAdmin(tt.claims); got != tt.want {
+				t.Errorf("isOIDCAdmin(%+v) = %v, want %v", tt.claims, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIDCRole(t *testing.T) {
+	openTestDB(t)
+	t.Setenv("OIDC_ADMIN_GROUPS", "oncall")
+	t.Setenv("OIDC_ADMIN_DOMAINS", "")
+	if err := db.DB.Create([]models.RoleBinding{
+		{UserID: "bob@example.com", Role: rbac.RoleViewer},
+		{UserID: "bob@example.com", Role: rbac.RoleEditor},
+		{UserID: "eve@example.com", Role: rbac.RoleAdmin},
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, defaultRole string
+		claims            oidcClaims
+		want              string
+	}{
+		{"admin group", "none", oidcClaims{Email: "ann@example.com", Groups: []string{"oncall"}}, roleAdmin},
+		{"strongest binding", "viewer", oidcClaims{Email: "bob@example.com"}, rbac.RoleEditor},
+		{"admin binding", "none", oidcClaims{Email: "eve@example.com"}, rbac.RoleAdmin},
+		{"default role", "viewer", oidcClaims{Email: "carl@example.com"}, rbac.RoleViewer},
+		{"default role above the bindings", "admin", oidcClaims{Email: "bob@example.com"}, rbac.RoleAdmin},
+		{"no role", "none", oidcClaims{Email: "carl@example.com"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RBAC_DEFAULT_ROLE", tt.defaultRole)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/auth/callback", nil)
+			if got, err := oidcRole(c, tt.claims); err != nil || got != tt.want {
+				t.Errorf("oidcRole(%+v) = %q, %v, want %q", tt.claims, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIDCSessionRole(t *testing.T) {
+	openTestDB(t)
+	useFakeNames(t)
+	useAuth(t)
+	r := gin.New()
+	r.GET("/api/alerts/summary", Authenticate(), Authorize(rbac.ActionAlertRead), GetAlertSummary)
+	r.POST("/api/alerts/:id/ack", Authenticate(), Authorize(rbac.ActionAlertWrite), AckAlert)
+	seedIssue(t, "A-1", "c-a", "tenant-a", time.Hour)
+	seedIssue(t, "B-1", "c-b", "tenant-b", time.Hour)
+
+	// A session as set by OIDCCallback: no tenant, the role of the user
+	session, err := jwt.NewWithClaims(jwt.SigningMethodHS256, AuthClaims{
+		Role:  rbac.RoleViewer,
+		Email: "bob@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "oidc-subject",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(sessionTTL)),
+		},
+	}).SignedString(authSecret())
+	if err != nil {
+		t.Fatal(err)
+	}
+	withSession := func(method, path string) *httptest.ResponseRecorder {
+		req := newRequest(method, path, "")
+		req.AddCookie(&http.Cookie{Name: sessionCookie, Value: session})
+		return serveRequest(r, req)
+	}
+
+	// Viewers see every tenant but may not ack
+	var summary AlertSummaryResponse
+	w := withSession(http.MethodGet, "/api/alerts/summary")
+	if w.Code != http.StatusOK {
+		t.Fatalf("summary as a viewer: %d %s", w.Code, w.Body)
+	}
+	decodeJSON(t, w.Body.String(), &summary)
+	if summary.Total != 2 {
+		t.Errorf("viewer session sees %d alerts, want 2", summary.Total)
+	}
+	if w := withSession(http.MethodPost, "/api/alerts/A-1/ack"); w.Code != http.StatusForbidden {
+		t.Errorf("ack as a viewer: %d, want 403", w.Code)
+	}
+
+	// Roles bound after sign-in apply to the session's user
+	if err := db.DB.Create(&models.RoleBinding{UserID: "bob@example.com", Role: rbac.RoleEditor}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if w := withSession(http.MethodPost, "/api/alerts/A-1/ack"); w.Code == http.StatusForbidden || w.Code == http.StatusUnauthorized {
+		t.Errorf("ack with an editor binding: %d %s", w.Code, w.Body)
+	}
+}
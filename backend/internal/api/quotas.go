@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+	"gorm.io/gorm"
+)
+
+// GetQuotas returns the explicit cluster alert quotas and the default quota
+func GetQuotas(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+
+	quotaService := services.NewQuotaService(requestDB(c))
+	quotas, err := quotaService.ListQuotas()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"default_max_active_alerts": quotaService.DefaultLimit, "quotas": quotas})
+}
+
+// SetQuota creates or replaces the alert quota of a cluster. A
+// max_active_alerts of 0 makes the cluster unlimited.
+func SetQuota(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+
+	var quota models.ClusterQuota
+	if err := c.ShouldBindJSON(&quota); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.NewQuotaService(requestDB(c)).SetQuota(&quota); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, quota)
+}
+
+// DeleteQuota removes the alert quota of a cluster, which falls back to the default
+func DeleteQuota(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+
+	if err := services.NewQuotaService(requestDB(c)).DeleteQuota(c.Param("cluster_id")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "quota not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// GetQuotaUsage returns the number of unresolved alerts of a cluster against its quota
+func GetQuotaUsage(c *gin.Context) {
+	if !checkRBACAdmin(c) {
+		return
+	}
+
+	usage, err := services.NewQuotaService(requestDB(c)).Usage(c.Param("cluster_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
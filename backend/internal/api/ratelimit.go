@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateBucket is a token bucket refilled continuously at rate tokens per second
+type rateBucket struct {
+	mu       sync.Mutex
+	tokens   float64
+	last     time.Time
+	lastSeen time.Time
+}
+
+// keyedLimiter holds one rateBucket per key (client IP or cluster ID)
+type keyedLimiter struct {
+	rate    float64
+	burst   float64
+	buckets sync.Map // key -> *rateBucket
+}
+
+func newKeyedLimiter(rate float64, burst int) *keyedLimiter {
+	return &keyedLimiter{rate: rate, burst: float64(burst)}
+}
+
+// allow takes a token from key's bucket. When the bucket is empty it returns
+// false and how long until the next token is available.
+func (l *keyedLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	v, _ := l.buckets.LoadOrStore(key, &rateBucket{tokens: l.burst, last: now})
+	b := v.(*rateBucket)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	b.lastSeen = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// cleanup drops buckets not used since before cutoff
+func (l *keyedLimiter) cleanup(cutoff time.Time) {
+	l.buckets.Range(func(key, v any) bool {
+		b := v.(*rateBucket)
+		b.mu.Lock()
+		idle := b.lastSeen.Before(cutoff)
+		b.mu.Unlock()
+		if idle {
+			l.buckets.Delete(key)
+		}
+		return true
+	})
+}
+
+// rateFromEnv reads a positive rate or burst from key, falling back to def
+func rateFromEnv(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+			return f
+		}
+	}
+	return def
+}
+
+// AlertRateLimit limits alert submissions per client IP and per cluster_id
+// label. Rates (per second) and bursts come from ALERT_RATE_LIMIT /
+// ALERT_RATE_BURST and ALERT_CLUSTER_RATE_LIMIT / ALERT_CLUSTER_RATE_BURST.
+// Rejected requests get 429 with a Retry-After header. Idle buckets are
+// dropped every minute.
+func AlertRateLimit() gin.HandlerFunc {
+	byIP := newKeyedLimiter(rateFromEnv("ALERT_RATE_LIMIT", 10), int(rateFromEnv("ALERT_RATE_BURST", 20)))
+	byCluster := newKeyedLimiter(rateFromEnv("ALERT_CLUSTER_RATE_LIMIT", 5), int(rateFromEnv("ALERT_CLUSTER_RATE_BURST", 10)))
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			cutoff := now.Add(-10 * time.Minute)
+			byIP.cleanup(cutoff)
+			byCluster.cleanup(cutoff)
+		}
+	}()
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		if ok, wait := byIP.allow(c.ClientIP(), now); !ok {
+			rejectRateLimited(c, wait, "too many alerts from this client")
+			return
+		}
+
+		if clusterID := alertClusterID(c); clusterID != "" {
+			if ok, wait := byCluster.allow(clusterID, now); !ok {
+				rejectRateLimited(c, wait, "too many alerts for cluster "+clusterID)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// alertClusterID peeks at labels.cluster_id in the JSON body, leaving the body
+// readable by the handler
+func alertClusterID(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+	orig := c.Request.Body
+	body, err := io.ReadAll(io.LimitReader(orig, 1<<20))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), orig), orig}
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		Labels map[string]string `json:"labels"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return ""
+	}
+	return payload.Labels["cluster_id"]
+}
+
+func rejectRateLimited(c *gin.Context, wait time.Duration, msg string) {
+	c.Header("Retry-After", strconv.Itoa(max(1, int(math.Ceil(wait.Seconds())))))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": msg})
+}
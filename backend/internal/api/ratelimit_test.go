@@ -0,0 +1,119 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitedRouter serves POST /alerts behind AlertRateLimit, answering with
+// the cluster_id label the handler read from the body
+func rateLimitedRouter() *gin.Engine {
+	r := gin.New()
+	r.POST("/alerts", AlertRateLimit(), func(c *gin.Context) {
+		var alert struct {
+			Labels map[string]string `json:"labels"`
+		}
+		if err := c.ShouldBindJSON(&alert); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.String(http.StatusAccepted, alert.Labels["cluster_id"])
+	})
+	return r
+}
+
+func postAlert(r http.Handler, ip, clusterID string) (int, string, string) {
+	req := newRequest(http.MethodPost, "/alerts", `{"labels": {"cluster_id": "`+clusterID+`"}}`)
+	req.RemoteAddr = ip + ":40000"
+	w := serveRequest(r, req)
+	return w.Code, w.Header().Get("Retry-After"), w.Body.String()
+}
+
+func TestAlertRateLimitPerClient(t *testing.T) {
+	t.Setenv("ALERT_RATE_LIMIT", "0.5")
+	t.Setenv("ALERT_RATE_BURST", "5")
+	t.Setenv("ALERT_CLUSTER_RATE_BURST", "1000")
+	r := rateLimitedRouter()
+
+	for i := 0; i < 5; i++ {
+		if code, _, body := postAlert(r, "10.0.0.1", "2001"); code != http.StatusAccepted || body != "2001" {
+			t.Fatalf("alert %d of the burst: %d %q", i, code, body)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		code, retryAfter, _ := postAlert(r, "10.0.0.1", "2001")
+		if code != http.StatusTooManyRequests {
+			t.Fatalf("alert %d after the burst: %d, want 429", i, code)
+		}
+		// One token every two seconds
+		if retryAfter != "2" {
+			t.Errorf("Retry-After = %q, want 2", retryAfter)
+		}
+	}
+	// Other clients have their own bucket
+	if code, _, _ := postAlert(r, "10.0.0.2", "2001"); code != http.StatusAccepted {
+		t.Errorf("another client: %d, want 202", code)
+	}
+}
+
+func TestAlertRateLimitPerCluster(t *testing.T) {
+	t.Setenv("ALERT_RATE_BURST", "1000")
+	t.Setenv("ALERT_CLUSTER_RATE_LIMIT", "1")
+	t.Setenv("ALERT_CLUSTER_RATE_BURST", "3")
+	r := rateLimitedRouter()
+
+	// The cluster's bucket is shared by every client
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		if code, _, _ := postAlert(r, ip, "2001"); code != http.StatusAccepted {
+			t.Fatalf("alert %d for 2001: %d", i, code)
+		}
+	}
+	if code, retryAfter, _ := postAlert(r, "10.0.0.4", "2001"); code != http.StatusTooManyRequests || retryAfter != "1" {
+		t.Errorf("fourth alert for 2001: %d Retry-After %q, want 429 after 1", code, retryAfter)
+	}
+	if code, _, _ := postAlert(r, "10.0.0.4", "2002"); code != http.StatusAccepted {
+		t.Errorf("another cluster: %d, want 202", code)
+	}
+	// Alerts without a cluster_id are only limited per client
+	for i := 0; i < 5; i++ {
+		if code, _, _ := postAlert(r, "10.0.0.5", ""); code != http.StatusAccepted {
+			t.Fatalf("alert %d without a cluster: %d", i, code)
+		}
+	}
+}
+
+func TestKeyedLimiterRefillAndCleanup(t *testing.T) {
+	l := newKeyedLimiter(2, 2)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("a", start); !ok {
+			t.Fatalf("token %d of the burst refused", i)
+		}
+	}
+	if ok, wait := l.allow("a", start); ok || wait != 500*time.Millisecond {
+		t.Errorf("empty bucket = %v, wait %v, want refused for 500ms", ok, wait)
+	}
+	if ok, _ := l.allow("a", start.Add(500*time.Millisecond)); !ok {
+		t.Error("token refilled after 500ms refused")
+	}
+	// Refills stop at the burst
+	for i := 0; i < 3; i++ {
+		ok, _ := l.allow("a", start.Add(time.Hour))
+		if ok != (i < 2) {
+			t.Errorf("token %d an hour later allowed = %v", i, ok)
+		}
+	}
+
+	l.allow("b", start.Add(2*time.Hour))
+	l.cleanup(start.Add(90 * time.Minute))
+	if _, ok := l.buckets.Load("a"); ok {
+		t.Error("idle bucket a was kept")
+	}
+	if _, ok := l.buckets.Load("b"); !ok {
+		t.Error("recently used bucket b was dropped")
+	}
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/rbac"
+)
+
+// adminRoutes are the configuration and operator routes cmd/server puts
+// behind Authorize(rbac.ActionAdmin)
+var adminRoutes = []struct {
+	method, path string
+	handler      gin.HandlerFunc
+}{
+	{"GET", "/api/routing-rules", GetRoutingRules},
+	{"POST", "/api/routing-rules", CreateRoutingRule},
+	{"PUT", "/api/routing-rules/1", UpdateRoutingRule},
+	{"DELETE", "/api/routing-rules/1", DeleteRoutingRule},
+	{"GET", "/api/notification-channels", GetNotificationChannels},
+	{"POST", "/api/notification-channels", CreateNotificationChannel},
+	{"PUT", "/api/notification-channels/1", UpdateNotificationChannel},
+	{"DELETE", "/api/notification-channels/1", DeleteNotificationChannel},
+	{"GET", "/api/escalation-policies", GetEscalationPolicies},
+	{"POST", "/api/escalation-policies", CreateEscalationPolicy},
+	{"DELETE", "/api/escalation-policies/1", DeleteEscalationPolicy},
+	{"POST", "/api/notifications/test", TestNotification},
+	{"POST", "/api/notifications/test-email", TestEmailNotification},
+	{"POST", "/api/cache/invalidate", InvalidateNameCache},
+	{"GET", "/api/cache/snapshot", ExportNameCacheSnapshot},
+	{"POST", "/api/cache/snapshot", ImportNameCacheSnapshot},
+	{"POST", "/api/admin/import/clusters", ImportClusterMetadata},
+	{"POST", "/api/admin/import/tenants", ImportTenantMetadata},
+	{"GET", "/api/admin/cache-consistency", CheckNameCacheConsistency},
+	{"GET", "/api/admin/resolved-names", GetResolvedNames},
+	{"GET", "/api/admin/stale-reaper/status", GetStaleReaperStatus},
+	{"GET", "/api/admin/sync/status", GetClusterSyncStatus},
+	{"GET", "/api/admin/name-resolver/config", GetNameResolverConfig},
+	{"POST", "/api/admin/name-resolver/config", UpdateNameResolverConfig},
+	{"GET", "/api/admin/quotas", GetQuotas},
+	{"POST", "/api/admin/quotas", SetQuota},
+	{"DELETE", "/api/admin/quotas/10001", DeleteQuota},
+	{"GET", "/api/admin/quotas/10001/usage", GetQuotaUsage},
+	{"GET", "/api/admin/fingerprint-config", GetFingerprintConfig},
+	{"POST", "/api/admin/fingerprint-config", UpdateFingerprintConfig},
+	{"POST", "/api/admin/reload-config", ReloadConfig},
+	{"GET", "/api/admin/version-filters", GetVersionFilters},
+	{"GET", "/api/debug/query", DebugAlertQuery},
+	{"GET", "/api/name-changes", GetNameChanges},
+	{"POST", "/api/name-changes/evict", EvictNameChanges},
+}
+
+func adminRouter() *gin.Engine {
+	r := gin.New()
+	for _, route := range adminRoutes {
+		r.Handle(route.method, route.path, Authenticate(), Authorize(rbac.ActionAdmin), route.handler)
+	}
+	return r
+}
+
+func TestAdminRoutesPermissionBoundary(t *testing.T) {
+	openTestDB(t)
+	useFakeNames(t)
+	useAuth(t)
+	r := adminRouter()
+
+	viewer := signToken(t, "tenant-a", rbac.RoleViewer)
+	editor := signToken(t, "tenant-a", rbac.RoleEditor)
+	admin := signToken(t, "", roleAdmin)
+
+	for _, route := range adminRoutes {
+		if w := serve(r, route.method, route.path, "", ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without a token: %d, want 401", route.method, route.path, w.Code)
+		}
+		for name, token := range map[string]string{"viewer": viewer, "editor": editor} {
+			if w := serve(r, route.method, route.path, token, ""); w.Code != http.StatusForbidden {
+				t.Errorf("%s %s as %s: %d %s, want 403", route.method, route.path, name, w.Code, w.Body)
+			}
+		}
+		// Admins get past authorization; the handlers may still reject the empty request
+		if w := serve(r, route.method, route.path, admin, ""); w.Code == http.StatusUnauthorized || w.Code == http.StatusForbidden {
+			t.Errorf("%s %s as admin: %d %s", route.method, route.path, w.Code, w.Body)
+		}
+	}
+}
+
+func TestAdminRoutesRoleBinding(t *testing.T) {
+	openTestDB(t)
+	useFakeNames(t)
+	useAuth(t)
+	r := adminRouter()
+
+	// The token only grants viewer; the role binding of its user grants admin
+	token := signToken(t, "tenant-a", rbac.RoleViewer)
+	if w := serve(r, "GET", "/api/admin/sync/status", token, ""); w.Code != http.StatusForbidden {
+		t.Fatalf("before binding: %d, want 403", w.Code)
+	}
+	if err := db.DB.Create(&models.RoleBinding{UserID: "viewer@tenant-a.example.com", Role: rbac.RoleAdmin}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(r, "GET", "/api/admin/sync/status", token, ""); w.Code != http.StatusOK {
+		t.Errorf("with an admin binding: %d %s, want 200", w.Code, w.Body)
+	}
+}
+
+func TestAdminRoutesWithoutAuthentication(t *testing.T) {
+	openTestDB(t)
+	useFakeNames(t)
+	t.Setenv("AUTH_SECRET", "")
+	t.Setenv("ADMIN_API_TOKEN", "admin-token")
+	t.Setenv("NAME_SERVICE_INVALIDATION_TOKEN", "cache-token")
+	r := adminRouter()
+
+	// Without AUTH_SECRET the shared tokens still guard the operator endpoints
+	if w := serve(r, "GET", "/api/admin/sync/status", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("sync status without the admin token: %d, want 401", w.Code)
+	}
+	if w := serve(r, "GET", "/api/admin/resolved-names", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("resolved names without the invalidation token: %d, want 401", w.Code)
+	}
+	for _, method := range []string{"GET", "POST"} {
+		if w := serve(r, method, "/api/admin/name-resolver/config", "", `{"use_cluster_view": true}`); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s name resolver config without the invalidation token: %d, want 401", method, w.Code)
+		}
+	}
+
+	req := newRequest("GET", "/api/admin/resolved-names", "")
+	req.Header.Set("X-Invalidation-Token", "cache-token")
+	if w := serveRequest(r, req); w.Code != http.StatusOK {
+		t.Errorf("resolved names with the invalidation token: %d %s, want 200", w.Code, w.Body)
+	}
+}
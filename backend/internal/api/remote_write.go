@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// maxRemoteWriteBody bounds the compressed body of a remote write request
+const maxRemoteWriteBody = 8 << 20
+
+var (
+	alertIngester     *services.DataUpdater
+	alertIngesterOnce sync.Once
+	alertIngesterErr  error
+)
+
+// getAlertIngester returns the DataUpdater storing pushed alerts, configured
+// like the JIRA sync except that alerts failing the label schema are rejected
+func getAlertIngester() (*services.DataUpdater, error) {
+	alertIngesterOnce.Do(func() {
+		sqlDB, err := db.DB.DB()
+		if err != nil {
+			alertIngesterErr = err
+			return
+		}
+		alertIngester = services.NewAlertIngester(sqlDB)
+		configureDataUpdater(alertIngester, db.DB)
+		alertIngester.SetLabelSchema(services.GetLabelSchema(), true)
+	})
+	return alertIngester, alertIngesterErr
+}
+
+// IngestRemoteWrite accepts a Prometheus remote write request (snappy
+// compressed prompb.WriteRequest) and stores the firing alerts of its ALERTS
+// series, see services.AlertsFromTimeSeries. They go through the pipeline of
+// the other alerts, services.DataUpdater.IngestAlert: labels are normalized,
+// severities mapped and the result validated like by ValidateAlertLabels.
+// Point a Prometheus remote_write at it with a write_relabel_configs keeping
+// __name__="ALERTS". Responds 204 like a remote write receiver; the counts are
+// in the X-Alerts-Received and X-Alerts-Created headers. Alerts with labels
+// failing the label schema are not stored: the response is then 400 with
+// their errors, which Prometheus does not retry.
+func IngestRemoteWrite(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxRemoteWriteBody+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+	if len(body) > maxRemoteWriteBody {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+		return
+	}
+
+	series, err := services.DecodeWriteRequest(body)
+	if errors.Is(err, services.ErrRemoteWriteTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ingester, err := getAlertIngester()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	alerts := services.AlertsFromTimeSeries(series)
+	created := 0
+	var rejected []*services.InvalidLabelsError
+	for _, alert := range alerts {
+		isNew, err := ingester.IngestAlert(alert)
+		var invalid *services.InvalidLabelsError
+		if errors.As(err, &invalid) {
+			rejected = append(rejected, invalid)
+			continue
+		}
+		if err != nil {
+			// Prometheus retries 5xx responses, which would store the rest again
+			log.Printf("[WARN] Failed to ingest remote write alert %s: %v\n", alert.ID, err)
+			continue
+		}
+		if isNew {
+			created++
+		}
+	}
+
+	c.Header("X-Alerts-Received", strconv.Itoa(len(alerts)))
+	c.Header("X-Alerts-Created", strconv.Itoa(created))
+	if len(rejected) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alerts with invalid labels were rejected", "rejected": rejected})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/snappy"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// remoteWriteBody returns a snappy-compressed prompb.WriteRequest with a
+// firing ALERTS series per cluster
+func remoteWriteBody(t *testing.T, clusterIDs ...string) []byte {
+	t.Helper()
+	var req prompb.WriteRequest
+	for _, clusterID := range clusterIDs {
+		req.Timeseries = append(req.Timeseries, prompb.TimeSeries{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: "ALERTS"},
+				{Name: "alertname", Value: "TiKVDown"},
+				{Name: "alertstate", Value: "firing"},
+				{Name: "severity", Value: "critical"},
+				{Name: "cluster_id", Value: clusterID},
+			},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: time.Now().UnixMilli()}},
+		})
+	}
+	raw, err := req.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return snappy.Encode(nil, raw)
+}
+
+func TestIngestRemoteWrite(t *testing.T) {
+	openTestDB(t)
+	useFakeNames(t)
+	alertIngesterOnce = sync.Once{}
+	t.Cleanup(func() { alertIngesterOnce = sync.Once{} })
+	r := gin.New()
+	r.POST("/api/v1/alerts/proto", IngestRemoteWrite)
+
+	post := func(body []byte) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "/api/v1/alerts/proto", bytes.NewReader(body))
+		req.Header.Set("Content-Encoding", "snappy")
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		return req
+	}
+
+	w := serveRequest(r, post(remoteWriteBody(t, "10001")))
+	if w.Code != http.StatusNoContent || w.Header().Get("X-Alerts-Created") != "1" {
+		t.Fatalf("valid write: %d, %s created, %s", w.Code, w.Header().Get("X-Alerts-Created"), w.Body)
+	}
+
+	// The label schema rejects the non-numeric cluster ID, the other alert is stored
+	w = serveRequest(r, post(remoteWriteBody(t, "c1", "10002")))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("write with invalid labels: %d, want 400", w.Code)
+	}
+	var resp struct {
+		Rejected []struct {
+			ID     string `json:"id"`
+			Errors []struct {
+				Field string `json:"field"`
+			} `json:"errors"`
+		} `json:"rejected"`
+	}
+	decodeJSON(t, w.Body.String(), &resp)
+	if len(resp.Rejected) != 1 || len(resp.Rejected[0].Errors) != 1 || resp.Rejected[0].Errors[0].Field != "cluster_id" {
+		t.Errorf("rejected = %+v, want the cluster_id error of one alert", resp.Rejected)
+	}
+	if got := w.Header().Get("X-Alerts-Received"); got != "2" {
+		t.Errorf("X-Alerts-Received = %s, want 2", got)
+	}
+
+	var clusters []string
+	if err := db.DB.Model(&models.Issue{}).Order("cluster_id").Pluck("cluster_id", &clusters).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 2 || clusters[0] != "10001" || clusters[1] != "10002" {
+		t.Errorf("stored alerts of clusters %v, want 10001 and 10002", clusters)
+	}
+
+	if w := serveRequest(r, post([]byte("not snappy"))); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid body: %d, want 400", w.Code)
+	}
+}
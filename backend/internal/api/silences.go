@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+	"gorm.io/gorm"
+)
+
+// GetSilences returns all silences, including expired ones
+func GetSilences(c *gin.Context) {
+	silences, err := services.NewSilenceService(requestDB(c)).ListSilences()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, silences)
+}
+
+// CreateSilence adds a silence
+func CreateSilence(c *gin.Context) {
+	var silence models.Silence
+	if err := c.ShouldBindJSON(&silence); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	silence.ID = 0
+
+	silenceService := services.NewSilenceService(requestDB(c))
+	if err := silenceService.CreateSilence(&silence); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Tell live subscribers about recent alerts the silence now covers
+	if !silence.StartsAt.After(time.Now()) {
+		go func() {
+			// The request context ends with the response
+			alerts, err := services.NewSilenceService(db.DB).MatchingAlerts(&silence, time.Now().Add(-24*time.Hour))
+			if err != nil {
+				log.Printf("[WARN] Failed to find alerts matched by silence %d: %v\n", silence.ID, err)
+				return
+			}
+			for i := range alerts {
+				services.GetAlertBroadcaster().Publish(services.AlertEventSilenced, services.AlertFromIssue(&alerts[i]))
+			}
+		}()
+	}
+	c.JSON(http.StatusCreated, silence)
+}
+
+// ExpireSilence ends the silence with the given id immediately. The silence is
+// kept for reference.
+func ExpireSilence(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	silence, err := services.NewSilenceService(requestDB(c)).ExpireSilence(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "silence not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, silence)
+}
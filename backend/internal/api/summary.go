@@ -0,0 +1,218 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// AlertSummaryGroup is one bucket of the alert summary
+type AlertSummaryGroup struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// AlertSummaryResponse is returned by GetAlertSummary. Counts holds the groups
+// of the current page keyed by group value.
+type AlertSummaryResponse struct {
+	GroupBy     string              `json:"group_by"`
+	Counts      map[string]int      `json:"counts"`
+	Groups      []AlertSummaryGroup `json:"groups"`
+	Total       int                 `json:"total"`
+	TotalGroups int                 `json:"total_groups"`
+	Page        int                 `json:"page"`
+	PageSize    int                 `json:"page_size"`
+}
+
+// GetAlertSummary counts alerts in the selected window grouped by severity
+// (default) or tenant_plan. Plans come from the cached cluster metadata, so
+// tenant_plan grouping costs one SQLite query plus one cache lookup per
+// distinct cluster; clusters that cannot be resolved are counted as "unknown".
+func GetAlertSummary(c *gin.Context) {
+	groupBy := c.DefaultQuery("group_by", "severity")
+	if groupBy != "severity" && groupBy != "tenant_plan" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must be severity or tenant_plan"})
+		return
+	}
+
+	var days, page, pageSize int
+	fmt.Sscanf(c.DefaultQuery("days", "30"), "%d", &days)
+	if days <= 0 {
+		days = 30
+	}
+	fmt.Sscanf(c.DefaultQuery("page", "1"), "%d", &page)
+	if page < 1 {
+		page = 1
+	}
+	fmt.Sscanf(c.DefaultQuery("page_size", "50"), "%d", &pageSize)
+	if pageSize < 1 {
+		pageSize = 50
+	}
+
+	now := time.Now().UTC()
+	endDate := now.Format("2006-01-02 15:04:05")
+	startDate := now.AddDate(0, 0, -days).Format("2006-01-02 15:04:05")
+
+	envCondition := ""
+	if env := c.DefaultQuery("env", "all"); env == "prod" {
+		envCondition = " AND alert_signature LIKE '[PROD]%'"
+	} else if env == "non_prod" {
+		envCondition = " AND alert_signature NOT LIKE '[PROD]%'"
+	}
+
+	column := "priority"
+	if groupBy == "tenant_plan" {
+		column = "cluster_id"
+	}
+
+	// Tenant-scoped tokens only count their own tenant's alerts
+	tenant := scopedTenant(c)
+
+	var rows []struct {
+		GroupKey string
+		Count    int
+	}
+	err := requestDB(c).Raw(`
+		SELECT COALESCE(`+column+`, '') as group_key, COUNT(*) as count
+		FROM issues
+		WHERE is_alert = 1 AND deleted_at IS NULL `+envCondition+` AND REPLACE(created, ' UTC', '') BETWEEN ? AND ?
+			AND (? = '' OR tenant_id = ?)
+		GROUP BY `+column, startDate, endDate, tenant, tenant).Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to query alert summary"})
+		return
+	}
+
+	counts := make(map[string]int)
+	total := 0
+	resolver := services.GetNameResolver()
+	for _, row := range rows {
+		key := row.GroupKey
+		if groupBy == "tenant_plan" {
+			key = "unknown"
+			if row.GroupKey != "" {
+				if info, err := resolver.ResolveCluster(row.GroupKey); err == nil && info.TenantPlan != "" {
+					key = strings.ToLower(info.TenantPlan)
+				}
+			}
+		} else if key == "" {
+			key = "unknown"
+		}
+		counts[key] += row.Count
+		total += row.Count
+	}
+
+	groups := make([]AlertSummaryGroup, 0, len(counts))
+	for key, count := range counts {
+		groups = append(groups, AlertSummaryGroup{Key: key, Count: count})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Key < groups[j].Key
+	})
+
+	start := (page - 1) * pageSize
+	if start > len(groups) {
+		start = len(groups)
+	}
+	end := start + pageSize
+	if end > len(groups) {
+		end = len(groups)
+	}
+	pageGroups := groups[start:end]
+
+	pageCounts := make(map[string]int, len(pageGroups))
+	for _, g := range pageGroups {
+		pageCounts[g.Key] = g.Count
+	}
+
+	c.JSON(http.StatusOK, AlertSummaryResponse{
+		GroupBy:     groupBy,
+		Counts:      pageCounts,
+		Groups:      pageGroups,
+		Total:       total,
+		TotalGroups: len(groups),
+		Page:        page,
+		PageSize:    pageSize,
+	})
+}
+
+// GetClusterHeatmap returns the per-day alert counts of the top clusters over
+// the trailing days (default 30, top 20), for rendering as a heatmap
+func GetClusterHeatmap(c *gin.Context) {
+	var days, top int
+	fmt.Sscanf(c.DefaultQuery("days", "30"), "%d", &days)
+	if days <= 0 || days > 90 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 90"})
+		return
+	}
+	fmt.Sscanf(c.DefaultQuery("top", "20"), "%d", &top)
+	if top <= 0 || top > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "top must be between 1 and 200"})
+		return
+	}
+
+	rows, err := services.ClusterHeatmap(requestDB(c), days, top, scopedTenant(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to compute cluster heatmap"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"days": days, "clusters": rows})
+}
+
+// GetTopNoisyAlerts ranks alerts of the window (default 7d) by summed
+// occurrence count, grouped by alertname (default), fingerprint or cluster_id,
+// e.g. ?n=10&window=7d&group_by=cluster_id&page=2
+func GetTopNoisyAlerts(c *gin.Context) {
+	window, err := services.ParseTrendWindow(c.DefaultQuery("window", "7d"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var n, page int
+	fmt.Sscanf(c.DefaultQuery("n", "10"), "%d", &n)
+	if n <= 0 || n > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "n must be between 1 and 200"})
+		return
+	}
+	fmt.Sscanf(c.DefaultQuery("page", "1"), "%d", &page)
+	if page < 1 {
+		page = 1
+	}
+
+	groupBy := c.DefaultQuery("group_by", "alertname")
+	result, err := services.TopNoisyAlerts(requestDB(c), services.NoisyQuery{
+		Window:  window,
+		GroupBy: groupBy,
+		Limit:   n,
+		Offset:  (page - 1) * n,
+
+		TenantID: scopedTenant(c),
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedNoisyGroup) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rank noisy alerts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"group_by":          groupBy,
+		"window":            c.DefaultQuery("window", "7d"),
+		"page":              page,
+		"n":                 n,
+		"total_groups":      result.TotalGroups,
+		"total_occurrences": result.TotalOccurrences,
+		"groups":            result.Groups,
+	})
+}
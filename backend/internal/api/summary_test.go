@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetTopNoisyAlertsParams(t *testing.T) {
+	openTestDB(t)
+	useFakeNames(t)
+	for i, id := range []string{"A-1", "A-2", "A-3"} {
+		seedIssue(t, id, "2001", "1001", time.Duration(i+1)*time.Hour)
+	}
+	r := gin.New()
+	r.GET("/api/alerts/top-noisy", GetTopNoisyAlerts)
+
+	for query, code := range map[string]int{
+		"":                        http.StatusOK,
+		"?n=200&page=3":           http.StatusOK,
+		"?group_by=cluster_id":    http.StatusOK,
+		"?n=0":                    http.StatusBadRequest,
+		"?n=201":                  http.StatusBadRequest,
+		"?window=week":            http.StatusBadRequest,
+		"?group_by=tenant_id":     http.StatusBadRequest,
+		"?group_by=alertname&n=1": http.StatusOK,
+	} {
+		if w := serve(r, http.MethodGet, "/api/alerts/top-noisy"+query, "", ""); w.Code != code {
+			t.Errorf("%q: %d %s, want %d", query, w.Code, w.Body, code)
+		}
+	}
+
+	var resp struct {
+		Page       int   `json:"page"`
+		N          int   `json:"n"`
+		TotalCount int64 `json:"total_occurrences"`
+		Groups     []struct {
+			Key         string `json:"key"`
+			Alerts      int64  `json:"alerts"`
+			ClusterName string `json:"cluster_name"`
+		} `json:"groups"`
+	}
+	decodeJSON(t, mustServe(t, r, http.MethodGet, "/api/alerts/top-noisy?group_by=cluster_id&page=0", "", ""), &resp)
+	if resp.Page != 1 || resp.N != 10 || len(resp.Groups) != 1 || resp.Groups[0].Key != "2001" || resp.Groups[0].Alerts != 3 {
+		t.Errorf("response = %+v, want page 1 with the 3 alerts of 2001", resp)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/nolouch/alerts-platform-v2/internal/db"
 	"github.com/nolouch/alerts-platform-v2/internal/models"
 	"github.com/nolouch/alerts-platform-v2/internal/services"
 )
@@ -17,7 +16,7 @@ func HandleGetTasks(c *gin.Context) {
 		return
 	}
 
-	taskService := services.NewTaskService(db.DB, services.NewRulesService())
+	taskService := services.NewTaskService(requestDB(c), services.NewRulesService())
 	tasks, err := taskService.GetTasksByComponent(componentName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -40,7 +39,7 @@ func HandleCreateTask(c *gin.Context) {
 		return
 	}
 
-	taskService := services.NewTaskService(db.DB, services.NewRulesService())
+	taskService := services.NewTaskService(requestDB(c), services.NewRulesService())
 	if err := taskService.CreateTask(&task); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/db/migrations"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+	"github.com/nolouch/alerts-platform-v2/internal/services/nametest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// openTestDB opens a migrated SQLite database in a temporary directory and
+// installs it as db.DB for the duration of the test
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	sqliteDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "alerts.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := migrations.Up(sqliteDB); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	prev := db.DB
+	db.DB = sqliteDB
+	t.Cleanup(func() {
+		db.DB = prev
+		if sqlDB, err := sqliteDB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return sqliteDB
+}
+
+// openTestTiDB opens SQLite with the clusters and tenants tables of TiDB and
+// installs it as the name service's TiDB for the duration of the test
+func openTestTiDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "tidb.db"))
+	if err != nil {
+		t.Fatalf("open test TiDB: %v", err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE tenants (
+			tenant_id TEXT PRIMARY KEY, tenant_name TEXT, kind TEXT DEFAULT 'tenant', parent_tenant_id TEXT,
+			created_at DATETIME DEFAULT '2026-01-01 00:00:00', updated_at DATETIME DEFAULT '2026-01-01 00:00:00')`,
+		`CREATE TABLE clusters (
+			cluster_id TEXT PRIMARY KEY, cluster_name TEXT, tenant_id TEXT, tenant_name TEXT,
+			deploy_type TEXT, version TEXT, cluster_lifecycle TEXT, creation_duration TEXT, tenant_plan TEXT,
+			provider TEXT, region TEXT, project_id TEXT, org_id TEXT, cluster_type TEXT,
+			created_at DATETIME DEFAULT '2026-01-01 00:00:00', updated_at DATETIME DEFAULT '2026-01-01 00:00:00')`,
+	} {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("create test TiDB schema: %v", err)
+		}
+	}
+
+	prev, prevReady := db.TiDB, db.TiDBReady()
+	db.SetTiDB(conn)
+	t.Cleanup(func() {
+		if prevReady {
+			db.SetTiDB(prev)
+		} else {
+			db.SetTiDB(nil)
+		}
+		conn.Close()
+	})
+	return conn
+}
+
+// useNameService installs svc as the NameService for the duration of the test
+func useNameService(t *testing.T, svc services.NameService) {
+	t.Helper()
+	services.SetNameService(svc)
+	t.Cleanup(func() { services.SetNameService(nil) })
+}
+
+// useFakeNames installs a nametest.FakeNameResolver as the NameService for
+// the duration of the test
+func useFakeNames(t *testing.T) *nametest.FakeNameResolver {
+	t.Helper()
+	fake := nametest.NewFakeNameResolver()
+	useNameService(t, fake)
+	return fake
+}
+
+// useNameResolver returns the shared NameResolver, without preloading and
+// with an empty cache, and installs it as the NameService for the duration of
+// the test
+func useNameResolver(t *testing.T) *services.NameResolver {
+	t.Helper()
+	t.Setenv("NAME_SERVICE_PRELOAD", "false")
+	nr := services.GetNameResolver()
+	nr.ClearCache()
+	t.Cleanup(nr.ClearCache)
+	useNameService(t, nr)
+	return nr
+}
+
+// seedIssue inserts an alert created ago before now, with the given cluster and tenant
+func seedIssue(t *testing.T, id, clusterID, tenantID string, ago time.Duration) models.Issue {
+	t.Helper()
+	issue := models.Issue{
+		ID:             id,
+		Title:          "alert " + id,
+		Created:        time.Now().UTC().Add(-ago).Format("2006-01-02 15:04:05") + " UTC",
+		Priority:       "Major",
+		IsAlert:        true,
+		AlertSignature: "[PROD] TiKV down",
+		ClusterID:      clusterID,
+		TenantID:       tenantID,
+		Status:         "Created",
+		Labels:         "[]",
+	}
+	if err := db.DB.Create(&issue).Error; err != nil {
+		t.Fatalf("seed issue %s: %v", id, err)
+	}
+	return issue
+}
+
+// useAuth enables authentication with a test AUTH_SECRET and ADMIN_API_TOKEN
+// for the duration of the test
+func useAuth(t *testing.T) {
+	t.Helper()
+	t.Setenv("AUTH_SECRET", "test-secret")
+	t.Setenv("ADMIN_API_TOKEN", "test-admin-token")
+	t.Setenv("RBAC_DEFAULT_ROLE", "none")
+}
+
+// signToken returns a token issued by POST /api/v1/auth/token for the user
+// role@tenantID.example.com, with the given tenant and role
+func signToken(t *testing.T, tenantID, role string) string {
+	t.Helper()
+	body, _ := json.Marshal(IssueTokenRequest{TenantID: tenantID, User: role + "@" + tenantID + ".example.com", Role: role})
+	r := gin.New()
+	r.POST("/api/v1/auth/token", IssueAuthToken)
+	req := newRequest(http.MethodPost, "/api/v1/auth/token", string(body))
+	req.Header.Set("X-Admin-Token", os.Getenv("ADMIN_API_TOKEN"))
+	w := serveRequest(r, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("issue token: %d %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Token string `json:"token"`
+	}
+	decodeJSON(t, w.Body.String(), &resp)
+	return resp.Token
+}
+
+// serve runs method path through r with an optional bearer token and body
+func serve(r http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	req := newRequest(method, path, body)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return serveRequest(r, req)
+}
+
+// newRequest returns a request with an optional JSON body
+func newRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+// serveRequest runs req through r
+func serveRequest(r http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// mustExec runs statements on conn, failing the test on the first error
+func mustExec(t *testing.T, conn *sql.DB, stmts ...string) {
+	t.Helper()
+	for _, stmt := range stmts {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+}
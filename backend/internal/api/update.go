@@ -1,6 +1,7 @@
 package api
 
 import (
+	"log"
 	"net/http"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/nolouch/alerts-platform-v2/internal/services"
+	"github.com/nolouch/alerts-platform-v2/internal/services/dedup"
 )
 
 // UpdateController handles data update operations
@@ -45,6 +47,8 @@ func NewUpdateController(db *gorm.DB) *UpdateController {
 		// Log error but don't panic - JIRA cred might not be configured  yet
 		println("⚠️  Warning: Failed to initialize data updater:", err.Error())
 		println("   Data update features will be unavailable")
+	} else {
+		configureDataUpdater(dataUpdater, db)
 	}
 
 	return &UpdateController{
@@ -55,6 +59,32 @@ func NewUpdateController(db *gorm.DB) *UpdateController {
 	}
 }
 
+// configureDataUpdater enables the ingestion pipeline of u: label validation,
+// dedup keys, routing, notifications, suppression, quotas, fingerprints and
+// spike detection. Alerts failing the label schema are stored flagged.
+func configureDataUpdater(u *services.DataUpdater, db *gorm.DB) {
+	u.SetLabelSchema(services.GetLabelSchema(), false)
+	u.SetDedupKeyFunc(func(labels map[string]string) (string, error) {
+		return dedup.DeduplicationKeyFor(labels, services.GetNameService())
+	})
+	u.SetRouter(services.NewRoutingService(db))
+	u.SetNotifier(services.GetNotificationService())
+	u.SetSuppressor(services.NewLifecycleSuppressor(services.GetNameResolver()))
+	u.SetVersionFilters(services.GetVersionFilters)
+	u.SetQuota(services.NewQuotaService(db))
+	u.SetFingerprinter(services.GetFingerprinter())
+	u.SetFlapDetector(services.GetFlapDetector())
+
+	anomaly := services.NewAnomalyDetectorFromEnv()
+	anomaly.OnSpike(func(factor float64, recent []services.Alert) {
+		log.Printf("[WARN] Alert volume spike: %.1fx the recent rate, %d alerts in the last minute\n", factor, len(recent))
+		if err := services.GetNotificationService().NotifySpike(factor, recent); err != nil {
+			log.Printf("[WARN] Failed to send alert spike notification: %v\n", err)
+		}
+	})
+	u.SetAnomalyDetector(anomaly)
+}
+
 // TriggerUpdate handles manual update trigger
 func (c *UpdateController) TriggerUpdate(ctx *gin.Context) {
 	if c.dataUpdater == nil {
@@ -0,0 +1,212 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// SQLitePath is the alerts database, relative to the backend directory
+const SQLitePath = "./alerts_v2.db"
+
+const (
+	// backupStepPages is the number of pages copied per backup step. The
+	// source is only read-locked during a step, so writers are never held up
+	// for longer than that.
+	backupStepPages = 256
+	backupStepPause = 10 * time.Millisecond
+)
+
+// Backup copies the live SQLite database src to the new file dest with the
+// SQLite online backup API. Pages are copied in small steps, so writers keep
+// going while the backup runs; a write between steps makes SQLite restart
+// the copy from a consistent state.
+func Backup(ctx context.Context, src *sql.DB, dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("backup file %s already exists", dest)
+	}
+
+	destDB, err := sql.Open("sqlite3", dest)
+	if err != nil {
+		return err
+	}
+	defer destDB.Close()
+
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+	destConn, err := destDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+
+	err = destConn.Raw(func(destRaw any) error {
+		return srcConn.Raw(func(srcRaw any) error {
+			destLite, ok1 := destRaw.(*sqlite3.SQLiteConn)
+			srcLite, ok2 := srcRaw.(*sqlite3.SQLiteConn)
+			if !ok1 || !ok2 {
+				return errors.New("backup needs a go-sqlite3 connection")
+			}
+
+			b, err := destLite.Backup("main", srcLite, "main")
+			if err != nil {
+				return err
+			}
+			for {
+				done, err := b.Step(backupStepPages)
+				if err != nil {
+					b.Finish()
+					return err
+				}
+				if done {
+					return b.Finish()
+				}
+				select {
+				case <-ctx.Done():
+					b.Finish()
+					return ctx.Err()
+				case <-time.After(backupStepPause):
+				}
+			}
+		})
+	})
+	if err != nil {
+		destDB.Close()
+		os.Remove(dest)
+		return fmt.Errorf("backup to %s failed: %w", dest, err)
+	}
+	return nil
+}
+
+// ErrDatabaseInUse is returned by Restore while a process, such as a running
+// server, has the database open
+var ErrDatabaseInUse = errors.New("database is open")
+
+// Restore replaces the SQLite database at path with the backup src, after
+// checking that src is an intact SQLite database. The replaced database is
+// kept as path.pre-restore-<unix time>. The server must not be running: a
+// process holding path open would keep writing to the replaced file, so
+// Restore refuses with ErrDatabaseInUse while one does. Open files are found
+// in /proc; where it is not available, the caller has to make sure.
+func Restore(src, path string) (string, error) {
+	if pids := processesUsing(path); len(pids) > 0 {
+		return "", fmt.Errorf("refusing to restore over %s: %w by process %v", path, ErrDatabaseInUse, pids)
+	}
+	if err := checkIntegrity(src); err != nil {
+		return "", fmt.Errorf("refusing to restore %s: %w", src, err)
+	}
+
+	// Copy next to path first, so the final rename cannot fail half way
+	tmp := path + ".restore"
+	if err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	previous := ""
+	if _, err := os.Stat(path); err == nil {
+		previous = fmt.Sprintf("%s.pre-restore-%d", path, time.Now().Unix())
+		if err := os.Rename(path, previous); err != nil {
+			os.Remove(tmp)
+			return "", err
+		}
+	}
+	// A leftover journal of the old database must not be replayed into the new one
+	os.Remove(path + "-journal")
+	os.Remove(path + "-wal")
+	os.Remove(path + "-shm")
+	if err := os.Rename(tmp, path); err != nil {
+		return previous, err
+	}
+	return previous, nil
+}
+
+// processesUsing returns the IDs of the processes with the file at path open,
+// as listed in /proc. Processes of other users, whose open files cannot be
+// read, and systems without /proc report none.
+func processesUsing(path string) []int {
+	target, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+
+	var pids []int
+	for _, proc := range procs {
+		pid, err := strconv.Atoi(proc.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join("/proc", proc.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			if link, err := os.Readlink(filepath.Join(fdDir, fd.Name())); err == nil && link == target {
+				pids = append(pids, pid)
+				break
+			}
+		}
+	}
+	return pids
+}
+
+func checkIntegrity(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	conn, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var result string
+	if err := conn.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	return nil
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
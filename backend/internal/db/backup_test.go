@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// openSQLiteFile opens the SQLite database at path with n rows in alerts
+func openSQLiteFile(t *testing.T, path string, n int) *sql.DB {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if _, err := conn.Exec(`CREATE TABLE IF NOT EXISTS alerts (id INTEGER PRIMARY KEY, title TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec(`
+		WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < ?)
+		INSERT INTO alerts (title) SELECT ? FROM n`, n, strings.Repeat("x", 200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// countAlerts returns the rows of alerts in the database at path
+func countAlerts(t *testing.T, path string) int {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var n int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM alerts`).Scan(&n); err != nil {
+		t.Fatalf("count alerts of %s: %v", path, err)
+	}
+	return n
+}
+
+func TestBackup(t *testing.T) {
+	dir := t.TempDir()
+	// Larger than one backup step, so the copy takes several
+	live := openSQLiteFile(t, filepath.Join(dir, "alerts_v2.db"), 6000)
+
+	dest := filepath.Join(dir, "backup.db")
+	if err := Backup(context.Background(), live, dest); err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if n := countAlerts(t, dest); n != 6000 {
+		t.Errorf("backup has %d alerts, want 6000", n)
+	}
+	if err := checkIntegrity(dest); err != nil {
+		t.Errorf("backup: %v", err)
+	}
+
+	// The live database stays writable
+	if _, err := live.Exec(`INSERT INTO alerts (title) VALUES ('after')`); err != nil {
+		t.Errorf("write after the backup: %v", err)
+	}
+
+	if err := Backup(context.Background(), live, dest); err == nil {
+		t.Error("backup over an existing file succeeded")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancelled := filepath.Join(dir, "cancelled.db")
+	if err := Backup(ctx, live, cancelled); err == nil {
+		t.Error("cancelled backup succeeded")
+	}
+	if _, err := os.Stat(cancelled); !os.IsNotExist(err) {
+		t.Errorf("cancelled backup left %s behind", cancelled)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	dir := t.TempDir()
+	backup := filepath.Join(dir, "backup.db")
+	openSQLiteFile(t, backup, 5).Close()
+	path := filepath.Join(dir, "alerts_v2.db")
+	openSQLiteFile(t, path, 8).Close()
+
+	previous, err := Restore(backup, path)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if n := countAlerts(t, path); n != 5 {
+		t.Errorf("restored database has %d alerts, want the 5 of the backup", n)
+	}
+	if !strings.HasPrefix(previous, path+".pre-restore-") || countAlerts(t, previous) != 8 {
+		t.Errorf("replaced database kept as %q", previous)
+	}
+	if _, err := os.Stat(path + ".restore"); !os.IsNotExist(err) {
+		t.Error("temporary copy left behind")
+	}
+
+	// Restoring where no database exists keeps nothing
+	fresh := filepath.Join(dir, "fresh.db")
+	if previous, err := Restore(backup, fresh); err != nil || previous != "" || countAlerts(t, fresh) != 5 {
+		t.Errorf("Restore to a new path = %q, %v", previous, err)
+	}
+
+	// Damaged or missing backups are refused and the database left alone
+	damaged := filepath.Join(dir, "damaged.db")
+	if err := os.WriteFile(damaged, []byte("not a database"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, src := range []string{damaged, filepath.Join(dir, "missing.db")} {
+		if _, err := Restore(src, path); err == nil {
+			t.Errorf("Restore(%s) succeeded", filepath.Base(src))
+		}
+	}
+	if n := countAlerts(t, path); n != 5 {
+		t.Errorf("database after refused restores has %d alerts, want 5", n)
+	}
+
+	// A database held open, here by this process, is not replaced
+	if _, err := os.Stat("/proc/self/fd"); err != nil {
+		t.Skip("no /proc to find open files in")
+	}
+	open := openSQLiteFile(t, path, 1)
+	if _, err := Restore(backup, path); !errors.Is(err, ErrDatabaseInUse) {
+		t.Errorf("Restore over an open database: got %v, want ErrDatabaseInUse", err)
+	}
+	open.Close()
+	if n := countAlerts(t, path); n != 6 {
+		t.Errorf("database after the refused restore has %d alerts, want 6", n)
+	}
+	if _, err := Restore(backup, path); err != nil {
+		t.Errorf("Restore once the database is closed: %v", err)
+	}
+}
@@ -3,9 +3,12 @@ package db
 import (
 	"crypto/tls"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
 	"os"
+	"sync/atomic"
 	"time"
 
 	mysqlDriver "github.com/go-sql-driver/mysql"
@@ -16,14 +19,44 @@ import (
 var DB *gorm.DB
 var TiDB *sql.DB
 
+// TiDBReplica is an optional read replica the name service falls back to when
+// the primary is slow. It is nil unless TIDB_REPLICA_DSN is set.
+var TiDBReplica *sql.DB
+
+// tidbReady is set once TiDB has been connected. TiDB is assigned before the
+// flag is set, so callers that check TiDBReady first can safely use TiDB.
+var tidbReady atomic.Bool
+
+const (
+	tidbRetryInitialDelay = 5 * time.Second
+	tidbRetryMaxDelay     = 5 * time.Minute
+)
+
+// TiDBReady reports whether the TiDB connection for the name service is available
+func TiDBReady() bool {
+	return tidbReady.Load()
+}
+
+// SetTiDB makes conn the TiDB connection of the name service, or marks TiDB
+// disconnected when conn is nil. It is for tests and tools that open the
+// connection themselves; the server connects with InitTiDB.
+func SetTiDB(conn *sql.DB) {
+	if conn == nil {
+		tidbReady.Store(false)
+		TiDB = nil
+		return
+	}
+	TiDB = conn
+	tidbReady.Store(true)
+}
+
 func Init() error {
 	var err error
 
 	// Use local database in backend directory
-	dbPath := "./alerts_v2.db"
-	log.Printf("Connecting to database at: %s", dbPath)
+	log.Printf("Connecting to database at: %s", SQLitePath)
 
-	DB, err = gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	DB, err = gorm.Open(sqlite.Open(SQLitePath), &gorm.Config{})
 	if err != nil {
 		return err
 	}
@@ -40,40 +73,128 @@ func Init() error {
 	// Initialize TiDB connection for name service
 	if err := InitTiDB(); err != nil {
 		log.Printf("Warning: TiDB connection failed: %v (name service will be unavailable)", err)
+		if tidbConfigured() && !errors.Is(err, ErrInvalidPoolConfig) {
+			go reconnectTiDB()
+		}
+	}
+
+	if err := InitTiDBReplica(); err != nil {
+		log.Printf("Warning: TiDB replica connection failed: %v (no fallback for slow queries)", err)
 	}
 
 	return nil
 }
 
 func InitTiDB() error {
-	dsn := os.Getenv("TIDB_DSN")
+	dsn, region := tidbDSN()
 	if dsn == "" {
 		return fmt.Errorf("TIDB_DSN environment variable not set")
 	}
 
+	pool, err := tidbPoolConfig()
+	if err != nil {
+		return err
+	}
+
+	conn, err := openTiDB(dsn, pool)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-shutdownCh:
+		conn.Close()
+		return fmt.Errorf("shutting down")
+	default:
+	}
+
+	TiDB = conn
+	activeRegion.Store(region)
+	activePool.Store(&pool)
+	tidbReady.Store(true)
+
+	if region != "" {
+		log.Printf("TiDB connection established for name service (region %s)", region)
+	} else {
+		log.Println("TiDB connection established for name service")
+	}
+	return nil
+}
+
+// InitTiDBReplica opens the read replica configured by TIDB_REPLICA_DSN, if any
+func InitTiDBReplica() error {
+	dsn := os.Getenv("TIDB_REPLICA_DSN")
+	if dsn == "" {
+		return nil
+	}
+
+	pool, err := tidbPoolConfig()
+	if err != nil {
+		return err
+	}
+
+	conn, err := openTiDB(dsn, pool)
+	if err != nil {
+		return err
+	}
+
+	TiDBReplica = conn
+	log.Println("TiDB replica connection established for name service")
+	return nil
+}
+
+// openTiDB opens and pings a TiDB connection pool for dsn
+func openTiDB(dsn string, pool PoolConfig) (*sql.DB, error) {
 	// Register TLS configuration for TiDB Cloud
 	err := mysqlDriver.RegisterTLSConfig("tidb", &tls.Config{
 		MinVersion: tls.VersionTLS12,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to register TLS config: %w", err)
+		return nil, fmt.Errorf("failed to register TLS config: %w", err)
 	}
 
-	TiDB, err = sql.Open("mysql", dsn)
+	conn, err := sql.Open("mysql", dsn)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Set connection pool settings for TiDB
-	TiDB.SetMaxOpenConns(20)
-	TiDB.SetMaxIdleConns(10)
-	TiDB.SetConnMaxLifetime(time.Minute * 5)
+	conn.SetMaxOpenConns(pool.MaxOpenConns)
+	conn.SetMaxIdleConns(pool.MaxIdleConns)
+	conn.SetConnMaxLifetime(pool.ConnMaxLifetime)
 
 	// Test connection
-	if err := TiDB.Ping(); err != nil {
-		return fmt.Errorf("failed to connect to TiDB: %w", err)
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to TiDB: %w", err)
 	}
 
-	log.Println("TiDB connection established for name service")
-	return nil
+	return conn, nil
+}
+
+// reconnectTiDB retries InitTiDB in the background with exponential backoff
+// (5s doubling up to 5m, with jitter) until it succeeds or Shutdown is called
+func reconnectTiDB() {
+	delay := tidbRetryInitialDelay
+	for attempt := 1; ; attempt++ {
+		// Jitter the wait to somewhere in [delay/2, delay*3/2)
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay)))
+		log.Printf("Retrying TiDB connection in %v (attempt %d)", wait.Round(time.Second), attempt)
+		select {
+		case <-time.After(wait):
+		case <-shutdownCh:
+			return
+		}
+
+		err := InitTiDB()
+		if err == nil {
+			return
+		}
+		log.Printf("Warning: TiDB reconnect attempt %d failed: %v", attempt, err)
+
+		delay *= 2
+		if delay > tidbRetryMaxDelay {
+			delay = tidbRetryMaxDelay
+		}
+	}
 }
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const healthCheckTimeout = 5 * time.Second
+
+// HealthStatus is the result of a round-trip to each database
+type HealthStatus struct {
+	SQLiteOK      bool          `json:"sqlite_ok"`
+	TiDBOK        bool          `json:"tidb_ok"`
+	SQLiteLatency time.Duration `json:"sqlite_latency_ns"`
+	TiDBLatency   time.Duration `json:"tidb_latency_ns"`
+	TiDBPool      *PoolConfig   `json:"tidb_pool,omitempty"` // pool settings of the connected TiDB
+	Errors        []string      `json:"errors"`
+	CheckedAt     time.Time     `json:"checked_at"`
+}
+
+// lastHealth holds the result of the most recent background check
+var lastHealth atomic.Pointer[HealthStatus]
+
+// HealthCheck issues SELECT 1 against SQLite and, if connected, TiDB and
+// records the round-trip times
+func HealthCheck() HealthStatus {
+	status := HealthStatus{Errors: []string{}, CheckedAt: time.Now()}
+
+	if DB == nil {
+		status.Errors = append(status.Errors, "sqlite: not initialized")
+	} else if sqlDB, err := DB.DB(); err != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("sqlite: %v", err))
+	} else {
+		status.SQLiteLatency, err = ping(sqlDB)
+		if err != nil {
+			status.Errors = append(status.Errors, fmt.Sprintf("sqlite: %v", err))
+		} else {
+			status.SQLiteOK = true
+		}
+	}
+
+	if !TiDBReady() {
+		status.Errors = append(status.Errors, "tidb: not connected")
+	} else {
+		var err error
+		status.TiDBLatency, err = ping(TiDB)
+		if err != nil {
+			status.Errors = append(status.Errors, fmt.Sprintf("tidb: %v", err))
+		} else {
+			status.TiDBOK = true
+		}
+		status.TiDBPool = ActivePoolConfig()
+	}
+
+	return status
+}
+
+// StartHealthChecks runs HealthCheck now and then every interval, caching the
+// result for LastHealthStatus
+func StartHealthChecks(interval time.Duration) {
+	status := HealthCheck()
+	lastHealth.Store(&status)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			status := HealthCheck()
+			lastHealth.Store(&status)
+		}
+	}()
+}
+
+// LastHealthStatus returns the most recent cached health check result without
+// touching the databases. The zero value is returned before the first check.
+func LastHealthStatus() HealthStatus {
+	if status := lastHealth.Load(); status != nil {
+		return *status
+	}
+	return HealthStatus{Errors: []string{"health check has not run yet"}}
+}
+
+func ping(conn *sql.DB) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var one int
+	err := conn.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+	return time.Since(start), err
+}
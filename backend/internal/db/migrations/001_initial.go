@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// initial creates the base schema. Databases created before numbered
+// migrations may still have the old issues schema; it is backed up (or dropped
+// if empty) before the new table is created.
+type initial struct{}
+
+func (initial) Up(db *gorm.DB) error {
+	if db.Migrator().HasTable("issues") {
+		// Check if we have the new schema or old schema
+		hasDescription := db.Migrator().HasColumn(&models.Issue{}, "description")
+		hasProject := db.Migrator().HasColumn(&models.Issue{}, "project")
+
+		if !hasDescription || !hasProject {
+			fmt.Println("⚠️  Old schema detected, replacing issues table")
+
+			// Backup table if it has data
+			var count int64
+			db.Table("issues").Count(&count)
+			if count > 0 {
+				backupTable := fmt.Sprintf("issues_backup_%d", int64(os.Getpid()))
+				fmt.Printf("💾 Backing up %d records to %s\n", count, backupTable)
+
+				if err := db.Exec(fmt.Sprintf("ALTER TABLE issues RENAME TO %s", backupTable)).Error; err != nil {
+					return fmt.Errorf("failed to backup table: %w", err)
+				}
+			} else if err := db.Migrator().DropTable("issues"); err != nil {
+				return fmt.Errorf("failed to drop old table: %w", err)
+			}
+		}
+	}
+
+	if err := db.AutoMigrate(initialModels()...); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+	return nil
+}
+
+func (initial) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(initialModels()...)
+}
+
+func initialModels() []interface{} {
+	return []interface{}{
+		&models.Issue{},
+		&models.ComponentStat{},
+		&models.DailyStat{},
+		&models.AlertRule{},
+		&models.MutedIssue{},
+		&models.Task{},
+		&models.NameCacheEntry{},
+	}
+}
@@ -0,0 +1,15 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// addClusterIndex indexes issues by cluster, used by the per-cluster views and
+// the name service warm-up query
+type addClusterIndex struct{}
+
+func (addClusterIndex) Up(db *gorm.DB) error {
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_issues_cluster_id ON issues (cluster_id)").Error
+}
+
+func (addClusterIndex) Down(db *gorm.DB) error {
+	return db.Exec("DROP INDEX IF EXISTS idx_issues_cluster_id").Error
+}
@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addMetadataTables creates the tables filled by the CMDB bulk import
+type addMetadataTables struct{}
+
+func (addMetadataTables) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&models.ClusterMetadata{}, &models.TenantMetadata{})
+}
+
+func (addMetadataTables) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.ClusterMetadata{}, &models.TenantMetadata{})
+}
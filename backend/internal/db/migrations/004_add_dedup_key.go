@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addDedupKey adds issues.dedup_key, used to collapse duplicate alerts
+type addDedupKey struct{}
+
+func (addDedupKey) Up(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.Issue{}, "dedup_key") {
+		if err := db.Migrator().AddColumn(&models.Issue{}, "DedupKey"); err != nil {
+			return err
+		}
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_issues_dedup_key ON issues (dedup_key)").Error
+}
+
+func (addDedupKey) Down(db *gorm.DB) error {
+	if err := db.Exec("DROP INDEX IF EXISTS idx_issues_dedup_key").Error; err != nil {
+		return err
+	}
+	return dropColumns(db, &models.Issue{}, "dedup_key")
+}
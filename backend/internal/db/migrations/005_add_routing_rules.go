@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addRoutingRules creates the tenant plan aware notification routing table
+type addRoutingRules struct{}
+
+func (addRoutingRules) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&models.RoutingRule{})
+}
+
+func (addRoutingRules) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.RoutingRule{})
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addIssueDeletedAt adds issues.deleted_at for soft-deleted alerts
+type addIssueDeletedAt struct{}
+
+func (addIssueDeletedAt) Up(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.Issue{}, "deleted_at") {
+		if err := db.Migrator().AddColumn(&models.Issue{}, "DeletedAt"); err != nil {
+			return err
+		}
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_issues_deleted_at ON issues (deleted_at)").Error
+}
+
+func (addIssueDeletedAt) Down(db *gorm.DB) error {
+	if err := db.Exec("DROP INDEX IF EXISTS idx_issues_deleted_at").Error; err != nil {
+		return err
+	}
+	return dropColumns(db, &models.Issue{}, "deleted_at")
+}
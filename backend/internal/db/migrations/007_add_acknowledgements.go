@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addAcknowledgements creates the alert acknowledgement audit table
+type addAcknowledgements struct{}
+
+func (addAcknowledgements) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&models.Acknowledgement{})
+}
+
+func (addAcknowledgements) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.Acknowledgement{})
+}
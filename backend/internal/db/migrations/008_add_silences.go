@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addSilences creates the label matcher based alert silences table
+type addSilences struct{}
+
+func (addSilences) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&models.Silence{})
+}
+
+func (addSilences) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.Silence{})
+}
@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addNotificationChannels creates the webhook notification channel table
+type addNotificationChannels struct{}
+
+func (addNotificationChannels) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&models.NotificationChannel{})
+}
+
+func (addNotificationChannels) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.NotificationChannel{})
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addSlackChannels adds the channel type and Slack message template to
+// notification_channels
+type addSlackChannels struct{}
+
+func (addSlackChannels) Up(db *gorm.DB) error {
+	for _, field := range []string{"Type", "Template"} {
+		if !db.Migrator().HasColumn(&models.NotificationChannel{}, field) {
+			if err := db.Migrator().AddColumn(&models.NotificationChannel{}, field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (addSlackChannels) Down(db *gorm.DB) error {
+	return dropColumns(db, &models.NotificationChannel{}, "template", "type")
+}
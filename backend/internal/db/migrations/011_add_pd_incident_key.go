@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addPDIncidentKey adds issues.pd_incident_key, the PagerDuty incident of an alert
+type addPDIncidentKey struct{}
+
+func (addPDIncidentKey) Up(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&models.Issue{}, "pd_incident_key") {
+		return nil
+	}
+	return db.Migrator().AddColumn(&models.Issue{}, "PDIncidentKey")
+}
+
+func (addPDIncidentKey) Down(db *gorm.DB) error {
+	return dropColumns(db, &models.Issue{}, "pd_incident_key")
+}
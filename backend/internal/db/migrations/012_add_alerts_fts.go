@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// addAlertsFTS creates the alerts_fts full-text index over issue text and
+// labels, kept in sync by triggers. SQLite builds without FTS5 skip it and
+// search falls back to LIKE.
+type addAlertsFTS struct{}
+
+var alertsFTSStatements = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS alerts_fts USING fts5(
+		id UNINDEXED, title, description, labels, alert_signature
+	)`,
+	// INSERT OR REPLACE does not fire delete triggers, so the insert trigger
+	// drops any previous row itself
+	`CREATE TRIGGER IF NOT EXISTS alerts_fts_insert AFTER INSERT ON issues BEGIN
+		DELETE FROM alerts_fts WHERE id = new.id;
+		INSERT INTO alerts_fts (id, title, description, labels, alert_signature)
+		VALUES (new.id, new.title, new.description, new.labels, new.alert_signature);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS alerts_fts_update AFTER UPDATE OF title, description, labels, alert_signature ON issues BEGIN
+		DELETE FROM alerts_fts WHERE id = old.id;
+		INSERT INTO alerts_fts (id, title, description, labels, alert_signature)
+		VALUES (new.id, new.title, new.description, new.labels, new.alert_signature);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS alerts_fts_delete AFTER DELETE ON issues BEGIN
+		DELETE FROM alerts_fts WHERE id = old.id;
+	END`,
+	`DELETE FROM alerts_fts`,
+	`INSERT INTO alerts_fts (id, title, description, labels, alert_signature)
+		SELECT id, title, description, labels, alert_signature FROM issues`,
+}
+
+func (addAlertsFTS) Up(db *gorm.DB) error {
+	for i, stmt := range alertsFTSStatements {
+		if err := db.Exec(stmt).Error; err != nil {
+			if i == 0 && strings.Contains(err.Error(), "no such module") {
+				log.Println("⚠️  SQLite was built without FTS5, alert search will use LIKE")
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (addAlertsFTS) Down(db *gorm.DB) error {
+	for _, stmt := range []string{
+		"DROP TRIGGER IF EXISTS alerts_fts_insert",
+		"DROP TRIGGER IF EXISTS alerts_fts_update",
+		"DROP TRIGGER IF EXISTS alerts_fts_delete",
+		"DROP TABLE IF EXISTS alerts_fts",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addTrendCache creates the cache table for the alert trend endpoint
+type addTrendCache struct{}
+
+func (addTrendCache) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&models.TrendCacheEntry{})
+}
+
+func (addTrendCache) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.TrendCacheEntry{})
+}
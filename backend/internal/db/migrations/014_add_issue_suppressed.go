@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addIssueSuppressed adds issues.suppressed for lifecycle suppressed alerts
+type addIssueSuppressed struct{}
+
+func (addIssueSuppressed) Up(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&models.Issue{}, "suppressed") {
+		return nil
+	}
+	return db.Migrator().AddColumn(&models.Issue{}, "Suppressed")
+}
+
+func (addIssueSuppressed) Down(db *gorm.DB) error {
+	return dropColumns(db, &models.Issue{}, "suppressed")
+}
@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addHeatmapCache creates the cache table for the cluster heatmap endpoint
+type addHeatmapCache struct{}
+
+func (addHeatmapCache) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&models.HeatmapCacheEntry{})
+}
+
+func (addHeatmapCache) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.HeatmapCacheEntry{})
+}
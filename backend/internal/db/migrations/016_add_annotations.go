@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addAnnotations adds the raw and rendered alert annotations to issues and
+// creates the runbooks table used to render them
+type addAnnotations struct{}
+
+func (addAnnotations) Up(db *gorm.DB) error {
+	for _, field := range []string{"Annotations", "RenderedAnnotations"} {
+		if db.Migrator().HasColumn(&models.Issue{}, field) {
+			continue
+		}
+		if err := db.Migrator().AddColumn(&models.Issue{}, field); err != nil {
+			return err
+		}
+	}
+	return db.AutoMigrate(&models.Runbook{})
+}
+
+func (addAnnotations) Down(db *gorm.DB) error {
+	if err := db.Migrator().DropTable(&models.Runbook{}); err != nil {
+		return err
+	}
+	return dropColumns(db, &models.Issue{}, "annotations", "rendered_annotations")
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addAutoResolved adds issues.resolved_at and issues.auto_resolved for the
+// stale alert reaper
+type addAutoResolved struct{}
+
+func (addAutoResolved) Up(db *gorm.DB) error {
+	for _, field := range []string{"ResolvedAt", "AutoResolved"} {
+		if db.Migrator().HasColumn(&models.Issue{}, field) {
+			continue
+		}
+		if err := db.Migrator().AddColumn(&models.Issue{}, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (addAutoResolved) Down(db *gorm.DB) error {
+	return dropColumns(db, &models.Issue{}, "resolved_at", "auto_resolved")
+}
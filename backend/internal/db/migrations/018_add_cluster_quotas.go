@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addClusterQuotas creates the per-cluster active alert quotas
+type addClusterQuotas struct{}
+
+func (addClusterQuotas) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&models.ClusterQuota{})
+}
+
+func (addClusterQuotas) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.ClusterQuota{})
+}
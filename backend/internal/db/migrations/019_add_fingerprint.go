@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addFingerprint adds issues.fingerprint, occurrence_count and updated_at,
+// used to fold repeats of an active alert into it
+type addFingerprint struct{}
+
+func (addFingerprint) Up(db *gorm.DB) error {
+	for _, field := range []string{"Fingerprint", "OccurrenceCount", "UpdatedAt"} {
+		if db.Migrator().HasColumn(&models.Issue{}, field) {
+			continue
+		}
+		if err := db.Migrator().AddColumn(&models.Issue{}, field); err != nil {
+			return err
+		}
+	}
+	if err := db.Exec("UPDATE issues SET updated_at = created WHERE updated_at IS NULL OR updated_at = ''").Error; err != nil {
+		return err
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_issues_fingerprint ON issues (fingerprint)").Error
+}
+
+func (addFingerprint) Down(db *gorm.DB) error {
+	if err := db.Exec("DROP INDEX IF EXISTS idx_issues_fingerprint").Error; err != nil {
+		return err
+	}
+	return dropColumns(db, &models.Issue{}, "fingerprint", "occurrence_count", "updated_at")
+}
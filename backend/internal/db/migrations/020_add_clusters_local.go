@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addClustersLocal creates the local copy of the TiDB clusters table
+type addClustersLocal struct{}
+
+func (addClustersLocal) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&models.LocalCluster{})
+}
+
+func (addClustersLocal) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.LocalCluster{})
+}
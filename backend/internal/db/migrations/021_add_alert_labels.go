@@ -0,0 +1,20 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addAlertLabels creates alert_labels, the labels of each alert as indexed rows
+type addAlertLabels struct{}
+
+func (addAlertLabels) Up(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.AlertLabel{}); err != nil {
+		return err
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_alert_labels_key_value ON alert_labels (key, value)").Error
+}
+
+func (addAlertLabels) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.AlertLabel{})
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// backfillAlertLabels fills alert_labels for alerts stored before it existed.
+// Their raw labels were never kept, so the labels are rebuilt from the columns
+// extracted from them.
+type backfillAlertLabels struct{}
+
+// backfilledLabels maps label keys to the issues column holding their value
+var backfilledLabels = [][2]string{
+	{"alertname", "alert_signature"},
+	{"cluster_id", "cluster_id"},
+	{"tenant_id", "tenant_id"},
+	{"severity", "priority"},
+	{"component", "component_name"},
+	{"source_component", "source_component"},
+	{"alertgroup", "alert_group"},
+	{"biz_type", "biz_type"},
+}
+
+func (backfillAlertLabels) Up(db *gorm.DB) error {
+	for _, label := range backfilledLabels {
+		err := db.Exec(`
+			INSERT OR IGNORE INTO alert_labels (alert_id, key, value)
+			SELECT id, ?, `+label[1]+` FROM issues
+			WHERE is_alert = 1 AND `+label[1]+` IS NOT NULL AND `+label[1]+` != ''
+		`, label[0]).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (backfillAlertLabels) Down(db *gorm.DB) error {
+	return db.Exec("DELETE FROM alert_labels").Error
+}
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addEscalationPolicies creates escalation_policies and adds
+// issues.escalated_at, set once an alert has been escalated
+type addEscalationPolicies struct{}
+
+func (addEscalationPolicies) Up(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.EscalationPolicy{}); err != nil {
+		return err
+	}
+	if db.Migrator().HasColumn(&models.Issue{}, "EscalatedAt") {
+		return nil
+	}
+	return db.Migrator().AddColumn(&models.Issue{}, "EscalatedAt")
+}
+
+func (addEscalationPolicies) Down(db *gorm.DB) error {
+	if err := dropColumns(db, &models.Issue{}, "escalated_at"); err != nil {
+		return err
+	}
+	return db.Migrator().DropTable(&models.EscalationPolicy{})
+}
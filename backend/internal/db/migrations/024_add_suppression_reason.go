@@ -0,0 +1,24 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addSuppressionReason adds issues.suppression_reason. Alerts suppressed
+// before it existed were suppressed by cluster lifecycle.
+type addSuppressionReason struct{}
+
+func (addSuppressionReason) Up(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&models.Issue{}, "suppression_reason") {
+		return nil
+	}
+	if err := db.Migrator().AddColumn(&models.Issue{}, "SuppressionReason"); err != nil {
+		return err
+	}
+	return db.Exec("UPDATE issues SET suppression_reason = 'lifecycle' WHERE suppressed = 1").Error
+}
+
+func (addSuppressionReason) Down(db *gorm.DB) error {
+	return dropColumns(db, &models.Issue{}, "suppression_reason")
+}
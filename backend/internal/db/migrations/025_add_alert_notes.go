@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addAlertNotes creates alert_notes and adds a notes column to alerts_fts
+// holding the note bodies of each alert, kept in sync by triggers on both
+// tables. FTS5 tables cannot be altered, so alerts_fts is rebuilt.
+type addAlertNotes struct{}
+
+// alertsFTSRow is the alerts_fts row of an issue, notes included
+const alertsFTSRow = `SELECT id, title, description, labels, alert_signature,
+		(SELECT group_concat(n.body, ' ') FROM alert_notes n WHERE n.alert_id = issues.id)
+	FROM issues`
+
+var alertNotesFTSStatements = []string{
+	"DROP TRIGGER IF EXISTS alerts_fts_insert",
+	"DROP TRIGGER IF EXISTS alerts_fts_update",
+	"DROP TRIGGER IF EXISTS alerts_fts_delete",
+	"DROP TABLE IF EXISTS alerts_fts",
+	`CREATE VIRTUAL TABLE alerts_fts USING fts5(
+		id UNINDEXED, title, description, labels, alert_signature, notes
+	)`,
+	`CREATE TRIGGER alerts_fts_insert AFTER INSERT ON issues BEGIN
+		DELETE FROM alerts_fts WHERE id = new.id;
+		INSERT INTO alerts_fts (id, title, description, labels, alert_signature, notes)
+		` + alertsFTSRow + ` WHERE id = new.id;
+	END`,
+	`CREATE TRIGGER alerts_fts_update AFTER UPDATE OF title, description, labels, alert_signature ON issues BEGIN
+		DELETE FROM alerts_fts WHERE id = old.id;
+		INSERT INTO alerts_fts (id, title, description, labels, alert_signature, notes)
+		` + alertsFTSRow + ` WHERE id = new.id;
+	END`,
+	`CREATE TRIGGER alerts_fts_delete AFTER DELETE ON issues BEGIN
+		DELETE FROM alerts_fts WHERE id = old.id;
+	END`,
+	`CREATE TRIGGER alert_notes_fts_insert AFTER INSERT ON alert_notes BEGIN
+		DELETE FROM alerts_fts WHERE id = new.alert_id;
+		INSERT INTO alerts_fts (id, title, description, labels, alert_signature, notes)
+		` + alertsFTSRow + ` WHERE id = new.alert_id;
+	END`,
+	`CREATE TRIGGER alert_notes_fts_update AFTER UPDATE OF body ON alert_notes BEGIN
+		DELETE FROM alerts_fts WHERE id = new.alert_id;
+		INSERT INTO alerts_fts (id, title, description, labels, alert_signature, notes)
+		` + alertsFTSRow + ` WHERE id = new.alert_id;
+	END`,
+	`CREATE TRIGGER alert_notes_fts_delete AFTER DELETE ON alert_notes BEGIN
+		DELETE FROM alerts_fts WHERE id = old.alert_id;
+		INSERT INTO alerts_fts (id, title, description, labels, alert_signature, notes)
+		` + alertsFTSRow + ` WHERE id = old.alert_id;
+	END`,
+	`INSERT INTO alerts_fts (id, title, description, labels, alert_signature, notes) ` + alertsFTSRow,
+}
+
+func (addAlertNotes) Up(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.AlertNote{}); err != nil {
+		return err
+	}
+	// Without FTS5 there is no index to extend, search falls back to LIKE
+	if !db.Migrator().HasTable("alerts_fts") {
+		return nil
+	}
+	for _, stmt := range alertNotesFTSStatements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (addAlertNotes) Down(db *gorm.DB) error {
+	if db.Migrator().HasTable("alerts_fts") {
+		for _, stmt := range []string{
+			"DROP TRIGGER IF EXISTS alert_notes_fts_insert",
+			"DROP TRIGGER IF EXISTS alert_notes_fts_update",
+			"DROP TRIGGER IF EXISTS alert_notes_fts_delete",
+			"DROP TRIGGER IF EXISTS alerts_fts_insert",
+			"DROP TRIGGER IF EXISTS alerts_fts_update",
+			"DROP TRIGGER IF EXISTS alerts_fts_delete",
+			"DROP TABLE IF EXISTS alerts_fts",
+		} {
+			if err := db.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		if err := (addAlertsFTS{}).Up(db); err != nil {
+			return err
+		}
+	}
+	return db.Migrator().DropTable(&models.AlertNote{})
+}
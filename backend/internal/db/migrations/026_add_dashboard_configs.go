@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addDashboardConfigs creates dashboard_configs, the dashboard layout of each user
+type addDashboardConfigs struct{}
+
+func (addDashboardConfigs) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&models.DashboardConfig{})
+}
+
+func (addDashboardConfigs) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.DashboardConfig{})
+}
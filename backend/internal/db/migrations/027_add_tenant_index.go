@@ -0,0 +1,32 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// addTenantIndex indexes issues by tenant, used by the tenant filters of the
+// alert list and the per-tenant views. cluster_id and tenant_id are already
+// columns filled on ingestion (cluster_id indexed since 002); alerts that got
+// them only as labels are backfilled from alert_labels first.
+type addTenantIndex struct{}
+
+func (addTenantIndex) Up(db *gorm.DB) error {
+	for _, column := range []string{"cluster_id", "tenant_id"} {
+		err := db.Exec(`
+			UPDATE issues SET ` + column + ` = (
+				SELECT l.value FROM alert_labels l WHERE l.alert_id = issues.id AND l.key = '` + column + `'
+			)
+			WHERE is_alert = 1 AND (` + column + ` IS NULL OR ` + column + ` = '')
+			AND EXISTS (
+				SELECT 1 FROM alert_labels l WHERE l.alert_id = issues.id AND l.key = '` + column + `' AND l.value != ''
+			)
+		`).Error
+		if err != nil {
+			return err
+		}
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_issues_tenant_id ON issues (tenant_id)").Error
+}
+
+// Down keeps the backfilled values, they match the alerts' labels
+func (addTenantIndex) Down(db *gorm.DB) error {
+	return db.Exec("DROP INDEX IF EXISTS idx_issues_tenant_id").Error
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addRBAC creates roles, seeded with the built-in roles, and role_bindings
+type addRBAC struct{}
+
+func (addRBAC) Up(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.Role{}, &models.RoleBinding{}); err != nil {
+		return err
+	}
+	return db.Exec(`
+		INSERT OR IGNORE INTO roles (name, description) VALUES
+			('viewer', 'Read alerts and silences'),
+			('editor', 'Viewer, and ack, silence and annotate alerts'),
+			('admin', 'Everything, including managing role bindings')
+	`).Error
+}
+
+func (addRBAC) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.RoleBinding{}, &models.Role{})
+}
@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addAuditLog creates audit_log, the record of mutating API requests
+type addAuditLog struct{}
+
+func (addAuditLog) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&models.AuditLog{})
+}
+
+func (addAuditLog) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.AuditLog{})
+}
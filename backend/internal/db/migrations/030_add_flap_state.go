@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addFlapState creates flap_state, the transition history of each alert fingerprint
+type addFlapState struct{}
+
+func (addFlapState) Up(db *gorm.DB) error {
+	return db.AutoMigrate(&models.FlapState{})
+}
+
+func (addFlapState) Down(db *gorm.DB) error {
+	return db.Migrator().DropTable(&models.FlapState{})
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addLabelErrors adds issues.label_errors, the labels of an alert failing the
+// label schema
+type addLabelErrors struct{}
+
+func (addLabelErrors) Up(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&models.Issue{}, "label_errors") {
+		return nil
+	}
+	return db.Migrator().AddColumn(&models.Issue{}, "LabelErrors")
+}
+
+func (addLabelErrors) Down(db *gorm.DB) error {
+	return dropColumns(db, &models.Issue{}, "label_errors")
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// addNameCacheDeployType adds name_cache.deploy_type so a restored cache keeps
+// the deploy type of clusters
+type addNameCacheDeployType struct{}
+
+func (addNameCacheDeployType) Up(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&models.NameCacheEntry{}, "deploy_type") {
+		return nil
+	}
+	return db.Migrator().AddColumn(&models.NameCacheEntry{}, "DeployType")
+}
+
+func (addNameCacheDeployType) Down(db *gorm.DB) error {
+	return dropColumns(db, &models.NameCacheEntry{}, "deploy_type")
+}
@@ -0,0 +1,172 @@
+// Package migrations holds the numbered SQLite schema migrations. Each
+// migration lives in its own NNN_name.go file and is listed in All.
+package migrations
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration changes the schema from one version to the next and back
+type Migration interface {
+	Up(db *gorm.DB) error
+	Down(db *gorm.DB) error
+}
+
+// Step is a Migration with its version number and name
+type Step struct {
+	Version int
+	Name    string
+	Migration
+}
+
+// All returns every migration in version order
+func All() []Step {
+	return []Step{
+		{1, "initial", initial{}},
+		{2, "add_cluster_index", addClusterIndex{}},
+		{3, "add_metadata_tables", addMetadataTables{}},
+		{4, "add_dedup_key", addDedupKey{}},
+		{5, "add_routing_rules", addRoutingRules{}},
+		{6, "add_issue_deleted_at", addIssueDeletedAt{}},
+		{7, "add_acknowledgements", addAcknowledgements{}},
+		{8, "add_silences", addSilences{}},
+		{9, "add_notification_channels", addNotificationChannels{}},
+		{10, "add_slack_channels", addSlackChannels{}},
+		{11, "add_pd_incident_key", addPDIncidentKey{}},
+		{12, "add_alerts_fts", addAlertsFTS{}},
+		{13, "add_trend_cache", addTrendCache{}},
+		{14, "add_issue_suppressed", addIssueSuppressed{}},
+		{15, "add_heatmap_cache", addHeatmapCache{}},
+		{16, "add_annotations", addAnnotations{}},
+		{17, "add_auto_resolved", addAutoResolved{}},
+		{18, "add_cluster_quotas", addClusterQuotas{}},
+		{19, "add_fingerprint", addFingerprint{}},
+		{20, "add_clusters_local", addClustersLocal{}},
+		{21, "add_alert_labels", addAlertLabels{}},
+		{22, "backfill_alert_labels", backfillAlertLabels{}},
+		{23, "add_escalation_policies", addEscalationPolicies{}},
+		{24, "add_suppression_reason", addSuppressionReason{}},
+		{25, "add_alert_notes", addAlertNotes{}},
+		{26, "add_dashboard_configs", addDashboardConfigs{}},
+		{27, "add_tenant_index", addTenantIndex{}},
+		{28, "add_rbac", addRBAC{}},
+		{29, "add_audit_log", addAuditLog{}},
+		{30, "add_flap_state", addFlapState{}},
+		{31, "add_label_errors", addLabelErrors{}},
+		{32, "add_name_cache_deploy_type", addNameCacheDeployType{}},
+	}
+}
+
+// SchemaMigration records an applied migration
+type SchemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"not null"`
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// CurrentVersion returns the highest applied migration version, 0 if none
+func CurrentVersion(db *gorm.DB) (int, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return 0, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var version int
+	if err := db.Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Up applies all pending migrations in order. Each migration runs in its own
+// transaction together with its schema_migrations row, so a failing migration
+// is rolled back and leaves the schema at the previous version.
+func Up(db *gorm.DB) error {
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range All() {
+		if step.Version <= current {
+			continue
+		}
+
+		log.Printf("Applying migration %03d_%s", step.Version, step.Name)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := step.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: step.Version, Name: step.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %03d_%s failed: %w", step.Version, step.Name, err)
+		}
+	}
+	return nil
+}
+
+// DownTo reverts applied migrations, newest first, until the schema is at target
+func DownTo(db *gorm.DB, target int) error {
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+
+	steps := All()
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		if step.Version > current || step.Version <= target {
+			continue
+		}
+
+		log.Printf("Reverting migration %03d_%s", step.Version, step.Name)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := step.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, step.Version).Error
+		})
+		if err != nil {
+			return fmt.Errorf("reverting migration %03d_%s failed: %w", step.Version, step.Name, err)
+		}
+	}
+	return nil
+}
+
+// dropColumns drops columns from the table of model. SQLite drops a column by
+// rebuilding the table, which also drops its indexes and triggers, so they are
+// read before and created again after. Indexes and triggers on the dropped
+// columns must be dropped first.
+func dropColumns(db *gorm.DB, model interface{}, columns ...string) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+
+	var dependents []string
+	err := db.Raw("SELECT sql FROM sqlite_master WHERE tbl_name = ? AND type IN ('index', 'trigger') AND sql IS NOT NULL",
+		stmt.Schema.Table).Scan(&dependents).Error
+	if err != nil {
+		return fmt.Errorf("failed to read indexes of %s: %w", stmt.Schema.Table, err)
+	}
+
+	for _, column := range columns {
+		if err := db.Migrator().DropColumn(model, column); err != nil {
+			return err
+		}
+	}
+	for _, sql := range dependents {
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to restore %q: %w", sql, err)
+		}
+	}
+	return nil
+}
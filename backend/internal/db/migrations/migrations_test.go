@@ -0,0 +1,260 @@
+package migrations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openMemoryDB opens a fresh in-memory SQLite database
+func openMemoryDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sqlite pool: %v", err)
+	}
+	// Every connection would get its own in-memory database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+// schemaObject is a table with its columns, an index with its columns, or a
+// trigger or view. SQLite rewrites the SQL of a table when one of its columns
+// is dropped and a column added back goes last, so tables are compared by
+// their columns in name order and indexes by definition, not by SQL.
+type schemaObject struct {
+	Type, Name, Def string
+}
+
+// schema returns every schema object except schema_migrations
+func schema(t *testing.T, db *gorm.DB) []schemaObject {
+	t.Helper()
+	var objects []schemaObject
+	err := db.Raw(`
+		SELECT m.type, m.name, (
+			SELECT group_concat(def, ', ') FROM (
+				SELECT c.name || ' ' || c.type || ' ' || c."notnull" || ' ' || COALESCE(c.dflt_value, '') || ' ' || c.pk AS def
+				FROM pragma_table_info(m.name) c ORDER BY c.name)) AS def
+		FROM sqlite_master m
+		WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%' AND m.name != 'schema_migrations'
+		UNION ALL
+		SELECT m.type, m.name, m.tbl_name || '(' || group_concat(COALESCE(i.name, 'expr'), ', ') || ')' ||
+			CASE WHEN m.sql LIKE 'CREATE UNIQUE%' THEN ' unique' ELSE '' END
+		FROM sqlite_master m, pragma_index_info(m.name) i
+		WHERE m.type = 'index' AND m.sql IS NOT NULL
+		GROUP BY m.name
+		UNION ALL
+		SELECT type, name, tbl_name FROM sqlite_master WHERE type IN ('trigger', 'view')
+		ORDER BY 1, 2`).Scan(&objects).Error
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	return objects
+}
+
+// schemaDiff returns the objects only in got and those only in want
+func schemaDiff(got, want []schemaObject) (extra, missing []schemaObject) {
+	in := func(objects []schemaObject, o schemaObject) bool {
+		for _, other := range objects {
+			if other == o {
+				return true
+			}
+		}
+		return false
+	}
+	for _, o := range got {
+		if !in(want, o) {
+			extra = append(extra, o)
+		}
+	}
+	for _, o := range want {
+		if !in(got, o) {
+			missing = append(missing, o)
+		}
+	}
+	return extra, missing
+}
+
+func TestVersionsAreSequential(t *testing.T) {
+	for i, step := range All() {
+		if step.Version != i+1 || step.Name == "" || step.Migration == nil {
+			t.Errorf("migration %d is %03d_%s", i+1, step.Version, step.Name)
+		}
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	steps := All()
+	latest := steps[len(steps)-1].Version
+
+	// The objects each step adds when applied on its own. 001_initial creates
+	// the tables from the current models, so later steps mostly add indexes.
+	db := openMemoryDB(t)
+	added := make([][]schemaObject, latest+1)
+	before := schema(t, db)
+	for _, step := range steps {
+		if err := step.Up(db); err != nil {
+			t.Fatalf("%03d_%s up: %v", step.Version, step.Name, err)
+		}
+		after := schema(t, db)
+		added[step.Version], _ = schemaDiff(after, before)
+		before = after
+	}
+	want := before
+
+	db = openMemoryDB(t)
+	if err := Up(db); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if v, err := CurrentVersion(db); err != nil || v != latest {
+		t.Fatalf("version after Up = %d, %v, want %d", v, err, latest)
+	}
+	if got := schema(t, db); !reflect.DeepEqual(got, want) {
+		t.Fatalf("schema after Up differs from applying every step")
+	}
+	if err := Up(db); err != nil {
+		t.Fatalf("Up with nothing pending: %v", err)
+	}
+
+	// Reverting down to each version removes what the step added and nothing
+	// that Up does not put back
+	for i := len(steps) - 1; i >= 0; i-- {
+		step := steps[i]
+		if err := DownTo(db, step.Version-1); err != nil {
+			t.Fatalf("DownTo(%d): %v", step.Version-1, err)
+		}
+		if v, _ := CurrentVersion(db); v != step.Version-1 {
+			t.Fatalf("version after reverting %03d_%s = %d", step.Version, step.Name, v)
+		}
+		if gone, _ := schemaDiff(added[step.Version], schema(t, db)); len(gone) != len(added[step.Version]) {
+			t.Errorf("reverting %03d_%s kept some of %v", step.Version, step.Name, added[step.Version])
+		}
+
+		if err := Up(db); err != nil {
+			t.Fatalf("Up after reverting %03d_%s: %v", step.Version, step.Name, err)
+		}
+		if extra, missing := schemaDiff(schema(t, db), want); len(extra)+len(missing) > 0 {
+			t.Errorf("reverting and re-applying %03d_%s left %v, without %v", step.Version, step.Name, extra, missing)
+		}
+	}
+
+	if err := DownTo(db, 0); err != nil {
+		t.Fatalf("DownTo(0): %v", err)
+	}
+	if got := schema(t, db); len(got) != 0 {
+		t.Errorf("schema after reverting everything = %v, want empty", got)
+	}
+}
+
+func TestAddDashboardConfigs(t *testing.T) {
+	db := openMemoryDB(t)
+	if err := Up(db); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if err := DownTo(db, 25); err != nil {
+		t.Fatalf("DownTo(25): %v", err)
+	}
+	if db.Migrator().HasTable("dashboard_configs") {
+		t.Fatal("dashboard_configs kept after reverting 026")
+	}
+	if err := Up(db); err != nil {
+		t.Fatalf("Up from 25: %v", err)
+	}
+
+	// One config per user, which the upsert of the API relies on
+	insert := `INSERT INTO dashboard_configs (user_id, config, updated_at) VALUES ('ops@example.com', '{}', CURRENT_TIMESTAMP)`
+	if err := db.Exec(insert).Error; err != nil {
+		t.Fatalf("insert config: %v", err)
+	}
+	if err := db.Exec(insert).Error; err == nil {
+		t.Error("second config of the same user inserted")
+	}
+	if err := db.Exec(`INSERT INTO dashboard_configs (user_id, updated_at) VALUES ('dev@example.com', CURRENT_TIMESTAMP)`).Error; err == nil {
+		t.Error("config without a body inserted")
+	}
+}
+
+func TestAddTenantIndexBackfill(t *testing.T) {
+	db := openMemoryDB(t)
+	if err := Up(db); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if err := DownTo(db, 26); err != nil {
+		t.Fatalf("DownTo(26): %v", err)
+	}
+	for _, q := range []string{
+		`INSERT INTO issues (id, is_alert, cluster_id, tenant_id) VALUES
+			('A-1', 1, '', ''), ('A-2', 1, NULL, NULL), ('A-3', 1, '2003', '1003'), ('T-1', 0, '', '')`,
+		`INSERT INTO alert_labels (alert_id, key, value) VALUES
+			('A-1', 'cluster_id', '2001'), ('A-1', 'tenant_id', '1001'),
+			('A-2', 'cluster_id', '2002'), ('A-2', 'tenant_id', ''),
+			('A-3', 'cluster_id', '9999'), ('T-1', 'cluster_id', '2004')`,
+	} {
+		if err := db.Exec(q).Error; err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	if err := Up(db); err != nil {
+		t.Fatalf("Up from 26: %v", err)
+	}
+
+	type row struct{ ID, ClusterID, TenantID string }
+	var rows []row
+	db.Raw(`SELECT id, COALESCE(cluster_id, '') AS cluster_id, COALESCE(tenant_id, '') AS tenant_id FROM issues ORDER BY id`).Scan(&rows)
+	// Only empty columns of alerts are filled, and only with non-empty labels
+	want := []row{{"A-1", "2001", "1001"}, {"A-2", "2002", ""}, {"A-3", "2003", "1003"}, {"T-1", "", ""}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("issues = %v, want %v", rows, want)
+	}
+
+	var plan []struct{ Detail string }
+	db.Raw(`EXPLAIN QUERY PLAN SELECT id FROM issues WHERE tenant_id = '1001'`).Scan(&plan)
+	if len(plan) == 0 || !strings.Contains(plan[0].Detail, "idx_issues_tenant_id") {
+		t.Errorf("tenant filter plan = %v, want idx_issues_tenant_id", plan)
+	}
+}
+
+// BenchmarkTenantFilter counts the alerts of one tenant among 100k, with and
+// without the index added by 027
+func BenchmarkTenantFilter(b *testing.B) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		b.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	defer sqlDB.Close()
+	if err := Up(db); err != nil {
+		b.Fatalf("Up: %v", err)
+	}
+	err = db.Exec(`
+		WITH RECURSIVE n(i) AS (SELECT 0 UNION ALL SELECT i + 1 FROM n WHERE i < 99999)
+		INSERT INTO issues (id, is_alert, cluster_id, tenant_id, created)
+		SELECT 'A-' || i, 1, CAST(20000 + i % 2000 AS TEXT), CAST(10000 + i % 500 AS TEXT), datetime('now', '-' || (i % 720) || ' hours') FROM n`).Error
+	if err != nil {
+		b.Fatalf("seed 100k alerts: %v", err)
+	}
+
+	count := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var n int64
+			if err := db.Raw(`SELECT COUNT(*) FROM issues WHERE is_alert = 1 AND tenant_id = ?`, "10042").Scan(&n).Error; err != nil || n != 200 {
+				b.Fatalf("count = %d, %v", n, err)
+			}
+		}
+	}
+	b.Run("indexed", count)
+	if err := DownTo(db, 26); err != nil {
+		b.Fatalf("DownTo(26): %v", err)
+	}
+	b.Run("scan", count)
+}
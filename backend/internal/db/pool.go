@@ -0,0 +1,77 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Pool defaults used when the TIDB_* pool variables are unset
+const (
+	defaultTiDBMaxOpenConns    = 20
+	defaultTiDBMaxIdleConns    = 10
+	defaultTiDBConnMaxLifetime = 5 * time.Minute
+)
+
+// ErrInvalidPoolConfig is returned by InitTiDB when the TIDB_* pool variables
+// are malformed. Retrying cannot fix it, so no reconnect is scheduled.
+var ErrInvalidPoolConfig = errors.New("invalid TiDB pool configuration")
+
+// PoolConfig is the connection pool applied to the TiDB primary and replica
+type PoolConfig struct {
+	MaxOpenConns    int           `json:"max_open_conns"`
+	MaxIdleConns    int           `json:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `json:"conn_max_lifetime_ns"`
+}
+
+// activePool is the pool configuration of the connected TiDB, nil before
+// InitTiDB succeeds
+var activePool atomic.Pointer[PoolConfig]
+
+// ActivePoolConfig returns the pool configuration TiDB was opened with, or nil
+// when TiDB is not connected
+func ActivePoolConfig() *PoolConfig {
+	return activePool.Load()
+}
+
+// tidbPoolConfig reads TIDB_MAX_OPEN_CONNS, TIDB_MAX_IDLE_CONNS and
+// TIDB_CONN_MAX_LIFETIME (Go duration, e.g. 5m). Unset variables keep the
+// defaults; malformed values and more idle than open connections are
+// configuration errors.
+func tidbPoolConfig() (PoolConfig, error) {
+	cfg := PoolConfig{
+		MaxOpenConns:    defaultTiDBMaxOpenConns,
+		MaxIdleConns:    defaultTiDBMaxIdleConns,
+		ConnMaxLifetime: defaultTiDBConnMaxLifetime,
+	}
+
+	if v := os.Getenv("TIDB_MAX_OPEN_CONNS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("%w: TIDB_MAX_OPEN_CONNS %q must be a positive integer", ErrInvalidPoolConfig, v)
+		}
+		cfg.MaxOpenConns = n
+	}
+	if v := os.Getenv("TIDB_MAX_IDLE_CONNS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("%w: TIDB_MAX_IDLE_CONNS %q must be a non-negative integer", ErrInvalidPoolConfig, v)
+		}
+		cfg.MaxIdleConns = n
+	}
+	if v := os.Getenv("TIDB_CONN_MAX_LIFETIME"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("%w: TIDB_CONN_MAX_LIFETIME %q must be a positive duration", ErrInvalidPoolConfig, v)
+		}
+		cfg.ConnMaxLifetime = d
+	}
+
+	if cfg.MaxIdleConns > cfg.MaxOpenConns {
+		return cfg, fmt.Errorf("%w: max idle connections (%d) exceed max open connections (%d)", ErrInvalidPoolConfig, cfg.MaxIdleConns, cfg.MaxOpenConns)
+	}
+	return cfg, nil
+}
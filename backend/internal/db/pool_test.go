@@ -0,0 +1,99 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTiDBPoolConfig(t *testing.T) {
+	defaults := PoolConfig{MaxOpenConns: 20, MaxIdleConns: 10, ConnMaxLifetime: 5 * time.Minute}
+	for _, tc := range []struct {
+		open, idle, lifetime string
+		want                 PoolConfig
+		invalid              bool
+	}{
+		{want: defaults},
+		{open: "100", idle: "50", lifetime: "90s", want: PoolConfig{MaxOpenConns: 100, MaxIdleConns: 50, ConnMaxLifetime: 90 * time.Second}},
+		// Unset variables keep their default
+		{open: "40", want: PoolConfig{MaxOpenConns: 40, MaxIdleConns: 10, ConnMaxLifetime: 5 * time.Minute}},
+		{idle: "0", lifetime: "1h", want: PoolConfig{MaxOpenConns: 20, MaxIdleConns: 0, ConnMaxLifetime: time.Hour}},
+		{open: "10", idle: "10", want: PoolConfig{MaxOpenConns: 10, MaxIdleConns: 10, ConnMaxLifetime: 5 * time.Minute}},
+
+		{open: "0", invalid: true},
+		{open: "-5", invalid: true},
+		{open: "many", invalid: true},
+		{idle: "-1", invalid: true},
+		{idle: "1.5", invalid: true},
+		{lifetime: "300", invalid: true},
+		{lifetime: "-1m", invalid: true},
+		{lifetime: "0s", invalid: true},
+		// More idle than open connections, explicitly or through a default
+		{open: "10", idle: "11", invalid: true},
+		{open: "5", invalid: true},
+		{idle: "21", invalid: true},
+	} {
+		t.Setenv("TIDB_MAX_OPEN_CONNS", tc.open)
+		t.Setenv("TIDB_MAX_IDLE_CONNS", tc.idle)
+		t.Setenv("TIDB_CONN_MAX_LIFETIME", tc.lifetime)
+
+		got, err := tidbPoolConfig()
+		if tc.invalid {
+			if !errors.Is(err, ErrInvalidPoolConfig) {
+				t.Errorf("open=%q idle=%q lifetime=%q: error %v, want ErrInvalidPoolConfig", tc.open, tc.idle, tc.lifetime, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("open=%q idle=%q lifetime=%q: %+v, %v, want %+v", tc.open, tc.idle, tc.lifetime, got, err, tc.want)
+		}
+	}
+}
+
+func TestInitTiDBInvalidPool(t *testing.T) {
+	t.Setenv("TIDB_REGION", "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("TIDB_DSN", "user:pw@tcp(127.0.0.1:1)/test")
+	t.Setenv("TIDB_MAX_OPEN_CONNS", "4")
+	t.Setenv("TIDB_MAX_IDLE_CONNS", "8")
+	t.Setenv("TIDB_CONN_MAX_LIFETIME", "")
+
+	// The configuration is rejected before connecting
+	if err := InitTiDB(); !errors.Is(err, ErrInvalidPoolConfig) {
+		t.Errorf("InitTiDB = %v, want ErrInvalidPoolConfig", err)
+	}
+	t.Setenv("TIDB_REPLICA_DSN", "user:pw@tcp(127.0.0.1:1)/test")
+	if err := InitTiDBReplica(); !errors.Is(err, ErrInvalidPoolConfig) {
+		t.Errorf("InitTiDBReplica = %v, want ErrInvalidPoolConfig", err)
+	}
+}
+
+func TestHealthCheckReportsPool(t *testing.T) {
+	oldTiDB, oldPool := TiDB, activePool.Load()
+	t.Cleanup(func() {
+		SetTiDB(oldTiDB)
+		activePool.Store(oldPool)
+	})
+
+	SetTiDB(nil)
+	activePool.Store(nil)
+	if status := HealthCheck(); status.TiDBPool != nil {
+		t.Errorf("pool without TiDB = %+v, want none", status.TiDBPool)
+	}
+
+	tidb, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open tidb stand-in: %v", err)
+	}
+	defer tidb.Close()
+	pool := PoolConfig{MaxOpenConns: 40, MaxIdleConns: 4, ConnMaxLifetime: time.Minute}
+	SetTiDB(tidb)
+	activePool.Store(&pool)
+
+	status := HealthCheck()
+	if !status.TiDBOK || status.TiDBPool == nil || *status.TiDBPool != pool {
+		t.Errorf("health = %+v, want TiDB ok with pool %+v", status, pool)
+	}
+}
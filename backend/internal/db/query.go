@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSQLiteQueryTimeout bounds SQLite queries when SQLITE_QUERY_TIMEOUT_MS is unset
+const defaultSQLiteQueryTimeout = 30 * time.Second
+
+var (
+	queryTimeoutOnce sync.Once
+	queryTimeout     time.Duration
+)
+
+// DefaultQueryTimeout returns the process-wide SQLite query timeout from
+// SQLITE_QUERY_TIMEOUT_MS, 30s by default
+func DefaultQueryTimeout() time.Duration {
+	queryTimeoutOnce.Do(func() {
+		queryTimeout = defaultSQLiteQueryTimeout
+		if v := os.Getenv("SQLITE_QUERY_TIMEOUT_MS"); v != "" {
+			if ms, err := strconv.Atoi(v); err == nil && ms > 0 {
+				queryTimeout = time.Duration(ms) * time.Millisecond
+			} else {
+				log.Printf("Warning: invalid SQLITE_QUERY_TIMEOUT_MS %q, using %s", v, defaultSQLiteQueryTimeout)
+			}
+		}
+	})
+	return queryTimeout
+}
+
+// QueryWithTimeout runs a raw SQLite query that is cancelled once timeout has
+// passed (DefaultQueryTimeout when timeout <= 0) or ctx is done. The deadline
+// also bounds reading the rows, which are closed when it passes. Errors
+// include the query, whitespace collapsed.
+func QueryWithTimeout(ctx context.Context, timeout time.Duration, query string, args ...interface{}) (*sql.Rows, error) {
+	if timeout <= 0 {
+		timeout = DefaultQueryTimeout()
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	rows, err := DB.WithContext(ctx).Raw(query, args...).Rows()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("query %q: %w", strings.Join(strings.Fields(query), " "), err)
+	}
+	// The caller reads the rows after we return, so the context is released
+	// once its deadline passes rather than now
+	context.AfterFunc(ctx, cancel)
+	return rows, nil
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// activeRegion is the region whose DSN InitTiDB connected with, "" for TIDB_DSN
+var activeRegion atomic.Value
+
+// ActiveRegion returns the region of the TiDB the name service is connected
+// to, or "" when the default TIDB_DSN is in use
+func ActiveRegion() string {
+	region, _ := activeRegion.Load().(string)
+	return region
+}
+
+// tidbDSN picks the TiDB DSN for this process. TIDB_REGION_DSNS holds
+// comma-separated region=dsn pairs; the entry matching the current region is
+// preferred, otherwise TIDB_DSN is used. The returned region is "" for the
+// fallback.
+func tidbDSN() (dsn string, region string) {
+	region = currentRegion()
+	if region != "" {
+		if dsn, ok := parseRegionDSNs(os.Getenv("TIDB_REGION_DSNS"))[region]; ok {
+			return dsn, region
+		}
+	}
+	return os.Getenv("TIDB_DSN"), ""
+}
+
+// tidbConfigured reports whether any TiDB DSN is configured
+func tidbConfigured() bool {
+	dsn, _ := tidbDSN()
+	return dsn != ""
+}
+
+// currentRegion detects the region this process runs in. TIDB_REGION takes
+// precedence over the AWS SDK variables.
+func currentRegion() string {
+	for _, key := range []string{"TIDB_REGION", "AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			return strings.ToLower(v)
+		}
+	}
+	return ""
+}
+
+// parseRegionDSNs parses "region=dsn,region=dsn". Regions are lowercased and
+// malformed pairs are skipped. Only the first "=" separates the region, since
+// DSNs may contain "=" in their query string.
+func parseRegionDSNs(s string) map[string]string {
+	dsns := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		region, dsn, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		region = strings.ToLower(strings.TrimSpace(region))
+		dsn = strings.TrimSpace(dsn)
+		if !ok || region == "" || dsn == "" {
+			continue
+		}
+		dsns[region] = dsn
+	}
+	return dsns
+}
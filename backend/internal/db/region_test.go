@@ -0,0 +1,76 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRegionDSNs(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want map[string]string
+	}{
+		{"", map[string]string{}},
+		{
+			"us-west-2=user:pw@tcp(west:4000)/db, AP-Southeast-1 = user:pw@tcp(ap:4000)/db",
+			map[string]string{"us-west-2": "user:pw@tcp(west:4000)/db", "ap-southeast-1": "user:pw@tcp(ap:4000)/db"},
+		},
+		// Only the first "=" separates the region from the DSN
+		{"eu-west-1=u@tcp(eu:4000)/db?tls=tidb&timeout=5s", map[string]string{"eu-west-1": "u@tcp(eu:4000)/db?tls=tidb&timeout=5s"}},
+		// Malformed pairs are skipped
+		{"no-dsn=,=u@tcp(x:4000)/db,garbage,us-east-1=u@tcp(east:4000)/db", map[string]string{"us-east-1": "u@tcp(east:4000)/db"}},
+	} {
+		if got := parseRegionDSNs(tc.in); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseRegionDSNs(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestCurrentRegion(t *testing.T) {
+	t.Setenv("TIDB_REGION", "")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", " US-East-1 ")
+	if got := currentRegion(); got != "us-east-1" {
+		t.Errorf("from AWS_DEFAULT_REGION: %q, want us-east-1", got)
+	}
+	t.Setenv("AWS_REGION", "eu-west-1")
+	if got := currentRegion(); got != "eu-west-1" {
+		t.Errorf("AWS_REGION over AWS_DEFAULT_REGION: %q, want eu-west-1", got)
+	}
+	t.Setenv("TIDB_REGION", "ap-southeast-1")
+	if got := currentRegion(); got != "ap-southeast-1" {
+		t.Errorf("TIDB_REGION over the AWS variables: %q, want ap-southeast-1", got)
+	}
+}
+
+func TestTiDBDSN(t *testing.T) {
+	t.Setenv("TIDB_DSN", "u@tcp(default:4000)/db")
+	t.Setenv("TIDB_REGION_DSNS", "us-west-2=u@tcp(west:4000)/db,ap-southeast-1=u@tcp(ap:4000)/db")
+	t.Setenv("TIDB_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+
+	for _, tc := range []struct {
+		region, wantDSN, wantRegion string
+	}{
+		{"us-west-2", "u@tcp(west:4000)/db", "us-west-2"},
+		{"AP-SOUTHEAST-1", "u@tcp(ap:4000)/db", "ap-southeast-1"},
+		// No DSN for the region, or no region detected: TIDB_DSN
+		{"eu-central-1", "u@tcp(default:4000)/db", ""},
+		{"", "u@tcp(default:4000)/db", ""},
+	} {
+		t.Setenv("AWS_REGION", tc.region)
+		if dsn, region := tidbDSN(); dsn != tc.wantDSN || region != tc.wantRegion {
+			t.Errorf("region %q: %q, %q, want %q, %q", tc.region, dsn, region, tc.wantDSN, tc.wantRegion)
+		}
+	}
+
+	t.Setenv("TIDB_DSN", "")
+	t.Setenv("AWS_REGION", "eu-central-1")
+	if tidbConfigured() {
+		t.Error("configured without a DSN for the region or TIDB_DSN")
+	}
+	t.Setenv("AWS_REGION", "us-west-2")
+	if !tidbConfigured() {
+		t.Error("not configured with a DSN for the region")
+	}
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+var (
+	// shutdownCh is closed by Shutdown to stop background reconnects
+	shutdownCh   = make(chan struct{})
+	shutdownOnce sync.Once
+)
+
+// Shutdown stops background reconnects and closes TiDB (and its replica), then
+// SQLite. Each Close is bounded by ctx; errors are logged and returned joined.
+func Shutdown(ctx context.Context) error {
+	shutdownOnce.Do(func() { close(shutdownCh) })
+
+	var errs []error
+	closeConn := func(name string, conn *sql.DB) {
+		if conn == nil {
+			return
+		}
+		if err := closeWithContext(ctx, conn); err != nil {
+			log.Printf("Error closing %s: %v", name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		log.Printf("%s connection closed", name)
+	}
+
+	// Stop the name service from picking up a connection that is going away
+	wasReady := tidbReady.Swap(false)
+	if wasReady {
+		closeConn("TiDB", TiDB)
+	}
+	closeConn("TiDB replica", TiDBReplica)
+
+	if DB != nil {
+		sqlDB, err := DB.DB()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("SQLite: %w", err))
+		} else {
+			closeConn("SQLite", sqlDB)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// closeWithContext closes conn, giving up waiting once ctx is done. sql.DB.Close
+// waits for in-flight queries, so it may still complete in the background.
+func closeWithContext(ctx context.Context, conn *sql.DB) error {
+	done := make(chan error, 1)
+	go func() { done <- conn.Close() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestShutdown(t *testing.T) {
+	oldDB, oldTiDB, oldReplica := DB, TiDB, TiDBReplica
+	t.Cleanup(func() {
+		DB, TiDBReplica = oldDB, oldReplica
+		SetTiDB(oldTiDB)
+		shutdownCh, shutdownOnce = make(chan struct{}), sync.Once{}
+	})
+
+	var err error
+	DB, err = gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, _ := DB.DB()
+	tidb, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("open tidb stand-in: %v", err)
+	}
+	replica, _ := sql.Open("sqlite3", ":memory:")
+	SetTiDB(tidb)
+	TiDBReplica = replica
+	for _, conn := range []*sql.DB{tidb, replica, sqlDB} {
+		if err := conn.Ping(); err != nil {
+			t.Fatalf("ping before Shutdown: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if err := TiDB.Ping(); err == nil {
+		t.Error("TiDB ping after Shutdown succeeded")
+	}
+	if err := replica.Ping(); err == nil {
+		t.Error("replica ping after Shutdown succeeded")
+	}
+	if err := sqlDB.Ping(); err == nil {
+		t.Error("SQLite ping after Shutdown succeeded")
+	}
+	if TiDBReady() {
+		t.Error("TiDB still reported ready after Shutdown")
+	}
+	select {
+	case <-shutdownCh:
+	default:
+		t.Error("background reconnects were not stopped")
+	}
+
+	// A second Shutdown does not panic on the closed channel
+	if err := Shutdown(ctx); err != nil {
+		t.Errorf("second Shutdown: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -19,6 +20,14 @@ type Issue struct {
 	// Alert specific fields
 	IsAlert        bool   `json:"is_alert"`
 	AlertSignature string `json:"alert_signature"`
+	DedupKey       string `json:"dedup_key"` // Collapses duplicates of the same alert, see services/dedup
+
+	// Fingerprint identifies repeats of an alert, see services.Fingerprinter.
+	// Repeats that arrive while it is active are folded into it: UpdatedAt is
+	// the created time (same format as Created) of the latest one.
+	Fingerprint     string `json:"fingerprint,omitempty"`
+	OccurrenceCount int    `gorm:"default:1" json:"occurrence_count"`
+	UpdatedAt       string `json:"updated_at"`
 
 	// Metadata for filtering
 	ClusterID string `json:"cluster_id"`
@@ -34,7 +43,25 @@ type Issue struct {
 	SourceComponent     string `json:"source_component"`
 	AlertGroup          string `json:"alert_group"`
 
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty"`                                    // Set by soft-delete, purged after ALERT_RETENTION_DAYS
+	PDIncidentKey     string     `gorm:"column:pd_incident_key" json:"pd_incident_key,omitempty"` // PagerDuty incident opened by routing
+	Suppressed        bool       `gorm:"default:false" json:"suppressed"`                         // Not routed, see SuppressionReason
+	SuppressionReason string     `json:"suppression_reason,omitempty"`                            // "lifecycle" (cluster being created or deleted) or "version_filter"
+	ResolvedAt        *time.Time `json:"resolved_at,omitempty"`                                   // When the alert was first seen resolved
+	AutoResolved      bool       `gorm:"default:false" json:"auto_resolved"`                      // Resolved by services.StaleAlertReaper
+	EscalatedAt       *time.Time `json:"escalated_at,omitempty"`                                  // Sent to an escalation policy's channel while unacknowledged
+	LabelErrors       string     `gorm:"type:text" json:"label_errors,omitempty"`                 // JSON array of the labels failing the label schema, see services.LabelSchema
+
+	// Annotations are the raw annotation templates of the alert payload and
+	// RenderedAnnotations their expansion, both JSON objects; see services.RenderAnnotations
+	Annotations         string `gorm:"type:text" json:"-"`
+	RenderedAnnotations string `gorm:"type:text" json:"-"`
+
+	// Acknowledged is computed by list queries from the acknowledgements table
+	Acknowledged bool `gorm:"->;-:migration" json:"acknowledged"`
+	// Silenced is set by services.SilenceService.MarkSilenced
+	Silenced bool `gorm:"-" json:"silenced"`
 }
 
 func (Issue) TableName() string {
@@ -88,3 +115,316 @@ type MutedIssue struct {
 func (MutedIssue) TableName() string {
 	return "muted_issues"
 }
+
+// NameCacheEntry maps to 'name_cache', a persisted copy of the name resolver cache
+type NameCacheEntry struct {
+	ID         string    `gorm:"primaryKey" json:"id"`
+	Type       string    `json:"type"`
+	Name       string    `json:"name"`
+	TenantID   string    `json:"tenant_id"`
+	TenantName string    `json:"tenant_name"`
+	DeployType string    `json:"deploy_type"` // clusters only, empty in rows persisted before it was
+	NotFound   bool      `json:"not_found"`
+	CachedAt   time.Time `json:"cached_at"`
+}
+
+func (NameCacheEntry) TableName() string {
+	return "name_cache"
+}
+
+// ClusterMetadata maps to 'cluster_metadata', cluster details imported from the CMDB
+type ClusterMetadata struct {
+	ClusterID   string    `gorm:"primaryKey" json:"cluster_id"`
+	ClusterName string    `json:"cluster_name"`
+	TenantID    string    `gorm:"index" json:"tenant_id"`
+	TenantName  string    `json:"tenant_name"`
+	DeployType  string    `json:"deploy_type"`
+	Version     string    `json:"version"`
+	Provider    string    `json:"provider"`
+	Region      string    `json:"region"`
+	ProjectID   string    `json:"project_id"`
+	OrgID       string    `json:"org_id"`
+	ClusterType string    `json:"cluster_type"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (ClusterMetadata) TableName() string {
+	return "cluster_metadata"
+}
+
+// TenantMetadata maps to 'tenant_metadata', tenant details imported from the CMDB
+type TenantMetadata struct {
+	TenantID   string    `gorm:"primaryKey" json:"tenant_id"`
+	TenantName string    `json:"tenant_name"`
+	Kind       string    `json:"kind"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (TenantMetadata) TableName() string {
+	return "tenant_metadata"
+}
+
+// RoutingRule maps to 'routing_rules'. Rules are evaluated by ascending
+// Priority; TenantPlan and Severity accept "*" as a wildcard.
+type RoutingRule struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	Priority      int             `gorm:"index" json:"priority"`
+	TenantPlan    string          `json:"tenant_plan"`                     // e.g. free, developer, enterprise, or *
+	Severity      string          `json:"severity"`                        // Critical, Major, Warning, or *
+	Channel       string          `json:"channel"`                         // slack or webhook
+	ChannelConfig json.RawMessage `gorm:"type:text" json:"channel_config"` // channel specific settings, e.g. {"url": "..."}
+	Enabled       bool            `gorm:"default:true" json:"enabled"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+func (RoutingRule) TableName() string {
+	return "routing_rules"
+}
+
+// Acknowledgement maps to 'acknowledgements', the audit trail of alert
+// acknowledgements. Unacknowledging appends an "unack" row, so an alert is
+// acknowledged when its latest row has Action "ack".
+type Acknowledgement struct {
+	ID      uint      `gorm:"primaryKey" json:"id"`
+	AlertID string    `gorm:"index;not null" json:"alert_id"`
+	Action  string    `gorm:"not null" json:"action"` // ack or unack
+	AckBy   string    `json:"ack_by"`
+	AckAt   time.Time `json:"ack_at"`
+	Comment string    `gorm:"type:text" json:"comment"`
+}
+
+func (Acknowledgement) TableName() string {
+	return "acknowledgements"
+}
+
+// Silence maps to 'silences'. Alerts matching all Matchers between StartsAt
+// and EndsAt are shown as silenced. Matchers use the Alertmanager format,
+// e.g. [{"name": "cluster_id", "value": "123", "isRegex": false}].
+type Silence struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	Matchers  json.RawMessage `gorm:"type:text;not null" json:"matchers"`
+	StartsAt  time.Time       `gorm:"index" json:"starts_at"`
+	EndsAt    time.Time       `gorm:"index" json:"ends_at"`
+	CreatedBy string          `json:"created_by"`
+	Comment   string          `gorm:"type:text" json:"comment"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func (Silence) TableName() string {
+	return "silences"
+}
+
+// NotificationChannel maps to 'notification_channels', the webhooks that new
+// alerts are sent to. LabelFilter holds silence style matchers; an empty
+// filter matches every alert.
+type NotificationChannel struct {
+	ID             uint            `gorm:"primaryKey" json:"id"`
+	Name           string          `gorm:"not null" json:"name"`
+	Type           string          `gorm:"default:webhook" json:"type"` // webhook or slack
+	Template       string          `gorm:"type:text" json:"template"`   // slack only, text/template message body
+	URL            string          `gorm:"not null" json:"url"`
+	Method         string          `json:"method"`                        // defaults to POST
+	Headers        json.RawMessage `gorm:"type:text" json:"headers"`      // e.g. {"Authorization": "Bearer ..."}
+	MaxRetries     int             `json:"max_retries"`                   // retries after the first attempt
+	TimeoutSeconds int             `json:"timeout_seconds"`               // per attempt, defaults to 10
+	LabelFilter    json.RawMessage `gorm:"type:text" json:"label_filter"` // e.g. [{"name": "severity", "value": "Critical"}]
+	Enabled        bool            `gorm:"default:true" json:"enabled"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+func (NotificationChannel) TableName() string {
+	return "notification_channels"
+}
+
+// TrendCacheEntry maps to 'trend_cache', recently computed alert trend
+// responses keyed by their query parameters
+type TrendCacheEntry struct {
+	CacheKey  string    `gorm:"primaryKey" json:"cache_key"`
+	Payload   string    `gorm:"type:text" json:"payload"` // JSON array of trend buckets
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (TrendCacheEntry) TableName() string {
+	return "trend_cache"
+}
+
+// Runbook maps to 'runbooks', the runbook link of each alert name, exposed to
+// annotation templates as runbookURL
+type Runbook struct {
+	AlertName string    `gorm:"column:alertname;primaryKey" json:"alertname"`
+	URL       string    `json:"url"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Runbook) TableName() string {
+	return "runbooks"
+}
+
+// HeatmapCacheEntry maps to 'heatmap_cache', recently computed cluster heatmap
+// responses keyed by their query parameters
+type HeatmapCacheEntry struct {
+	CacheKey  string    `gorm:"primaryKey" json:"cache_key"`
+	Payload   string    `gorm:"type:text" json:"payload"` // JSON array of heatmap rows
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (HeatmapCacheEntry) TableName() string {
+	return "heatmap_cache"
+}
+
+// ClusterQuota maps to 'cluster_quotas', the most unresolved alerts a cluster
+// may have before new non-critical alerts are refused
+type ClusterQuota struct {
+	ClusterID       string    `gorm:"primaryKey" json:"cluster_id" binding:"required"`
+	MaxActiveAlerts int       `gorm:"not null" json:"max_active_alerts"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func (ClusterQuota) TableName() string {
+	return "cluster_quotas"
+}
+
+// LocalCluster maps to 'clusters_local', a copy of the TiDB clusters table kept
+// by services.ClusterSyncer so cluster details stay available while TiDB is
+// unreachable. CreatedAt and UpdatedAt are the TiDB timestamps.
+type LocalCluster struct {
+	ClusterID        string    `gorm:"primaryKey" json:"cluster_id"`
+	ClusterName      string    `json:"cluster_name"`
+	TenantID         string    `gorm:"index" json:"tenant_id"`
+	TenantName       string    `json:"tenant_name"`
+	DeployType       string    `json:"deploy_type"`
+	Version          string    `json:"version"`
+	ClusterLifecycle string    `json:"cluster_lifecycle"`
+	CreationDuration string    `json:"creation_duration"`
+	TenantPlan       string    `json:"tenant_plan"`
+	Provider         string    `json:"provider"`
+	Region           string    `json:"region"`
+	ProjectID        string    `json:"project_id"`
+	OrgID            string    `json:"org_id"`
+	ClusterType      string    `json:"cluster_type"`
+	CreatedAt        time.Time `gorm:"autoCreateTime:false" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime:false;index" json:"updated_at"`
+	SyncedAt         time.Time `json:"synced_at"`
+}
+
+func (LocalCluster) TableName() string {
+	return "clusters_local"
+}
+
+// AlertLabel maps to 'alert_labels', one row per label of an alert so label
+// filters can use the (key, value) index instead of scanning the issues
+type AlertLabel struct {
+	AlertID string `gorm:"primaryKey" json:"alert_id"`
+	Key     string `gorm:"primaryKey" json:"key"`
+	Value   string `gorm:"not null" json:"value"`
+}
+
+func (AlertLabel) TableName() string {
+	return "alert_labels"
+}
+
+// EscalationPolicy maps to 'escalation_policies'. Unresolved alerts matching
+// AlertFilter that are still unacknowledged AckWindowSeconds after they fired
+// are sent once to the notification channel EscalateToChannelID. AlertFilter
+// holds silence style matchers; an empty filter matches every alert.
+type EscalationPolicy struct {
+	ID                  uint            `gorm:"primaryKey" json:"id"`
+	Name                string          `json:"name"`
+	AlertFilter         json.RawMessage `gorm:"type:text" json:"alert_filter"` // e.g. [{"name": "severity", "value": "Critical"}]
+	AckWindowSeconds    int             `gorm:"not null" json:"ack_window_seconds" binding:"required"`
+	EscalateToChannelID uint            `gorm:"not null;index" json:"escalate_to_channel_id" binding:"required"`
+	Enabled             bool            `gorm:"default:true" json:"enabled"`
+	CreatedAt           time.Time       `json:"created_at"`
+}
+
+func (EscalationPolicy) TableName() string {
+	return "escalation_policies"
+}
+
+// AlertNote maps to 'alert_notes', free-text notes operators attach to an
+// alert during and after an incident. Body is Markdown, stored as written and
+// rendered by the client.
+type AlertNote struct {
+	ID        uint      `gorm:"primaryKey;column:note_id" json:"note_id"`
+	AlertID   string    `gorm:"index;not null" json:"alert_id"`
+	Author    string    `json:"author"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (AlertNote) TableName() string {
+	return "alert_notes"
+}
+
+// DashboardConfig maps to 'dashboard_configs', the dashboard layout saved by
+// each user (panels, default time range, ...). Config is validated against
+// the schema embedded in services.
+type DashboardConfig struct {
+	UserID    string          `gorm:"primaryKey" json:"user_id"`
+	Config    json.RawMessage `gorm:"type:text;not null" json:"config"`
+	UpdatedAt time.Time       `gorm:"autoUpdateTime:false" json:"updated_at"`
+}
+
+func (DashboardConfig) TableName() string {
+	return "dashboard_configs"
+}
+
+// Role maps to 'roles', the roles that can be bound to users. Their
+// permissions are defined by package rbac.
+type Role struct {
+	Name        string `gorm:"primaryKey" json:"name"`
+	Description string `json:"description"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+// RoleBinding maps to 'role_bindings', granting Role to the user signed in as
+// UserID (JWT email, or subject)
+type RoleBinding struct {
+	UserID    string    `gorm:"primaryKey" json:"user_id" binding:"required"`
+	Role      string    `gorm:"primaryKey" json:"role" binding:"required"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (RoleBinding) TableName() string {
+	return "role_bindings"
+}
+
+// AuditLog maps to 'audit_log', one row per mutating API request written by
+// api.AuditMiddleware. RequestBody is the (redacted) JSON body, or the raw
+// body as a JSON string when it is not JSON or was truncated.
+type AuditLog struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	UserID       string          `gorm:"index" json:"user_id"`
+	Action       string          `json:"action"` // method and route, e.g. "POST /api/alerts/:id/ack"
+	ResourceType string          `gorm:"index" json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	RequestBody  json.RawMessage `gorm:"type:text" json:"request_body"`
+	ResponseCode int             `json:"response_code"`
+	CreatedAt    time.Time       `gorm:"index" json:"created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
+
+// FlapState maps to 'flap_state', the latest fire/resolve transitions of an
+// alert fingerprint kept by services.FlapDetector. Transitions is a JSON array
+// of {"at", "firing"}, oldest first.
+type FlapState struct {
+	Fingerprint      string     `gorm:"primaryKey" json:"fingerprint"`
+	Transitions      string     `gorm:"type:text;not null" json:"transitions"`
+	Flapping         bool       `json:"flapping"`
+	FlappingSince    *time.Time `json:"flapping_since,omitempty"`
+	LastTransitionAt time.Time  `gorm:"index" json:"last_transition_at"`
+}
+
+func (FlapState) TableName() string {
+	return "flap_state"
+}
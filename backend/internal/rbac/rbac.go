@@ -0,0 +1,155 @@
+// Package rbac decides what users may do. Users are granted roles through the
+// role_bindings table; the permissions of each role are fixed here.
+package rbac
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// Actions checked by the API
+const (
+	ActionAlertRead    = "alert:read"    // view alerts, their notes and silences
+	ActionAlertWrite   = "alert:write"   // ack, annotate, mute and delete alerts
+	ActionSilenceWrite = "silence:write" // create and expire silences
+	ActionAdmin        = "admin:*"       // manage role bindings; grants every action
+)
+
+// Built-in roles
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// rolePermissions lists the actions of each role. A "<resource>:*" action
+// grants every action on the resource, and admin:* every action.
+var rolePermissions = map[string][]string{
+	RoleViewer: {ActionAlertRead},
+	RoleEditor: {ActionAlertRead, ActionAlertWrite, ActionSilenceWrite},
+	RoleAdmin:  {ActionAdmin},
+}
+
+// roleOrder lists the built-in roles from the weakest to the strongest
+var roleOrder = []string{RoleViewer, RoleEditor, RoleAdmin}
+
+// ValidRole reports whether role is a built-in role
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RoleCan reports whether role grants action
+func RoleCan(role, action string) bool {
+	resource, _, _ := strings.Cut(action, ":")
+	for _, granted := range rolePermissions[role] {
+		if granted == action || granted == ActionAdmin || granted == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorizer checks actions against the roles bound to users. Every user also
+// has the DefaultRole, if any.
+type Authorizer struct {
+	DB          *gorm.DB
+	DefaultRole string
+}
+
+// NewAuthorizer returns an Authorizer whose default role is RBAC_DEFAULT_ROLE
+// (default: viewer; "none" for no default role)
+func NewAuthorizer(db *gorm.DB) *Authorizer {
+	return &Authorizer{DB: db, DefaultRole: defaultRole()}
+}
+
+func defaultRole() string {
+	role := os.Getenv("RBAC_DEFAULT_ROLE")
+	switch {
+	case role == "":
+		return RoleViewer
+	case role == "none":
+		return ""
+	case !ValidRole(role):
+		log.Printf("[WARN] Unknown RBAC_DEFAULT_ROLE %q, using viewer\n", role)
+		return RoleViewer
+	}
+	return role
+}
+
+// Roles returns the roles bound to userID, without the default role
+func (a *Authorizer) Roles(userID string) ([]string, error) {
+	if userID == "" {
+		return nil, nil
+	}
+	var roles []string
+	if err := a.DB.Model(&models.RoleBinding{}).Where("user_id = ?", userID).Order("role").Pluck("role", &roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to load role bindings: %w", err)
+	}
+	return roles, nil
+}
+
+// Role returns the strongest of the DefaultRole and the roles bound to
+// userID, or "" when the user has no role
+func (a *Authorizer) Role(userID string) (string, error) {
+	roles, err := a.Roles(userID)
+	if err != nil {
+		return "", err
+	}
+	strongest := a.DefaultRole
+	for _, role := range roles {
+		if slices.Index(roleOrder, role) > slices.Index(roleOrder, strongest) {
+			strongest = role
+		}
+	}
+	return strongest, nil
+}
+
+// Can reports whether userID may perform action. Lookup failures deny.
+func (a *Authorizer) Can(userID, action string) bool {
+	if a.DefaultRole != "" && RoleCan(a.DefaultRole, action) {
+		return true
+	}
+	roles, err := a.Roles(userID)
+	if err != nil {
+		log.Printf("[WARN] %v\n", err)
+		return false
+	}
+	for _, role := range roles {
+		if RoleCan(role, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bindings returns every role binding, ordered by user
+func (a *Authorizer) Bindings() ([]models.RoleBinding, error) {
+	var bindings []models.RoleBinding
+	err := a.DB.Order("user_id, role").Find(&bindings).Error
+	return bindings, err
+}
+
+// Bind grants role to userID. Binding a role twice is not an error.
+func (a *Authorizer) Bind(userID, role string) (*models.RoleBinding, error) {
+	if !ValidRole(role) {
+		return nil, fmt.Errorf("unknown role %q, expected viewer, editor or admin", role)
+	}
+	binding := models.RoleBinding{UserID: userID, Role: role}
+	if err := a.DB.Where(binding).FirstOrCreate(&binding).Error; err != nil {
+		return nil, err
+	}
+	return &binding, nil
+}
+
+// Unbind revokes role from userID. It reports whether the binding existed.
+func (a *Authorizer) Unbind(userID, role string) (bool, error) {
+	result := a.DB.Where("user_id = ? AND role = ?", userID, role).Delete(&models.RoleBinding{})
+	return result.RowsAffected > 0, result.Error
+}
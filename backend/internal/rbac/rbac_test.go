@@ -0,0 +1,179 @@
+package rbac
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB opens a SQLite database with the role_bindings table
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "rbac.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.RoleBinding{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestRoleCan(t *testing.T) {
+	actions := []string{ActionAlertRead, ActionAlertWrite, ActionSilenceWrite, ActionAdmin, "silence:read", "report:read"}
+	tests := []struct {
+		role string
+		can  map[string]bool
+	}{
+		{RoleViewer, map[string]bool{ActionAlertRead: true}},
+		{RoleEditor, map[string]bool{ActionAlertRead: true, ActionAlertWrite: true, ActionSilenceWrite: true}},
+		// admin:* grants every action, including ones no role lists
+		{RoleAdmin, map[string]bool{ActionAlertRead: true, ActionAlertWrite: true, ActionSilenceWrite: true, ActionAdmin: true, "silence:read": true, "report:read": true}},
+		{"owner", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		for _, action := range actions {
+			if got := RoleCan(tt.role, action); got != tt.can[action] {
+				t.Errorf("RoleCan(%q, %q) = %v, want %v", tt.role, action, got, tt.can[action])
+			}
+		}
+	}
+}
+
+func TestValidRole(t *testing.T) {
+	for role, want := range map[string]bool{
+		RoleViewer: true, RoleEditor: true, RoleAdmin: true,
+		"": false, "none": false, "Admin": false, "owner": false,
+	} {
+		if got := ValidRole(role); got != want {
+			t.Errorf("ValidRole(%q) = %v, want %v", role, got, want)
+		}
+	}
+}
+
+func TestNewAuthorizerDefaultRole(t *testing.T) {
+	for env, want := range map[string]string{
+		"":       RoleViewer,
+		"none":   "",
+		"editor": RoleEditor,
+		"admin":  RoleAdmin,
+		"owner":  RoleViewer, // unknown roles fall back to viewer
+	} {
+		t.Setenv("RBAC_DEFAULT_ROLE", env)
+		if got := NewAuthorizer(nil).DefaultRole; got != want {
+			t.Errorf("RBAC_DEFAULT_ROLE=%q: default role %q, want %q", env, got, want)
+		}
+	}
+}
+
+func TestAuthorizerCan(t *testing.T) {
+	db := openTestDB(t)
+	a := &Authorizer{DB: db}
+	for _, b := range []struct{ user, role string }{
+		{"vera@example.com", RoleViewer},
+		{"ed@example.com", RoleViewer},
+		{"ed@example.com", RoleEditor},
+		{"ada@example.com", RoleAdmin},
+	} {
+		if _, err := a.Bind(b.user, b.role); err != nil {
+			t.Fatalf("Bind(%s, %s): %v", b.user, b.role, err)
+		}
+	}
+
+	tests := []struct {
+		user, defaultRole, action string
+		want                      bool
+	}{
+		{"vera@example.com", "", ActionAlertRead, true},
+		{"vera@example.com", "", ActionAlertWrite, false},
+		{"ed@example.com", "", ActionSilenceWrite, true},
+		{"ed@example.com", "", ActionAdmin, false},
+		{"ada@example.com", "", ActionAdmin, true},
+		{"ada@example.com", "", ActionAlertWrite, true},
+		// Users without bindings only have the default role
+		{"nobody@example.com", "", ActionAlertRead, false},
+		{"nobody@example.com", RoleViewer, ActionAlertRead, true},
+		{"nobody@example.com", RoleViewer, ActionAlertWrite, false},
+		{"vera@example.com", RoleEditor, ActionAlertWrite, true},
+		// An empty user has no bindings, only the default role
+		{"", "", ActionAlertRead, false},
+		{"", RoleViewer, ActionAlertRead, true},
+		{"", RoleViewer, ActionAdmin, false},
+	}
+	for _, tt := range tests {
+		a.DefaultRole = tt.defaultRole
+		if got := a.Can(tt.user, tt.action); got != tt.want {
+			t.Errorf("Can(%q, %q) with default role %q = %v, want %v", tt.user, tt.action, tt.defaultRole, got, tt.want)
+		}
+	}
+
+	// Lookup failures deny
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+	a.DefaultRole = ""
+	if a.Can("ada@example.com", ActionAlertRead) {
+		t.Error("Can succeeded without a database")
+	}
+}
+
+func TestAuthorizerRole(t *testing.T) {
+	a := &Authorizer{DB: openTestDB(t)}
+	for _, role := range []string{RoleAdmin, RoleViewer} {
+		if _, err := a.Bind("ada@example.com", role); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := a.Bind("vera@example.com", RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct{ user, defaultRole, want string }{
+		{"ada@example.com", RoleEditor, RoleAdmin},
+		{"vera@example.com", RoleEditor, RoleEditor},
+		{"vera@example.com", "", RoleViewer},
+		{"nobody@example.com", RoleViewer, RoleViewer},
+		{"nobody@example.com", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		a.DefaultRole = tt.defaultRole
+		if got, err := a.Role(tt.user); err != nil || got != tt.want {
+			t.Errorf("Role(%q) with default role %q = %q, %v, want %q", tt.user, tt.defaultRole, got, err, tt.want)
+		}
+	}
+}
+
+func TestBindUnbind(t *testing.T) {
+	a := &Authorizer{DB: openTestDB(t)}
+	if _, err := a.Bind("ada@example.com", "owner"); err == nil {
+		t.Error("bound an unknown role")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := a.Bind("ada@example.com", RoleEditor); err != nil {
+			t.Fatalf("Bind #%d: %v", i+1, err)
+		}
+	}
+	if bindings, err := a.Bindings(); err != nil || len(bindings) != 1 {
+		t.Errorf("Bindings after binding twice = %+v, %v, want one", bindings, err)
+	}
+
+	if removed, err := a.Unbind("ada@example.com", RoleEditor); err != nil || !removed {
+		t.Errorf("Unbind = %v, %v, want removed", removed, err)
+	}
+	if removed, err := a.Unbind("ada@example.com", RoleEditor); err != nil || removed {
+		t.Errorf("second Unbind = %v, %v, want nothing removed", removed, err)
+	}
+	if roles, err := a.Roles("ada@example.com"); err != nil || len(roles) != 0 {
+		t.Errorf("Roles after Unbind = %v, %v", roles, err)
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"strings"
+	"text/template"
+)
+
+// AnnotationData is the data annotation templates are executed with, e.g.
+// "{{ .Labels.instance }} of {{ clusterName .ClusterID }} is down"
+type AnnotationData struct {
+	AlertName string
+	Severity  string
+	ClusterID string
+	TenantID  string
+	Labels    map[string]string
+}
+
+// annotationFuncs returns the functions available to annotation templates.
+// Names fall back to the raw ID and runbookURL to "" when they cannot be resolved.
+func annotationFuncs(db *sql.DB) template.FuncMap {
+	return template.FuncMap{
+		"clusterName": func(id string) string {
+			if id == "" {
+				return ""
+			}
+			info, _ := GetNameService().Resolve(id)
+			return info.Name
+		},
+		"tenantName": func(id string) string {
+			if id == "" {
+				return ""
+			}
+			info, _ := GetNameService().Resolve(id)
+			return info.Name
+		},
+		"runbookURL": func(alertName string) string {
+			var url string
+			if err := db.QueryRow("SELECT url FROM runbooks WHERE alertname = ?", alertName).Scan(&url); err != nil && err != sql.ErrNoRows {
+				log.Printf("[WARN] Failed to look up runbook of %s: %v\n", alertName, err)
+			}
+			return url
+		},
+	}
+}
+
+// RenderAnnotations expands every annotation template with data. Annotations
+// that fail to parse or execute are kept verbatim.
+func RenderAnnotations(db *sql.DB, annotations map[string]string, data AnnotationData) map[string]string {
+	funcs := annotationFuncs(db)
+	rendered := make(map[string]string, len(annotations))
+	for name, text := range annotations {
+		rendered[name] = text
+		if !strings.Contains(text, "{{") {
+			continue
+		}
+
+		tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			log.Printf("[WARN] Invalid template in annotation %s: %v\n", name, err)
+			continue
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			log.Printf("[WARN] Failed to render annotation %s: %v\n", name, err)
+			continue
+		}
+		rendered[name] = b.String()
+	}
+	return rendered
+}
+
+// rawAlertAnnotations returns the "annotations" object and the "labels" of the
+// raw alert payload, with non-string values dropped
+func rawAlertAnnotations(rawData interface{}) (annotations, labels map[string]string) {
+	var jsonData []byte
+	switch v := rawData.(type) {
+	case string:
+		jsonData = []byte(v)
+	default:
+		var err error
+		if jsonData, err = json.Marshal(rawData); err != nil {
+			return nil, nil
+		}
+	}
+
+	var payload struct {
+		Annotations map[string]interface{} `json:"annotations"`
+		Labels      map[string]interface{} `json:"labels"`
+	}
+	if err := json.Unmarshal(jsonData, &payload); err != nil {
+		return nil, nil
+	}
+	return stringValues(payload.Annotations), Normalize(GetLabelNormalizer(), stringValues(payload.Labels))
+}
+
+func stringValues(m map[string]interface{}) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		}
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// Alert event types published by AlertBroadcaster
+const (
+	AlertEventCreated        = "created"
+	AlertEventResolved       = "resolved"
+	AlertEventSilenced       = "silenced"
+	AlertEventAcknowledged   = "acknowledged"
+	AlertEventUnacknowledged = "unacknowledged"
+)
+
+// AlertEvent is a change to an alert pushed to live subscribers
+type AlertEvent struct {
+	Type  string    `json:"type"`
+	Time  time.Time `json:"time"`
+	Alert Alert     `json:"alert"`
+}
+
+// AlertBroadcaster fans alert events out to every subscriber. Publish never
+// blocks: a subscriber whose buffer is full misses the event.
+type AlertBroadcaster struct {
+	mu          sync.RWMutex
+	subscribers map[chan AlertEvent]struct{}
+	closed      bool
+}
+
+var (
+	broadcasterOnce     sync.Once
+	broadcasterInstance *AlertBroadcaster
+)
+
+// GetAlertBroadcaster returns the process-wide broadcaster
+func GetAlertBroadcaster() *AlertBroadcaster {
+	broadcasterOnce.Do(func() {
+		broadcasterInstance = NewAlertBroadcaster()
+	})
+	return broadcasterInstance
+}
+
+// NewAlertBroadcaster returns a broadcaster without subscribers
+func NewAlertBroadcaster() *AlertBroadcaster {
+	return &AlertBroadcaster{subscribers: make(map[chan AlertEvent]struct{})}
+}
+
+// Subscribe returns a channel receiving every published event and a function
+// that unsubscribes and closes the channel
+func (b *AlertBroadcaster) Subscribe(buffer int) (<-chan AlertEvent, func()) {
+	ch := make(chan AlertEvent, buffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close closes every subscriber channel so connections can shut down cleanly.
+// Later subscribers receive an already closed channel.
+func (b *AlertBroadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Publish sends an event of the given type to every subscriber
+func (b *AlertBroadcaster) Publish(eventType string, alert Alert) {
+	event := AlertEvent{Type: eventType, Time: time.Now().UTC(), Alert: alert}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribers returns the number of connected subscribers
+func (b *AlertBroadcaster) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers)
+}
+
+// AlertFromIssue builds the notification payload of a stored alert
+func AlertFromIssue(issue *models.Issue) Alert {
+	return Alert{
+		ID:        issue.ID,
+		Title:     issue.Title,
+		Severity:  issue.Priority,
+		Status:    issue.Status,
+		ClusterID: issue.ClusterID,
+		TenantID:  issue.TenantID,
+		Created:   issue.Created,
+		Labels:    AlertLabels(issue),
+	}
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// alertIdentity identifies an alert across duplicates: its dedup key, or its
+// issue ID when it has none
+const alertIdentity = "COALESCE(NULLIF(dedup_key, ''), id)"
+
+// AlertDiffBucket is one side of an AlertDiff: the latest alert of each identity
+type AlertDiffBucket struct {
+	Count  int            `json:"count"`
+	Alerts []models.Issue `json:"alerts"`
+}
+
+// AlertDiff compares the alerts firing at two points in time
+type AlertDiff struct {
+	Baseline time.Time       `json:"baseline"`
+	Current  time.Time       `json:"current"`
+	Window   string          `json:"window"`
+	New      AlertDiffBucket `json:"new"`      // firing at current only
+	Resolved AlertDiffBucket `json:"resolved"` // firing at baseline only
+	Ongoing  AlertDiffBucket `json:"ongoing"`  // firing at both
+}
+
+// DiffAlerts compares the alerts firing at baseline and at current. An alert
+// is firing at t if it was created in the window before t; alerts are matched
+// by dedup key. The sets are computed in SQLite with EXCEPT and INTERSECT. A
+// non-empty tenantID only compares that tenant's alerts.
+func DiffAlerts(db *gorm.DB, baseline, current time.Time, window time.Duration, tenantID string) (*AlertDiff, error) {
+	if window <= 0 {
+		return nil, errors.New("window must be positive")
+	}
+
+	const layout = "2006-01-02 15:04:05"
+	baseFrom, baseTo := baseline.UTC().Add(-window).Format(layout), baseline.UTC().Format(layout)
+	curFrom, curTo := current.UTC().Add(-window).Format(layout), current.UTC().Format(layout)
+
+	inTenant := ""
+	if tenantID != "" {
+		inTenant = " AND tenant_id = ?"
+	}
+	firing := "SELECT " + alertIdentity + " FROM issues WHERE is_alert = 1 AND deleted_at IS NULL AND REPLACE(created, ' UTC', '') > ? AND REPLACE(created, ' UTC', '') <= ?" + inTenant
+	// firingArgs returns the arguments of firing for the window from..to
+	firingArgs := func(from, to string) []interface{} {
+		if tenantID != "" {
+			return []interface{}{from, to, tenantID}
+		}
+		return []interface{}{from, to}
+	}
+
+	diff := &AlertDiff{Baseline: baseline.UTC(), Current: current.UTC(), Window: window.String()}
+	buckets := []struct {
+		bucket   *AlertDiffBucket
+		keys     string
+		keyArgs  []interface{}
+		from, to string // window the returned alert objects come from
+	}{
+		{&diff.New, firing + " EXCEPT " + firing, append(firingArgs(curFrom, curTo), firingArgs(baseFrom, baseTo)...), curFrom, curTo},
+		{&diff.Resolved, firing + " EXCEPT " + firing, append(firingArgs(baseFrom, baseTo), firingArgs(curFrom, curTo)...), baseFrom, baseTo},
+		{&diff.Ongoing, firing + " INTERSECT " + firing, append(firingArgs(curFrom, curTo), firingArgs(baseFrom, baseTo)...), curFrom, curTo},
+	}
+
+	for _, b := range buckets {
+		var issues []models.Issue
+		args := append(firingArgs(b.from, b.to), b.keyArgs...)
+		err := db.Model(&models.Issue{}).
+			Where("is_alert = 1 AND deleted_at IS NULL AND REPLACE(created, ' UTC', '') > ? AND REPLACE(created, ' UTC', '') <= ?"+inTenant+" AND "+alertIdentity+" IN ("+b.keys+")", args...).
+			Order("created DESC").
+			Find(&issues).Error
+		if err != nil {
+			return nil, err
+		}
+
+		// Keep the latest alert of each identity
+		seen := make(map[string]bool)
+		b.bucket.Alerts = []models.Issue{}
+		for _, issue := range issues {
+			key := issue.DedupKey
+			if key == "" {
+				key = issue.ID
+			}
+			if !seen[key] {
+				seen[key] = true
+				b.bucket.Alerts = append(b.bucket.Alerts, issue)
+			}
+		}
+		b.bucket.Count = len(b.bucket.Alerts)
+	}
+	return diff, nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrEmptyGroupBy is returned by ParseGroupBy when no label is given
+var ErrEmptyGroupBy = errors.New("group_by must name at least one label")
+
+// groupingFields are the labels read from the alert row itself. Any other
+// label is looked up in alert_labels.
+var groupingFields = map[string]func(*models.Issue) string{
+	"alertname":        func(i *models.Issue) string { return i.AlertSignature },
+	"cluster_id":       func(i *models.Issue) string { return i.ClusterID },
+	"tenant_id":        func(i *models.Issue) string { return i.TenantID },
+	"severity":         func(i *models.Issue) string { return i.Priority },
+	"priority":         func(i *models.Issue) string { return i.Priority },
+	"status":           func(i *models.Issue) string { return i.Status },
+	"component":        func(i *models.Issue) string { return i.ComponentName },
+	"alert_group":      func(i *models.Issue) string { return i.AlertGroup },
+	"fingerprint":      func(i *models.Issue) string { return i.Fingerprint },
+	"biz_type":         func(i *models.Issue) string { return i.BizType },
+	"project":          func(i *models.Issue) string { return i.Project },
+	"dedup_key":        func(i *models.Issue) string { return i.DedupKey },
+	"source_component": func(i *models.Issue) string { return i.SourceComponent },
+}
+
+// severityRanks orders severities from worst to least severe; severities not
+// listed rank below all of them
+var severityRanks = map[string]int{
+	"critical": 0,
+	"major":    1,
+	"high":     1,
+	"warning":  2,
+	"medium":   2,
+	"minor":    3,
+	"low":      3,
+	"info":     4,
+}
+
+// GroupedAlert is a set of alerts sharing the values of the group_by labels
+type GroupedAlert struct {
+	Key      string            `json:"key"`      // label=value pairs in group_by order, e.g. "cluster_id=1001,alertname=TiKVDown"
+	Labels   map[string]string `json:"labels"`   // the group_by labels; missing ones are ""
+	Count    int               `json:"count"`    // number of alerts
+	Severity string            `json:"severity"` // worst severity in the group
+	Alerts   []models.Issue    `json:"alerts"`   // in the order given to Group
+}
+
+// GroupingService groups a page of alerts by some of their labels. Groups are
+// built for each call and not kept.
+type GroupingService struct {
+	DB *gorm.DB // reads the labels that are not alert columns; may be nil
+}
+
+func NewGroupingService(db *gorm.DB) *GroupingService {
+	return &GroupingService{DB: db}
+}
+
+// ParseGroupBy splits a comma-separated group_by parameter, dropping blanks
+// and repeats
+func ParseGroupBy(s string) ([]string, error) {
+	var groupBy []string
+	seen := make(map[string]bool)
+	for _, label := range strings.Split(s, ",") {
+		label = strings.TrimSpace(label)
+		if label == "" || seen[label] {
+			continue
+		}
+		seen[label] = true
+		groupBy = append(groupBy, label)
+	}
+	if len(groupBy) == 0 {
+		return nil, ErrEmptyGroupBy
+	}
+	return groupBy, nil
+}
+
+// Group buckets alerts by their values of the groupBy labels. Groups are
+// sorted by size, then severity, then key. Alerts lacking a label fall in the
+// group where it is "". Without groupBy every alert is in one group.
+func (s *GroupingService) Group(alerts []models.Issue, groupBy []string) []GroupedAlert {
+	if len(alerts) == 0 {
+		return []GroupedAlert{}
+	}
+	extra := s.loadLabels(alerts, groupBy)
+
+	index := make(map[string]int)
+	var groups []GroupedAlert
+	for _, alert := range alerts {
+		labels := make(map[string]string, len(groupBy))
+		parts := make([]string, len(groupBy))
+		for i, name := range groupBy {
+			value := ""
+			if field, ok := groupingFields[name]; ok {
+				value = field(&alert)
+			} else {
+				value = extra[alert.ID][name]
+			}
+			labels[name] = value
+			parts[i] = name + "=" + value
+		}
+		key := strings.Join(parts, ",")
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, GroupedAlert{Key: key, Labels: labels, Severity: alert.Priority})
+		}
+		g := &groups[i]
+		g.Alerts = append(g.Alerts, alert)
+		g.Count++
+		g.Severity = WorseSeverity(g.Severity, alert.Priority)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		if ri, rj := severityRank(groups[i].Severity), severityRank(groups[j].Severity); ri != rj {
+			return ri < rj
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+// WorseSeverity returns the more severe of a and b (case-insensitive). Unknown
+// or empty severities lose to known ones.
+func WorseSeverity(a, b string) string {
+	if severityRank(b) < severityRank(a) {
+		return b
+	}
+	return a
+}
+
+func severityRank(severity string) int {
+	if rank, ok := severityRanks[strings.ToLower(strings.TrimSpace(severity))]; ok {
+		return rank
+	}
+	if severity == "" {
+		return len(severityRanks) + 1
+	}
+	return len(severityRanks)
+}
+
+// loadLabels reads the groupBy labels that are not alert columns from
+// alert_labels, keyed by alert ID
+func (s *GroupingService) loadLabels(alerts []models.Issue, groupBy []string) map[string]map[string]string {
+	var keys []string
+	for _, name := range groupBy {
+		if _, ok := groupingFields[name]; !ok {
+			keys = append(keys, name)
+		}
+	}
+	if len(keys) == 0 || s.DB == nil {
+		return nil
+	}
+
+	ids := make([]string, len(alerts))
+	for i, alert := range alerts {
+		ids[i] = alert.ID
+	}
+	var rows []models.AlertLabel
+	if err := s.DB.Where("alert_id IN ? AND key IN ?", ids, keys).Find(&rows).Error; err != nil {
+		log.Printf("[WARN] Failed to load alert labels for grouping: %v\n", err)
+		return nil
+	}
+	labels := make(map[string]map[string]string)
+	for _, row := range rows {
+		if labels[row.AlertID] == nil {
+			labels[row.AlertID] = make(map[string]string)
+		}
+		labels[row.AlertID][row.Key] = row.Value
+	}
+	return labels
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+func groupingAlert(id, cluster, alertname, severity string) models.Issue {
+	return models.Issue{ID: id, ClusterID: cluster, AlertSignature: alertname, Priority: severity}
+}
+
+func TestGroupAlerts(t *testing.T) {
+	alerts := []models.Issue{
+		groupingAlert("A-1", "2001", "TiKVDown", "Major"),
+		groupingAlert("A-2", "2002", "TiKVDown", "Warning"),
+		groupingAlert("A-3", "2001", "TiKVDown", "Critical"),
+		groupingAlert("A-4", "2002", "PDLeader", "Critical"),
+		groupingAlert("A-5", "", "TiKVDown", "Info"),
+		groupingAlert("A-6", "2001", "TiKVDown", "Minor"),
+	}
+	groups := NewGroupingService(nil).Group(alerts, []string{"cluster_id", "alertname"})
+
+	type group struct {
+		key, severity string
+		ids           []string
+	}
+	var got []group
+	for _, g := range groups {
+		var ids []string
+		for _, a := range g.Alerts {
+			ids = append(ids, a.ID)
+		}
+		if g.Count != len(g.Alerts) {
+			t.Errorf("%s: count %d for %d alerts", g.Key, g.Count, len(g.Alerts))
+		}
+		got = append(got, group{g.Key, g.Severity, ids})
+	}
+	// Largest first, then the worst severity, then by key; alerts keep their order
+	want := []group{
+		{"cluster_id=2001,alertname=TiKVDown", "Critical", []string{"A-1", "A-3", "A-6"}},
+		{"cluster_id=2002,alertname=PDLeader", "Critical", []string{"A-4"}},
+		{"cluster_id=2002,alertname=TiKVDown", "Warning", []string{"A-2"}},
+		{"cluster_id=,alertname=TiKVDown", "Info", []string{"A-5"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groups = %+v, want %+v", got, want)
+	}
+	if labels := groups[3].Labels; !reflect.DeepEqual(labels, map[string]string{"cluster_id": "", "alertname": "TiKVDown"}) {
+		t.Errorf("labels of the group without a cluster = %v", labels)
+	}
+}
+
+func TestGroupAlertsEdgeCases(t *testing.T) {
+	s := NewGroupingService(nil)
+	if groups := s.Group(nil, []string{"cluster_id"}); groups == nil || len(groups) != 0 {
+		t.Errorf("no alerts = %#v, want an empty list", groups)
+	}
+
+	alerts := []models.Issue{groupingAlert("A-1", "2001", "TiKVDown", ""), groupingAlert("A-2", "2002", "PDLeader", "")}
+	groups := s.Group(alerts, nil)
+	if len(groups) != 1 || groups[0].Key != "" || groups[0].Count != 2 || groups[0].Severity != "" || len(groups[0].Labels) != 0 {
+		t.Errorf("without group_by = %+v, want one group of every alert", groups)
+	}
+
+	// Labels that are not columns need the database, and are "" without it
+	groups = s.Group(alerts, []string{"env"})
+	if len(groups) != 1 || groups[0].Key != "env=" {
+		t.Errorf("group by a label without a database = %+v", groups)
+	}
+}
+
+func TestGroupAlertsByStoredLabels(t *testing.T) {
+	conn := openTestDB(t)
+	for _, label := range []models.AlertLabel{
+		{AlertID: "A-1", Key: "env", Value: "prod"},
+		{AlertID: "A-2", Key: "env", Value: "prod"},
+		{AlertID: "A-3", Key: "env", Value: "staging"},
+		{AlertID: "A-3", Key: "team", Value: "storage"},
+	} {
+		if err := conn.Create(&label).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	alerts := []models.Issue{
+		groupingAlert("A-1", "2001", "TiKVDown", "Major"),
+		groupingAlert("A-2", "2002", "TiKVDown", "Critical"),
+		groupingAlert("A-3", "2001", "TiKVDown", "Major"),
+		groupingAlert("A-4", "2001", "TiKVDown", "Major"),
+	}
+
+	var keys []string
+	for _, g := range NewGroupingService(conn).Group(alerts, []string{"env", "alertname"}) {
+		keys = append(keys, g.Key)
+	}
+	want := []string{"env=prod,alertname=TiKVDown", "env=,alertname=TiKVDown", "env=staging,alertname=TiKVDown"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("groups = %v, want %v", keys, want)
+	}
+}
+
+func TestWorseSeverity(t *testing.T) {
+	for _, tc := range []struct{ a, b, want string }{
+		{"Major", "Critical", "Critical"},
+		{"critical", "Major", "critical"},
+		{"warning", "HIGH", "HIGH"},
+		{"info", "low", "low"},
+		// Unknown severities lose to known ones, empty ones to everything
+		{"P3", "info", "info"},
+		{"", "P3", "P3"},
+		{"", "", ""},
+		// Ties keep the first
+		{"Major", "high", "Major"},
+	} {
+		if got := WorseSeverity(tc.a, tc.b); got != tc.want {
+			t.Errorf("WorseSeverity(%q, %q) = %q, want %q", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestParseGroupBy(t *testing.T) {
+	got, err := ParseGroupBy(" cluster_id, alertname,,cluster_id ")
+	if err != nil || !reflect.DeepEqual(got, []string{"cluster_id", "alertname"}) {
+		t.Errorf("ParseGroupBy = %v, %v", got, err)
+	}
+	for _, s := range []string{"", " , "} {
+		if _, err := ParseGroupBy(s); !errors.Is(err, ErrEmptyGroupBy) {
+			t.Errorf("ParseGroupBy(%q) error = %v, want ErrEmptyGroupBy", s, err)
+		}
+	}
+}
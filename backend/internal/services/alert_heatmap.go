@@ -0,0 +1,122 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// heatmapCacheTTL is how long a computed heatmap is served from heatmap_cache
+const heatmapCacheTTL = 10 * time.Minute
+
+// HeatmapDay is the alert count of one cluster on one day
+type HeatmapDay struct {
+	Date  string `json:"date"` // 2006-01-02, UTC
+	Count int    `json:"count"`
+}
+
+// HeatmapRow is one cluster of the heatmap with a count for every day in the window
+type HeatmapRow struct {
+	ClusterID   string       `json:"cluster_id"`
+	ClusterName string       `json:"cluster_name"`
+	TenantName  string       `json:"tenant_name"`
+	Provider    string       `json:"provider"`
+	Region      string       `json:"region"`
+	Total       int          `json:"total"`
+	Days        []HeatmapDay `json:"days"`
+}
+
+// ClusterHeatmap returns the top clusters by alert count over the trailing
+// days, with a per-day count for each of them. Every day in the window is
+// returned, including empty ones. Results are cached in heatmap_cache for ten
+// minutes. A non-empty tenantID restricts the heatmap to that tenant's alerts.
+func ClusterHeatmap(db *gorm.DB, days, top int, tenantID string) ([]HeatmapRow, error) {
+	key := fmt.Sprintf("days=%d|top=%d", days, top)
+	if tenantID != "" {
+		key += "|tenant=" + tenantID
+	}
+	var cached models.HeatmapCacheEntry
+	if err := db.Where("cache_key = ? AND created_at > ?", key, time.Now().Add(-heatmapCacheTTL)).Limit(1).Find(&cached).Error; err == nil && cached.Payload != "" {
+		var rows []HeatmapRow
+		if json.Unmarshal([]byte(cached.Payload), &rows) == nil {
+			return rows, nil
+		}
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start := today.AddDate(0, 0, -(days - 1))
+	created := "REPLACE(created, ' UTC', '')"
+	inWindow := "is_alert = 1 AND deleted_at IS NULL AND cluster_id != '' AND cluster_id IS NOT NULL AND " + created + " >= ? AND (? = '' OR tenant_id = ?)"
+
+	var counts []struct {
+		ClusterID string
+		Day       string
+		Count     int
+	}
+	err := db.Raw(`
+		SELECT cluster_id, date(`+created+`) AS day, COUNT(*) AS count
+		FROM issues
+		WHERE `+inWindow+` AND cluster_id IN (
+			SELECT cluster_id FROM issues
+			WHERE `+inWindow+`
+			GROUP BY cluster_id
+			ORDER BY COUNT(*) DESC
+			LIMIT ?
+		)
+		GROUP BY cluster_id, day
+	`, start.Format("2006-01-02 15:04:05"), tenantID, tenantID, start.Format("2006-01-02 15:04:05"), tenantID, tenantID, top).Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	perCluster := make(map[string]map[string]int)
+	for _, c := range counts {
+		if perCluster[c.ClusterID] == nil {
+			perCluster[c.ClusterID] = make(map[string]int)
+		}
+		perCluster[c.ClusterID][c.Day] = c.Count
+	}
+
+	resolver := GetNameResolver()
+	rows := make([]HeatmapRow, 0, len(perCluster))
+	for clusterID, byDay := range perCluster {
+		row := HeatmapRow{ClusterID: clusterID, ClusterName: clusterID}
+		if info, err := resolver.ResolveCluster(clusterID); err == nil {
+			row.ClusterName = info.ClusterName
+			row.TenantName = info.TenantName
+			row.Provider = info.Provider
+			row.Region = info.Region
+		}
+		for d := start; !d.After(today); d = d.AddDate(0, 0, 1) {
+			date := d.Format("2006-01-02")
+			row.Days = append(row.Days, HeatmapDay{Date: date, Count: byDay[date]})
+			row.Total += byDay[date]
+		}
+		rows = append(rows, row)
+	}
+	sortHeatmapRows(rows)
+
+	if payload, err := json.Marshal(rows); err == nil {
+		db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&models.HeatmapCacheEntry{
+			CacheKey:  key,
+			Payload:   string(payload),
+			CreatedAt: time.Now(),
+		})
+	}
+	return rows, nil
+}
+
+// sortHeatmapRows orders rows by total alerts, busiest cluster first
+func sortHeatmapRows(rows []HeatmapRow) {
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Total != rows[j].Total {
+			return rows[i].Total > rows[j].Total
+		}
+		return rows[i].ClusterID < rows[j].ClusterID
+	})
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InvalidLabelsError is returned by IngestAlert for an alert whose labels fail
+// the label schema of a DataUpdater that rejects them (see SetLabelSchema)
+type InvalidLabelsError struct {
+	ID     string            `json:"id"`
+	Errors []ValidationError `json:"errors"`
+}
+
+func (e *InvalidLabelsError) Error() string {
+	return fmt.Sprintf("alert %s has invalid labels: %v", e.ID, e.Errors)
+}
+
+// IngestAlert stores a firing alert pushed to the platform, e.g. by Prometheus
+// remote write, through the pipeline of the alerts synced from JIRA: label
+// normalization, severity mapping, label validation, dedup key, fingerprint,
+// suppression, quota, routing and live updates. alert.ID identifies the alert
+// across pushes: while an alert stored under it is unresolved, pushes only
+// move its updated_at. Otherwise a new alert is stored as alert.ID, "-" and
+// the UNIX time it was created, unless the fingerprinter folds it into an
+// active alert. It reports whether a new alert was stored, and returns an
+// InvalidLabelsError for an alert dropped by the label schema.
+func (u *DataUpdater) IngestAlert(alert Alert) (bool, error) {
+	created, err := time.Parse("2006-01-02 15:04:05", strings.TrimSuffix(alert.Created, " UTC"))
+	if err != nil {
+		created = time.Now().UTC()
+	}
+	createdText := created.Format("2006-01-02 15:04:05") + " UTC"
+
+	placeholders, args := inClause(resolvedStatuses)
+	var activeID string
+	err = u.db.QueryRow(`
+		SELECT id FROM issues
+		WHERE id LIKE ? AND is_alert = 1 AND deleted_at IS NULL
+		AND LOWER(status) NOT IN (`+placeholders+`)
+		ORDER BY created DESC
+		LIMIT 1
+	`, append([]interface{}{alert.ID + "-%"}, args...)...).Scan(&activeID)
+	switch {
+	case err == nil:
+		_, err = u.db.Exec("UPDATE issues SET updated_at = ? WHERE id = ? AND COALESCE(updated_at, '') < ?", createdText, activeID, createdText)
+		if err != nil {
+			return false, fmt.Errorf("failed to update alert %s: %w", activeID, err)
+		}
+		return false, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return false, fmt.Errorf("failed to look up alert %s: %w", alert.ID, err)
+	}
+
+	labels := make(map[string]interface{}, len(alert.Labels))
+	for k, v := range alert.Labels {
+		labels[k] = v
+	}
+	issue := JiraIssue{
+		Key: fmt.Sprintf("%s-%d", alert.ID, created.Unix()),
+		Fields: JiraIssueFields{
+			Summary: alert.Title,
+			// Mentions Prometheus so the issue is recognized as an alert
+			Description:  fmt.Sprintf("Prometheus alert %s firing", alert.Labels["alertname"]),
+			Created:      created.Format(time.RFC3339),
+			Priority:     &JiraPriority{Name: alert.Severity},
+			Labels:       []string{"prometheus"},
+			IssueType:    &JiraIssueType{Name: "Alert"},
+			Project:      JiraProject{Key: "PROMETHEUS"},
+			Status:       &JiraStatus{Name: "Open"},
+			RawAlertData: map[string]interface{}{"labels": labels},
+		},
+	}
+	data, ok := u.processLiveIssue(&issue)
+	if !ok && u.rejectInvalid && len(data.LabelErrors) > 0 {
+		return false, &InvalidLabelsError{ID: alert.ID, Errors: data.LabelErrors}
+	}
+	// A repeat folded into an active alert of the same fingerprint is not new
+	return ok && data.DuplicateOf == "", nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// newTestIngester returns an alert ingester on a migrated SQLite database
+// validating labels against the default label schema
+func newTestIngester(t *testing.T, reject bool) *DataUpdater {
+	t.Helper()
+	gdb := openTestDB(t)
+	useNameService(t, stubNames{})
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := NewAlertIngester(sqlDB)
+	u.SetLabelSchema(DefaultLabelSchema(), reject)
+	return u
+}
+
+func TestIngestAlertRejectsInvalidLabels(t *testing.T) {
+	u := newTestIngester(t, true)
+
+	_, err := u.IngestAlert(Alert{
+		ID:       "PROM-1",
+		Title:    "TiKVDown",
+		Severity: "critical",
+		Created:  "2026-01-01 00:00:00 UTC",
+		Labels:   map[string]string{"alertname": "TiKVDown", "cluster_id": "c1"},
+	})
+	var invalid *InvalidLabelsError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("IngestAlert of a non-numeric cluster ID: got %v, want InvalidLabelsError", err)
+	}
+	if invalid.ID != "PROM-1" || len(invalid.Errors) != 1 || invalid.Errors[0].Field != "cluster_id" {
+		t.Errorf("errors = %+v, want one for cluster_id", invalid)
+	}
+	var count int64
+	if err := db.DB.Model(&models.Issue{}).Count(&count).Error; err != nil || count != 0 {
+		t.Errorf("%d alerts stored, %v, want the invalid one dropped", count, err)
+	}
+
+	// "Major" is what the sync stores for JIRA's High priority
+	isNew, err := u.IngestAlert(Alert{
+		ID:       "PROM-2",
+		Title:    "TiKVDown",
+		Severity: "Major",
+		Created:  "2026-01-01 00:00:00 UTC",
+		Labels:   map[string]string{"alertname": "TiKVDown", "cluster_id": "10001"},
+	})
+	if err != nil || !isNew {
+		t.Fatalf("IngestAlert of a valid alert = %v, %v, want stored", isNew, err)
+	}
+}
+
+func TestInvalidLabelsAreFlagged(t *testing.T) {
+	u := newTestIngester(t, false)
+
+	isNew, err := u.IngestAlert(Alert{
+		ID:       "PROM-1",
+		Title:    "TiKVDown",
+		Severity: "urgent",
+		Created:  "2026-01-01 00:00:00 UTC",
+		Labels:   map[string]string{"alertname": "TiKVDown", "cluster_id": "10001"},
+	})
+	if err != nil || !isNew {
+		t.Fatalf("IngestAlert = %v, %v, want the alert stored flagged", isNew, err)
+	}
+	var issue models.Issue
+	if err := db.DB.First(&issue).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(issue.LabelErrors, `"field":"severity"`) {
+		t.Errorf("label_errors = %q, want the severity error", issue.LabelErrors)
+	}
+}
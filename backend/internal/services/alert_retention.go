@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// PurgeDeletedAlerts hard-deletes alerts that were soft-deleted more than
+// retention ago and returns the number of rows removed
+func PurgeDeletedAlerts(db *gorm.DB, retention time.Duration) (int64, error) {
+	cutoff := time.Now().UTC().Add(-retention)
+	var purged int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{"alert_labels", "alert_notes"} {
+			err := tx.Exec("DELETE FROM "+table+" WHERE alert_id IN (SELECT id FROM issues WHERE deleted_at IS NOT NULL AND deleted_at < ?)", cutoff).Error
+			if err != nil {
+				return err
+			}
+		}
+		result := tx.Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(&models.Issue{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
+
+// StartAlertRetention runs PurgeDeletedAlerts every interval until ctx is cancelled
+func StartAlertRetention(ctx context.Context, db *gorm.DB, retention, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			purged, err := PurgeDeletedAlerts(db, retention)
+			if err != nil {
+				log.Printf("[WARN] Failed to purge deleted alerts: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("[INFO] Purged %d alerts deleted more than %s ago", purged, retention)
+			}
+		}
+	}()
+}
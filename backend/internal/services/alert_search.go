@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// alertSearchColumns are the issue columns matched by the LIKE fallback; with
+// the note bodies they mirror the columns indexed in alerts_fts
+var alertSearchColumns = []string{"title", "description", "labels", "alert_signature"}
+
+// parseSearchQuery splits q into OR-separated groups of terms that must all
+// match. Terms are separated by whitespace; "AND" is implied and may be
+// written explicitly, "OR" starts a new group.
+func parseSearchQuery(q string) [][]string {
+	var groups [][]string
+	var current []string
+	for _, term := range strings.Fields(q) {
+		switch term {
+		case "AND":
+			continue
+		case "OR":
+			if len(current) > 0 {
+				groups = append(groups, current)
+			}
+			current = nil
+		default:
+			current = append(current, term)
+		}
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+// ErrEmptySearchQuery is returned by SearchAlerts for a query without terms
+var ErrEmptySearchQuery = errors.New("query is empty")
+
+// SearchAlerts returns up to limit alerts whose text, labels or notes contain
+// the terms of q, newest first. It uses the alerts_fts index when present and a
+// case-insensitive LIKE scan otherwise. A non-empty tenantID restricts the
+// results to that tenant.
+func SearchAlerts(db *gorm.DB, q, tenantID string, limit int) ([]models.Issue, error) {
+	groups := parseSearchQuery(q)
+	if len(groups) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+
+	query := db.Model(&models.Issue{}).
+		Where("is_alert = 1 AND deleted_at IS NULL")
+	if tenantID != "" {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+
+	if db.Migrator().HasTable("alerts_fts") {
+		query = query.Where("id IN (SELECT id FROM alerts_fts WHERE alerts_fts MATCH ?)", ftsExpression(groups))
+	} else {
+		where, args := likeExpression(groups)
+		query = query.Where(where, args...)
+	}
+
+	var issues []models.Issue
+	if err := query.Order("created DESC").Limit(limit).Find(&issues).Error; err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// ftsExpression builds an FTS5 MATCH expression, quoting each term so
+// punctuation in label values is not parsed as query syntax
+func ftsExpression(groups [][]string) string {
+	ors := make([]string, len(groups))
+	for i, terms := range groups {
+		quoted := make([]string, len(terms))
+		for j, term := range terms {
+			quoted[j] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		}
+		ors[i] = "(" + strings.Join(quoted, " AND ") + ")"
+	}
+	return strings.Join(ors, " OR ")
+}
+
+// likeExpression builds the equivalent WHERE clause using LIKE. SQLite's LIKE
+// is case-insensitive for ASCII.
+func likeExpression(groups [][]string) (string, []interface{}) {
+	var args []interface{}
+	ors := make([]string, len(groups))
+	for i, terms := range groups {
+		ands := make([]string, len(terms))
+		for j, term := range terms {
+			pattern := "%" + escapeLike(term) + "%"
+			cols := make([]string, len(alertSearchColumns))
+			for k, col := range alertSearchColumns {
+				cols[k] = col + ` LIKE ? ESCAPE '\'`
+				args = append(args, pattern)
+			}
+			cols = append(cols, `id IN (SELECT alert_id FROM alert_notes WHERE body LIKE ? ESCAPE '\')`)
+			args = append(args, pattern)
+			ands[j] = "(" + strings.Join(cols, " OR ") + ")"
+		}
+		ors[i] = "(" + strings.Join(ands, " AND ") + ")"
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
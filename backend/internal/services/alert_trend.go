@@ -0,0 +1,141 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// trendCacheTTL is how long a computed trend is served from trend_cache
+const trendCacheTTL = 5 * time.Minute
+
+// maxTrendWindow bounds the window accepted by AlertTrend
+const maxTrendWindow = 90 * 24 * time.Hour
+
+// trendGranularities maps the supported granularities to their bucket size
+var trendGranularities = map[string]time.Duration{
+	"1h": time.Hour,
+	"6h": 6 * time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// ErrUnsupportedGranularity is returned by AlertTrend for granularities other than 1h, 6h and 1d
+var ErrUnsupportedGranularity = errors.New("unsupported granularity, expected 1h, 6h or 1d")
+
+// TrendBucket is the alert count of one time bucket
+type TrendBucket struct {
+	Bucket string `json:"bucket"` // bucket start, RFC 3339 UTC
+	Count  int    `json:"count"`
+}
+
+// TrendQuery selects the alerts counted by AlertTrend
+type TrendQuery struct {
+	Window      time.Duration
+	Granularity string // 1h, 6h or 1d
+	Severity    string
+	ClusterID   string
+	TenantID    string
+}
+
+// ParseTrendWindow parses a window such as "7d" or "36h"
+func ParseTrendWindow(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid window %q", s)
+		}
+	}
+	if d <= 0 || d > maxTrendWindow {
+		return 0, fmt.Errorf("window must be between 1h and %dd", int(maxTrendWindow.Hours()/24))
+	}
+	return d, nil
+}
+
+func (q TrendQuery) cacheKey() string {
+	return strings.Join([]string{q.Window.String(), q.Granularity, q.Severity, q.ClusterID, q.TenantID}, "|")
+}
+
+// AlertTrend counts alerts per bucket over the window ending now. Every bucket
+// in the window is returned, including empty ones. Results are cached in
+// trend_cache for five minutes.
+func AlertTrend(db *gorm.DB, q TrendQuery) ([]TrendBucket, error) {
+	size, ok := trendGranularities[q.Granularity]
+	if !ok {
+		return nil, ErrUnsupportedGranularity
+	}
+
+	key := q.cacheKey()
+	var cached models.TrendCacheEntry
+	if err := db.Where("cache_key = ? AND created_at > ?", key, time.Now().Add(-trendCacheTTL)).Limit(1).Find(&cached).Error; err == nil && cached.Payload != "" {
+		var buckets []TrendBucket
+		if json.Unmarshal([]byte(cached.Payload), &buckets) == nil {
+			return buckets, nil
+		}
+	}
+
+	now := time.Now().UTC()
+	start := now.Add(-q.Window).Truncate(size)
+
+	// created is stored as "2006-01-02 15:04:05 UTC"; 6h buckets floor the hour
+	created := "REPLACE(created, ' UTC', '')"
+	var bucketExpr string
+	switch q.Granularity {
+	case "1h":
+		bucketExpr = "strftime('%Y-%m-%dT%H:00:00Z', " + created + ")"
+	case "6h":
+		bucketExpr = "printf('%sT%02d:00:00Z', date(" + created + "), (CAST(strftime('%H', " + created + ") AS INTEGER) / 6) * 6)"
+	case "1d":
+		bucketExpr = "strftime('%Y-%m-%dT00:00:00Z', " + created + ")"
+	}
+
+	query := db.Table("issues").
+		Select(bucketExpr+" AS bucket, COUNT(*) AS count").
+		Where("is_alert = 1 AND deleted_at IS NULL AND "+created+" >= ?", start.Format("2006-01-02 15:04:05"))
+	if q.Severity != "" {
+		query = query.Where("priority = ?", q.Severity)
+	}
+	if q.ClusterID != "" {
+		query = query.Where("cluster_id = ?", q.ClusterID)
+	}
+	if q.TenantID != "" {
+		query = query.Where("tenant_id = ?", q.TenantID)
+	}
+
+	var rows []TrendBucket
+	if err := query.Group("bucket").Order("bucket").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(rows))
+	for _, r := range rows {
+		counts[r.Bucket] = r.Count
+	}
+
+	var buckets []TrendBucket
+	for t := start; !t.After(now); t = t.Add(size) {
+		b := t.Format("2006-01-02T15:04:05Z")
+		buckets = append(buckets, TrendBucket{Bucket: b, Count: counts[b]})
+	}
+
+	if payload, err := json.Marshal(buckets); err == nil {
+		db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&models.TrendCacheEntry{
+			CacheKey:  key,
+			Payload:   string(payload),
+			CreatedAt: time.Now(),
+		})
+	}
+	return buckets, nil
+}
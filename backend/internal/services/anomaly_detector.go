@@ -0,0 +1,159 @@
+package services
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAnomalyWindow    = 15  // one-minute buckets
+	defaultAnomalyThreshold = 3.0 // standard deviations above the window mean
+	defaultAnomalyMinAlerts = 10  // smaller buckets never count as a spike
+
+	// maxSpikeAlerts caps the alerts of the latest bucket handed to OnSpike
+	maxSpikeAlerts = 50
+)
+
+// anomalyBucket counts the alerts of one minute
+type anomalyBucket struct {
+	minute int64 // UNIX time / 60
+	count  int
+}
+
+// AnomalyDetector tracks alert volume in one-minute buckets over a sliding
+// window and calls the OnSpike callback when the latest bucket is more than
+// Threshold standard deviations above the mean of the others. It fires at most
+// once per bucket. It is safe for concurrent use.
+type AnomalyDetector struct {
+	Threshold float64
+	MinAlerts int
+
+	mu      sync.Mutex
+	buckets []anomalyBucket // ring indexed by minute % window
+	latest  int64           // newest minute observed
+	fired   int64           // minute the last spike was reported for
+	recent  []Alert         // alerts of the latest bucket, up to maxSpikeAlerts
+	onSpike func(factor float64, recentAlerts []Alert)
+}
+
+// NewAnomalyDetector returns a detector over window one-minute buckets
+func NewAnomalyDetector(window int, threshold float64) *AnomalyDetector {
+	if window < 2 {
+		window = defaultAnomalyWindow
+	}
+	return &AnomalyDetector{
+		Threshold: threshold,
+		MinAlerts: defaultAnomalyMinAlerts,
+		buckets:   make([]anomalyBucket, window),
+	}
+}
+
+// NewAnomalyDetectorFromEnv configures the detector from ANOMALY_WINDOW_MINUTES
+// (default 15), ANOMALY_THRESHOLD_STDDEV (default 3) and ANOMALY_MIN_ALERTS
+// (default 10)
+func NewAnomalyDetectorFromEnv() *AnomalyDetector {
+	window, threshold := defaultAnomalyWindow, defaultAnomalyThreshold
+	if v, err := strconv.Atoi(os.Getenv("ANOMALY_WINDOW_MINUTES")); err == nil && v >= 2 {
+		window = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("ANOMALY_THRESHOLD_STDDEV"), 64); err == nil && v > 0 {
+		threshold = v
+	}
+	d := NewAnomalyDetector(window, threshold)
+	if v, err := strconv.Atoi(os.Getenv("ANOMALY_MIN_ALERTS")); err == nil && v > 0 {
+		d.MinAlerts = v
+	}
+	return d
+}
+
+// OnSpike registers the callback run when a spike is detected. factor is the
+// latest bucket's count over the window mean. The callback runs in its own
+// goroutine.
+func (d *AnomalyDetector) OnSpike(fn func(factor float64, recentAlerts []Alert)) {
+	d.mu.Lock()
+	d.onSpike = fn
+	d.mu.Unlock()
+}
+
+// Observe counts an alert seen at the given time. Alerts older than the window
+// are ignored.
+func (d *AnomalyDetector) Observe(alert Alert, at time.Time) {
+	minute := at.Unix() / 60
+	window := int64(len(d.buckets))
+
+	d.mu.Lock()
+	if minute <= d.latest-window {
+		d.mu.Unlock()
+		return
+	}
+	if minute > d.latest {
+		d.latest = minute
+		d.recent = d.recent[:0]
+	}
+
+	b := &d.buckets[minute%window]
+	if b.minute != minute {
+		*b = anomalyBucket{minute: minute}
+	}
+	b.count++
+	if minute == d.latest && len(d.recent) < maxSpikeAlerts {
+		d.recent = append(d.recent, alert)
+	}
+
+	factor, spike := d.checkSpike()
+	var fn func(float64, []Alert)
+	var recent []Alert
+	if spike && d.onSpike != nil {
+		d.fired = d.latest
+		fn = d.onSpike
+		recent = append([]Alert(nil), d.recent...)
+	}
+	d.mu.Unlock()
+
+	if fn != nil {
+		go fn(factor, recent)
+	}
+}
+
+// checkSpike compares the latest bucket with the rest of the window. Buckets
+// without alerts count as zero. Must be called with d.mu held.
+func (d *AnomalyDetector) checkSpike() (float64, bool) {
+	window := int64(len(d.buckets))
+	if d.fired == d.latest {
+		return 0, false
+	}
+
+	current := 0
+	var sum, sumSq float64
+	for i := int64(0); i < window; i++ {
+		minute := d.latest - i
+		count := 0
+		if b := d.buckets[minute%window]; b.minute == minute {
+			count = b.count
+		}
+		if i == 0 {
+			current = count
+			continue
+		}
+		sum += float64(count)
+		sumSq += float64(count * count)
+	}
+	if current < d.MinAlerts {
+		return 0, false
+	}
+
+	n := float64(window - 1)
+	mean := sum / n
+	stddev := math.Sqrt(math.Max(0, sumSq/n-mean*mean))
+	if float64(current) <= mean+d.Threshold*stddev {
+		return 0, false
+	}
+
+	if mean == 0 {
+		return float64(current), true
+	}
+	return float64(current) / mean, true
+}
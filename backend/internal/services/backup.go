@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultBackupDir      = "./backups"
+	defaultBackupSchedule = "0 3 * * *" // nightly at 03:00
+	defaultBackupKeep     = 7
+
+	backupPrefix     = "alerts_v2-"
+	backupSuffix     = ".db"
+	backupTimeLayout = "20060102T150405Z"
+)
+
+// BackupInfo is a backup file of the alerts database
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	SizeBytes int64     `json:"size_bytes"`
+	CreatedAt time.Time `json:"created_at"`
+	Uploaded  bool      `json:"uploaded,omitempty"` // copied to S3 by this run
+}
+
+// BackupService copies the SQLite alerts database to timestamped files in
+// Dir, keeping the Keep newest, and uploads each one to S3 when an uploader
+// is configured. Only one backup runs at a time.
+type BackupService struct {
+	Dir      string
+	Keep     int
+	Schedule string // 5-field cron expression, "" disables scheduled backups
+	S3       *S3Uploader
+
+	mu sync.Mutex
+}
+
+var (
+	backupServiceInstance *BackupService
+	backupServiceOnce     sync.Once
+)
+
+// NewBackupService returns a service writing to dir, keeping 7 backups and
+// running nightly at 03:00, without S3 upload
+func NewBackupService(dir string) *BackupService {
+	return &BackupService{
+		Dir:      dir,
+		Keep:     defaultBackupKeep,
+		Schedule: defaultBackupSchedule,
+	}
+}
+
+// GetBackupService returns the shared service, configured from BACKUP_DIR
+// (default ./backups), BACKUP_KEEP (default 7), BACKUP_CRON (default
+// "0 3 * * *", "off" to disable) and AWS_S3_BACKUP_BUCKET
+func GetBackupService() *BackupService {
+	backupServiceOnce.Do(func() {
+		dir := os.Getenv("BACKUP_DIR")
+		if dir == "" {
+			dir = defaultBackupDir
+		}
+		s := NewBackupService(dir)
+		if n, err := strconv.Atoi(os.Getenv("BACKUP_KEEP")); err == nil && n > 0 {
+			s.Keep = n
+		}
+		switch spec := os.Getenv("BACKUP_CRON"); spec {
+		case "":
+		case "off":
+			s.Schedule = ""
+		default:
+			s.Schedule = spec
+		}
+		s.S3 = NewS3UploaderFromEnv()
+		backupServiceInstance = s
+	})
+	return backupServiceInstance
+}
+
+// Run backs up sqlite to a new file in Dir, uploads it to S3 if configured
+// and removes the backups beyond Keep. A failed upload is reported but the
+// local backup is kept.
+func (s *BackupService) Run(ctx context.Context, sqlite *gorm.DB) (BackupInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return BackupInfo{}, err
+	}
+	sqlDB, err := sqlite.DB()
+	if err != nil {
+		return BackupInfo{}, err
+	}
+
+	now := time.Now().UTC()
+	name := backupPrefix + now.Format(backupTimeLayout) + backupSuffix
+	path := filepath.Join(s.Dir, name)
+	if err := db.Backup(ctx, sqlDB, path); err != nil {
+		return BackupInfo{}, err
+	}
+	info := BackupInfo{Name: name, CreatedAt: now}
+	if st, err := os.Stat(path); err == nil {
+		info.SizeBytes = st.Size()
+	}
+
+	var uploadErr error
+	if s.S3 != nil {
+		if uploadErr = s.S3.Upload(ctx, "backups/"+name, path); uploadErr == nil {
+			info.Uploaded = true
+		}
+	}
+	s.prune()
+	return info, uploadErr
+}
+
+// List returns the backups in Dir, newest first
+func (s *BackupService) List() ([]BackupInfo, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return []BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []BackupInfo{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		created, err := time.Parse(backupTimeLayout, strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupSuffix))
+		if err != nil {
+			continue
+		}
+		info := BackupInfo{Name: name, CreatedAt: created}
+		if st, err := e.Info(); err == nil {
+			info.SizeBytes = st.Size()
+		}
+		backups = append(backups, info)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// prune removes the backups beyond the Keep newest. Must be called with s.mu held.
+func (s *BackupService) prune() {
+	backups, err := s.List()
+	if err != nil || len(backups) <= s.Keep {
+		return
+	}
+	for _, b := range backups[s.Keep:] {
+		if err := os.Remove(filepath.Join(s.Dir, b.Name)); err != nil {
+			log.Printf("[WARN] Failed to remove old backup %s: %v\n", b.Name, err)
+		}
+	}
+}
+
+// Start runs Run on Schedule until ctx is cancelled. It does nothing when
+// Schedule is empty.
+func (s *BackupService) Start(ctx context.Context, sqlite *gorm.DB) error {
+	if s.Schedule == "" {
+		return nil
+	}
+	schedule, err := parseCron(s.Schedule)
+	if err != nil {
+		return fmt.Errorf("BACKUP_CRON: %w", err)
+	}
+
+	go func() {
+		for {
+			next := schedule.next(time.Now())
+			if next.IsZero() {
+				log.Printf("[WARN] Backup schedule %q never fires\n", s.Schedule)
+				return
+			}
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			info, err := s.Run(ctx, sqlite)
+			if err != nil {
+				log.Printf("[WARN] Scheduled backup failed: %v\n", err)
+			}
+			if info.Name != "" {
+				log.Printf("[INFO] Backed up the alerts database to %s (%d bytes)\n", info.Name, info.SizeBytes)
+			}
+		}
+	}()
+	return nil
+}
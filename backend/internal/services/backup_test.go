@@ -0,0 +1,146 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+func TestBackupServiceRun(t *testing.T) {
+	sqlite := openTestDB(t)
+	if err := sqlite.Create(&models.Issue{ID: "A-1", IsAlert: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{
+		"alerts_v2-20260101T030000Z.db",
+		"alerts_v2-20260102T030000Z.db",
+		"alerts_v2-latest.db",
+		"notes.txt",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("old"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewBackupService(dir)
+	s.Keep = 2
+
+	info, err := s.Run(context.Background(), sqlite)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !strings.HasPrefix(info.Name, "alerts_v2-") || info.SizeBytes == 0 || info.Uploaded || time.Since(info.CreatedAt) > time.Minute {
+		t.Errorf("backup = %+v", info)
+	}
+	backup, err := sql.Open("sqlite3", "file:"+filepath.Join(dir, info.Name)+"?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backup.Close()
+	var id string
+	if err := backup.QueryRow(`SELECT id FROM issues`).Scan(&id); err != nil || id != "A-1" {
+		t.Errorf("backed up issue = %q, %v", id, err)
+	}
+
+	// The oldest backup beyond Keep is removed; other files are left alone
+	backups, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, b := range backups {
+		names = append(names, b.Name)
+	}
+	if !reflect.DeepEqual(names, []string{info.Name, "alerts_v2-20260102T030000Z.db"}) {
+		t.Errorf("backups = %v, want the new one and the newest old one", names)
+	}
+	for _, name := range []string{"alerts_v2-latest.db", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if backups, err := NewBackupService(filepath.Join(dir, "missing")).List(); err != nil || len(backups) != 0 {
+		t.Errorf("List of a missing directory = %v, %v", backups, err)
+	}
+}
+
+func TestBackupServiceUploadsToS3(t *testing.T) {
+	sqlite := openTestDB(t)
+
+	var got struct {
+		path, auth, sha string
+		body            []byte
+	}
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path, got.auth, got.sha = r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("X-Amz-Content-Sha256")
+		got.body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	s := NewBackupService(t.TempDir())
+	s.S3 = &S3Uploader{Bucket: "alerts", Region: "us-west-2", Endpoint: srv.URL, AccessKey: "AKID", SecretKey: "secret", Client: srv.Client()}
+	info, err := s.Run(context.Background(), sqlite)
+	if err != nil || !info.Uploaded {
+		t.Fatalf("Run = %+v, %v", info, err)
+	}
+
+	local, _ := os.ReadFile(filepath.Join(s.Dir, info.Name))
+	sum := sha256.Sum256(local)
+	if got.path != "/alerts/backups/"+info.Name || !bytes.Equal(got.body, local) || got.sha != hex.EncodeToString(sum[:]) {
+		t.Errorf("upload to %s of %d bytes with hash %s, want the %d bytes of %s", got.path, len(got.body), got.sha, len(local), info.Name)
+	}
+	scope := "AKID/" + info.CreatedAt.Format("20060102") + "/us-west-2/s3/aws4_request"
+	if !strings.HasPrefix(got.auth, "AWS4-HMAC-SHA256 Credential="+scope+", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Errorf("Authorization = %q", got.auth)
+	}
+
+	// A failed upload is reported, the local backup is kept
+	status = http.StatusForbidden
+	s.Dir = t.TempDir()
+	info, err = s.Run(context.Background(), sqlite)
+	if err == nil || info.Uploaded {
+		t.Errorf("Run with a failing upload = %+v, %v", info, err)
+	}
+	if _, statErr := os.Stat(filepath.Join(s.Dir, info.Name)); statErr != nil {
+		t.Errorf("local backup after a failed upload: %v", statErr)
+	}
+
+	if err := (&S3Uploader{Bucket: "alerts"}).Upload(context.Background(), "k", filepath.Join(s.Dir, info.Name)); err == nil {
+		t.Error("upload without credentials succeeded")
+	}
+}
+
+func TestGetBackupServiceFromEnv(t *testing.T) {
+	t.Setenv("BACKUP_DIR", "/var/backups/alerts")
+	t.Setenv("BACKUP_KEEP", "14")
+	t.Setenv("BACKUP_CRON", "off")
+	t.Setenv("AWS_S3_BACKUP_BUCKET", "alerts-backups")
+	t.Setenv("AWS_REGION", "")
+
+	s := GetBackupService()
+	if s.Dir != "/var/backups/alerts" || s.Keep != 14 || s.Schedule != "" || s.S3 == nil || s.S3.Bucket != "alerts-backups" || s.S3.Region != "us-east-1" {
+		t.Errorf("service = %+v", s)
+	}
+	if err := s.Start(context.Background(), nil); err != nil {
+		t.Errorf("Start without a schedule: %v", err)
+	}
+	if err := (&BackupService{Schedule: "0 3 * *"}).Start(context.Background(), nil); err == nil {
+		t.Error("Start with an invalid schedule succeeded")
+	}
+}
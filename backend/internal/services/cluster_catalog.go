@@ -0,0 +1,166 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// ClusterFilter narrows ListClusters to clusters whose fields equal the
+// non-empty ones
+type ClusterFilter struct {
+	Provider         string
+	Region           string
+	DeployType       string
+	ClusterLifecycle string
+	TenantPlan       string
+}
+
+// columns returns the filtered column names and their values
+func (f ClusterFilter) columns() ([]string, []interface{}) {
+	var cols []string
+	var args []interface{}
+	for _, c := range []struct {
+		col, value string
+	}{
+		{"provider", f.Provider},
+		{"region", f.Region},
+		{"deploy_type", f.DeployType},
+		{"cluster_lifecycle", f.ClusterLifecycle},
+		{"tenant_plan", f.TenantPlan},
+	} {
+		if c.value != "" {
+			cols = append(cols, c.col)
+			args = append(args, c.value)
+		}
+	}
+	return cols, args
+}
+
+// ListClusters returns a page of the known clusters matching filter, most
+// recently updated first, with the total number of matches and the backend
+// that served them. Clusters are read from clusters_local, or from TiDB while
+// clusters_local is empty, e.g. before the first ClusterSyncer run. Tenant
+// names cached by the resolver take precedence over the stored ones.
+func (nr *NameResolver) ListClusters(sqlite *gorm.DB, filter ClusterFilter, offset, limit int) ([]ClusterInfo, int64, string, error) {
+	var synced int64
+	if err := sqlite.Model(&models.LocalCluster{}).Count(&synced).Error; err != nil {
+		return nil, 0, "", fmt.Errorf("failed to count clusters_local: %w", err)
+	}
+
+	var clusters []ClusterInfo
+	var total int64
+	var source string
+	var err error
+	if synced > 0 || !db.TiDBReady() {
+		source = sourceSQLite
+		clusters, total, err = listLocalClusters(sqlite, filter, offset, limit)
+	} else {
+		source = sourcePrimary
+		clusters, total, err = listTiDBClusters(filter, offset, limit)
+	}
+	if err != nil {
+		return nil, 0, source, err
+	}
+
+	for i := range clusters {
+		if name, ok := nr.cachedName(clusters[i].TenantID); ok {
+			clusters[i].TenantName = name
+		}
+	}
+	return clusters, total, source, nil
+}
+
+// cachedName returns the name of id from a valid cache entry, without
+// querying any backend
+func (nr *NameResolver) cachedName(id string) (string, bool) {
+	if id == "" {
+		return "", false
+	}
+	entry, ok := nr.cache.peek(id)
+	if !ok || entry.notFound || !nr.isEntryValid(entry) || entry.info.Name == "" {
+		return "", false
+	}
+	return entry.info.Name, true
+}
+
+func listLocalClusters(sqlite *gorm.DB, filter ClusterFilter, offset, limit int) ([]ClusterInfo, int64, error) {
+	query := sqlite.Model(&models.LocalCluster{})
+	cols, args := filter.columns()
+	for i, col := range cols {
+		query = query.Where(col+" = ?", args[i])
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count clusters_local: %w", err)
+	}
+	var records []models.LocalCluster
+	if err := query.Order("updated_at DESC").Order("cluster_id").Offset(offset).Limit(limit).Find(&records).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to read clusters_local: %w", err)
+	}
+
+	clusters := make([]ClusterInfo, 0, len(records))
+	for _, rec := range records {
+		clusters = append(clusters, clusterInfoFromLocal(rec))
+	}
+	return clusters, total, nil
+}
+
+func listTiDBClusters(filter ClusterFilter, offset, limit int) ([]ClusterInfo, int64, error) {
+	cols, args := filter.columns()
+	where := ""
+	if len(cols) > 0 {
+		conds := make([]string, len(cols))
+		for i, col := range cols {
+			conds[i] = "COALESCE(c." + col + ", '') = ?"
+		}
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int64
+	if err := db.TiDB.QueryRow("SELECT COUNT(*) FROM clusters c "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count clusters: %w", err)
+	}
+
+	rows, err := db.TiDB.Query(`
+		SELECT c.cluster_id, c.cluster_name, c.tenant_id,
+		       COALESCE(NULLIF(c.tenant_name, ''), t.tenant_name, '') as tenant_name,
+		       COALESCE(c.deploy_type, '') as deploy_type,
+		       COALESCE(c.version, '') as version,
+		       COALESCE(c.cluster_lifecycle, '') as cluster_lifecycle,
+		       COALESCE(c.creation_duration, '') as creation_duration,
+		       COALESCE(c.tenant_plan, '') as tenant_plan,
+		       COALESCE(c.provider, '') as provider,
+		       COALESCE(c.region, '') as region,
+		       COALESCE(c.project_id, '') as project_id,
+		       COALESCE(c.org_id, '') as org_id,
+		       COALESCE(c.cluster_type, '') as cluster_type,
+		       c.created_at, c.updated_at
+		FROM clusters c
+		LEFT JOIN tenants t ON c.tenant_id = t.tenant_id
+		`+where+`
+		ORDER BY c.updated_at DESC, c.cluster_id
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query clusters: %w", err)
+	}
+	defer rows.Close()
+
+	clusters := []ClusterInfo{}
+	for rows.Next() {
+		var info ClusterInfo
+		if err := rows.Scan(&info.ClusterID, &info.ClusterName, &info.TenantID, &info.TenantName,
+			&info.DeployType, &info.Version, &info.ClusterLifecycle, &info.CreationDuration,
+			&info.TenantPlan, &info.Provider, &info.Region, &info.ProjectID, &info.OrgID, &info.ClusterType,
+			&info.CreatedAt, &info.UpdatedAt); err != nil {
+			return nil, 0, err
+		}
+		clusters = append(clusters, info)
+	}
+	return clusters, total, rows.Err()
+}
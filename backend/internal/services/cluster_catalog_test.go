@@ -0,0 +1,106 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// clusterIDs returns the IDs of clusters, in order
+func clusterIDs(clusters []ClusterInfo) []string {
+	ids := []string{}
+	for _, c := range clusters {
+		ids = append(ids, c.ClusterID)
+	}
+	return ids
+}
+
+func TestListClustersFromTiDB(t *testing.T) {
+	conn := openTestTiDB(t)
+	seedTestTiDB(t, conn)
+	_, err := conn.Exec(`INSERT INTO clusters (cluster_id, cluster_name, tenant_id, deploy_type, provider, region, cluster_lifecycle, tenant_plan, updated_at) VALUES
+		('2002', 'staging', '1001', 'dedicated', 'aws', 'us-east-1', 'active', 'enterprise', '2026-03-01 00:00:00'),
+		('2003', 'dev', '1002', 'serverless', 'gcp', 'us-east-1', 'creating', 'free', '2026-02-01 00:00:00'),
+		('2004', 'eu', '1001', 'dedicated', 'aws', 'eu-west-1', 'active', 'enterprise', '2026-02-01 00:00:00')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite := openTestDB(t)
+	nr := NewNameResolver()
+
+	clusters, total, source, err := nr.ListClusters(sqlite, ClusterFilter{}, 0, 10)
+	if err != nil || source != sourcePrimary {
+		t.Fatalf("ListClusters = %v, %s, want TiDB while clusters_local is empty", err, source)
+	}
+	// Most recently updated first, then by ID
+	if ids := clusterIDs(clusters); total != 4 || !reflect.DeepEqual(ids, []string{"2002", "2003", "2004", "2001"}) {
+		t.Errorf("clusters = %v of %d", ids, total)
+	}
+	if c := clusters[0]; c.TenantName != "acme" || c.Region != "us-east-1" || c.TenantPlan != "enterprise" || !c.UpdatedAt.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("2002 = %+v", c)
+	}
+
+	for _, tc := range []struct {
+		filter ClusterFilter
+		ids    []string
+	}{
+		{ClusterFilter{Provider: "aws"}, []string{"2002", "2004"}},
+		{ClusterFilter{Region: "us-east-1", DeployType: "serverless"}, []string{"2003"}},
+		{ClusterFilter{ClusterLifecycle: "active", TenantPlan: "enterprise", Region: "eu-west-1"}, []string{"2004"}},
+		{ClusterFilter{Provider: "azure"}, []string{}},
+	} {
+		clusters, total, _, err := nr.ListClusters(sqlite, tc.filter, 0, 10)
+		if ids := clusterIDs(clusters); err != nil || !reflect.DeepEqual(ids, tc.ids) || total != int64(len(tc.ids)) {
+			t.Errorf("%+v: %v of %d, %v, want %v", tc.filter, ids, total, err, tc.ids)
+		}
+	}
+
+	// Pages keep the total of all matches
+	clusters, total, _, _ = nr.ListClusters(sqlite, ClusterFilter{}, 2, 2)
+	if ids := clusterIDs(clusters); total != 4 || !reflect.DeepEqual(ids, []string{"2004", "2001"}) {
+		t.Errorf("second page = %v of %d", ids, total)
+	}
+}
+
+func TestListClustersFromLocal(t *testing.T) {
+	conn := openTestTiDB(t)
+	seedTestTiDB(t, conn)
+	sqlite := openTestDB(t)
+	for _, c := range []models.LocalCluster{
+		{ClusterID: "3001", ClusterName: "old", TenantID: "1001", TenantName: "acme-stored", Provider: "aws", UpdatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{ClusterID: "3002", ClusterName: "new", TenantID: "1009", TenantName: "globex", Provider: "gcp", UpdatedAt: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		if err := sqlite.Create(&c).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	nr := NewNameResolver()
+	defer nr.ClosePreparedStatements()
+
+	// Synced clusters are served even when TiDB is up
+	clusters, total, source, err := nr.ListClusters(sqlite, ClusterFilter{}, 0, 10)
+	if err != nil || source != sourceSQLite || total != 2 || !reflect.DeepEqual(clusterIDs(clusters), []string{"3002", "3001"}) {
+		t.Fatalf("ListClusters = %v of %d from %s, %v", clusterIDs(clusters), total, source, err)
+	}
+	if clusters[1].TenantName != "acme-stored" {
+		t.Errorf("tenant name without a cached one = %q", clusters[1].TenantName)
+	}
+
+	// Tenant names cached by the resolver win over the stored ones
+	nr.Resolve("1001")
+	clusters, _, _, _ = nr.ListClusters(sqlite, ClusterFilter{Provider: "aws"}, 0, 10)
+	if len(clusters) != 1 || clusters[0].TenantName != "acme" {
+		t.Errorf("aws clusters = %+v, want 3001 with the cached tenant name", clusters)
+	}
+
+	// Without TiDB an empty clusters_local is served as is
+	sqlite.Where("1 = 1").Delete(&models.LocalCluster{})
+	db.SetTiDB(nil)
+	clusters, total, source, err = nr.ListClusters(sqlite, ClusterFilter{}, 0, 10)
+	if err != nil || source != sourceSQLite || total != 0 || len(clusters) != 0 {
+		t.Errorf("without TiDB = %v of %d from %s, %v", clusterIDs(clusters), total, source, err)
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+const (
+	defaultClusterChangeInterval = 30 * time.Second
+	// clusterChangeBuffer is the channel buffer of each subscriber
+	clusterChangeBuffer = 64
+	// maxClusterChangesPerPoll bounds the rows read by one poll; the rest are
+	// picked up by the next one
+	maxClusterChangesPerPoll = 1000
+)
+
+// ClusterChangedEvent is a cluster whose metadata (name, lifecycle, version,
+// ...) changed in TiDB. Before is the ClusterInfo cached when the change was
+// seen; it is the zero value when the cluster was not cached.
+type ClusterChangedEvent struct {
+	Before ClusterInfo
+	After  ClusterInfo
+}
+
+// ClusterChangePoller polls TiDB every Interval for clusters updated since the
+// previous poll and emits a ClusterChangedEvent for each one whose metadata
+// differs from the cached ClusterInfo. Changed clusters are evicted from the
+// NameResolver caches and their new ClusterInfo is cached in their place.
+// Events are delivered to every subscriber without blocking: a subscriber
+// whose buffer is full misses the event.
+type ClusterChangePoller struct {
+	Interval time.Duration
+
+	nr *NameResolver
+
+	mu          sync.Mutex
+	since       time.Time // updated_at of the newest row seen
+	subscribers map[chan ClusterChangedEvent]struct{}
+}
+
+var (
+	clusterChangePollerInstance *ClusterChangePoller
+	clusterChangePollerOnce     sync.Once
+)
+
+// NewClusterChangePoller returns a poller of changes since now, running every
+// 30 seconds
+func NewClusterChangePoller(nr *NameResolver) *ClusterChangePoller {
+	return &ClusterChangePoller{
+		Interval:    defaultClusterChangeInterval,
+		nr:          nr,
+		since:       time.Now(),
+		subscribers: make(map[chan ClusterChangedEvent]struct{}),
+	}
+}
+
+// GetClusterChangePoller returns the shared poller of the shared NameResolver,
+// running every CLUSTER_CHANGE_POLL_INTERVAL (default 30s)
+func GetClusterChangePoller() *ClusterChangePoller {
+	clusterChangePollerOnce.Do(func() {
+		p := NewClusterChangePoller(GetNameResolver())
+		if d, err := time.ParseDuration(os.Getenv("CLUSTER_CHANGE_POLL_INTERVAL")); err == nil && d > 0 {
+			p.Interval = d
+		}
+		clusterChangePollerInstance = p
+	})
+	return clusterChangePollerInstance
+}
+
+// Subscribe returns a channel receiving every later change event
+func (p *ClusterChangePoller) Subscribe() <-chan ClusterChangedEvent {
+	ch := make(chan ClusterChangedEvent, clusterChangeBuffer)
+	p.mu.Lock()
+	p.subscribers[ch] = struct{}{}
+	p.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe stops delivering events to a channel returned by Subscribe and
+// closes it
+func (p *ClusterChangePoller) Unsubscribe(sub <-chan ClusterChangedEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for ch := range p.subscribers {
+		if ch == sub {
+			delete(p.subscribers, ch)
+			close(ch)
+			return
+		}
+	}
+}
+
+// Start polls every Interval until ctx is cancelled. Polls are skipped while
+// TiDB is not connected.
+func (p *ClusterChangePoller) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if !db.TiDBReady() {
+				continue
+			}
+			if _, err := p.Poll(ctx); err != nil {
+				p.nr.logger.Warn("Cluster change poll failed", slog.Any("error", err))
+			}
+		}
+	}()
+}
+
+// Poll reads the clusters updated since the previous poll, emits and returns
+// the events of those that changed, and updates the resolver caches
+func (p *ClusterChangePoller) Poll(ctx context.Context) ([]ClusterChangedEvent, error) {
+	if !db.TiDBReady() {
+		return nil, fmt.Errorf("TiDB not connected")
+	}
+
+	p.mu.Lock()
+	since := p.since
+	p.mu.Unlock()
+
+	updated, err := p.updatedClustersSince(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	if len(updated) == 0 {
+		return []ClusterChangedEvent{}, nil
+	}
+
+	events := []ClusterChangedEvent{}
+	nr := p.nr
+	nr.cacheMutex.RLock()
+	for _, after := range updated {
+		before := nr.clusterCache[after.ClusterID].info
+		if clusterInfoChanged(before, after) {
+			events = append(events, ClusterChangedEvent{Before: before, After: after})
+		}
+	}
+	nr.cacheMutex.RUnlock()
+
+	if len(events) > 0 {
+		ids := make([]string, len(events))
+		for i, e := range events {
+			ids[i] = e.After.ClusterID
+		}
+		nr.Invalidate(ids)
+		// Keep the new details to compare the next change against
+		nr.cacheMutex.Lock()
+		for _, e := range events {
+			nr.clusterCache[e.After.ClusterID] = clusterCacheEntry{info: e.After, timestamp: nr.now()}
+		}
+		nr.cacheMutex.Unlock()
+		nr.logger.Info("Detected changed clusters", slog.Int("count", len(events)))
+	}
+
+	p.mu.Lock()
+	if newest := updated[len(updated)-1].UpdatedAt; newest.After(p.since) {
+		p.since = newest
+	}
+	for _, e := range events {
+		for ch := range p.subscribers {
+			select {
+			case ch <- e:
+			default:
+			}
+		}
+	}
+	p.mu.Unlock()
+	return events, nil
+}
+
+// updatedClustersSince returns the clusters updated after since, oldest first
+func (p *ClusterChangePoller) updatedClustersSince(ctx context.Context, since time.Time) ([]ClusterInfo, error) {
+	rows, err := db.TiDB.QueryContext(ctx, `
+		SELECT c.cluster_id, c.cluster_name, c.tenant_id,
+		       COALESCE(NULLIF(c.tenant_name, ''), t.tenant_name, '') as tenant_name,
+		       COALESCE(c.deploy_type, '') as deploy_type,
+		       COALESCE(c.version, '') as version,
+		       COALESCE(c.cluster_lifecycle, '') as cluster_lifecycle,
+		       COALESCE(c.creation_duration, '') as creation_duration,
+		       COALESCE(c.tenant_plan, '') as tenant_plan,
+		       COALESCE(c.provider, '') as provider,
+		       COALESCE(c.region, '') as region,
+		       COALESCE(c.project_id, '') as project_id,
+		       COALESCE(c.org_id, '') as org_id,
+		       COALESCE(c.cluster_type, '') as cluster_type,
+		       c.created_at, c.updated_at
+		FROM clusters c
+		LEFT JOIN tenants t ON c.tenant_id = t.tenant_id
+		WHERE c.updated_at > ?
+		ORDER BY c.updated_at
+		LIMIT ?
+	`, since, maxClusterChangesPerPoll)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query updated clusters: %w", err)
+	}
+	defer rows.Close()
+
+	var clusters []ClusterInfo
+	for rows.Next() {
+		var info ClusterInfo
+		if err := rows.Scan(&info.ClusterID, &info.ClusterName, &info.TenantID, &info.TenantName,
+			&info.DeployType, &info.Version, &info.ClusterLifecycle, &info.CreationDuration,
+			&info.TenantPlan, &info.Provider, &info.Region, &info.ProjectID, &info.OrgID, &info.ClusterType,
+			&info.CreatedAt, &info.UpdatedAt); err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, info)
+	}
+	return clusters, rows.Err()
+}
+
+// clusterInfoChanged reports whether the metadata of a and b differ. The
+// timestamps are not metadata.
+func clusterInfoChanged(a, b ClusterInfo) bool {
+	a.CreatedAt, a.UpdatedAt = time.Time{}, time.Time{}
+	b.CreatedAt, b.UpdatedAt = time.Time{}, time.Time{}
+	return a != b
+}
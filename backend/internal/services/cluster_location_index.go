@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// providerRegionIndex maps "provider|region" (lowercased) to the IDs of the
+// clusters deployed there
+type providerRegionIndex map[string][]string
+
+// ClusterLocationIndex lets alert queries filter by cloud provider and region,
+// which are not stored on issues, by expanding them to cluster IDs. It covers
+// the clusters that appear in alerts and is rebuilt periodically from the
+// name resolver's cluster details.
+type ClusterLocationIndex struct {
+	mu      sync.RWMutex
+	index   providerRegionIndex
+	builtAt time.Time
+
+	buildMu sync.Mutex
+}
+
+var (
+	locationIndexInstance *ClusterLocationIndex
+	locationIndexOnce     sync.Once
+)
+
+// GetClusterLocationIndex returns the shared index
+func GetClusterLocationIndex() *ClusterLocationIndex {
+	locationIndexOnce.Do(func() {
+		locationIndexInstance = &ClusterLocationIndex{}
+	})
+	return locationIndexInstance
+}
+
+func locationKey(provider, region string) string {
+	return strings.ToLower(provider) + "|" + strings.ToLower(region)
+}
+
+// Rebuild resolves every cluster seen in alerts and replaces the index.
+// Clusters that cannot be resolved are left out.
+func (idx *ClusterLocationIndex) Rebuild(db *gorm.DB) error {
+	idx.buildMu.Lock()
+	defer idx.buildMu.Unlock()
+
+	var clusterIDs []string
+	if err := db.Raw("SELECT DISTINCT cluster_id FROM issues WHERE is_alert = 1 AND cluster_id != '' AND cluster_id IS NOT NULL").Scan(&clusterIDs).Error; err != nil {
+		return err
+	}
+
+	resolver := GetNameResolver()
+	index := make(providerRegionIndex)
+	for _, id := range clusterIDs {
+		info, err := resolver.ResolveCluster(id)
+		if err != nil || (info.Provider == "" && info.Region == "") {
+			continue
+		}
+		key := locationKey(info.Provider, info.Region)
+		index[key] = append(index[key], id)
+	}
+
+	idx.mu.Lock()
+	idx.index = index
+	idx.builtAt = time.Now()
+	idx.mu.Unlock()
+	return nil
+}
+
+// ClusterIDs returns the clusters in provider and region. Either may be empty
+// to match any value; matching is case-insensitive. The index is built on
+// first use if the background job has not run yet.
+func (idx *ClusterLocationIndex) ClusterIDs(db *gorm.DB, provider, region string) ([]string, error) {
+	idx.mu.RLock()
+	built := !idx.builtAt.IsZero()
+	idx.mu.RUnlock()
+	if !built {
+		if err := idx.Rebuild(db); err != nil {
+			return nil, err
+		}
+	}
+
+	provider, region = strings.ToLower(provider), strings.ToLower(region)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	if provider != "" && region != "" {
+		return idx.index[locationKey(provider, region)], nil
+	}
+	var ids []string
+	for key, clusters := range idx.index {
+		p, r, _ := strings.Cut(key, "|")
+		if (provider == "" || p == provider) && (region == "" || r == region) {
+			ids = append(ids, clusters...)
+		}
+	}
+	return ids, nil
+}
+
+// Start rebuilds the index every interval until ctx is cancelled
+func (idx *ClusterLocationIndex) Start(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := idx.Rebuild(db); err != nil {
+				log.Printf("[WARN] Failed to rebuild cluster location index: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
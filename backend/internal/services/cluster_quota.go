@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrQuotaExceeded is returned when a cluster has reached its active alert quota
+var ErrQuotaExceeded = errors.New("cluster alert quota exceeded")
+
+// QuotaUsage is the number of unresolved alerts of a cluster against its quota.
+// A Limit of 0 means unlimited.
+type QuotaUsage struct {
+	ClusterID string `json:"cluster_id"`
+	Active    int64  `json:"active"`
+	Limit     int    `json:"limit"`
+	Default   bool   `json:"default"` // no explicit quota, Limit is ALERT_CLUSTER_QUOTA
+	Exceeded  bool   `json:"exceeded"`
+}
+
+// QuotaService manages per-cluster quotas on unresolved alerts. Clusters
+// without an entry in cluster_quotas use DefaultLimit.
+type QuotaService struct {
+	DB           *gorm.DB
+	DefaultLimit int
+}
+
+// NewQuotaService reads the default quota from ALERT_CLUSTER_QUOTA (default:
+// 0, unlimited)
+func NewQuotaService(db *gorm.DB) *QuotaService {
+	s := &QuotaService{DB: db}
+	if n, err := strconv.Atoi(os.Getenv("ALERT_CLUSTER_QUOTA")); err == nil && n > 0 {
+		s.DefaultLimit = n
+	}
+	return s
+}
+
+// ListQuotas returns all explicit quotas ordered by cluster ID
+func (s *QuotaService) ListQuotas() ([]models.ClusterQuota, error) {
+	var quotas []models.ClusterQuota
+	if err := s.DB.Order("cluster_id").Find(&quotas).Error; err != nil {
+		return nil, err
+	}
+	return quotas, nil
+}
+
+// SetQuota creates or replaces the quota of quota.ClusterID
+func (s *QuotaService) SetQuota(quota *models.ClusterQuota) error {
+	if quota.ClusterID == "" {
+		return errors.New("cluster_id is required")
+	}
+	if quota.MaxActiveAlerts < 0 {
+		return errors.New("max_active_alerts must not be negative")
+	}
+	return s.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cluster_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_active_alerts"}),
+	}).Create(quota).Error
+}
+
+// DeleteQuota removes the explicit quota of clusterID so it falls back to the
+// default. Returns gorm.ErrRecordNotFound if there is none.
+func (s *QuotaService) DeleteQuota(clusterID string) error {
+	result := s.DB.Delete(&models.ClusterQuota{}, "cluster_id = ?", clusterID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Usage counts the unresolved alerts of clusterID against its quota
+func (s *QuotaService) Usage(clusterID string) (*QuotaUsage, error) {
+	usage := &QuotaUsage{ClusterID: clusterID, Limit: s.DefaultLimit, Default: true}
+
+	var quota models.ClusterQuota
+	err := s.DB.First(&quota, "cluster_id = ?", clusterID).Error
+	switch {
+	case err == nil:
+		usage.Limit = quota.MaxActiveAlerts
+		usage.Default = false
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	err = s.DB.Model(&models.Issue{}).
+		Where("is_alert = 1 AND deleted_at IS NULL AND cluster_id = ?", clusterID).
+		Where("LOWER(status) NOT IN ?", resolvedStatuses).
+		Count(&usage.Active).Error
+	if err != nil {
+		return nil, err
+	}
+	usage.Exceeded = usage.Limit > 0 && usage.Active >= int64(usage.Limit)
+	return usage, nil
+}
+
+// Check returns ErrQuotaExceeded if a new alert of the given severity on
+// clusterID must be refused. Critical alerts and alerts without a cluster are
+// always accepted.
+func (s *QuotaService) Check(clusterID, severity string) (*QuotaUsage, error) {
+	if clusterID == "" || strings.EqualFold(severity, "Critical") {
+		return nil, nil
+	}
+	usage, err := s.Usage(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if usage.Exceeded {
+		return usage, ErrQuotaExceeded
+	}
+	return usage, nil
+}
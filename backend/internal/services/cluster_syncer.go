@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultClusterSyncInterval = time.Hour
+
+// ClusterSyncStatus summarizes the runs of a ClusterSyncer
+type ClusterSyncStatus struct {
+	Running      bool       `json:"running"`
+	Interval     string     `json:"interval"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	RowsSynced   int        `json:"rows_synced"` // clusters copied by the last run
+	TotalRows    int64      `json:"total_rows"`  // clusters held in clusters_local
+	NextSyncAt   *time.Time `json:"next_sync_at,omitempty"`
+	Cursor       *time.Time `json:"cursor,omitempty"` // newest TiDB updated_at copied so far
+	Offline      bool       `json:"offline"`          // the last run served clusters_local because TiDB was down
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// ClusterSyncer copies clusters updated in TiDB into the clusters_local SQLite
+// table every Interval and refreshes the name resolver with them. While TiDB
+// is unreachable each run loads clusters_local into the resolver instead, so
+// cluster names and details stay available read-only.
+type ClusterSyncer struct {
+	Interval time.Duration
+
+	mu           sync.Mutex
+	running      bool
+	cursor       time.Time
+	lastSyncedAt time.Time
+	rowsSynced   int
+	nextSyncAt   time.Time
+	offline      bool
+	lastErr      error
+}
+
+var (
+	clusterSyncerInstance *ClusterSyncer
+	clusterSyncerOnce     sync.Once
+)
+
+// GetClusterSyncer returns the shared syncer, running every
+// CLUSTER_SYNC_INTERVAL (default 1h)
+func GetClusterSyncer() *ClusterSyncer {
+	clusterSyncerOnce.Do(func() {
+		s := &ClusterSyncer{Interval: defaultClusterSyncInterval}
+		if d, err := time.ParseDuration(os.Getenv("CLUSTER_SYNC_INTERVAL")); err == nil && d > 0 {
+			s.Interval = d
+		}
+		clusterSyncerInstance = s
+	})
+	return clusterSyncerInstance
+}
+
+// RunOnce copies the clusters updated in TiDB since the previous run into
+// clusters_local and the resolver cache, and returns how many were copied.
+// The first run after a restart resumes from the newest row already in
+// clusters_local. When TiDB is not connected it loads clusters_local into the
+// cache instead and returns an error.
+func (s *ClusterSyncer) RunOnce(sqlite *gorm.DB) (int, error) {
+	resolver := GetNameResolver()
+
+	if !db.TiDBReady() {
+		loaded, err := resolver.loadLocalClusters(sqlite)
+		if err == nil {
+			err = fmt.Errorf("TiDB not connected, served %d clusters from clusters_local", loaded)
+		}
+		s.record(0, time.Time{}, true, err)
+		return 0, err
+	}
+
+	s.mu.Lock()
+	cursor := s.cursor
+	s.mu.Unlock()
+	if cursor.IsZero() {
+		var latest models.LocalCluster
+		if err := sqlite.Order("updated_at DESC").Limit(1).Find(&latest).Error; err != nil {
+			s.record(0, time.Time{}, false, err)
+			return 0, err
+		}
+		cursor = latest.UpdatedAt
+	}
+
+	clusters, err := resolver.getClustersUpdatedSince(cursor)
+	if err != nil {
+		err = fmt.Errorf("failed to read clusters from TiDB: %w", err)
+		s.record(0, time.Time{}, false, err)
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	records := make([]models.LocalCluster, 0, len(clusters))
+	for _, info := range clusters {
+		records = append(records, localClusterFromInfo(info, now))
+		if info.UpdatedAt.After(cursor) {
+			cursor = info.UpdatedAt
+		}
+	}
+	if len(records) > 0 {
+		if err := sqlite.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(records, 500).Error; err != nil {
+			err = fmt.Errorf("failed to write clusters_local: %w", err)
+			s.record(0, time.Time{}, false, err)
+			return 0, err
+		}
+	}
+
+	for _, info := range clusters {
+		resolver.cacheClusterDetails(info, sourceSync, now)
+	}
+	s.record(len(records), cursor, false, nil)
+	return len(records), nil
+}
+
+// Start runs RunOnce now and then every Interval until ctx is cancelled
+func (s *ClusterSyncer) Start(ctx context.Context, sqlite *gorm.DB) {
+	s.mu.Lock()
+	s.running = true
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			s.running = false
+			s.nextSyncAt = time.Time{}
+			s.mu.Unlock()
+		}()
+
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+
+		for {
+			s.mu.Lock()
+			s.nextSyncAt = time.Now().UTC().Add(s.Interval)
+			s.mu.Unlock()
+
+			if synced, err := s.RunOnce(sqlite); err != nil {
+				log.Printf("[WARN] Cluster sync: %v\n", err)
+			} else if synced > 0 {
+				log.Printf("[INFO] Synced %d clusters from TiDB\n", synced)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *ClusterSyncer) record(rows int, cursor time.Time, offline bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		s.lastSyncedAt = time.Now().UTC()
+		s.rowsSynced = rows
+		s.cursor = cursor
+	}
+	s.offline = offline
+	s.lastErr = err
+}
+
+// Status returns when the syncer last copied clusters and when it runs next
+func (s *ClusterSyncer) Status(sqlite *gorm.DB) ClusterSyncStatus {
+	s.mu.Lock()
+	status := ClusterSyncStatus{
+		Running:    s.running,
+		Interval:   s.Interval.String(),
+		RowsSynced: s.rowsSynced,
+		Offline:    s.offline,
+	}
+	if !s.lastSyncedAt.IsZero() {
+		lastSyncedAt := s.lastSyncedAt
+		status.LastSyncedAt = &lastSyncedAt
+	}
+	if !s.nextSyncAt.IsZero() {
+		nextSyncAt := s.nextSyncAt
+		status.NextSyncAt = &nextSyncAt
+	}
+	if !s.cursor.IsZero() {
+		cursor := s.cursor
+		status.Cursor = &cursor
+	}
+	if s.lastErr != nil {
+		status.LastError = s.lastErr.Error()
+	}
+	s.mu.Unlock()
+
+	sqlite.Model(&models.LocalCluster{}).Count(&status.TotalRows)
+	return status
+}
+
+// getClustersUpdatedSince reads every cluster updated in TiDB after since,
+// oldest first
+func (nr *NameResolver) getClustersUpdatedSince(since time.Time) ([]ClusterInfo, error) {
+	rows, err := db.TiDB.Query(`
+		SELECT c.cluster_id, c.cluster_name, c.tenant_id,
+		       COALESCE(NULLIF(c.tenant_name, ''), t.tenant_name, '') as tenant_name,
+		       COALESCE(c.deploy_type, '') as deploy_type,
+		       COALESCE(c.version, '') as version,
+		       COALESCE(c.cluster_lifecycle, '') as cluster_lifecycle,
+		       COALESCE(c.creation_duration, '') as creation_duration,
+		       COALESCE(c.tenant_plan, '') as tenant_plan,
+		       COALESCE(c.provider, '') as provider,
+		       COALESCE(c.region, '') as region,
+		       COALESCE(c.project_id, '') as project_id,
+		       COALESCE(c.org_id, '') as org_id,
+		       COALESCE(c.cluster_type, '') as cluster_type,
+		       c.created_at, c.updated_at
+		FROM clusters c
+		LEFT JOIN tenants t ON c.tenant_id = t.tenant_id
+		WHERE c.updated_at > ?
+		ORDER BY c.updated_at
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clusters []ClusterInfo
+	for rows.Next() {
+		var info ClusterInfo
+		if err := rows.Scan(&info.ClusterID, &info.ClusterName, &info.TenantID, &info.TenantName,
+			&info.DeployType, &info.Version, &info.ClusterLifecycle, &info.CreationDuration,
+			&info.TenantPlan, &info.Provider, &info.Region, &info.ProjectID, &info.OrgID, &info.ClusterType,
+			&info.CreatedAt, &info.UpdatedAt); err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, info)
+	}
+	return clusters, rows.Err()
+}
+
+// loadLocalClusters puts every row of clusters_local into the name and cluster
+// caches. Entries still valid from a live source are kept.
+func (nr *NameResolver) loadLocalClusters(sqlite *gorm.DB) (int, error) {
+	var records []models.LocalCluster
+	if err := sqlite.Find(&records).Error; err != nil {
+		return 0, fmt.Errorf("failed to read clusters_local: %w", err)
+	}
+
+	now := nr.now()
+	entries := make(map[string]cacheEntry, len(records))
+	nr.cacheMutex.Lock()
+	for _, rec := range records {
+		if existing, ok := nr.cache.peek(rec.ClusterID); !ok || existing.notFound || !nr.isEntryValid(existing) {
+			entries[rec.ClusterID] = cacheEntry{
+				info: NameInfo{
+					Type:       "cluster",
+					ID:         rec.ClusterID,
+					Name:       rec.ClusterName,
+					TenantID:   rec.TenantID,
+					TenantName: rec.TenantName,
+					DeployType: rec.DeployType,
+				},
+				timestamp: now,
+				source:    sourceSync,
+			}
+		}
+		if existing, ok := nr.clusterCache[rec.ClusterID]; !ok || nr.now().Sub(existing.timestamp) >= nr.detailTTL {
+			nr.clusterCache[rec.ClusterID] = clusterCacheEntry{info: clusterInfoFromLocal(rec), timestamp: now}
+		}
+	}
+	nr.cache.setMany(entries)
+	nr.cacheMutex.Unlock()
+	return len(records), nil
+}
+
+// cacheClusterDetails stores info as both the cluster's name entry and its
+// ResolveCluster details
+func (nr *NameResolver) cacheClusterDetails(info ClusterInfo, source string, now time.Time) {
+	nr.setCacheEntry(info.ClusterID, NameInfo{
+		Type:       "cluster",
+		ID:         info.ClusterID,
+		Name:       info.ClusterName,
+		TenantID:   info.TenantID,
+		TenantName: info.TenantName,
+		DeployType: info.DeployType,
+	}, false, source)
+
+	nr.cacheMutex.Lock()
+	nr.clusterCache[info.ClusterID] = clusterCacheEntry{info: info, timestamp: now}
+	nr.cacheMutex.Unlock()
+}
+
+func localClusterFromInfo(info ClusterInfo, syncedAt time.Time) models.LocalCluster {
+	return models.LocalCluster{
+		ClusterID:        info.ClusterID,
+		ClusterName:      info.ClusterName,
+		TenantID:         info.TenantID,
+		TenantName:       info.TenantName,
+		DeployType:       info.DeployType,
+		Version:          info.Version,
+		ClusterLifecycle: info.ClusterLifecycle,
+		CreationDuration: info.CreationDuration,
+		TenantPlan:       info.TenantPlan,
+		Provider:         info.Provider,
+		Region:           info.Region,
+		ProjectID:        info.ProjectID,
+		OrgID:            info.OrgID,
+		ClusterType:      info.ClusterType,
+		CreatedAt:        info.CreatedAt,
+		UpdatedAt:        info.UpdatedAt,
+		SyncedAt:         syncedAt,
+	}
+}
+
+func clusterInfoFromLocal(rec models.LocalCluster) ClusterInfo {
+	return ClusterInfo{
+		ClusterID:        rec.ClusterID,
+		ClusterName:      rec.ClusterName,
+		TenantID:         rec.TenantID,
+		TenantName:       rec.TenantName,
+		DeployType:       rec.DeployType,
+		Version:          rec.Version,
+		ClusterLifecycle: rec.ClusterLifecycle,
+		CreationDuration: rec.CreationDuration,
+		TenantPlan:       rec.TenantPlan,
+		Provider:         rec.Provider,
+		Region:           rec.Region,
+		ProjectID:        rec.ProjectID,
+		OrgID:            rec.OrgID,
+		ClusterType:      rec.ClusterType,
+		CreatedAt:        rec.CreatedAt,
+		UpdatedAt:        rec.UpdatedAt,
+	}
+}
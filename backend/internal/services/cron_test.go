@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// A Sunday
+	from := time.Date(2026, 3, 1, 12, 7, 30, 0, time.UTC)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	for _, tc := range []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"*/15 * * * *", from, at(3, 1, 12, 15)},
+		// Strictly after the current minute
+		{"*/15 * * * *", at(3, 1, 12, 15), at(3, 1, 12, 30)},
+		{"* * * * *", from, at(3, 1, 12, 8)},
+		{"0 * * * *", from, at(3, 1, 13, 0)},
+		{"5,10-12 12 * * *", from, at(3, 1, 12, 10)},
+		{"10/20 * * * *", from, at(3, 1, 12, 10)},
+		{"30 9 * * *", from, at(3, 2, 9, 30)},
+		{"0 9 * * 1-5", from, at(3, 2, 9, 0)},
+		{"0 0 * * 7", from, at(3, 8, 0, 0)},
+		{"0 0 1 * *", from, at(4, 1, 0, 0)},
+		{"0 0 1 1 *", from, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Day of month or day of week when both are restricted
+		{"0 0 15 * 1", from, at(3, 2, 0, 0)},
+		{"0 0 15 * 0", at(3, 9, 0, 0), at(3, 15, 0, 0)},
+		{"0 0 31 2 *", from, time.Time{}},
+	} {
+		s, err := parseCron(tc.spec)
+		if err != nil {
+			t.Errorf("parseCron(%q): %v", tc.spec, err)
+			continue
+		}
+		if got := s.next(tc.from); !got.Equal(tc.want) {
+			t.Errorf("%q after %v = %v, want %v", tc.spec, tc.from, got, tc.want)
+		}
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+	} {
+		if _, err := parseCron(spec); err == nil {
+			t.Errorf("parseCron(%q) succeeded", spec)
+		}
+	}
+}
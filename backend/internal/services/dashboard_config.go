@@ -0,0 +1,87 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	//go:embed dashboard_config.schema.json
+	dashboardConfigSchemaJSON []byte
+	//go:embed dashboard_config.default.json
+	defaultDashboardConfigJSON []byte
+
+	dashboardConfigSchema = mustParseJSONSchema(dashboardConfigSchemaJSON)
+)
+
+func mustParseJSONSchema(data []byte) *JSONSchema {
+	s, err := ParseJSONSchema(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// ErrNoDashboardConfig is returned when a user has not saved a dashboard config
+var ErrNoDashboardConfig = errors.New("no dashboard config saved")
+
+// DashboardConfigService stores the dashboard layout of each user
+type DashboardConfigService struct {
+	DB *gorm.DB
+}
+
+func NewDashboardConfigService(db *gorm.DB) *DashboardConfigService {
+	return &DashboardConfigService{DB: db}
+}
+
+// DefaultDashboardConfig returns the system default config, used by users who
+// have not saved their own
+func DefaultDashboardConfig() json.RawMessage {
+	return json.RawMessage(defaultDashboardConfigJSON)
+}
+
+// ValidateDashboardConfig checks config against the embedded
+// dashboard_config.schema.json
+func ValidateDashboardConfig(config []byte) []ValidationError {
+	return dashboardConfigSchema.Validate(config)
+}
+
+// Get returns the config saved by userID, or ErrNoDashboardConfig
+func (s *DashboardConfigService) Get(userID string) (*models.DashboardConfig, error) {
+	var config models.DashboardConfig
+	err := s.DB.Where("user_id = ?", userID).First(&config).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoDashboardConfig
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Put validates config and saves it as the config of userID, replacing any
+// previous one. Validation problems are returned without saving.
+func (s *DashboardConfigService) Put(userID string, config json.RawMessage) (*models.DashboardConfig, []ValidationError, error) {
+	if errs := ValidateDashboardConfig(config); len(errs) > 0 {
+		return nil, errs, nil
+	}
+	record := models.DashboardConfig{
+		UserID:    userID,
+		Config:    config,
+		UpdatedAt: time.Now().UTC(),
+	}
+	err := s.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"config", "updated_at"}),
+	}).Create(&record).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	return &record, nil, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateDashboardConfig(t *testing.T) {
+	if errs := ValidateDashboardConfig(DefaultDashboardConfig()); len(errs) > 0 {
+		t.Errorf("default config is invalid: %v", errs)
+	}
+	for _, valid := range []string{
+		`{}`,
+		`{"panels": [{"id": "trend"}], "filters": {"biz_type": "tidbcloud"}, "refresh_interval_seconds": 0}`,
+	} {
+		if errs := ValidateDashboardConfig([]byte(valid)); len(errs) > 0 {
+			t.Errorf("%s: %v", valid, errs)
+		}
+	}
+
+	for config, fields := range map[string]string{
+		`[]`:                                "$",
+		`{"panels": `:                       "$",
+		`{"theme": "dark"}`:                 "theme",
+		`{"panels": [{"visible": true}]}`:   "panels[0].id",
+		`{"panels": [{"id": ""}]}`:          "panels[0].id",
+		`{"panels": [{"id": "a", "x": 1}]}`: "panels[0].x",
+		`{"panels": [{"id": "a", "width": 13}, {"id": "b", "width": 1.5}]}`: "panels[0].width panels[1].width",
+		`{"default_time_range": "2h"}`:                                      "default_time_range",
+		`{"refresh_interval_seconds": -1}`:                                  "refresh_interval_seconds",
+		`{"refresh_interval_seconds": "60"}`:                                "refresh_interval_seconds",
+		`{"filters": {"env": 1}}`:                                           "filters.env",
+		`{"panels": {"id": "a"}, "timezone": 1}`:                            "panels timezone",
+	} {
+		var got []string
+		for _, e := range ValidateDashboardConfig([]byte(config)) {
+			got = append(got, e.Field)
+		}
+		if strings.Join(got, " ") != fields {
+			t.Errorf("%s: errors on %v, want %s", config, got, fields)
+		}
+	}
+
+	var panels []string
+	for i := 0; i < 51; i++ {
+		panels = append(panels, `{"id": "p"}`)
+	}
+	if errs := ValidateDashboardConfig([]byte(`{"panels": [` + strings.Join(panels, ",") + `]}`)); len(errs) != 1 || errs[0].Field != "panels" {
+		t.Errorf("51 panels: %v, want one error on panels", errs)
+	}
+}
+
+func TestDashboardConfigService(t *testing.T) {
+	s := NewDashboardConfigService(openTestDB(t))
+
+	if _, err := s.Get("ops@example.com"); !errors.Is(err, ErrNoDashboardConfig) {
+		t.Fatalf("Get before Put = %v, want ErrNoDashboardConfig", err)
+	}
+
+	first := json.RawMessage(`{"default_time_range": "7d"}`)
+	if _, errs, err := s.Put("ops@example.com", first); err != nil || len(errs) > 0 {
+		t.Fatalf("Put = %v, %v", errs, err)
+	}
+	second := json.RawMessage(`{"default_time_range": "1h", "panels": [{"id": "trend"}]}`)
+	saved, errs, err := s.Put("ops@example.com", second)
+	if err != nil || len(errs) > 0 {
+		t.Fatalf("second Put = %v, %v", errs, err)
+	}
+
+	// Put replaces the previous config of the user
+	got, err := s.Get("ops@example.com")
+	if err != nil || string(got.Config) != string(second) || !got.UpdatedAt.Equal(saved.UpdatedAt) {
+		t.Errorf("Get = %+v, %v, want %s saved at %v", got, err, second, saved.UpdatedAt)
+	}
+	var n int64
+	s.DB.Table("dashboard_configs").Count(&n)
+	if n != 1 {
+		t.Errorf("%d rows after two Puts of one user, want 1", n)
+	}
+
+	// Invalid configs are not saved, and users do not share configs
+	if _, errs, err := s.Put("ops@example.com", json.RawMessage(`{"timezone": 1}`)); err != nil || len(errs) != 1 {
+		t.Errorf("invalid Put = %v, %v, want one validation error", errs, err)
+	}
+	if got, _ := s.Get("ops@example.com"); string(got.Config) != string(second) {
+		t.Errorf("config after an invalid Put = %s", got.Config)
+	}
+	if _, err := s.Get("dev@example.com"); !errors.Is(err, ErrNoDashboardConfig) {
+		t.Errorf("another user's config = %v, want ErrNoDashboardConfig", err)
+	}
+}
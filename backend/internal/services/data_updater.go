@@ -3,9 +3,11 @@ package services
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 )
@@ -15,26 +17,56 @@ type DataUpdater struct {
 	db         *sql.DB
 	jiraClient *JiraClient
 	logger     *log.Logger
+
+	// dedupKey computes IssueData.DedupKey; nil leaves it empty
+	dedupKey func(labels map[string]string) (string, error)
+
+	// router notifies about alerts that are new in an incremental update; nil disables routing
+	router *RoutingService
+	// notifier sends alerts that are new in an incremental update to webhook channels; nil disables it
+	notifier *NotificationService
+	// suppressor marks alerts of clusters being created or deleted; nil disables suppression
+	suppressor *LifecycleSuppressor
+	// versionFilters returns the filters suppressing known false positives of cluster versions; nil disables them
+	versionFilters func() *VersionFilters
+	// anomaly watches the volume of new alerts for spikes; nil disables it
+	anomaly *AnomalyDetector
+	// quota refuses new non-critical alerts of clusters over their quota; nil disables it
+	quota *QuotaService
+	// fingerprinter folds repeats of active alerts into them; nil disables it
+	fingerprinter *Fingerprinter
+	// flaps suppresses alerts of fingerprints that fire and resolve repeatedly; nil disables it
+	flaps *FlapDetector
+	// labelSchema validates the labels of alerts; nil disables validation
+	labelSchema *LabelSchema
+	// rejectInvalid drops alerts failing labelSchema instead of flagging them
+	rejectInvalid bool
 }
 
+// errNoJiraClient is returned by the JIRA syncs of an alert ingester
+var errNoJiraClient = errors.New("JIRA client not configured")
+
 // IssueData represents processed issue data ready for database insertion
 type IssueData struct {
-	ID             string
-	Title          string
-	Description    string
-	Created        string
-	Priority       string
-	Labels         string // JSON array
-	IssueType      string
-	Components     string // JSON array
-	Project        string
-	IsAlert        bool
-	AlertSignature string
-	ClusterID      string
-	TenantID       string
-	BizType        string
-	Status         string
-	IsSubtask      bool
+	ID                string
+	Title             string
+	Description       string
+	Created           string
+	Priority          string
+	Labels            string // JSON array
+	IssueType         string
+	Components        string // JSON array
+	Project           string
+	IsAlert           bool
+	AlertSignature    string
+	DedupKey          string
+	Suppressed        bool   // set by the lifecycle suppressor, a version filter or the flap detector, suppressed alerts are not routed
+	SuppressionReason string // SuppressionLifecycle, SuppressionVersionFilter or SuppressionFlapping
+	ClusterID         string
+	TenantID          string
+	BizType           string
+	Status            string
+	IsSubtask         bool
 
 	// New fields
 	StabilityGovernance string
@@ -42,6 +74,15 @@ type IssueData struct {
 	ComponentName       string
 	SourceComponent     string
 	AlertGroup          string
+
+	Annotations         string // JSON object of annotation templates from the raw alert data
+	RenderedAnnotations string // JSON object of the expanded annotations
+
+	Fingerprint string // see Fingerprinter
+	DuplicateOf string // ID of the active alert this one was folded into, not stored itself
+
+	AlertLabels map[string]string // stored as rows of alert_labels
+	LabelErrors []ValidationError // AlertLabels failing the label schema, stored as issues.label_errors
 }
 
 // NewDataUpdater creates a new data updater
@@ -58,11 +99,23 @@ func NewDataUpdater(db *sql.DB) (*DataUpdater, error) {
 	}, nil
 }
 
+// NewAlertIngester returns a DataUpdater without a JIRA client, for alerts
+// pushed to the platform with IngestAlert. Syncing from JIRA fails.
+func NewAlertIngester(db *sql.DB) *DataUpdater {
+	return &DataUpdater{
+		db:     db,
+		logger: log.Default(),
+	}
+}
+
 // FetchInitialData fetches initial data for the last N days
 func (u *DataUpdater) FetchInitialData(daysBack int) (int, error) {
 	u.logger.Printf("[INFO] Starting initial data fetch for last %d days\n", daysBack)
 
 	// Test connection first
+	if u.jiraClient == nil {
+		return 0, errNoJiraClient
+	}
 	if err := u.jiraClient.TestConnection(); err != nil {
 		return 0, fmt.Errorf("JIRA connection test failed: %w", err)
 	}
@@ -84,7 +137,7 @@ func (u *DataUpdater) FetchInitialData(daysBack int) (int, error) {
 	// Process and store issues
 	successCount := 0
 	for i, issue := range allIssues {
-		if u.processIssue(&issue) {
+		if _, ok := u.processIssue(&issue, false); ok {
 			successCount++
 		}
 
@@ -104,6 +157,9 @@ func (u *DataUpdater) IncrementalUpdate() (int, error) {
 	u.logger.Println("[INFO] Starting incremental update")
 
 	// Test connection first
+	if u.jiraClient == nil {
+		return 0, errNoJiraClient
+	}
 	if err := u.jiraClient.TestConnection(); err != nil {
 		return 0, fmt.Errorf("JIRA connection test failed: %w", err)
 	}
@@ -143,7 +199,7 @@ func (u *DataUpdater) IncrementalUpdate() (int, error) {
 	// Process and store issues
 	successCount := 0
 	for i, issue := range allIssues {
-		if u.processIssue(&issue) {
+		if _, ok := u.processLiveIssue(&issue); ok {
 			successCount++
 		}
 
@@ -158,6 +214,38 @@ func (u *DataUpdater) IncrementalUpdate() (int, error) {
 	return successCount, nil
 }
 
+// processLiveIssue stores an issue that is changing now, and routes,
+// broadcasts and resolves the PagerDuty incident of its alert as needed
+func (u *DataUpdater) processLiveIssue(issue *JiraIssue) (*IssueData, bool) {
+	// Only alerts seen for the first time are routed, not updates of known ones
+	prev, exists := u.previousState(issue.Key)
+	data, ok := u.processIssue(issue, true)
+	if !ok {
+		return data, false
+	}
+
+	// A repeat folded into an active alert is not a new alert
+	exists = exists || data.DuplicateOf != ""
+	if !exists && data.IsAlert && !data.Suppressed && (u.router != nil || u.notifier != nil) {
+		go u.routeAlert(data)
+	}
+	if !exists && data.IsAlert && u.anomaly != nil {
+		u.observeAlert(data)
+	}
+	if data.IsAlert {
+		if !exists {
+			GetAlertBroadcaster().Publish(AlertEventCreated, alertFromData(data))
+		} else if !isResolvedStatus(prev.status) && isResolvedStatus(data.Status) {
+			GetAlertBroadcaster().Publish(AlertEventResolved, alertFromData(data))
+		}
+	}
+	// Close the PagerDuty incident once the alert is resolved
+	if u.router != nil && prev.incidentKey != "" && !isResolvedStatus(prev.status) && isResolvedStatus(data.Status) {
+		go u.resolveAlert(data, prev.incidentKey)
+	}
+	return data, true
+}
+
 // fetchAllO11YAlerts fetches all alerts from O11Y-related projects
 func (u *DataUpdater) fetchAllO11YAlerts(startDate, endDate time.Time) ([]JiraIssue, error) {
 	projects := []struct {
@@ -200,12 +288,210 @@ func (u *DataUpdater) fetchAllO11YAlerts(startDate, endDate time.Time) ([]JiraIs
 }
 
 // processIssue processes and stores a single JIRA issue
-func (u *DataUpdater) processIssue(issue *JiraIssue) bool {
+// processIssue stores issue. live is set for issues fetched by an
+// incremental update, whose state changes are happening now; only those are
+// tracked by the flap detector.
+func (u *DataUpdater) processIssue(issue *JiraIssue, live bool) (*IssueData, bool) {
 	// Extract data
 	issueData := u.extractIssueData(issue)
 
+	if u.invalidLabels(issueData) {
+		return issueData, false
+	}
+	if u.overQuota(issueData) {
+		return issueData, false
+	}
+	if u.foldDuplicate(issueData) {
+		return issueData, true
+	}
+	if live {
+		u.markFlapping(issueData)
+	}
+
 	// Insert or update in database
-	return u.insertOrUpdateIssue(issueData)
+	return issueData, u.insertOrUpdateIssue(issueData)
+}
+
+// SetFingerprinter enables folding repeats of active alerts into them
+func (u *DataUpdater) SetFingerprinter(fingerprinter *Fingerprinter) {
+	u.fingerprinter = fingerprinter
+}
+
+// foldDuplicate reports whether data is a new alert with the fingerprint of an
+// active one, and if so counts it as an occurrence of that alert instead of
+// storing it. An occurrence is only counted when it is newer than the latest
+// one seen, so syncing the same issues again does not count them twice.
+func (u *DataUpdater) foldDuplicate(data *IssueData) bool {
+	if data.Fingerprint == "" || isResolvedStatus(data.Status) {
+		return false
+	}
+	if _, exists := u.previousState(data.ID); exists {
+		return false
+	}
+
+	placeholders, args := inClause(resolvedStatuses)
+	var activeID, lastSeen string
+	err := u.db.QueryRow(`
+		SELECT id, COALESCE(NULLIF(updated_at, ''), created)
+		FROM issues
+		WHERE fingerprint = ? AND is_alert = 1 AND deleted_at IS NULL
+		AND LOWER(status) NOT IN (`+placeholders+`)
+		ORDER BY created ASC
+		LIMIT 1
+	`, append([]interface{}{data.Fingerprint}, args...)...).Scan(&activeID, &lastSeen)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	if err != nil {
+		u.logger.Printf("[WARN] Failed to look up alerts with fingerprint of %s: %v\n", data.ID, err)
+		return false
+	}
+
+	data.DuplicateOf = activeID
+	if data.Created > lastSeen {
+		_, err = u.db.Exec("UPDATE issues SET occurrence_count = COALESCE(occurrence_count, 1) + 1, updated_at = ? WHERE id = ?", data.Created, activeID)
+		if err != nil {
+			u.logger.Printf("[WARN] Failed to count %s as an occurrence of %s: %v\n", data.ID, activeID, err)
+		}
+	}
+	return true
+}
+
+// SetFlapDetector enables suppressing the alerts of flapping fingerprints
+func (u *DataUpdater) SetFlapDetector(flaps *FlapDetector) {
+	u.flaps = flaps
+}
+
+// markFlapping records the fire/resolve transition data makes, if any, and
+// suppresses data when it is a new firing alert of a flapping fingerprint.
+// New alerts fire when they were created; resolutions are recorded as they
+// are seen.
+func (u *DataUpdater) markFlapping(data *IssueData) {
+	if u.flaps == nil || !data.IsAlert || data.Fingerprint == "" {
+		return
+	}
+	firing := !isResolvedStatus(data.Status)
+	prev, exists := u.previousState(data.ID)
+
+	var flapping bool
+	switch {
+	case !exists:
+		created, err := time.Parse("2006-01-02 15:04:05", strings.TrimSuffix(data.Created, " UTC"))
+		if err != nil {
+			created = time.Now()
+		}
+		flapping = u.flaps.RecordTransition(data.Fingerprint, true, created)
+		if !firing {
+			flapping = u.flaps.RecordTransition(data.Fingerprint, false, time.Now())
+		}
+	case isResolvedStatus(prev.status) == firing:
+		flapping = u.flaps.RecordTransition(data.Fingerprint, firing, time.Now())
+	default:
+		return
+	}
+
+	if flapping && !exists && firing && !data.Suppressed {
+		data.Suppressed, data.SuppressionReason = true, SuppressionFlapping
+		u.logger.Printf("[INFO] Suppressed alert %s: fingerprint %s is flapping\n", data.ID, data.Fingerprint)
+	}
+}
+
+// SetLabelSchema validates the labels of every alert against schema. Alerts
+// failing validation are stored with their errors in issues.label_errors, or
+// dropped when reject is set.
+func (u *DataUpdater) SetLabelSchema(schema *LabelSchema, reject bool) {
+	u.labelSchema = schema
+	u.rejectInvalid = reject
+}
+
+// invalidLabels reports whether data is an alert dropped because its labels
+// fail the label schema
+func (u *DataUpdater) invalidLabels(data *IssueData) bool {
+	if len(data.LabelErrors) == 0 {
+		return false
+	}
+	if u.rejectInvalid {
+		u.logger.Printf("[WARN] Dropped alert %s with invalid labels: %v\n", data.ID, data.LabelErrors)
+		return true
+	}
+	u.logger.Printf("[WARN] Alert %s has invalid labels: %v\n", data.ID, data.LabelErrors)
+	return false
+}
+
+// SetQuota enables per-cluster active alert quotas
+func (u *DataUpdater) SetQuota(quota *QuotaService) {
+	u.quota = quota
+}
+
+// overQuota reports whether data is a new unresolved alert refused by the
+// cluster quota. Updates of stored alerts are always accepted.
+func (u *DataUpdater) overQuota(data *IssueData) bool {
+	if u.quota == nil || !data.IsAlert || isResolvedStatus(data.Status) {
+		return false
+	}
+	if _, exists := u.previousState(data.ID); exists {
+		return false
+	}
+
+	usage, err := u.quota.Check(data.ClusterID, data.Priority)
+	if errors.Is(err, ErrQuotaExceeded) {
+		u.logger.Printf("[WARN] Dropped alert %s: cluster %s has %d active alerts (quota %d)\n", data.ID, data.ClusterID, usage.Active, usage.Limit)
+		return true
+	}
+	if err != nil {
+		u.logger.Printf("[WARN] Failed to check alert quota of cluster %s: %v\n", data.ClusterID, err)
+	}
+	return false
+}
+
+// SetRouter enables notification routing for new alerts
+func (u *DataUpdater) SetRouter(router *RoutingService) {
+	u.router = router
+}
+
+// SetNotifier enables webhook channel notifications for new alerts
+func (u *DataUpdater) SetNotifier(notifier *NotificationService) {
+	u.notifier = notifier
+}
+
+// issueState is the stored state of an issue before an update
+type issueState struct {
+	status      string
+	incidentKey string
+}
+
+// previousState returns the stored state of issue id and whether it exists
+func (u *DataUpdater) previousState(id string) (issueState, bool) {
+	var s issueState
+	err := u.db.QueryRow("SELECT COALESCE(status, ''), COALESCE(pd_incident_key, '') FROM issues WHERE id = ?", id).Scan(&s.status, &s.incidentKey)
+	return s, err == nil
+}
+
+// isResolvedStatus reports whether a JIRA status ends the alert
+// resolvedStatuses are the lowercased JIRA statuses of a resolved alert
+var resolvedStatuses = []string{"resolved", "closed", "done", "fake alarm"}
+
+func isResolvedStatus(status string) bool {
+	return slices.Contains(resolvedStatuses, strings.ToLower(status))
+}
+
+func (u *DataUpdater) resolveAlert(data *IssueData, incidentKey string) {
+	if err := u.router.ResolveAlert(data, incidentKey); err != nil {
+		u.logger.Printf("[WARN] Failed to resolve PagerDuty incident of %s: %v\n", data.ID, err)
+	}
+}
+
+func (u *DataUpdater) routeAlert(data *IssueData) {
+	if u.router != nil {
+		if err := u.router.RouteAlert(data); err != nil {
+			u.logger.Printf("[WARN] Failed to route alert %s: %v\n", data.ID, err)
+		}
+	}
+	if u.notifier != nil {
+		if err := u.notifier.NotifyAlert(data); err != nil {
+			u.logger.Printf("[WARN] Failed to notify channels of alert %s: %v\n", data.ID, err)
+		}
+	}
 }
 
 // extractIssueData extracts and processes issue data
@@ -261,6 +547,7 @@ func (u *DataUpdater) extractIssueData(issue *JiraIssue) *IssueData {
 	data.IsAlert = u.isAlert(data.Description, data.Title)
 	if data.IsAlert {
 		data.AlertSignature = data.Title
+		data.Priority = GetSeverityMapper().Map(data.Priority)
 	}
 
 	// Extract cluster_id, tenant_id, biz_type
@@ -269,6 +556,12 @@ func (u *DataUpdater) extractIssueData(issue *JiraIssue) *IssueData {
 		data.ClusterID, data.TenantID, data.BizType, data.Labels, data.StabilityGovernance, data.Visibility, data.ComponentName, data.SourceComponent, data.AlertGroup = u.extractFromRawAlertData(issue.Fields.RawAlertData, issue.Fields.Labels)
 	}
 
+	// Annotations of the raw alert data, rendered once the IDs are known
+	var annotations, rawLabels map[string]string
+	if issue.Fields.RawAlertData != nil {
+		annotations, rawLabels = rawAlertAnnotations(issue.Fields.RawAlertData)
+	}
+
 	// Fallback to description if not found in raw alert data
 	if data.ClusterID == "" || data.TenantID == "" || data.BizType == "" {
 		clusterID, tenantID := u.extractIDsFromDescription(data.Description)
@@ -285,14 +578,126 @@ func (u *DataUpdater) extractIssueData(issue *JiraIssue) *IssueData {
 
 	// NEW: Try to resolve tenant_id from cluster_id if still missing
 	if data.TenantID == "" && data.ClusterID != "" {
-		if info, err := GetNameResolver().Resolve(data.ClusterID); err == nil && info.TenantID != "" {
+		if info, err := GetNameService().Resolve(data.ClusterID); err == nil && info.TenantID != "" {
 			data.TenantID = info.TenantID
 		}
 	}
 
+	if len(annotations) > 0 {
+		alertName := rawLabels["alertname"]
+		if alertName == "" {
+			alertName = data.AlertSignature
+		}
+		rendered := RenderAnnotations(u.db, annotations, AnnotationData{
+			AlertName: alertName,
+			Severity:  data.Priority,
+			ClusterID: data.ClusterID,
+			TenantID:  data.TenantID,
+			Labels:    rawLabels,
+		})
+		data.Annotations = u.toJSON(annotations)
+		data.RenderedAnnotations = u.toJSON(rendered)
+	}
+
+	if data.IsAlert && u.dedupKey != nil {
+		key, err := u.dedupKey(dedupLabels(data))
+		if err != nil {
+			u.logger.Printf("[WARN] Failed to compute dedup key for %s: %v\n", data.ID, err)
+		} else {
+			data.DedupKey = key
+		}
+	}
+
+	if data.IsAlert {
+		data.AlertLabels = alertLabels(data, rawLabels)
+		if u.labelSchema != nil {
+			data.LabelErrors = u.labelSchema.Validate(data.AlertLabels)
+		}
+	}
+
+	if data.IsAlert && u.fingerprinter != nil {
+		data.Fingerprint = u.fingerprinter.Fingerprint(data.AlertLabels)
+	}
+
+	if data.IsAlert && u.suppressor != nil && u.suppressor.ShouldSuppress(data.ClusterID, data.Priority) {
+		data.Suppressed, data.SuppressionReason = true, SuppressionLifecycle
+	}
+
+	if data.IsAlert && !data.Suppressed && u.versionFilters != nil &&
+		u.versionFilters().ShouldSuppress(data.ClusterID, data.AlertLabels["alertname"]) {
+		data.Suppressed, data.SuppressionReason = true, SuppressionVersionFilter
+	}
+
 	return data
 }
 
+// SetAnomalyDetector feeds every new alert to the given spike detector
+func (u *DataUpdater) SetAnomalyDetector(detector *AnomalyDetector) {
+	u.anomaly = detector
+}
+
+// observeAlert counts a new alert in the minute it was created
+func (u *DataUpdater) observeAlert(data *IssueData) {
+	created, err := time.Parse("2006-01-02 15:04:05", strings.TrimSuffix(data.Created, " UTC"))
+	if err != nil {
+		created = time.Now()
+	}
+	u.anomaly.Observe(alertFromData(data), created)
+}
+
+// SetSuppressor enables lifecycle based alert suppression
+func (u *DataUpdater) SetSuppressor(suppressor *LifecycleSuppressor) {
+	u.suppressor = suppressor
+}
+
+// SetVersionFilters enables version based alert suppression. filters is called
+// for every alert so reloaded filters apply right away.
+func (u *DataUpdater) SetVersionFilters(filters func() *VersionFilters) {
+	u.versionFilters = filters
+}
+
+// SetDedupKeyFunc sets the function used to compute each alert's deduplication
+// key (see services/dedup)
+func (u *DataUpdater) SetDedupKeyFunc(fn func(labels map[string]string) (string, error)) {
+	u.dedupKey = fn
+}
+
+// dedupLabels returns the labels that identify an alert for deduplication
+func dedupLabels(data *IssueData) map[string]string {
+	return map[string]string{
+		"alert_signature":  data.AlertSignature,
+		"cluster_id":       data.ClusterID,
+		"tenant_id":        data.TenantID,
+		"biz_type":         data.BizType,
+		"component":        data.ComponentName,
+		"source_component": data.SourceComponent,
+		"alertgroup":       data.AlertGroup,
+	}
+}
+
+// alertLabels returns the raw alert labels with the fields extracted
+// from the issue taking precedence
+func alertLabels(data *IssueData, rawLabels map[string]string) map[string]string {
+	labels := make(map[string]string, len(rawLabels)+4)
+	for k, v := range rawLabels {
+		labels[k] = v
+	}
+	if labels["alertname"] == "" {
+		labels["alertname"] = data.AlertSignature
+	}
+	for k, v := range map[string]string{
+		"cluster_id": data.ClusterID,
+		"tenant_id":  data.TenantID,
+		"severity":   data.Priority,
+		"component":  data.ComponentName,
+	} {
+		if v != "" {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
 // convertPriority converts priority names (including Chinese)
 func (u *DataUpdater) convertPriority(priority string) string {
 	mapping := map[string]string{
@@ -378,6 +783,7 @@ func (u *DataUpdater) extractFromRawAlertData(rawData interface{}, existingLabel
 	if !ok {
 		return "", "", "", u.toJSON(existingLabels), "", "", "", "", ""
 	}
+	labels = Normalize(GetLabelNormalizer(), labels)
 
 	// Basic fields
 	clusterID, _ := labels["tidb_cluster_id"].(string)
@@ -471,12 +877,44 @@ func (u *DataUpdater) insertOrUpdateIssue(data *IssueData) bool {
 	query := `
 		INSERT OR REPLACE INTO issues (
 			id, title, description, created, priority, labels, issue_type,
-			components, project, is_alert, alert_signature, cluster_id,
-			tenant_id, biz_type, status, is_subtask,
-			stability_governance, visibility, component_name, source_component, alert_group
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			components, project, is_alert, alert_signature, dedup_key, cluster_id,
+			tenant_id, biz_type, is_subtask,
+			stability_governance, visibility, component_name, source_component, alert_group,
+			annotations, rendered_annotations,
+			deleted_at, pd_incident_key, suppressed, suppression_reason,
+			status, resolved_at, auto_resolved,
+			fingerprint, occurrence_count, updated_at,
+			escalated_at, label_errors
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+			(SELECT deleted_at FROM issues WHERE id = ?),
+			(SELECT pd_incident_key FROM issues WHERE id = ?),
+			COALESCE((SELECT suppressed FROM issues WHERE id = ?), ?),
+			COALESCE((SELECT COALESCE(suppression_reason, '') FROM issues WHERE id = ?), ?),
+			?,
+			COALESCE((SELECT resolved_at FROM issues WHERE id = ? AND ?), ?),
+			COALESCE((SELECT auto_resolved FROM issues WHERE id = ? AND ?), 0),
+			?,
+			COALESCE((SELECT occurrence_count FROM issues WHERE id = ?), 1),
+			COALESCE((SELECT NULLIF(updated_at, '') FROM issues WHERE id = ?), ?),
+			(SELECT escalated_at FROM issues WHERE id = ?),
+			?)
 	`
 
+	// resolved_at records when a sync first saw the alert resolved. An alert
+	// synced as not resolved is open again, even one the stale reaper resolved,
+	// and loses both resolved_at and auto_resolved.
+	resolved := isResolvedStatus(data.Status)
+	var resolvedAt *time.Time
+	if resolved {
+		now := time.Now().UTC()
+		resolvedAt = &now
+	}
+
+	var labelErrors string
+	if len(data.LabelErrors) > 0 {
+		labelErrors = u.toJSON(data.LabelErrors)
+	}
+
 	_, err := u.db.Exec(
 		query,
 		data.ID,
@@ -490,16 +928,36 @@ func (u *DataUpdater) insertOrUpdateIssue(data *IssueData) bool {
 		data.Project,
 		data.IsAlert,
 		data.AlertSignature,
+		data.DedupKey,
 		data.ClusterID,
 		data.TenantID,
 		data.BizType,
-		data.Status,
 		data.IsSubtask,
 		data.StabilityGovernance,
 		data.Visibility,
 		data.ComponentName,
 		data.SourceComponent,
 		data.AlertGroup,
+		data.Annotations,
+		data.RenderedAnnotations,
+		data.ID, // keep a soft-delete across re-syncs
+		data.ID, // and the PagerDuty incident
+		data.ID, // suppression is decided when the alert is first stored
+		data.Suppressed,
+		data.ID,
+		data.SuppressionReason,
+		data.Status,
+		data.ID,
+		resolved,
+		resolvedAt,
+		data.ID,
+		resolved,
+		data.Fingerprint,
+		data.ID, // occurrences are counted by foldDuplicate
+		data.ID,
+		data.Created,
+		data.ID, // an escalated alert is not escalated again
+		labelErrors,
 	)
 
 	if err != nil {
@@ -507,5 +965,33 @@ func (u *DataUpdater) insertOrUpdateIssue(data *IssueData) bool {
 		return false
 	}
 
+	if data.IsAlert {
+		if err := u.replaceAlertLabels(data.ID, data.AlertLabels); err != nil {
+			u.logger.Printf("[WARN] Failed to store labels of alert %s: %v\n", data.ID, err)
+		}
+	}
+
 	return true
 }
+
+// replaceAlertLabels replaces the alert_labels rows of alertID with labels
+func (u *DataUpdater) replaceAlertLabels(alertID string, labels map[string]string) error {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM alert_labels WHERE alert_id = ?", alertID); err != nil {
+		return err
+	}
+	for key, value := range labels {
+		if value == "" {
+			continue
+		}
+		if _, err := tx.Exec("INSERT INTO alert_labels (alert_id, key, value) VALUES (?, ?, ?)", alertID, key, value); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
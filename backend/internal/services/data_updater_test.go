@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+func TestSyncReopensResolvedAlerts(t *testing.T) {
+	u := newTestIngester(t, false)
+	sqlite := db.DB
+	created := time.Now().UTC().Add(-48*time.Hour).Format("2006-01-02 15:04:05") + " UTC"
+	store := func(id, status string) models.Issue {
+		t.Helper()
+		if !u.insertOrUpdateIssue(&IssueData{ID: id, Title: "TiKVDown", Created: created, Priority: "Critical", Labels: "[]", IsAlert: true, Status: status}) {
+			t.Fatalf("store %s as %s", id, status)
+		}
+		var issue models.Issue
+		if err := sqlite.First(&issue, "id = ?", id).Error; err != nil {
+			t.Fatal(err)
+		}
+		return issue
+	}
+
+	resolved := store("A-1", "Resolved")
+	if resolved.ResolvedAt == nil {
+		t.Fatal("resolved_at not set for a resolved alert")
+	}
+	if again := store("A-1", "Closed"); again.ResolvedAt == nil || !again.ResolvedAt.Equal(*resolved.ResolvedAt) {
+		t.Errorf("resolved_at = %v after a later sync, want the first %v", again.ResolvedAt, resolved.ResolvedAt)
+	}
+	if reopened := store("A-1", "Created"); reopened.ResolvedAt != nil || reopened.Status != "Created" {
+		t.Errorf("reopened alert: status %q, resolved_at %v, want open with no resolved_at", reopened.Status, reopened.ResolvedAt)
+	}
+
+	// An alert the stale reaper resolved is open again when synced as open
+	store("A-2", "Created")
+	if _, err := (&StaleAlertReaper{StaleAfter: 24 * time.Hour}).RunOnce(sqlite); err != nil {
+		t.Fatal(err)
+	}
+	if reaped := store("A-2", "Done"); !reaped.AutoResolved || reaped.ResolvedAt == nil {
+		t.Errorf("auto-resolved alert synced as resolved: auto_resolved %v, resolved_at %v, want both kept", reaped.AutoResolved, reaped.ResolvedAt)
+	}
+	if reopened := store("A-2", "Created"); reopened.AutoResolved || reopened.ResolvedAt != nil || reopened.Status != "Created" {
+		t.Errorf("auto-resolved alert synced as open: status %q, auto_resolved %v, resolved_at %v, want open", reopened.Status, reopened.AutoResolved, reopened.ResolvedAt)
+	}
+}
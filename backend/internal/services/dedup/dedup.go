@@ -0,0 +1,79 @@
+// Package dedup derives stable keys for collapsing duplicate alerts whose
+// labels identify the same cluster or tenant in different ways.
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// entityLabels maps the label names alerts use for a cluster or tenant to the
+// canonical label they are folded into
+var entityLabels = map[string]string{
+	"cluster_id":      "cluster",
+	"tidb_cluster_id": "cluster",
+	"cluster_name":    "cluster",
+	"cluster":         "cluster",
+	"tenant_id":       "tenant",
+	"o11y_tenant_id":  "tenant",
+	"tenant_name":     "tenant",
+	"tenant":          "tenant",
+}
+
+// DeduplicationKeyFor returns a SHA-256 hex key for labels. Cluster and tenant
+// labels are folded into canonical "cluster"/"tenant" labels holding the
+// resolved name, so an alert labelled by ID and one labelled by name produce
+// the same key. The remaining labels are kept as-is, sorted and URL-encoded
+// before hashing. IDs the resolver does not know are used verbatim.
+func DeduplicationKeyFor(labels map[string]string, resolver services.NameService) (string, error) {
+	normalized := make(map[string]string, len(labels))
+	for k, v := range labels {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		canonical, ok := entityLabels[strings.ToLower(k)]
+		if !ok {
+			normalized[k] = v
+			continue
+		}
+
+		name := v
+		if resolver != nil {
+			info, err := resolver.Resolve(v)
+			if err != nil && !errors.Is(err, services.ErrIDNotFound) {
+				return "", err
+			}
+			if err == nil && info.Name != "" {
+				name = info.Name
+			}
+		}
+		normalized[canonical] = strings.ToLower(name)
+	}
+
+	keys := make([]string, 0, len(normalized))
+	for k := range normalized {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(url.QueryEscape(k))
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(normalized[k]))
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	escalationInterval = time.Minute
+	// escalationLookback bounds how long after firing an alert may still be
+	// escalated, so a new policy does not page for every old open alert
+	escalationLookback = 24 * time.Hour
+)
+
+// EscalationService manages escalation policies
+type EscalationService struct {
+	DB *gorm.DB
+}
+
+func NewEscalationService(db *gorm.DB) *EscalationService {
+	return &EscalationService{DB: db}
+}
+
+// ListPolicies returns all escalation policies ordered by id
+func (s *EscalationService) ListPolicies() ([]models.EscalationPolicy, error) {
+	var policies []models.EscalationPolicy
+	if err := s.DB.Order("id asc").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// CreatePolicy validates and stores a new escalation policy
+func (s *EscalationService) CreatePolicy(policy *models.EscalationPolicy) error {
+	if policy.AckWindowSeconds <= 0 {
+		return errors.New("ack_window_seconds must be positive")
+	}
+	if hasLabelFilter(policy.AlertFilter) {
+		if _, err := ParseMatchers(policy.AlertFilter); err != nil {
+			return fmt.Errorf("alert_filter: %w", err)
+		}
+	}
+	var channel models.NotificationChannel
+	if err := s.DB.First(&channel, policy.EscalateToChannelID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("notification channel %d does not exist", policy.EscalateToChannelID)
+		}
+		return err
+	}
+	return s.DB.Create(policy).Error
+}
+
+// DeletePolicy removes the policy with id. Returns gorm.ErrRecordNotFound if it
+// does not exist.
+func (s *EscalationService) DeletePolicy(id uint) error {
+	result := s.DB.Delete(&models.EscalationPolicy{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// EscalationWorker sends alerts that nobody acknowledged within the window of
+// a matching escalation policy to the policy's channel. Every alert is
+// escalated at most once: escalated_at is stamped before sending, and only if
+// it is still NULL, so concurrent workers cannot both fire.
+type EscalationWorker struct {
+	Interval time.Duration
+}
+
+var (
+	escalationWorkerInstance *EscalationWorker
+	escalationWorkerOnce     sync.Once
+)
+
+// GetEscalationWorker returns the shared worker, polling every minute
+func GetEscalationWorker() *EscalationWorker {
+	escalationWorkerOnce.Do(func() {
+		escalationWorkerInstance = &EscalationWorker{Interval: escalationInterval}
+	})
+	return escalationWorkerInstance
+}
+
+// RunOnce escalates the alerts that are due under the enabled policies, in
+// policy id order, and returns how many were escalated. Silenced alerts are
+// skipped. An alert whose notification fails is unstamped so the next run
+// retries it.
+func (w *EscalationWorker) RunOnce(db *gorm.DB) (int, error) {
+	var policies []models.EscalationPolicy
+	if err := db.Where("enabled = ?", true).Order("id asc").Find(&policies).Error; err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	silences := NewSilenceService(db)
+	notifications := GetNotificationService()
+	escalated := 0
+	var errs []error
+	for _, policy := range policies {
+		var filter []Matcher
+		if hasLabelFilter(policy.AlertFilter) {
+			var err error
+			if filter, err = ParseMatchers(policy.AlertFilter); err != nil {
+				errs = append(errs, fmt.Errorf("policy %d: invalid alert_filter: %w", policy.ID, err))
+				continue
+			}
+		}
+
+		window := time.Duration(policy.AckWindowSeconds) * time.Second
+		var due []models.Issue
+		err := db.Where("is_alert = 1 AND deleted_at IS NULL AND escalated_at IS NULL").
+			Where("LOWER(status) NOT IN ?", resolvedStatuses).
+			Where("REPLACE(created, ' UTC', '') BETWEEN ? AND ?",
+				now.Add(-window-escalationLookback).Format("2006-01-02 15:04:05"),
+				now.Add(-window).Format("2006-01-02 15:04:05")).
+			Where("COALESCE((SELECT a.action FROM acknowledgements a WHERE a.alert_id = issues.id ORDER BY a.id DESC LIMIT 1), '') != 'ack'").
+			Order("created asc").
+			Find(&due).Error
+		if err != nil {
+			errs = append(errs, fmt.Errorf("policy %d: %w", policy.ID, err))
+			continue
+		}
+		if err := silences.MarkSilenced(due, now); err != nil {
+			errs = append(errs, fmt.Errorf("policy %d: %w", policy.ID, err))
+			continue
+		}
+
+		for i := range due {
+			issue := &due[i]
+			alert := AlertFromIssue(issue)
+			if issue.Silenced || (filter != nil && !matchAll(filter, alert.Labels)) {
+				continue
+			}
+
+			claim := db.Model(&models.Issue{}).
+				Where("id = ? AND escalated_at IS NULL", issue.ID).
+				Update("escalated_at", now)
+			if claim.Error != nil {
+				errs = append(errs, fmt.Errorf("alert %s: %w", issue.ID, claim.Error))
+				continue
+			}
+			if claim.RowsAffected == 0 {
+				continue // escalated meanwhile, e.g. by an earlier policy
+			}
+
+			alert.Labels["escalation_policy_id"] = strconv.FormatUint(uint64(policy.ID), 10)
+			if err := notifications.SendToChannel(policy.EscalateToChannelID, alert); err != nil {
+				db.Model(&models.Issue{}).Where("id = ?", issue.ID).Update("escalated_at", nil)
+				errs = append(errs, fmt.Errorf("alert %s: %w", issue.ID, err))
+				continue
+			}
+			log.Printf("[INFO] Escalated alert %s, unacknowledged for %s (policy %d)\n", issue.ID, window, policy.ID)
+			escalated++
+		}
+	}
+	return escalated, errors.Join(errs...)
+}
+
+// Start runs RunOnce every Interval until ctx is cancelled
+func (w *EscalationWorker) Start(ctx context.Context, db *gorm.DB) {
+	go func() {
+		ticker := time.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if _, err := w.RunOnce(db); err != nil {
+				log.Printf("[WARN] Alert escalation: %v\n", err)
+			}
+		}
+	}()
+}
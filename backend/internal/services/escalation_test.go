@@ -0,0 +1,149 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// webhookReceiver is a webhook channel endpoint recording the IDs of the
+// alerts sent to it, failing every request while fail is set
+type webhookReceiver struct {
+	mu   sync.Mutex
+	ids  []string
+	fail bool
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	if rcv.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var alert Alert
+	json.NewDecoder(r.Body).Decode(&alert)
+	rcv.ids = append(rcv.ids, alert.ID)
+}
+
+func (rcv *webhookReceiver) received() []string {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return slices.Sorted(slices.Values(rcv.ids))
+}
+
+// useEscalation returns a webhook receiver behind an escalation policy with a
+// 10 minute ack window, and points the notification service at sqlite
+func useEscalation(t *testing.T, sqlite *gorm.DB) *webhookReceiver {
+	t.Helper()
+	rcv := &webhookReceiver{}
+	srv := httptest.NewServer(rcv)
+	t.Cleanup(srv.Close)
+
+	notifications := GetNotificationService()
+	prev := notifications.DB
+	notifications.DB = sqlite
+	t.Cleanup(func() { notifications.DB = prev })
+
+	channel := models.NotificationChannel{Name: "oncall", URL: srv.URL, Enabled: true}
+	if err := sqlite.Create(&channel).Error; err != nil {
+		t.Fatal(err)
+	}
+	policy := models.EscalationPolicy{Name: "page", AckWindowSeconds: 600, EscalateToChannelID: channel.ID, Enabled: true}
+	if err := NewEscalationService(sqlite).CreatePolicy(&policy); err != nil {
+		t.Fatal(err)
+	}
+	return rcv
+}
+
+func ack(t *testing.T, sqlite *gorm.DB, id string, actions ...string) {
+	t.Helper()
+	for _, action := range actions {
+		if err := sqlite.Create(&models.Acknowledgement{AlertID: id, Action: action, AckBy: "ann", AckAt: time.Now()}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEscalationTiming(t *testing.T) {
+	sqlite := openTestDB(t)
+	rcv := useEscalation(t, sqlite)
+
+	seedAlert(t, sqlite, "DUE", 20*time.Minute)
+	seedAlert(t, sqlite, "FRESH", 5*time.Minute)
+	seedAlert(t, sqlite, "ACKED", 20*time.Minute)
+	ack(t, sqlite, "ACKED", "ack")
+	seedAlert(t, sqlite, "UNACKED", 20*time.Minute)
+	ack(t, sqlite, "UNACKED", "ack", "unack")
+	seedAlert(t, sqlite, "RESOLVED", 20*time.Minute, func(i *models.Issue) { i.Status = "Resolved" })
+	// Older than the lookback: a new policy does not page for it
+	seedAlert(t, sqlite, "ANCIENT", 48*time.Hour)
+
+	w := &EscalationWorker{}
+	if n, err := w.RunOnce(sqlite); err != nil || n != 2 {
+		t.Fatalf("RunOnce = %d, %v, want 2", n, err)
+	}
+	if got := rcv.received(); !slices.Equal(got, []string{"DUE", "UNACKED"}) {
+		t.Errorf("escalated %v, want the unacknowledged alerts past the ack window", got)
+	}
+
+	// Every alert is escalated once
+	if n, err := w.RunOnce(sqlite); err != nil || n != 0 {
+		t.Errorf("second RunOnce = %d, %v, want nothing escalated again", n, err)
+	}
+	var escalated []string
+	sqlite.Model(&models.Issue{}).Where("escalated_at IS NOT NULL").Order("id").Pluck("id", &escalated)
+	if !slices.Equal(escalated, []string{"DUE", "UNACKED"}) {
+		t.Errorf("escalated_at set on %v", escalated)
+	}
+}
+
+func TestEscalationAckCancels(t *testing.T) {
+	sqlite := openTestDB(t)
+	rcv := useEscalation(t, sqlite)
+	seedAlert(t, sqlite, "A-1", 20*time.Minute)
+
+	// Acknowledging an alert before its window ends cancels its escalation
+	ack(t, sqlite, "A-1", "ack")
+	w := &EscalationWorker{}
+	if n, err := w.RunOnce(sqlite); err != nil || n != 0 {
+		t.Fatalf("RunOnce of an acknowledged alert = %d, %v, want 0", n, err)
+	}
+
+	// Unacknowledging it makes it due again
+	ack(t, sqlite, "A-1", "unack")
+	if n, err := w.RunOnce(sqlite); err != nil || n != 1 || len(rcv.received()) != 1 {
+		t.Errorf("RunOnce after unack = %d, %v, want it escalated", n, err)
+	}
+}
+
+func TestEscalationRetriesFailedNotifications(t *testing.T) {
+	sqlite := openTestDB(t)
+	rcv := useEscalation(t, sqlite)
+	seedAlert(t, sqlite, "A-1", 20*time.Minute)
+
+	rcv.fail = true
+	w := &EscalationWorker{}
+	if n, err := w.RunOnce(sqlite); err == nil || n != 0 {
+		t.Fatalf("RunOnce with a failing channel = %d, %v, want an error", n, err)
+	}
+	var issue models.Issue
+	sqlite.First(&issue, "id = ?", "A-1")
+	if issue.EscalatedAt != nil {
+		t.Error("escalated_at kept after the notification failed")
+	}
+
+	rcv.mu.Lock()
+	rcv.fail = false
+	rcv.mu.Unlock()
+	if n, err := w.RunOnce(sqlite); err != nil || n != 1 {
+		t.Errorf("retry = %d, %v, want the alert escalated", n, err)
+	}
+}
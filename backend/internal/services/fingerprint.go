@@ -0,0 +1,109 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// defaultFingerprintLabels identify an alert when ALERT_FINGERPRINT_LABELS is unset
+var defaultFingerprintLabels = []string{"alertname", "cluster_id", "severity"}
+
+// Fingerprinter derives the identity of an alert from the values of the
+// GroupBy labels. Alerts with the same fingerprint are the same alert firing
+// again. It is safe for concurrent use; GroupBy can be changed at runtime.
+type Fingerprinter struct {
+	mu      sync.RWMutex
+	groupBy []string
+}
+
+var (
+	fingerprinterInstance *Fingerprinter
+	fingerprinterOnce     sync.Once
+)
+
+// NewFingerprinter groups alerts by the given label keys
+func NewFingerprinter(groupBy []string) (*Fingerprinter, error) {
+	f := &Fingerprinter{}
+	if err := f.SetGroupBy(groupBy); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// GetFingerprinter returns the shared fingerprinter, grouping by the
+// comma-separated ALERT_FINGERPRINT_LABELS (default: alertname,cluster_id,severity)
+func GetFingerprinter() *Fingerprinter {
+	fingerprinterOnce.Do(func() {
+		groupBy := defaultFingerprintLabels
+		if v := os.Getenv("ALERT_FINGERPRINT_LABELS"); v != "" {
+			groupBy = strings.Split(v, ",")
+		}
+		f, err := NewFingerprinter(groupBy)
+		if err != nil {
+			f, _ = NewFingerprinter(defaultFingerprintLabels)
+		}
+		fingerprinterInstance = f
+	})
+	return fingerprinterInstance
+}
+
+// GroupBy returns the sorted label keys alerts are grouped by
+func (f *Fingerprinter) GroupBy() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return slices.Clone(f.groupBy)
+}
+
+// SetGroupBy replaces the label keys. Keys are trimmed, deduplicated and
+// sorted; at least one is required. Alerts stored earlier keep their
+// fingerprint until they are synced again.
+func (f *Fingerprinter) SetGroupBy(groupBy []string) error {
+	var keys []string
+	for _, k := range groupBy {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return errors.New("at least one fingerprint label is required")
+	}
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	f.mu.Lock()
+	f.groupBy = keys
+	f.mu.Unlock()
+	return nil
+}
+
+// Fingerprint returns the SHA-256 hex digest of the GroupBy labels, in key
+// order. It returns "" when none of them is set, so unlabelled alerts are
+// never merged.
+func (f *Fingerprinter) Fingerprint(labels map[string]string) string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var b strings.Builder
+	found := false
+	for _, k := range f.groupBy {
+		v := strings.TrimSpace(labels[k])
+		if v != "" {
+			found = true
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(v)
+		b.WriteByte('\n')
+	}
+	if !found {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// SuppressionFlapping is stored in issues.suppression_reason for alerts that
+// fired while their fingerprint was flapping
+const SuppressionFlapping = "flapping"
+
+const (
+	defaultFlapWindow         = 10 * time.Minute
+	defaultFlapMaxTransitions = 3
+	defaultFlapStableAfter    = 30 * time.Minute
+	flapPruneInterval         = 5 * time.Minute
+
+	// flapHistorySize is the number of transitions kept per fingerprint. It
+	// bounds MaxTransitions.
+	flapHistorySize = 16
+)
+
+// FlapTransition is an alert of a fingerprint starting to fire or resolving
+type FlapTransition struct {
+	At     time.Time `json:"at"`
+	Firing bool      `json:"firing"`
+}
+
+// flapHistory is the ring buffer of the latest transitions of a fingerprint
+type flapHistory struct {
+	ring          [flapHistorySize]FlapTransition
+	next, n       int
+	flapping      bool
+	flappingSince time.Time
+}
+
+func (h *flapHistory) add(t FlapTransition) {
+	h.ring[h.next] = t
+	h.next = (h.next + 1) % flapHistorySize
+	if h.n < flapHistorySize {
+		h.n++
+	}
+}
+
+// transitions returns the buffered transitions, oldest first
+func (h *flapHistory) transitions() []FlapTransition {
+	out := make([]FlapTransition, 0, h.n)
+	for i := h.n; i > 0; i-- {
+		out = append(out, h.ring[(h.next-i+flapHistorySize)%flapHistorySize])
+	}
+	return out
+}
+
+func (h *flapHistory) last() (FlapTransition, bool) {
+	if h.n == 0 {
+		return FlapTransition{}, false
+	}
+	return h.ring[(h.next-1+flapHistorySize)%flapHistorySize], true
+}
+
+// countSince counts the transitions at or after cutoff
+func (h *flapHistory) countSince(cutoff time.Time) int {
+	count := 0
+	for _, t := range h.transitions() {
+		if !t.At.Before(cutoff) {
+			count++
+		}
+	}
+	return count
+}
+
+// FlapStatus is the flap state of a fingerprint
+type FlapStatus struct {
+	Fingerprint   string           `json:"fingerprint"`
+	Flapping      bool             `json:"flapping"`
+	FlappingSince *time.Time       `json:"flapping_since,omitempty"`
+	Transitions   []FlapTransition `json:"transitions"` // latest ones, oldest first
+}
+
+// FlapDetector tracks the fire/resolve transitions of each alert fingerprint.
+// A fingerprint with more than MaxTransitions transitions within Window is
+// flapping: alerts of it that fire are suppressed. It stops flapping after
+// StableAfter without transitions. The latest transitions of each fingerprint
+// are kept in memory and in the flap_state table, so restarts do not reset
+// them. It is safe for concurrent use.
+type FlapDetector struct {
+	Window         time.Duration
+	MaxTransitions int
+	StableAfter    time.Duration
+
+	db *gorm.DB
+
+	mu        sync.Mutex
+	histories map[string]*flapHistory
+}
+
+var (
+	flapDetectorInstance *FlapDetector
+	flapDetectorOnce     sync.Once
+)
+
+// NewFlapDetector returns a detector with the default 10m window, 3
+// transitions and 30m of stability, storing its state in sqlite
+func NewFlapDetector(sqlite *gorm.DB) *FlapDetector {
+	return &FlapDetector{
+		Window:         defaultFlapWindow,
+		MaxTransitions: defaultFlapMaxTransitions,
+		StableAfter:    defaultFlapStableAfter,
+		db:             sqlite,
+		histories:      make(map[string]*flapHistory),
+	}
+}
+
+// GetFlapDetector returns the shared detector, configured from
+// FLAP_WINDOW (default 10m), FLAP_MAX_TRANSITIONS (default 3, at most 15) and
+// FLAP_STABLE_AFTER (default 30m)
+func GetFlapDetector() *FlapDetector {
+	flapDetectorOnce.Do(func() {
+		d := NewFlapDetector(db.DB)
+		if v, err := time.ParseDuration(os.Getenv("FLAP_WINDOW")); err == nil && v > 0 {
+			d.Window = v
+		}
+		if v, err := strconv.Atoi(os.Getenv("FLAP_MAX_TRANSITIONS")); err == nil && v > 0 {
+			d.MaxTransitions = min(v, flapHistorySize-1)
+		}
+		if v, err := time.ParseDuration(os.Getenv("FLAP_STABLE_AFTER")); err == nil && v > 0 {
+			d.StableAfter = v
+		}
+		flapDetectorInstance = d
+	})
+	return flapDetectorInstance
+}
+
+// RecordTransition records an alert of fingerprint starting to fire (or
+// resolving) at the given time and reports whether the fingerprint is
+// flapping. Repeating the latest state is not a transition.
+func (d *FlapDetector) RecordTransition(fingerprint string, firing bool, at time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h := d.history(fingerprint)
+	if h == nil {
+		h = &flapHistory{}
+		d.histories[fingerprint] = h
+	}
+	if last, ok := h.last(); ok && last.Firing == firing {
+		if d.update(h, at) {
+			d.persist(fingerprint, h)
+		}
+		return h.flapping
+	}
+
+	h.add(FlapTransition{At: at.UTC(), Firing: firing})
+	d.update(h, at)
+	d.persist(fingerprint, h)
+	return h.flapping
+}
+
+// IsFlapping reports whether fingerprint is flapping now
+func (d *FlapDetector) IsFlapping(fingerprint string) bool {
+	return d.Status(fingerprint).Flapping
+}
+
+// Status returns the flap state of fingerprint. Fingerprints without recent
+// transitions are not flapping.
+func (d *FlapDetector) Status(fingerprint string) FlapStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	status := FlapStatus{Fingerprint: fingerprint, Transitions: []FlapTransition{}}
+	h := d.history(fingerprint)
+	if h == nil {
+		return status
+	}
+	if d.update(h, time.Now()) {
+		d.persist(fingerprint, h)
+	}
+	status.Flapping = h.flapping
+	if h.flapping {
+		since := h.flappingSince
+		status.FlappingSince = &since
+	}
+	status.Transitions = h.transitions()
+	return status
+}
+
+// update starts or stops h flapping as of now and reports whether it changed.
+// Must be called with d.mu held.
+func (d *FlapDetector) update(h *flapHistory, now time.Time) bool {
+	last, ok := h.last()
+	switch {
+	case !ok:
+		return false
+	case h.flapping && !last.At.After(now.Add(-d.StableAfter)):
+		h.flapping, h.flappingSince = false, time.Time{}
+		return true
+	case !h.flapping && h.countSince(now.Add(-d.Window)) > d.MaxTransitions:
+		h.flapping, h.flappingSince = true, now.UTC()
+		return true
+	}
+	return false
+}
+
+// history returns the history of fingerprint, loading it from flap_state if
+// it is not in memory, or nil if there is none. Must be called with d.mu held.
+func (d *FlapDetector) history(fingerprint string) *flapHistory {
+	if h, ok := d.histories[fingerprint]; ok {
+		return h
+	}
+	if d.db == nil {
+		return nil
+	}
+
+	var row models.FlapState
+	err := d.db.Where("fingerprint = ?", fingerprint).First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		log.Printf("[WARN] Failed to load flap state of %s: %v\n", fingerprint, err)
+		return nil
+	}
+
+	h := &flapHistory{flapping: row.Flapping}
+	if row.FlappingSince != nil {
+		h.flappingSince = *row.FlappingSince
+	}
+	var transitions []FlapTransition
+	if err := json.Unmarshal([]byte(row.Transitions), &transitions); err != nil {
+		log.Printf("[WARN] Ignoring corrupt flap state of %s: %v\n", fingerprint, err)
+	}
+	for _, t := range transitions {
+		h.add(t)
+	}
+	d.histories[fingerprint] = h
+	return h
+}
+
+// persist writes h to flap_state. Must be called with d.mu held.
+func (d *FlapDetector) persist(fingerprint string, h *flapHistory) {
+	if d.db == nil {
+		return
+	}
+	transitions, _ := json.Marshal(h.transitions())
+	row := models.FlapState{
+		Fingerprint: fingerprint,
+		Transitions: string(transitions),
+		Flapping:    h.flapping,
+	}
+	if last, ok := h.last(); ok {
+		row.LastTransitionAt = last.At
+	}
+	if h.flapping {
+		since := h.flappingSince
+		row.FlappingSince = &since
+	}
+	if err := d.db.Save(&row).Error; err != nil {
+		log.Printf("[WARN] Failed to save flap state of %s: %v\n", fingerprint, err)
+	}
+}
+
+// Prune forgets the fingerprints that are not flapping and had no transition
+// within the window or the stability period, in memory and in flap_state
+func (d *FlapDetector) Prune(now time.Time) {
+	cutoff := now.Add(-max(d.Window, d.StableAfter))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for fingerprint, h := range d.histories {
+		d.update(h, now)
+		if last, ok := h.last(); !h.flapping && (!ok || last.At.Before(cutoff)) {
+			delete(d.histories, fingerprint)
+		}
+	}
+	if d.db == nil {
+		return
+	}
+	// A row still flapping past the cutoff has been stable for StableAfter
+	if err := d.db.Where("last_transition_at < ?", cutoff.UTC()).Delete(&models.FlapState{}).Error; err != nil {
+		log.Printf("[WARN] Failed to prune flap state: %v\n", err)
+	}
+}
+
+// Start prunes stable fingerprints every 5 minutes until ctx is cancelled
+func (d *FlapDetector) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(flapPruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case t := <-ticker.C:
+				d.Prune(t)
+			}
+		}
+	}()
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlapDetectorEnterAndExit(t *testing.T) {
+	d := NewFlapDetector(nil) // 10m window, 3 transitions, 30m stability
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return t0.Add(time.Duration(minutes) * time.Minute) }
+
+	steps := []struct {
+		minutes int
+		firing  bool
+		want    bool
+	}{
+		{0, true, false},
+		{1, false, false},
+		{2, true, false},
+		// The fourth transition within the window is more than MaxTransitions
+		{3, false, true},
+		// Repeating the latest state is not a transition and changes nothing
+		{4, false, true},
+		{20, true, true},
+		// Still flapping 29 minutes after the latest transition
+		{49, true, true},
+		// Stable for StableAfter: no longer flapping
+		{50, true, false},
+		{51, false, false},
+	}
+	for _, s := range steps {
+		if got := d.RecordTransition("fp", s.firing, at(s.minutes)); got != s.want {
+			t.Errorf("transition to firing=%v at +%dm: flapping = %v, want %v", s.firing, s.minutes, got, s.want)
+		}
+	}
+	if n := len(d.Status("fp").Transitions); n != 6 {
+		t.Errorf("%d transitions recorded, want 6", n)
+	}
+}
+
+func TestFlapDetectorWindow(t *testing.T) {
+	d := NewFlapDetector(nil)
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Four transitions 5 minutes apart never have more than 3 within 10 minutes
+	for i := 0; i < 4; i++ {
+		if d.RecordTransition("slow", i%2 == 0, t0.Add(time.Duration(i)*5*time.Minute)) {
+			t.Fatalf("transition %d flapping, want transitions spread over the window ignored", i+1)
+		}
+	}
+
+	// A lower threshold makes the same transitions flap
+	d.MaxTransitions = 1
+	if !d.RecordTransition("slow", true, t0.Add(16*time.Minute)) {
+		t.Error("not flapping with 3 transitions within the window and MaxTransitions 1")
+	}
+}
+
+func TestFlapDetectorPersists(t *testing.T) {
+	sqlite := openTestDB(t)
+	now := time.Now().UTC()
+	d := NewFlapDetector(sqlite)
+	for i := 0; i < 4; i++ {
+		d.RecordTransition("fp", i%2 == 0, now.Add(time.Duration(i-4)*time.Minute))
+	}
+	if !d.IsFlapping("fp") {
+		t.Fatal("not flapping after 4 transitions in 4 minutes")
+	}
+
+	// A restarted detector loads the state from flap_state
+	restarted := NewFlapDetector(sqlite)
+	status := restarted.Status("fp")
+	if !status.Flapping || status.FlappingSince == nil || len(status.Transitions) != 4 {
+		t.Errorf("status after a restart = %+v, want flapping with 4 transitions", status)
+	}
+
+	// Pruning keeps flapping fingerprints and drops them once stable
+	restarted.Prune(now)
+	if !NewFlapDetector(sqlite).IsFlapping("fp") {
+		t.Error("prune dropped a flapping fingerprint")
+	}
+	restarted.Prune(now.Add(time.Hour))
+	if s := NewFlapDetector(sqlite).Status("fp"); s.Flapping || len(s.Transitions) != 0 {
+		t.Errorf("status after pruning a stable fingerprint = %+v, want none", s)
+	}
+}
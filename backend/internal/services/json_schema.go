@@ -0,0 +1,196 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONSchema is the subset of JSON Schema (draft-07) needed to validate
+// configs stored by the API: type, properties, required,
+// additionalProperties, items, enum, minimum, maximum, minLength, maxLength,
+// minItems and maxItems. Other keywords are ignored.
+type JSONSchema struct {
+	Type                 string                 `json:"type"`
+	Properties           map[string]*JSONSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties json.RawMessage        `json:"additionalProperties"` // false or a schema
+	Items                *JSONSchema            `json:"items"`
+	Enum                 []interface{}          `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+
+	noAdditional bool
+	additional   *JSONSchema
+}
+
+// ParseJSONSchema parses and checks a schema
+func ParseJSONSchema(data []byte) (*JSONSchema, error) {
+	var s JSONSchema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	if err := s.compile("$"); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *JSONSchema) compile(path string) error {
+	switch s.Type {
+	case "", "object", "array", "string", "number", "integer", "boolean", "null":
+	default:
+		return fmt.Errorf("%s: unsupported type %q", path, s.Type)
+	}
+	if raw := strings.TrimSpace(string(s.AdditionalProperties)); raw != "" {
+		switch raw {
+		case "true":
+		case "false":
+			s.noAdditional = true
+		default:
+			s.additional = &JSONSchema{}
+			if err := json.Unmarshal(s.AdditionalProperties, s.additional); err != nil {
+				return fmt.Errorf("%s: invalid additionalProperties: %w", path, err)
+			}
+			if err := s.additional.compile(path + ".*"); err != nil {
+				return err
+			}
+		}
+	}
+	for name, prop := range s.Properties {
+		if err := prop.compile(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(path + "[]")
+	}
+	return nil
+}
+
+// Validate checks the JSON document data against s and returns one error per
+// violation, sorted by field. Fields are paths like panels[0].id.
+func (s *JSONSchema) Validate(data []byte) []ValidationError {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return []ValidationError{{Field: "$", Message: "is not valid JSON: " + err.Error()}}
+	}
+	var errs []ValidationError
+	s.validate("", doc, &errs)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+func (s *JSONSchema) validate(path string, v interface{}, errs *[]ValidationError) {
+	field := path
+	if field == "" {
+		field = "$"
+	}
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Type != "" && !jsonTypeMatches(s.Type, v) {
+		fail("must be of type %s", s.Type)
+		return
+	}
+	if len(s.Enum) > 0 && !jsonEnumContains(s.Enum, v) {
+		fail("must be one of %s", jsonEnumString(s.Enum))
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				*errs = append(*errs, ValidationError{Field: joinJSONPath(path, name), Message: "is required"})
+			}
+		}
+		for name, child := range value {
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(joinJSONPath(path, name), child, errs)
+			} else if s.noAdditional {
+				*errs = append(*errs, ValidationError{Field: joinJSONPath(path, name), Message: "is not allowed"})
+			} else if s.additional != nil {
+				s.additional.validate(joinJSONPath(path, name), child, errs)
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(value) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(value) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range value {
+				s.Items.validate(path+"["+strconv.Itoa(i)+"]", item, errs)
+			}
+		}
+	case string:
+		n := len([]rune(value))
+		if s.MinLength != nil && n < *s.MinLength {
+			fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+	case float64:
+		if s.Minimum != nil && value < *s.Minimum {
+			fail("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && value > *s.Maximum {
+			fail("must be <= %v", *s.Maximum)
+		}
+	}
+}
+
+func joinJSONPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func jsonTypeMatches(t string, v interface{}) bool {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return t == "object"
+	case []interface{}:
+		return t == "array"
+	case string:
+		return t == "string"
+	case float64:
+		return t == "number" || (t == "integer" && value == math.Trunc(value))
+	case bool:
+		return t == "boolean"
+	case nil:
+		return t == "null"
+	}
+	return false
+}
+
+func jsonEnumContains(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonEnumString(enum []interface{}) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		b, _ := json.Marshal(e)
+		parts[i] = string(b)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NormalizeRule rewrites the label keys matching Pattern. Transform is one of
+// "snake_case", "lowercase" or "rename:<new_key>".
+type NormalizeRule struct {
+	Pattern   string `yaml:"pattern"`
+	Transform string `yaml:"transform"`
+
+	re *regexp.Regexp
+}
+
+// LabelNormalizer canonicalizes the label keys of incoming alerts (e.g.
+// ClusterID, clusterid -> cluster_id). Rules are applied in order, each to the
+// key produced by the rules before it.
+type LabelNormalizer struct {
+	Rules []NormalizeRule `yaml:"rules"`
+}
+
+// NewLabelNormalizer validates and compiles rules
+func NewLabelNormalizer(rules []NormalizeRule) (*LabelNormalizer, error) {
+	n := &LabelNormalizer{Rules: make([]NormalizeRule, len(rules))}
+	for i, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: invalid pattern %q: %w", i+1, rule.Pattern, err)
+		}
+		switch {
+		case rule.Transform == "snake_case", rule.Transform == "lowercase":
+		case strings.HasPrefix(rule.Transform, "rename:") && strings.TrimPrefix(rule.Transform, "rename:") != "":
+		default:
+			return nil, fmt.Errorf("rule %d: unknown transform %q, expected snake_case, lowercase or rename:<new_key>", i+1, rule.Transform)
+		}
+		rule.re = re
+		n.Rules[i] = rule
+	}
+	return n, nil
+}
+
+// LoadLabelNormalizerFile reads rules from a YAML file of the form
+//
+//	rules:
+//	  - pattern: "^[A-Z]"
+//	    transform: snake_case
+//	  - pattern: "^clusterid$"
+//	    transform: rename:cluster_id
+func LoadLabelNormalizerFile(path string) (*LabelNormalizer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read label normalize config: %w", err)
+	}
+	var config LabelNormalizer
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse label normalize config %s: %w", path, err)
+	}
+	return NewLabelNormalizer(config.Rules)
+}
+
+// NormalizeKey runs key through every rule
+func (n *LabelNormalizer) NormalizeKey(key string) string {
+	if n == nil {
+		return key
+	}
+	for _, rule := range n.Rules {
+		if !rule.re.MatchString(key) {
+			continue
+		}
+		switch {
+		case rule.Transform == "snake_case":
+			key = snakeCase(key)
+		case rule.Transform == "lowercase":
+			key = strings.ToLower(key)
+		default:
+			key = strings.TrimPrefix(rule.Transform, "rename:")
+		}
+	}
+	return key
+}
+
+// Normalize returns labels with normalized keys. When several keys normalize
+// to the same one, a key that was already canonical wins, otherwise the first
+// in sorted order.
+func Normalize[V any](n *LabelNormalizer, labels map[string]V) map[string]V {
+	if n == nil || len(n.Rules) == 0 {
+		return labels
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make(map[string]V, len(labels))
+	renamed := make(map[string]string, len(labels)) // original -> normalized
+	for _, k := range keys {
+		nk := n.NormalizeKey(k)
+		if nk == k {
+			out[k] = labels[k]
+		} else {
+			renamed[k] = nk
+		}
+	}
+	for _, k := range keys {
+		if nk, ok := renamed[k]; ok {
+			if _, exists := out[nk]; !exists {
+				out[nk] = labels[k]
+			}
+		}
+	}
+	return out
+}
+
+// snakeCase converts ClusterID, clusterId, cluster-id and "Cluster ID" to cluster_id
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ' || r == '.':
+			r = '_'
+		case unicode.IsUpper(r):
+			// Split before an uppercase letter that follows a lowercase letter
+			// or digit (clusterId), or that starts a word after an acronym (IDName)
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var (
+	labelNormalizerOnce     sync.Once
+	labelNormalizerInstance *LabelNormalizer
+)
+
+// GetLabelNormalizer returns the normalizer configured by the YAML file in
+// LABEL_NORMALIZE_CONFIG, or config/label_normalize.yaml if present. Without a
+// config label keys are kept as is.
+func GetLabelNormalizer() *LabelNormalizer {
+	labelNormalizerOnce.Do(func() {
+		labelNormalizerInstance = &LabelNormalizer{}
+
+		paths := []string{"../config/label_normalize.yaml", "../../config/label_normalize.yaml", "config/label_normalize.yaml"}
+		if p := os.Getenv("LABEL_NORMALIZE_CONFIG"); p != "" {
+			paths = []string{p}
+		}
+		for _, path := range paths {
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			normalizer, err := LoadLabelNormalizerFile(path)
+			if err != nil {
+				log.Printf("⚠️  %v", err)
+			} else {
+				labelNormalizerInstance = normalizer
+				log.Printf("✅ Loaded label normalization rules from %s", path)
+			}
+			break
+		}
+	})
+	return labelNormalizerInstance
+}
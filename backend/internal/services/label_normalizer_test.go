@@ -0,0 +1,151 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func mustNormalizer(t *testing.T, rules ...NormalizeRule) *LabelNormalizer {
+	t.Helper()
+	n, err := NewLabelNormalizer(rules)
+	if err != nil {
+		t.Fatalf("NewLabelNormalizer: %v", err)
+	}
+	return n
+}
+
+func TestNormalizeTransforms(t *testing.T) {
+	for _, tc := range []struct {
+		rule NormalizeRule
+		in   map[string]string
+	}{
+		{
+			NormalizeRule{Pattern: ".", Transform: "snake_case"},
+			map[string]string{
+				"ClusterID": "cluster_id", "clusterId": "cluster_id", "cluster-id": "cluster_id",
+				"Cluster ID": "cluster_id", "k8s.namespace": "k8s_namespace", "IDName": "id_name",
+				"Pod2Name": "pod2_name", "tenant_id": "tenant_id",
+			},
+		},
+		{
+			NormalizeRule{Pattern: ".", Transform: "lowercase"},
+			map[string]string{"ClusterID": "clusterid", "Cluster-ID": "cluster-id", "severity": "severity"},
+		},
+		{
+			NormalizeRule{Pattern: "(?i)^cluster_?id$", Transform: "rename:cluster_id"},
+			map[string]string{"clusterid": "cluster_id", "ClusterID": "cluster_id", "Cluster_Id": "cluster_id", "cluster": "cluster"},
+		},
+		{
+			// Only the keys matching the pattern are transformed
+			NormalizeRule{Pattern: "^[A-Z]", Transform: "lowercase"},
+			map[string]string{"Severity": "severity", "alertName": "alertName"},
+		},
+	} {
+		n := mustNormalizer(t, tc.rule)
+		for key, want := range tc.in {
+			if got := n.NormalizeKey(key); got != want {
+				t.Errorf("%s %q: %q, want %q", tc.rule.Transform, key, got, want)
+			}
+		}
+	}
+}
+
+func TestNormalizeRuleOrder(t *testing.T) {
+	snake := NormalizeRule{Pattern: "[A-Z]", Transform: "snake_case"}
+	rename := NormalizeRule{Pattern: "^cluster_id$", Transform: "rename:k8s_cluster"}
+
+	// Each rule sees the key produced by the rules before it
+	if got := mustNormalizer(t, snake, rename).NormalizeKey("ClusterID"); got != "k8s_cluster" {
+		t.Errorf("snake_case then rename: %q, want k8s_cluster", got)
+	}
+	if got := mustNormalizer(t, rename, snake).NormalizeKey("ClusterID"); got != "cluster_id" {
+		t.Errorf("rename then snake_case: %q, want cluster_id", got)
+	}
+
+	// A renamed key can be transformed again
+	n := mustNormalizer(t,
+		NormalizeRule{Pattern: "^clusterid$", Transform: "rename:ClusterName"},
+		NormalizeRule{Pattern: ".", Transform: "snake_case"},
+	)
+	if got := n.NormalizeKey("clusterid"); got != "cluster_name" {
+		t.Errorf("rename then snake_case of the new key: %q, want cluster_name", got)
+	}
+
+	var none *LabelNormalizer
+	if got := none.NormalizeKey("ClusterID"); got != "ClusterID" {
+		t.Errorf("nil normalizer changed the key to %q", got)
+	}
+}
+
+func TestNormalizeLabels(t *testing.T) {
+	n := mustNormalizer(t, NormalizeRule{Pattern: ".", Transform: "snake_case"})
+
+	got := Normalize(n, map[string]string{"ClusterID": "2001", "AlertName": "TiKVDown", "severity": "critical"})
+	want := map[string]string{"cluster_id": "2001", "alert_name": "TiKVDown", "severity": "critical"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Normalize = %v, want %v", got, want)
+	}
+
+	// An already canonical key wins over the keys normalized to it, then the
+	// first in sorted order
+	got = Normalize(n, map[string]string{"ClusterID": "a", "cluster_id": "b", "clusterId": "c"})
+	if got["cluster_id"] != "b" || len(got) != 1 {
+		t.Errorf("collision with a canonical key = %v, want cluster_id=b", got)
+	}
+	got = Normalize(n, map[string]string{"clusterId": "c", "ClusterID": "a"})
+	if got["cluster_id"] != "a" || len(got) != 1 {
+		t.Errorf("collision = %v, want cluster_id=a from ClusterID", got)
+	}
+
+	labels := map[string]string{"ClusterID": "2001"}
+	if got := Normalize(&LabelNormalizer{}, labels); !reflect.DeepEqual(got, labels) {
+		t.Errorf("without rules = %v, want the labels unchanged", got)
+	}
+}
+
+func TestNewLabelNormalizerErrors(t *testing.T) {
+	for _, rule := range []NormalizeRule{
+		{Pattern: "(", Transform: "lowercase"},
+		{Pattern: ".", Transform: "uppercase"},
+		{Pattern: ".", Transform: "rename:"},
+		{Pattern: ".", Transform: ""},
+	} {
+		if _, err := NewLabelNormalizer([]NormalizeRule{rule}); err == nil {
+			t.Errorf("rule %+v accepted", rule)
+		}
+	}
+}
+
+func TestLoadLabelNormalizerFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "label_normalize.yaml")
+	config := `rules:
+  - pattern: "^clusterid$"
+    transform: rename:cluster_id
+  - pattern: "[A-Z]"
+    transform: snake_case
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	n, err := LoadLabelNormalizerFile(path)
+	if err != nil {
+		t.Fatalf("LoadLabelNormalizerFile: %v", err)
+	}
+	for key, want := range map[string]string{"clusterid": "cluster_id", "TenantID": "tenant_id"} {
+		if got := n.NormalizeKey(key); got != want {
+			t.Errorf("%q: %q, want %q", key, got, want)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("rules:\n  - pattern: \".\"\n    transform: reverse\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadLabelNormalizerFile(path); err == nil {
+		t.Error("config with an unknown transform loaded")
+	}
+	if _, err := LoadLabelNormalizerFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("missing config loaded")
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ValidationError is a problem with one label of an alert payload
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// LabelSchema describes the labels an alert payload must carry
+type LabelSchema struct {
+	Required   []string
+	Validators map[string]func(string) error
+}
+
+// Validate returns one error per missing required label and per label its
+// validator rejects, sorted by field
+func (s *LabelSchema) Validate(labels map[string]string) []ValidationError {
+	var errs []ValidationError
+	for _, field := range s.Required {
+		if strings.TrimSpace(labels[field]) == "" {
+			errs = append(errs, ValidationError{Field: field, Message: "is required"})
+		}
+	}
+	for field, validate := range s.Validators {
+		value, ok := labels[field]
+		if !ok || value == "" {
+			continue
+		}
+		if err := validate(value); err != nil {
+			errs = append(errs, ValidationError{Field: field, Message: err.Error()})
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+// severityValues are the severities accepted by the built-in schema: the
+// canonical ones of the request and those stored by the JIRA sync
+// (convertPriority) and the example severity map
+var severityValues = []string{"critical", "major", "high", "medium", "warning", "low", "info"}
+
+// numericID rejects values that fail isNumeric, like the cluster and tenant IDs
+func numericID(s string) error {
+	if !isNumeric(s) {
+		return fmt.Errorf("must be numeric, got %q", s)
+	}
+	return nil
+}
+
+func oneOf(values []string) func(string) error {
+	return func(s string) error {
+		if !slices.Contains(values, strings.ToLower(s)) {
+			return fmt.Errorf("must be one of %s, got %q", strings.Join(values, "/"), s)
+		}
+		return nil
+	}
+}
+
+func matches(re *regexp.Regexp) func(string) error {
+	return func(s string) error {
+		if !re.MatchString(s) {
+			return fmt.Errorf("must match %s, got %q", re, s)
+		}
+		return nil
+	}
+}
+
+// DefaultLabelSchema requires severity and cluster_id, restricts severity to
+// severityValues and requires numeric cluster, tenant, project and org IDs
+func DefaultLabelSchema() *LabelSchema {
+	return &LabelSchema{
+		Required: []string{"severity", "cluster_id"},
+		Validators: map[string]func(string) error{
+			"severity":   oneOf(severityValues),
+			"cluster_id": numericID,
+			"tenant_id":  numericID,
+			"project_id": numericID,
+			"org_id":     numericID,
+		},
+	}
+}
+
+// labelSchemaConfig is the JSON format of custom label schema files, e.g.
+//
+//	{"required": ["alertname"], "validators": {"env": {"enum": ["prod", "staging"]}, "region": {"pattern": "^[a-z]+-[a-z]+-[0-9]$"}}}
+type labelSchemaConfig struct {
+	Required   []string                        `json:"required"`
+	Validators map[string]labelValidatorConfig `json:"validators"`
+}
+
+type labelValidatorConfig struct {
+	Enum    []string `json:"enum"`
+	Pattern string   `json:"pattern"`
+	Numeric bool     `json:"numeric"`
+}
+
+// LoadLabelSchemaFile adds the required labels and validators from a JSON
+// config file to s. Validators replace built-in ones for the same field.
+func (s *LabelSchema) LoadLabelSchemaFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var cfg labelSchemaConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("invalid label schema %s: %w", path, err)
+	}
+
+	for _, field := range cfg.Required {
+		if !slices.Contains(s.Required, field) {
+			s.Required = append(s.Required, field)
+		}
+	}
+	for field, v := range cfg.Validators {
+		var checks []func(string) error
+		if v.Numeric {
+			checks = append(checks, numericID)
+		}
+		if len(v.Enum) > 0 {
+			enum := make([]string, len(v.Enum))
+			for i, e := range v.Enum {
+				enum[i] = strings.ToLower(e)
+			}
+			checks = append(checks, oneOf(enum))
+		}
+		if v.Pattern != "" {
+			re, err := regexp.Compile(v.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern for %s in %s: %w", field, path, err)
+			}
+			checks = append(checks, matches(re))
+		}
+		if len(checks) == 0 {
+			return fmt.Errorf("validator for %s in %s has no enum, pattern or numeric rule", field, path)
+		}
+		s.Validators[field] = func(value string) error {
+			for _, check := range checks {
+				if err := check(value); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+var (
+	labelSchemaInstance *LabelSchema
+	labelSchemaOnce     sync.Once
+)
+
+// GetLabelSchema returns the default schema extended with LABEL_SCHEMA_CONFIG
+// (or config/label_schema.json when present)
+func GetLabelSchema() *LabelSchema {
+	labelSchemaOnce.Do(func() {
+		labelSchemaInstance = DefaultLabelSchema()
+
+		paths := []string{"../config/label_schema.json", "../../config/label_schema.json", "config/label_schema.json"}
+		if p := os.Getenv("LABEL_SCHEMA_CONFIG"); p != "" {
+			paths = []string{p}
+		}
+		for _, path := range paths {
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			if err := labelSchemaInstance.LoadLabelSchemaFile(path); err != nil {
+				log.Printf("⚠️  %v", err)
+			} else {
+				log.Printf("✅ Loaded label schema from %s", path)
+			}
+			break
+		}
+	})
+	return labelSchemaInstance
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+// defaultSuppressedLifecycles are the cluster lifecycle states whose
+// non-critical alerts are suppressed when SUPPRESS_LIFECYCLES is unset
+var defaultSuppressedLifecycles = []string{"creating", "deleting"}
+
+// LifecycleSuppressor marks non-critical alerts of clusters in a transient
+// lifecycle state (e.g. creating, deleting) as suppressed. Suppressed alerts
+// are stored but not routed to notification channels.
+type LifecycleSuppressor struct {
+	Lifecycles []string // lowercased lifecycle states to suppress
+	Resolver   *NameResolver
+}
+
+// NewLifecycleSuppressor suppresses the comma-separated lifecycles in
+// SUPPRESS_LIFECYCLES, or creating and deleting by default
+func NewLifecycleSuppressor(resolver *NameResolver) *LifecycleSuppressor {
+	lifecycles := defaultSuppressedLifecycles
+	if v := os.Getenv("SUPPRESS_LIFECYCLES"); v != "" {
+		lifecycles = nil
+		for _, l := range strings.Split(v, ",") {
+			if l = strings.ToLower(strings.TrimSpace(l)); l != "" {
+				lifecycles = append(lifecycles, l)
+			}
+		}
+	}
+	return &LifecycleSuppressor{Lifecycles: lifecycles, Resolver: resolver}
+}
+
+// ShouldSuppress reports whether an alert of the given severity on clusterID
+// is suppressed. Critical alerts and clusters that cannot be resolved never are.
+func (s *LifecycleSuppressor) ShouldSuppress(clusterID, severity string) bool {
+	if clusterID == "" || strings.EqualFold(severity, "Critical") {
+		return false
+	}
+	info, err := s.Resolver.ResolveCluster(clusterID)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(s.Lifecycles, strings.ToLower(info.ClusterLifecycle))
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLifecycleSuppressor(t *testing.T) {
+	conn := openTestTiDB(t)
+	_, err := conn.Exec(`INSERT INTO clusters (cluster_id, cluster_name, tenant_id, cluster_lifecycle) VALUES
+			('3001', 'new', '1001', 'creating'),
+			('3002', 'old', '1001', 'deleting'),
+			('3003', 'live', '1001', 'active'),
+			('3004', 'paused', '1001', 'paused'),
+			('3005', 'loud', '1001', 'CREATING'),
+			('3006', 'unknown', '1001', NULL)`)
+	if err != nil {
+		t.Fatalf("seed clusters: %v", err)
+	}
+	s := NewLifecycleSuppressor(NewNameResolver())
+	defer s.Resolver.ClosePreparedStatements()
+
+	for _, tc := range []struct {
+		cluster, lifecycle string
+		suppressed         bool
+	}{
+		{"3001", "creating", true},
+		{"3002", "deleting", true},
+		{"3003", "active", false},
+		{"3004", "paused", false},
+		{"3005", "CREATING", true},
+		{"3006", "none", false},
+		{"3999", "unresolvable", false},
+		{"", "no cluster", false},
+	} {
+		if got := s.ShouldSuppress(tc.cluster, "Major"); got != tc.suppressed {
+			t.Errorf("Major alert of a %s cluster suppressed = %v, want %v", tc.lifecycle, got, tc.suppressed)
+		}
+		// Critical alerts are never suppressed
+		if s.ShouldSuppress(tc.cluster, "critical") {
+			t.Errorf("critical alert of a %s cluster suppressed", tc.lifecycle)
+		}
+	}
+}
+
+func TestNewLifecycleSuppressorFromEnv(t *testing.T) {
+	t.Setenv("SUPPRESS_LIFECYCLES", "")
+	if got := NewLifecycleSuppressor(nil).Lifecycles; !reflect.DeepEqual(got, []string{"creating", "deleting"}) {
+		t.Errorf("default lifecycles = %v", got)
+	}
+	t.Setenv("SUPPRESS_LIFECYCLES", " Paused, ,upgrading ")
+	if got := NewLifecycleSuppressor(nil).Lifecycles; !reflect.DeepEqual(got, []string{"paused", "upgrading"}) {
+		t.Errorf("lifecycles from SUPPRESS_LIFECYCLES = %v, want paused and upgrading", got)
+	}
+}
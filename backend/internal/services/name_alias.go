@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+// clusterAlias is an alternative cluster identifier column in TiDB
+type clusterAlias struct {
+	kind    string
+	column  string
+	missing atomic.Bool // set once TiDB reports the column does not exist
+}
+
+var (
+	clusterUUIDAlias = &clusterAlias{kind: "uuid", column: "cluster_uuid"}
+	clusterSlugAlias = &clusterAlias{kind: "slug", column: "cluster_slug"}
+)
+
+// aliasFor returns the alias column an ID that is not numeric may be stored in:
+// cluster_uuid for UUIDs, cluster_slug for slugs like "prod-us-east-1"
+func aliasFor(id string) *clusterAlias {
+	switch {
+	case uuidPattern.MatchString(id):
+		return clusterUUIDAlias
+	case slugPattern.MatchString(id):
+		return clusterSlugAlias
+	}
+	return nil
+}
+
+// resolveAlias maps a cluster UUID or slug to the numeric cluster ID. It
+// returns false when id is neither, matches no cluster, or cannot be looked up
+// right now. Mappings and misses are cached in aliasCache, apart from the names.
+func (nr *NameResolver) resolveAlias(ctx context.Context, id string) (string, bool) {
+	alias := aliasFor(id)
+	if alias == nil || alias.missing.Load() {
+		return "", false
+	}
+	// UUIDs are stored in lowercase
+	if alias == clusterUUIDAlias {
+		id = strings.ToLower(id)
+	}
+	key := alias.kind + ":" + id
+
+	nr.cacheMutex.RLock()
+	cached, ok := nr.aliasCache[key]
+	nr.cacheMutex.RUnlock()
+	if ok && nr.now().Sub(cached.timestamp) < nr.aliasTTL(cached) {
+		return cached.id, cached.id != ""
+	}
+
+	if !db.TiDBReady() || !nr.breaker.allow() {
+		return "", false
+	}
+
+	var canonical string
+	start := time.Now()
+	_, err := nr.queryRow(ctx, `SELECT cluster_id FROM clusters WHERE `+alias.column+` = ? LIMIT 1`, []interface{}{id}, &canonical)
+	if err == sql.ErrNoRows {
+		canonical, err = "", nil
+	}
+	nr.observeLookup(start, err)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1054 {
+			if !alias.missing.Swap(true) {
+				nr.logger.Info("No alias column in TiDB clusters table, lookups disabled", slog.String("column", alias.column))
+			}
+		} else {
+			nr.logger.Warn("Cluster alias lookup failed", slog.String("id", id), slog.Any("error", err))
+		}
+		return "", false
+	}
+
+	nr.cacheMutex.Lock()
+	nr.aliasCache[key] = reverseEntry{id: canonical, timestamp: nr.now()}
+	nr.cacheMutex.Unlock()
+	return canonical, canonical != ""
+}
+
+// aliasTTL keeps mappings as long as names and misses as long as unknown IDs
+func (nr *NameResolver) aliasTTL(entry reverseEntry) time.Duration {
+	if entry.id == "" {
+		return nr.notFoundTTL
+	}
+	return nr.cacheTTL
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// CacheBackend is a shared cache tier consulted after the in-memory LRU misses
+// and before TiDB is queried, so several instances can share resolved names.
+// Implementations must treat failures as misses; the resolver then falls back
+// to its in-memory cache and the database.
+type CacheBackend interface {
+	Get(key string) (cacheEntry, bool)
+	Set(key string, entry cacheEntry)
+}
+
+// cacheBackendDeleter is implemented by backends that support eviction, used
+// by Invalidate
+type cacheBackendDeleter interface {
+	Delete(key string)
+}
+
+// WithCacheBackend adds a shared cache tier in front of TiDB
+func WithCacheBackend(b CacheBackend) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.backend = b
+	}
+}
+
+const (
+	defaultRedisKeyPrefix = "name_resolver:"
+	redisOpTimeout        = 200 * time.Millisecond
+)
+
+// RedisCache is a CacheBackend storing entries as JSON in Redis. Keys expire
+// with the same TTL the resolver applies to the entry.
+type RedisCache struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    func(cacheEntry) time.Duration
+	now    func() time.Time
+	logger *slog.Logger
+}
+
+// redisCacheEntry is the JSON form of a cacheEntry
+type redisCacheEntry struct {
+	Info      NameInfo  `json:"info"`
+	NotFound  bool      `json:"not_found"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewRedisCache creates a Redis backend. Keys are prefixed with prefix, or
+// "name_resolver:" when it is empty.
+func NewRedisCache(client redis.UniversalClient, prefix string) *RedisCache {
+	if prefix == "" {
+		prefix = defaultRedisKeyPrefix
+	}
+	return &RedisCache{
+		client: client,
+		prefix: prefix,
+		now:    time.Now,
+		logger: slog.Default(),
+	}
+}
+
+// attach is called by NewNameResolver so keys expire with the resolver's TTLs
+// and clock
+func (rc *RedisCache) attach(nr *NameResolver) {
+	rc.ttl = nr.entryTTL
+	rc.now = nr.now
+	rc.logger = nr.logger
+}
+
+// Get returns the entry stored for key. Redis errors are logged and reported as a miss.
+func (rc *RedisCache) Get(key string) (cacheEntry, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	data, err := rc.client.Get(ctx, rc.prefix+key).Bytes()
+	if err == redis.Nil {
+		return cacheEntry{}, false
+	}
+	if err != nil {
+		rc.logger.Warn("Redis name cache get failed", slog.String("id", key), slog.Any("error", err))
+		return cacheEntry{}, false
+	}
+
+	var stored redisCacheEntry
+	if err := json.Unmarshal(data, &stored); err != nil {
+		rc.logger.Warn("Invalid Redis name cache entry", slog.String("id", key), slog.Any("error", err))
+		return cacheEntry{}, false
+	}
+	return cacheEntry{
+		info:      stored.Info,
+		notFound:  stored.NotFound,
+		timestamp: stored.Timestamp,
+		source:    sourceRedis,
+	}, true
+}
+
+// Set stores entry for key with the remaining TTL of the entry
+func (rc *RedisCache) Set(key string, entry cacheEntry) {
+	ttl := 24 * time.Hour
+	if rc.ttl != nil {
+		ttl = rc.ttl(entry) - rc.now().Sub(entry.timestamp)
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(redisCacheEntry{
+		Info:      entry.info,
+		NotFound:  entry.notFound,
+		Timestamp: entry.timestamp,
+	})
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	if err := rc.client.Set(ctx, rc.prefix+key, data, ttl).Err(); err != nil {
+		rc.logger.Warn("Redis name cache set failed", slog.String("id", key), slog.Any("error", err))
+	}
+}
+
+// Delete removes the entry stored for key
+func (rc *RedisCache) Delete(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+	if err := rc.client.Del(ctx, rc.prefix+key).Err(); err != nil {
+		rc.logger.Warn("Redis name cache delete failed", slog.String("id", key), slog.Any("error", err))
+	}
+}
+
+// redisCacheFromEnv builds a RedisCache from NAME_SERVICE_REDIS_URL and
+// NAME_SERVICE_REDIS_PREFIX. An unreachable Redis is still used, since every
+// operation falls back to the in-memory cache until it comes back.
+func redisCacheFromEnv(url, prefix string) (*RedisCache, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		slog.Warn("Redis name cache unreachable, using in-memory cache until it recovers", slog.Any("error", err))
+	}
+	return NewRedisCache(client, prefix), nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedisCache returns a RedisCache on a fresh miniredis server
+func newTestRedisCache(t *testing.T, prefix string) (*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisCache(client, prefix), mr
+}
+
+func TestRedisCacheSharesEntries(t *testing.T) {
+	seedTestTiDB(t, openTestTiDB(t))
+	clock := newTestClock()
+	rc, mr := newTestRedisCache(t, "test:")
+
+	first := NewNameResolver(WithCacheBackend(rc), WithClock(clock.Now), WithTypeTTL("cluster", 2*time.Hour))
+	defer first.ClosePreparedStatements()
+	if info, err := first.Resolve("2001"); err != nil || info.Name != "prod-east" {
+		t.Fatalf("Resolve(2001) = %+v, %v", info, err)
+	}
+	first.Resolve("3999")
+
+	// Entries are JSON and expire with the resolver's TTL for their type
+	var stored redisCacheEntry
+	if err := json.Unmarshal([]byte(mustGet(t, mr, "test:2001")), &stored); err != nil {
+		t.Fatalf("stored entry: %v", err)
+	}
+	if stored.Info.Name != "prod-east" || stored.NotFound || !stored.Timestamp.Equal(clock.Now()) {
+		t.Errorf("stored entry = %+v", stored)
+	}
+	if ttl := mr.TTL("test:2001"); ttl != 2*time.Hour {
+		t.Errorf("cluster key TTL = %v, want 2h", ttl)
+	}
+	if ttl := mr.TTL("test:3999"); ttl != time.Hour {
+		t.Errorf("not-found key TTL = %v, want the 1h not-found TTL", ttl)
+	}
+
+	// Another instance reads both from Redis without querying TiDB
+	reg := prometheus.NewRegistry()
+	second := NewNameResolver(WithCacheBackend(rc), WithClock(clock.Now), WithMetrics(reg))
+	if info, err := second.Resolve("2001"); err != nil || info.Name != "prod-east" || info.TenantName != "acme" {
+		t.Errorf("second instance resolved %+v, %v", info, err)
+	}
+	if info, _ := second.Resolve("3999"); info.Name != "3999" {
+		t.Errorf("second instance resolved the miss as %+v", info)
+	}
+	if n := counterValue(t, reg, "name_resolver_db_lookups_total"); n != 0 {
+		t.Errorf("second instance made %v database lookups, want 0", n)
+	}
+
+	// Invalidation removes the shared entry too
+	first.Invalidate([]string{"2001"})
+	if mr.Exists("test:2001") {
+		t.Error("invalidated entry is still in Redis")
+	}
+}
+
+func TestRedisCacheRemainingTTL(t *testing.T) {
+	clock := newTestClock()
+	rc, mr := newTestRedisCache(t, "")
+	NewNameResolver(WithCacheBackend(rc), WithClock(clock.Now))
+
+	// An entry cached 20 hours ago keeps the 4 hours it has left
+	rc.Set("2001", cacheEntry{info: NameInfo{Type: "cluster", Name: "prod-east"}, timestamp: clock.Now().Add(-20 * time.Hour)})
+	if ttl := mr.TTL(defaultRedisKeyPrefix + "2001"); ttl != 4*time.Hour {
+		t.Errorf("TTL = %v, want 4h", ttl)
+	}
+	// Expired entries are not stored
+	rc.Set("2002", cacheEntry{info: NameInfo{Type: "cluster"}, timestamp: clock.Now().Add(-25 * time.Hour)})
+	if mr.Exists(defaultRedisKeyPrefix + "2002") {
+		t.Error("expired entry was stored")
+	}
+
+	mr.Set(defaultRedisKeyPrefix+"2003", "not json")
+	if _, ok := rc.Get("2003"); ok {
+		t.Error("invalid entry was returned")
+	}
+}
+
+func TestRedisCacheUnavailable(t *testing.T) {
+	seedTestTiDB(t, openTestTiDB(t))
+	rc, mr := newTestRedisCache(t, "")
+	nr := NewNameResolver(WithCacheBackend(rc))
+	defer nr.ClosePreparedStatements()
+	mr.Close()
+
+	// Lookups fall back to TiDB and the in-memory cache
+	if info, err := nr.Resolve("2001"); err != nil || info.Name != "prod-east" {
+		t.Fatalf("Resolve with Redis down = %+v, %v", info, err)
+	}
+	if entry, ok := nr.cache.peek("2001"); !ok || entry.info.Name != "prod-east" {
+		t.Errorf("in-memory entry = %+v, %v", entry, ok)
+	}
+}
+
+func mustGet(t *testing.T, mr *miniredis.Miniredis, key string) string {
+	t.Helper()
+	v, err := mr.Get(key)
+	if err != nil {
+		t.Fatalf("redis GET %s: %v", key, err)
+	}
+	return v
+}
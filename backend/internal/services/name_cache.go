@@ -0,0 +1,229 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// cowRetries is the number of compare-and-swap attempts a write makes before
+// it serializes on lruCache.writeMu
+const cowRetries = 3
+
+// lruCache is a map of cache entries bounded by maxSize. Once full, the least
+// recently used entry is evicted before a new one is inserted. It is safe for
+// concurrent use; the name cache uses one per shard (see shardedCache).
+//
+// The map holds items whose entry is replaced in place, so refreshing a cached
+// ID is a single atomic store. The map itself is copy-on-write for inserts and
+// deletes: readers load the current version without locking, writers clone
+// it, apply their change and compare-and-swap it back. A write that loses the
+// race cowRetries times falls back to writeMu, so under heavy contention
+// writers queue instead of spinning. Each insert copies the map, so bulk loads
+// should go through setMany.
+//
+// Recency is a per-item stamp from a counter that get bumps atomically, so
+// reads never lock either. A full cache evicts the item with the oldest stamp.
+type lruCache struct {
+	maxSize int // 0 means unbounded
+	entries atomic.Pointer[map[string]*lruItem]
+	writeMu sync.Mutex
+
+	clock     atomic.Uint64 // recency stamps, bounded caches only
+	evictions atomic.Int64
+}
+
+// lruItem is a cache entry and when it was last used. Items are shared by the
+// versions of the map until their key is deleted.
+type lruItem struct {
+	entry atomic.Pointer[cacheEntry]
+	used  atomic.Uint64
+}
+
+func (c *lruCache) newItem(entry cacheEntry) *lruItem {
+	item := &lruItem{}
+	item.entry.Store(&entry)
+	if c.maxSize > 0 {
+		item.used.Store(c.clock.Add(1))
+	}
+	return item
+}
+
+// touch marks item as the most recently used. Hot keys are usually the newest
+// already, so the shared counter is only bumped when needed.
+func (c *lruCache) touch(item *lruItem) {
+	if c.maxSize > 0 && item.used.Load() != c.clock.Load() {
+		item.used.Store(c.clock.Add(1))
+	}
+}
+
+// cacheOp is one change applied by lruCache.apply
+type cacheOp struct {
+	key    string
+	entry  cacheEntry
+	delete bool
+}
+
+func newLRUCache(maxSize int) *lruCache {
+	c := &lruCache{maxSize: maxSize}
+	c.entries.Store(&map[string]*lruItem{})
+	return c
+}
+
+// get returns the entry for key and marks it as most recently used
+func (c *lruCache) get(key string) (cacheEntry, bool) {
+	item, ok := (*c.entries.Load())[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	c.touch(item)
+	return *item.entry.Load(), true
+}
+
+// peek returns the entry for key without touching its recency
+func (c *lruCache) peek(key string) (cacheEntry, bool) {
+	item, ok := (*c.entries.Load())[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	return *item.entry.Load(), true
+}
+
+// set inserts or replaces the entry for key, evicting the least recently used
+// entry first if the cache is full
+func (c *lruCache) set(key string, entry cacheEntry) {
+	if item, ok := (*c.entries.Load())[key]; ok {
+		item.entry.Store(&entry)
+		c.touch(item)
+		return
+	}
+	c.apply([]cacheOp{{key: key, entry: entry}})
+}
+
+// setMany inserts or replaces all entries with at most one copy of the map
+func (c *lruCache) setMany(entries map[string]cacheEntry) {
+	current := *c.entries.Load()
+	var ops []cacheOp
+	for key, entry := range entries {
+		if item, ok := current[key]; ok {
+			item.entry.Store(&entry)
+			c.touch(item)
+			continue
+		}
+		ops = append(ops, cacheOp{key: key, entry: entry})
+	}
+	if len(ops) > 0 {
+		c.apply(ops)
+	}
+}
+
+// delete removes the entry for key if present
+func (c *lruCache) delete(key string) bool {
+	if _, ok := c.peek(key); !ok {
+		return false
+	}
+	c.apply([]cacheOp{{key: key, delete: true}})
+	return true
+}
+
+// each calls fn for every entry of the current version of the map, most
+// recently used first for bounded caches. fn may modify the cache.
+func (c *lruCache) each(fn func(key string, entry cacheEntry)) {
+	entries := *c.entries.Load()
+	if c.maxSize == 0 {
+		for key, item := range entries {
+			fn(key, *item.entry.Load())
+		}
+		return
+	}
+
+	type usedKey struct {
+		key  string
+		used uint64
+	}
+	keys := make([]usedKey, 0, len(entries))
+	for key, item := range entries {
+		keys = append(keys, usedKey{key, item.used.Load()})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].used > keys[j].used })
+	for _, k := range keys {
+		fn(k.key, *entries[k.key].entry.Load())
+	}
+}
+
+// clear drops all entries but keeps the eviction counter
+func (c *lruCache) clear() {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.entries.Store(&map[string]*lruItem{})
+}
+
+func (c *lruCache) len() int {
+	return len(*c.entries.Load())
+}
+
+// evicted returns the number of entries evicted because the cache was full
+func (c *lruCache) evicted() int64 {
+	return c.evictions.Load()
+}
+
+// apply performs ops in order on a new version of the map
+func (c *lruCache) apply(ops []cacheOp) {
+	for range cowRetries {
+		old := c.entries.Load()
+		next, evicted := c.mutate(*old, ops)
+		if c.entries.CompareAndSwap(old, next) {
+			c.evictions.Add(evicted)
+			return
+		}
+	}
+
+	// Still contended: queue behind the other fallback writers. Lock-free
+	// writers can still win the race, so keep retrying until the swap lands.
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	for {
+		old := c.entries.Load()
+		next, evicted := c.mutate(*old, ops)
+		if c.entries.CompareAndSwap(old, next) {
+			c.evictions.Add(evicted)
+			return
+		}
+	}
+}
+
+// mutate returns a copy of old with ops applied and the number of entries
+// evicted to make room. Other items, and their recency, are shared.
+func (c *lruCache) mutate(old map[string]*lruItem, ops []cacheOp) (*map[string]*lruItem, int64) {
+	next := make(map[string]*lruItem, len(old)+len(ops))
+	for key, item := range old {
+		next[key] = item
+	}
+
+	var evicted int64
+	for _, op := range ops {
+		if op.delete {
+			delete(next, op.key)
+			continue
+		}
+		if _, exists := next[op.key]; !exists && c.maxSize > 0 && len(next) >= c.maxSize {
+			delete(next, oldestKey(next))
+			evicted++
+		}
+		next[op.key] = c.newItem(op.entry)
+	}
+	return &next, evicted
+}
+
+// oldestKey returns the least recently used key of a non-empty map
+func oldestKey(entries map[string]*lruItem) string {
+	var oldest string
+	var oldestUsed uint64
+	first := true
+	for key, item := range entries {
+		if used := item.used.Load(); first || used < oldestUsed {
+			oldest, oldestUsed, first = key, used, false
+		}
+	}
+	return oldest
+}
@@ -0,0 +1,123 @@
+package services
+
+// defaultCacheShards is the number of shards of the name cache when
+// WithShardCount is not given
+const defaultCacheShards = 64
+
+// shardedCache partitions the name cache into independent lruCaches by a hash
+// of the ID. Writes only copy their own shard, so concurrent writers
+// of different IDs rarely contend and each copy-on-write clones 1/n of the
+// entries.
+//
+// A bounded cache splits maxSize evenly between the shards and evicts the
+// least recently used entry of the full shard, so eviction order is only LRU
+// within a shard. Iteration visits one shard after the other.
+type shardedCache struct {
+	shards []*lruCache
+}
+
+// newShardedCache returns a cache of n shards (at least 1) holding up to
+// maxSize entries in total, or unbounded when maxSize is 0. A bounded cache
+// has at most maxSize shards so that every shard holds at least one entry.
+func newShardedCache(n, maxSize int) *shardedCache {
+	n = max(n, 1)
+	if maxSize > 0 {
+		n = min(n, maxSize)
+	}
+	c := &shardedCache{shards: make([]*lruCache, n)}
+	for i := range c.shards {
+		size := 0
+		if maxSize > 0 {
+			size = maxSize / n
+			if i < maxSize%n {
+				size++
+			}
+		}
+		c.shards[i] = newLRUCache(size)
+	}
+	return c
+}
+
+// shard returns the shard of key by its FNV-1a hash, computed inline so the
+// read path does not allocate
+func (c *shardedCache) shard(key string) *lruCache {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return c.shards[h%uint32(len(c.shards))]
+}
+
+// get returns the entry for key and marks it as most recently used
+func (c *shardedCache) get(key string) (cacheEntry, bool) {
+	return c.shard(key).get(key)
+}
+
+// peek returns the entry for key without touching its recency
+func (c *shardedCache) peek(key string) (cacheEntry, bool) {
+	return c.shard(key).peek(key)
+}
+
+// set inserts or replaces the entry for key
+func (c *shardedCache) set(key string, entry cacheEntry) {
+	c.shard(key).set(key, entry)
+}
+
+// setMany inserts or replaces all entries with a single copy of each shard
+func (c *shardedCache) setMany(entries map[string]cacheEntry) {
+	if len(c.shards) == 1 {
+		c.shards[0].setMany(entries)
+		return
+	}
+	perShard := make(map[*lruCache]map[string]cacheEntry)
+	for key, entry := range entries {
+		shard := c.shard(key)
+		if perShard[shard] == nil {
+			perShard[shard] = make(map[string]cacheEntry)
+		}
+		perShard[shard][key] = entry
+	}
+	for shard, shardEntries := range perShard {
+		shard.setMany(shardEntries)
+	}
+}
+
+// delete removes the entry for key if present
+func (c *shardedCache) delete(key string) bool {
+	return c.shard(key).delete(key)
+}
+
+// each calls fn for every entry, shard by shard. fn may modify the cache.
+func (c *shardedCache) each(fn func(key string, entry cacheEntry)) {
+	for _, shard := range c.shards {
+		shard.each(fn)
+	}
+}
+
+// clear drops all entries but keeps the eviction counters
+func (c *shardedCache) clear() {
+	for _, shard := range c.shards {
+		shard.clear()
+	}
+}
+
+func (c *shardedCache) len() int {
+	n := 0
+	for _, shard := range c.shards {
+		n += shard.len()
+	}
+	return n
+}
+
+// evicted returns the number of entries evicted because a shard was full
+func (c *shardedCache) evicted() int64 {
+	var n int64
+	for _, shard := range c.shards {
+		n += shard.evicted()
+	}
+	return n
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// PersistCache writes all valid cache entries to the name_cache table, replacing
+// whatever was stored before
+func (nr *NameResolver) PersistCache(db *gorm.DB) error {
+	// Snapshot under the read lock, write to SQLite outside of it
+	nr.cacheMutex.RLock()
+	rows := make([]models.NameCacheEntry, 0, nr.cache.len())
+	nr.cache.each(func(id string, entry cacheEntry) {
+		// Fallback entries are reloaded from their file instead
+		if !nr.isEntryValid(entry) || entry.source == sourceFallback {
+			return
+		}
+		rows = append(rows, models.NameCacheEntry{
+			ID:         id,
+			Type:       entry.info.Type,
+			Name:       entry.info.Name,
+			TenantID:   entry.info.TenantID,
+			TenantName: entry.info.TenantName,
+			DeployType: entry.info.DeployType,
+			NotFound:   entry.notFound,
+			CachedAt:   entry.timestamp,
+		})
+	})
+	nr.cacheMutex.RUnlock()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.NameCacheEntry{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to persist name cache: %w", err)
+	}
+
+	nr.logger.Info("Persisted name cache", slog.Int("entries", len(rows)))
+	return nil
+}
+
+// LoadCachedNames restores entries from the name_cache table that are still
+// within their TTL. Entries already in memory with a newer timestamp are kept.
+func (nr *NameResolver) LoadCachedNames(db *gorm.DB) error {
+	var rows []models.NameCacheEntry
+	if err := db.Find(&rows).Error; err != nil {
+		return fmt.Errorf("failed to load name cache: %w", err)
+	}
+
+	loaded := make(map[string]cacheEntry, len(rows))
+	nr.cacheMutex.Lock()
+	for _, row := range rows {
+		entry := cacheEntry{
+			info: NameInfo{
+				Type:       row.Type,
+				ID:         row.ID,
+				Name:       row.Name,
+				TenantID:   row.TenantID,
+				TenantName: row.TenantName,
+				DeployType: row.DeployType,
+			},
+			notFound:  row.NotFound,
+			timestamp: row.CachedAt,
+			source:    sourceSQLite,
+		}
+		if !nr.isEntryValid(entry) {
+			continue
+		}
+		if existing, ok := nr.cache.peek(row.ID); ok && !existing.timestamp.Before(entry.timestamp) {
+			continue
+		}
+		loaded[row.ID] = entry
+	}
+	nr.cache.setMany(loaded)
+	nr.cacheMutex.Unlock()
+
+	nr.logger.Info("Loaded persisted name cache", slog.Int("loaded", len(loaded)), slog.Int("total", len(rows)))
+	return nil
+}
+
+// StartCachePersistence periodically saves the cache to SQLite
+func (nr *NameResolver) StartCachePersistence(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := nr.PersistCache(db); err != nil {
+				nr.logger.Warn("Name cache persistence failed", slog.Any("error", err))
+			}
+		}
+	}()
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+func TestPersistCacheKeepsDeployType(t *testing.T) {
+	sqlite := openTestDB(t)
+	seedTestTiDB(t, openTestTiDB(t))
+	nr := NewNameResolver()
+	defer nr.ClosePreparedStatements()
+
+	info, err := nr.Resolve("2001")
+	if err != nil || info.DeployType != "dedicated" {
+		t.Fatalf("Resolve(2001) = %+v, %v", info, err)
+	}
+	if err := nr.PersistCache(sqlite); err != nil {
+		t.Fatal(err)
+	}
+	var row models.NameCacheEntry
+	if err := sqlite.First(&row, "id = ?", "2001").Error; err != nil || row.DeployType != "dedicated" {
+		t.Fatalf("persisted row = %+v, %v, want deploy_type dedicated", row, err)
+	}
+
+	// A restarted resolver restores the deploy type along with the name
+	restarted := NewNameResolver()
+	defer restarted.ClosePreparedStatements()
+	if err := restarted.LoadCachedNames(sqlite); err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := restarted.cache.peek("2001")
+	if !ok || entry.info != info {
+		t.Errorf("restored entry = %+v, %v, want %+v", entry.info, ok, info)
+	}
+}
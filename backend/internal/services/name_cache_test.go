@@ -0,0 +1,236 @@
+package services
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testEntry(name string) cacheEntry {
+	return cacheEntry{info: NameInfo{ID: name, Name: name}}
+}
+
+func TestLRUCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newLRUCache(3)
+	c.set("a", testEntry("a"))
+	c.set("b", testEntry("b"))
+	c.set("c", testEntry("c"))
+
+	// a becomes the most recently used, so b is the first to go
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("a missing")
+	}
+	c.set("d", testEntry("d"))
+	if _, ok := c.peek("b"); ok {
+		t.Error("b was not evicted")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, ok := c.peek(key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+
+	// peek does not count as a use
+	c.peek("c")
+	c.set("e", testEntry("e"))
+	if _, ok := c.peek("c"); ok {
+		t.Error("c was kept although only peeked at")
+	}
+	if c.len() != 3 || c.evicted() != 2 {
+		t.Errorf("len = %d, evicted = %d, want 3 and 2", c.len(), c.evicted())
+	}
+
+	var order []string
+	c.each(func(key string, _ cacheEntry) { order = append(order, key) })
+	if fmt.Sprint(order) != "[e d a]" {
+		t.Errorf("each visited %v, want most recently used first", order)
+	}
+}
+
+func TestLRUCacheSetManyEvicts(t *testing.T) {
+	c := newLRUCache(2)
+	c.set("old", testEntry("old"))
+	c.setMany(map[string]cacheEntry{"x": testEntry("x"), "y": testEntry("y")})
+	if c.len() != 2 || c.evicted() != 1 {
+		t.Errorf("len = %d, evicted = %d, want 2 and 1", c.len(), c.evicted())
+	}
+	if _, ok := c.peek("old"); ok {
+		t.Error("the oldest entry survived a bulk insert")
+	}
+
+	// Replacing an entry neither grows the cache nor evicts
+	c.set("x", testEntry("x2"))
+	if entry, _ := c.peek("x"); entry.info.Name != "x2" || c.evicted() != 1 {
+		t.Errorf("x = %+v, evicted = %d", entry.info, c.evicted())
+	}
+	if !c.delete("x") || c.delete("x") {
+		t.Error("delete did not report whether the entry existed")
+	}
+}
+
+func TestShardedCacheConcurrent(t *testing.T) {
+	c := newShardedCache(8, 800)
+	var wg sync.WaitGroup
+	for g := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 2000 {
+				key := fmt.Sprintf("id-%d", (g*31+i)%1200)
+				if i%4 == 0 {
+					c.set(key, testEntry(key))
+				} else if entry, ok := c.get(key); ok && entry.info.ID != key {
+					t.Errorf("get(%s) returned %s", key, entry.info.ID)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := c.len(); n > 800 {
+		t.Errorf("len = %d, over the bound of 800", n)
+	}
+}
+
+func TestWithShardCount(t *testing.T) {
+	for _, tc := range []struct {
+		opts   []NameResolverOption
+		shards int
+	}{
+		{nil, defaultCacheShards},
+		{[]NameResolverOption{WithShardCount(8)}, 8},
+		{[]NameResolverOption{WithShardCount(0)}, 1},
+		// A bounded cache has no more shards than entries
+		{[]NameResolverOption{WithShardCount(16), WithMaxCacheSize(4)}, 4},
+	} {
+		if got := len(NewNameResolver(tc.opts...).cache.shards); got != tc.shards {
+			t.Errorf("%d options: %d shards, want %d", len(tc.opts), got, tc.shards)
+		}
+	}
+
+	// Every ID always maps to the same shard, and IDs spread over all of them
+	c := newShardedCache(8, 0)
+	used := map[*lruCache]int{}
+	for i := range 800 {
+		key := fmt.Sprintf("1%018d", i)
+		if c.shard(key) != c.shard(key) {
+			t.Fatalf("%s maps to different shards", key)
+		}
+		c.set(key, testEntry(key))
+		used[c.shard(key)]++
+	}
+	if len(used) != 8 {
+		t.Errorf("800 IDs used %d of 8 shards", len(used))
+	}
+	for shard, n := range used {
+		if shard.len() != n {
+			t.Errorf("shard holds %d entries, %d were routed to it", shard.len(), n)
+		}
+	}
+}
+
+// BenchmarkCacheShardsP99 compares the p99 latency of a single shard, the
+// cache before sharding, with the default shard count under a workload from
+// 1000 goroutines. Writes delete and insert IDs, so each one copies its shard.
+func BenchmarkCacheShardsP99(b *testing.B) {
+	const (
+		goroutines = 1000
+		keys       = 10_000
+		writePct   = 10
+	)
+	ids := make([]string, keys)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("1%018d", i)
+	}
+
+	for _, shards := range []int{1, defaultCacheShards} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			c := newShardedCache(shards, 0)
+			initial := make(map[string]cacheEntry, keys)
+			for _, id := range ids {
+				initial[id] = testEntry(id)
+			}
+			c.setMany(initial)
+
+			perGoroutine := max(b.N/goroutines, 1)
+			latencies := make([][]time.Duration, goroutines)
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for g := range goroutines {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					r := rand.New(rand.NewPCG(uint64(g), 0))
+					own := make([]time.Duration, perGoroutine)
+					<-start
+					for i := range own {
+						id := ids[r.IntN(keys)]
+						begin := time.Now()
+						switch n := r.IntN(100); {
+						case n < writePct/2:
+							c.delete(id)
+						case n < writePct:
+							c.set(id, testEntry(id))
+						default:
+							c.get(id)
+						}
+						own[i] = time.Since(begin)
+					}
+					latencies[g] = own
+				}()
+			}
+
+			b.ResetTimer()
+			close(start)
+			wg.Wait()
+			b.StopTimer()
+
+			all := make([]time.Duration, 0, goroutines*perGoroutine)
+			for _, own := range latencies {
+				all = append(all, own...)
+			}
+			sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+			b.ReportMetric(float64(all[len(all)*99/100].Nanoseconds()), "p99-ns/op")
+		})
+	}
+}
+
+// BenchmarkNameCache measures lookups and writes of the name cache at
+// different read/write mixes, bounded (LRU) and unbounded
+func BenchmarkNameCache(b *testing.B) {
+	const keys = 100_000
+	ids := make([]string, keys)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("1%018d", i)
+	}
+
+	for _, maxSize := range []int{0, keys / 2} {
+		for _, writePct := range []int{0, 1, 10} {
+			b.Run(fmt.Sprintf("max=%d/writes=%d%%", maxSize, writePct), func(b *testing.B) {
+				c := newShardedCache(defaultCacheShards, maxSize)
+				initial := make(map[string]cacheEntry, keys)
+				for _, id := range ids {
+					initial[id] = testEntry(id)
+				}
+				c.setMany(initial)
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					r := rand.New(rand.NewPCG(rand.Uint64(), 0))
+					for pb.Next() {
+						id := ids[r.IntN(keys)]
+						if r.IntN(100) < writePct {
+							c.set(id, testEntry(id))
+						} else {
+							c.get(id)
+						}
+					}
+				})
+			})
+		}
+	}
+}
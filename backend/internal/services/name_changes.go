@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// NameChange is a cluster or tenant whose upstream name differs from the cached one
+type NameChange struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	OldName   string    `json:"old_name"`
+	NewName   string    `json:"new_name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DetectNameChanges finds the clusters and tenants renamed upstream since
+// since (see FindNameChanges) and evicts them (see EvictNameChanges) so the
+// next Resolve picks up the new name
+func (nr *NameResolver) DetectNameChanges(sqlite *gorm.DB, since time.Time) ([]NameChange, error) {
+	changes, err := nr.FindNameChanges(sqlite, since)
+	if err != nil {
+		return nil, err
+	}
+	nr.EvictNameChanges(sqlite, changes)
+	return changes, nil
+}
+
+// FindNameChanges compares clusters and tenants updated in TiDB after since
+// with the cached names (in memory, or in the name_cache table) and returns
+// those whose name changed, leaving the cache as it is. Entities that were
+// never cached are not reported.
+func (nr *NameResolver) FindNameChanges(sqlite *gorm.DB, since time.Time) ([]NameChange, error) {
+	if !db.TiDBReady() {
+		return nil, fmt.Errorf("TiDB not connected")
+	}
+
+	upstream, err := nr.updatedNamesSince(since)
+	if err != nil {
+		return nil, err
+	}
+	if len(upstream) == 0 {
+		return []NameChange{}, nil
+	}
+
+	ids := make([]string, 0, len(upstream))
+	for _, u := range upstream {
+		ids = append(ids, u.ID)
+	}
+
+	// Persisted names fill in for IDs no longer held in memory
+	persisted := make(map[string]string)
+	for start := 0; start < len(ids); start += 500 {
+		end := min(start+500, len(ids))
+		var rows []models.NameCacheEntry
+		if err := sqlite.Where("id IN ? AND not_found = ?", ids[start:end], false).Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("failed to read name cache: %w", err)
+		}
+		for _, row := range rows {
+			persisted[row.ID] = row.Name
+		}
+	}
+
+	changes := []NameChange{}
+	nr.cacheMutex.RLock()
+	for _, u := range upstream {
+		oldName, ok := persisted[u.ID]
+		if entry, cached := nr.cache.peek(u.ID); cached && !entry.notFound {
+			oldName, ok = entry.info.Name, true
+		}
+		if ok && oldName != u.NewName {
+			u.OldName = oldName
+			changes = append(changes, u)
+		}
+	}
+	nr.cacheMutex.RUnlock()
+	return changes, nil
+}
+
+// EvictNameChanges drops the renamed IDs of changes from the name cache, in
+// memory and in the name_cache table
+func (nr *NameResolver) EvictNameChanges(sqlite *gorm.DB, changes []NameChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	changed := make([]string, len(changes))
+	for i, c := range changes {
+		changed[i] = c.ID
+	}
+	nr.Invalidate(changed)
+	if err := sqlite.Where("id IN ?", changed).Delete(&models.NameCacheEntry{}).Error; err != nil {
+		nr.logger.Warn("Failed to drop renamed entries from persisted name cache", slog.Any("error", err))
+	}
+
+	nr.logger.Info("Evicted renamed clusters/tenants", slog.Int("count", len(changes)))
+}
+
+// updatedNamesSince returns the current names of clusters and tenants updated after since
+func (nr *NameResolver) updatedNamesSince(since time.Time) ([]NameChange, error) {
+	var results []NameChange
+
+	rows, err := db.TiDB.Query(`
+		SELECT cluster_id, cluster_name, COALESCE(deploy_type, ''), updated_at
+		FROM clusters WHERE updated_at > ?
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query updated clusters: %w", err)
+	}
+	for rows.Next() {
+		var id, name, deployType string
+		var updatedAt time.Time
+		if err := rows.Scan(&id, &name, &deployType, &updatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		results = append(results, NameChange{
+			ID:        id,
+			Type:      "cluster",
+			NewName:   nr.clusterDisplayName(id, name, deployType),
+			UpdatedAt: updatedAt,
+		})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.TiDB.Query(`
+		SELECT tenant_id, tenant_name, updated_at
+		FROM tenants WHERE updated_at > ?
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query updated tenants: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, name string
+		var updatedAt time.Time
+		if err := rows.Scan(&id, &name, &updatedAt); err != nil {
+			return nil, err
+		}
+		results = append(results, NameChange{
+			ID:        id,
+			Type:      "tenant",
+			NewName:   name,
+			UpdatedAt: updatedAt,
+		})
+	}
+	return results, rows.Err()
+}
+
+// StartNameChangeWatcher runs DetectNameChanges every interval, each time
+// looking at what changed since the previous run, until ctx is cancelled
+func (nr *NameResolver) StartNameChangeWatcher(ctx context.Context, sqlite *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		since := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			checkedAt := time.Now()
+			if _, err := nr.DetectNameChanges(sqlite, since); err != nil {
+				nr.logger.Warn("Name change check failed", slog.Any("error", err))
+				continue
+			}
+			since = checkedAt
+		}
+	}()
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindAndEvictNameChanges(t *testing.T) {
+	sqlite := openTestDB(t)
+	conn := openTestTiDB(t)
+	nr := useNameResolver(t)
+	if _, err := conn.Exec(`INSERT INTO clusters (cluster_id, cluster_name, tenant_id) VALUES ('10001', 'orders', '1')`); err != nil {
+		t.Fatal(err)
+	}
+	before, err := nr.Resolve("10001")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	if _, err := conn.Exec(`UPDATE clusters SET cluster_name = 'payments', updated_at = '2026-06-01 00:00:00' WHERE cluster_id = '10001'`); err != nil {
+		t.Fatal(err)
+	}
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// Finding the changes leaves the cache alone
+	changes, err := nr.FindNameChanges(sqlite, since)
+	if err != nil {
+		t.Fatalf("FindNameChanges: %v", err)
+	}
+	if len(changes) != 1 || changes[0].ID != "10001" || changes[0].OldName != before.Name || changes[0].NewName == before.Name {
+		t.Fatalf("changes = %+v, want the rename of 10001 from %q", changes, before.Name)
+	}
+	if info, _ := nr.Resolve("10001"); info.Name != before.Name {
+		t.Errorf("name after FindNameChanges = %q, want the cached %q", info.Name, before.Name)
+	}
+
+	// Detecting them evicts the renamed IDs
+	if changes, err := nr.DetectNameChanges(sqlite, since); err != nil || len(changes) != 1 {
+		t.Fatalf("DetectNameChanges = %+v, %v", changes, err)
+	}
+	if info, _ := nr.Resolve("10001"); info.Name != changes[0].NewName {
+		t.Errorf("name after DetectNameChanges = %q, want %q", info.Name, changes[0].NewName)
+	}
+	if changes, err := nr.FindNameChanges(sqlite, since); err != nil || len(changes) != 0 {
+		t.Errorf("FindNameChanges after the eviction = %+v, %v, want none", changes, err)
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+// InconsistencyReport is a cache entry whose name no longer matches TiDB.
+// DBName is empty when the ID no longer exists upstream.
+type InconsistencyReport struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	CachedName string    `json:"cached_name"`
+	DBName     string    `json:"db_name"`
+	CachedAt   time.Time `json:"cached_at"`
+}
+
+// ConsistencyCheck re-fetches every live cache entry from TiDB and reports the
+// ones whose cached name differs from the current one. Inconsistent entries
+// are evicted so the next Resolve picks up the database value.
+func (nr *NameResolver) ConsistencyCheck(ctx context.Context) ([]InconsistencyReport, error) {
+	if !db.TiDBReady() {
+		return nil, fmt.Errorf("TiDB not connected")
+	}
+
+	// Snapshot the entries so the lookups below run without holding the lock
+	type cached struct {
+		id    string
+		entry cacheEntry
+	}
+	var entries []cached
+	nr.cacheMutex.RLock()
+	nr.cache.each(func(id string, entry cacheEntry) {
+		if !entry.notFound && entry.source != sourceFallback && nr.isEntryValid(entry) {
+			entries = append(entries, cached{id, entry})
+		}
+	})
+	nr.cacheMutex.RUnlock()
+
+	reports := []InconsistencyReport{}
+	for _, c := range entries {
+		if err := ctx.Err(); err != nil {
+			return reports, err
+		}
+
+		dbName, err := nr.currentName(c.id, c.entry.info)
+		if err != nil {
+			return reports, fmt.Errorf("failed to look up %s: %w", c.id, err)
+		}
+		if dbName != c.entry.info.Name {
+			reports = append(reports, InconsistencyReport{
+				ID:         c.id,
+				Type:       c.entry.info.Type,
+				CachedName: c.entry.info.Name,
+				DBName:     dbName,
+				CachedAt:   c.entry.timestamp,
+			})
+		}
+	}
+
+	if len(reports) > 0 {
+		ids := make([]string, len(reports))
+		for i, r := range reports {
+			ids[i] = r.ID
+		}
+		nr.Invalidate(ids)
+		nr.logger.Info("Evicted inconsistent name cache entries", slog.Int("count", len(reports)))
+	}
+	return reports, nil
+}
+
+// currentName returns the name TiDB holds for id as Resolve would build it, or
+// "" if it no longer exists. Entries of unknown type are returned unchanged.
+func (nr *NameResolver) currentName(id string, cached NameInfo) (string, error) {
+	switch cached.Type {
+	case "cluster":
+		info, _, err := nr.getCluster(context.Background(), id)
+		if err != nil || info == nil {
+			return "", err
+		}
+		return nr.clusterDisplayName(id, info.ClusterName, info.DeployType), nil
+	case "tenant":
+		name, _, err := nr.getTenantName(context.Background(), id)
+		return name, err
+	case "project":
+		name, _, err := nr.getProjectName(context.Background(), id)
+		return name, err
+	}
+	return cached.Name, nil
+}
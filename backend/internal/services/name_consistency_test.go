@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+func TestConsistencyCheck(t *testing.T) {
+	conn := openTestTiDB(t)
+	seedTestTiDB(t, conn)
+	if _, err := conn.Exec(`INSERT INTO clusters (cluster_id, cluster_name, tenant_id, deploy_type) VALUES ('2002', 'staging', '1001', 'dedicated')`); err != nil {
+		t.Fatalf("seed cluster 2002: %v", err)
+	}
+	clock := newTestClock()
+	nr := NewNameResolver(WithClock(clock.Now))
+	defer nr.ClosePreparedStatements()
+
+	for _, id := range []string{"2001", "2002", "1001"} {
+		if _, err := nr.Resolve(id); err != nil {
+			t.Fatalf("Resolve(%s): %v", id, err)
+		}
+	}
+	nr.Resolve("3999")
+	cachedAt := clock.Now()
+
+	// The cluster is renamed and the other one removed after they were cached
+	for _, q := range []string{
+		`UPDATE clusters SET cluster_name = 'prod-east-2' WHERE cluster_id = '2001'`,
+		`DELETE FROM clusters WHERE cluster_id = '2002'`,
+	} {
+		if _, err := conn.Exec(q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+	if info, _ := nr.Resolve("2001"); info.Name != "prod-east" {
+		t.Fatalf("cached name = %q, want the stale prod-east", info.Name)
+	}
+
+	reports, err := nr.ConsistencyCheck(context.Background())
+	if err != nil {
+		t.Fatalf("ConsistencyCheck: %v", err)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].ID < reports[j].ID })
+	want := []InconsistencyReport{
+		{ID: "2001", Type: "cluster", CachedName: "prod-east", DBName: "prod-east-2", CachedAt: cachedAt},
+		{ID: "2002", Type: "cluster", CachedName: "staging", DBName: "", CachedAt: cachedAt},
+	}
+	if len(reports) != len(want) {
+		t.Fatalf("reports = %+v, want %+v", reports, want)
+	}
+	for i := range want {
+		if reports[i] != want[i] {
+			t.Errorf("report %d = %+v, want %+v", i, reports[i], want[i])
+		}
+	}
+
+	// Inconsistent entries were evicted, the consistent tenant was kept
+	if _, ok := nr.cache.peek("2001"); ok {
+		t.Error("inconsistent entry 2001 is still cached")
+	}
+	if _, ok := nr.cache.peek("1001"); !ok {
+		t.Error("consistent entry 1001 was evicted")
+	}
+	if info, _ := nr.Resolve("2001"); info.Name != "prod-east-2" {
+		t.Errorf("name after the check = %q, want prod-east-2", info.Name)
+	}
+	if reports, err := nr.ConsistencyCheck(context.Background()); err != nil || len(reports) != 0 {
+		t.Errorf("second check = %+v, %v, want no reports", reports, err)
+	}
+}
+
+func TestConsistencyCheckErrors(t *testing.T) {
+	conn := openTestTiDB(t)
+	seedTestTiDB(t, conn)
+	nr := NewNameResolver()
+	defer nr.ClosePreparedStatements()
+	nr.Resolve("2001")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := nr.ConsistencyCheck(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled check error = %v, want context.Canceled", err)
+	}
+
+	db.SetTiDB(nil)
+	if _, err := nr.ConsistencyCheck(context.Background()); err == nil {
+		t.Error("check without TiDB succeeded")
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+// clusterCacheEntry is a cached ClusterInfo
+type clusterCacheEntry struct {
+	info      ClusterInfo
+	timestamp time.Time
+}
+
+// tenantCacheEntry is a cached TenantInfo
+type tenantCacheEntry struct {
+	info      TenantInfo
+	timestamp time.Time
+}
+
+// WithDetailTTL sets the TTL for the full cluster and tenant details returned
+// by ResolveCluster and ResolveTenant
+func WithDetailTTL(ttl time.Duration) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.detailTTL = ttl
+	}
+}
+
+// ResolveCluster returns the full ClusterInfo (deploy type, version, provider,
+// region, ...) for clusterID, from cache or TiDB. Returns ErrIDNotFound if the
+// cluster does not exist.
+func (nr *NameResolver) ResolveCluster(clusterID string) (*ClusterInfo, error) {
+	if clusterID == "" {
+		return nil, fmt.Errorf("empty cluster ID")
+	}
+
+	nr.cacheMutex.RLock()
+	entry, ok := nr.clusterCache[clusterID]
+	nr.cacheMutex.RUnlock()
+	if ok && nr.now().Sub(entry.timestamp) < nr.detailTTL {
+		info := entry.info
+		return &info, nil
+	}
+
+	if !db.TiDBReady() {
+		return nil, fmt.Errorf("TiDB not connected")
+	}
+	if !nr.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	v, err, _ := nr.lookupGroup.Do("cluster:"+clusterID, func() (interface{}, error) {
+		start := time.Now()
+		info, _, err := nr.getCluster(context.Background(), clusterID)
+		nr.observeLookup(start, err)
+		if err != nil {
+			return nil, err
+		}
+		if info == nil {
+			return nil, fmt.Errorf("%w: %s", ErrIDNotFound, clusterID)
+		}
+
+		nr.cacheMutex.Lock()
+		nr.clusterCache[clusterID] = clusterCacheEntry{info: *info, timestamp: nr.now()}
+		nr.cacheMutex.Unlock()
+		return *info, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	info := v.(ClusterInfo)
+	return &info, nil
+}
+
+// ResolveTenant returns the full TenantInfo for tenantID, from cache or TiDB.
+// Returns ErrIDNotFound if the tenant does not exist.
+func (nr *NameResolver) ResolveTenant(tenantID string) (*TenantInfo, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("empty tenant ID")
+	}
+
+	nr.cacheMutex.RLock()
+	entry, ok := nr.tenantCache[tenantID]
+	nr.cacheMutex.RUnlock()
+	if ok && nr.now().Sub(entry.timestamp) < nr.detailTTL {
+		info := entry.info
+		return &info, nil
+	}
+
+	if !db.TiDBReady() {
+		return nil, fmt.Errorf("TiDB not connected")
+	}
+	if !nr.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	v, err, _ := nr.lookupGroup.Do("tenant:"+tenantID, func() (interface{}, error) {
+		start := time.Now()
+		info, _, err := nr.getTenant(context.Background(), tenantID)
+		nr.observeLookup(start, err)
+		if err != nil {
+			return nil, err
+		}
+		if info == nil {
+			return nil, fmt.Errorf("%w: %s", ErrIDNotFound, tenantID)
+		}
+
+		nr.cacheMutex.Lock()
+		nr.tenantCache[tenantID] = tenantCacheEntry{info: *info, timestamp: nr.now()}
+		nr.cacheMutex.Unlock()
+		return *info, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	info := v.(TenantInfo)
+	return &info, nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// NameResolvedEvent announces a cluster or tenant seen for the first time by
+// this resolver
+type NameResolvedEvent struct {
+	EntityType   string    `json:"entityType"`
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	TenantID     string    `json:"tenantId,omitempty"`
+	DiscoveredAt time.Time `json:"discoveredAt"`
+}
+
+// EventEmitter publishes NameResolvedEvents to other services
+type EventEmitter interface {
+	Emit(event NameResolvedEvent) error
+}
+
+// WithEventEmitter emits a NameResolvedEvent the first time each entity is
+// resolved from the database
+func WithEventEmitter(e EventEmitter) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.emitter = e
+	}
+}
+
+// emitDiscovered emits an event for info unless it was emitted before. Runs in
+// its own goroutine so a slow broker never delays Resolve. Failed emits are
+// forgotten so the entity is announced again on its next resolution.
+func (nr *NameResolver) emitDiscovered(info NameInfo) {
+	nr.seenMutex.Lock()
+	if _, ok := nr.seen[info.ID]; ok {
+		nr.seenMutex.Unlock()
+		return
+	}
+	nr.seen[info.ID] = struct{}{}
+	nr.seenMutex.Unlock()
+
+	go func() {
+		err := nr.emitter.Emit(NameResolvedEvent{
+			EntityType:   info.Type,
+			ID:           info.ID,
+			Name:         info.Name,
+			TenantID:     info.TenantID,
+			DiscoveredAt: time.Now(),
+		})
+		if err != nil {
+			nr.logger.Warn("Failed to emit name resolved event", slog.String("id", info.ID), slog.Any("error", err))
+			nr.seenMutex.Lock()
+			delete(nr.seen, info.ID)
+			nr.seenMutex.Unlock()
+		}
+	}()
+}
+
+// KafkaEmitter is an EventEmitter writing JSON events to a Kafka topic, keyed by entity ID
+type KafkaEmitter struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaEmitter creates an emitter for topic on the given brokers
+func NewKafkaEmitter(brokers []string, topic string) *KafkaEmitter {
+	return &KafkaEmitter{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireOne,
+		},
+	}
+}
+
+// kafkaEmitterFromEnv creates a KafkaEmitter from KAFKA_BROKER (comma-separated)
+// and KAFKA_TOPIC, or returns nil if either is unset
+func kafkaEmitterFromEnv() *KafkaEmitter {
+	brokers, topic := os.Getenv("KAFKA_BROKER"), os.Getenv("KAFKA_TOPIC")
+	if brokers == "" || topic == "" {
+		return nil
+	}
+	return NewKafkaEmitter(strings.Split(brokers, ","), topic)
+}
+
+// Emit writes event to the topic
+func (k *KafkaEmitter) Emit(event NameResolvedEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return k.writer.WriteMessages(ctx, kafka.Message{Key: []byte(event.ID), Value: value})
+}
+
+// Close flushes and closes the underlying writer
+func (k *KafkaEmitter) Close() error {
+	return k.writer.Close()
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+// fallbackTimestamp keeps fallback entries valid regardless of the cache TTLs
+var fallbackTimestamp = time.Date(9999, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// fallbackEntry is one record of the NAME_SERVICE_FALLBACK_FILE JSON array
+type fallbackEntry struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	TenantID   string `json:"tenant_id"`
+	TenantName string `json:"tenant_name"`
+}
+
+// LoadFallbackFile loads a static JSON list of names for environments where
+// TiDB is unreachable. The entries never expire but have lower priority than
+// live results: entries already cached from TiDB are kept, and Resolve
+// replaces a fallback entry as soon as TiDB can resolve the ID.
+func (nr *NameResolver) LoadFallbackFile(path string) (int, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read name fallback file: %w", err)
+	}
+
+	var entries []fallbackEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return 0, fmt.Errorf("invalid name fallback file %s: %w", path, err)
+	}
+
+	loaded := make(map[string]cacheEntry, len(entries))
+	nr.cacheMutex.Lock()
+	for _, e := range entries {
+		if e.ID == "" || e.Name == "" {
+			continue
+		}
+		if existing, ok := nr.cache.peek(e.ID); ok && existing.source != sourceFallback && nr.isEntryValid(existing) {
+			continue
+		}
+		loaded[e.ID] = cacheEntry{
+			info: NameInfo{
+				Type:       e.Type,
+				ID:         e.ID,
+				Name:       e.Name,
+				TenantID:   e.TenantID,
+				TenantName: e.TenantName,
+			},
+			timestamp: fallbackTimestamp,
+			source:    sourceFallback,
+		}
+	}
+	nr.cache.setMany(loaded)
+	nr.cacheMutex.Unlock()
+
+	nr.logger.Info("Loaded name fallback file", slog.String("path", path), slog.Int("loaded", len(loaded)), slog.Int("total", len(entries)))
+	return len(loaded), nil
+}
+
+// refreshFallback looks id up in TiDB when it is reachable so the live result
+// replaces the fallback entry, and keeps serving the entry otherwise.
+// resolveStart is when the calling Resolve began.
+func (nr *NameResolver) refreshFallback(ctx context.Context, id string, entry cacheEntry, resolveStart time.Time) NameInfo {
+	if !db.TiDBReady() || !nr.breaker.allow() {
+		return entry.info
+	}
+
+	result, err, _ := nr.lookupGroup.Do(id, func() (interface{}, error) {
+		return nr.resolveFromDB(context.WithoutCancel(ctx), id, resolveStart)
+	})
+	if err == nil {
+		return result.(NameInfo)
+	}
+
+	// resolveFromDB cached a miss, put the fallback entry back
+	nr.cache.set(id, entry)
+	return entry.info
+}
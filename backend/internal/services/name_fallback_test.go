@@ -0,0 +1,113 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+// writeFallbackFile writes content to a fallback file in a temporary directory
+func writeFallbackFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "names.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const fallbackNames = `[
+	{"id": "2001", "type": "cluster", "name": "prod-1", "tenant_id": "1001", "tenant_name": "acme"},
+	{"id": "2005", "type": "cluster", "name": "dr-1", "tenant_id": "1001", "tenant_name": "acme"},
+	{"id": "1001", "type": "tenant", "name": "acme"},
+	{"id": "2009", "type": "cluster", "name": ""}
+]`
+
+func TestFallbackFileWithoutTiDB(t *testing.T) {
+	prev, prevReady := db.TiDB, db.TiDBReady()
+	db.SetTiDB(nil)
+	t.Cleanup(func() {
+		if prevReady {
+			db.SetTiDB(prev)
+		}
+	})
+
+	clock := newTestClock()
+	nr := NewNameResolver(WithClock(clock.Now))
+	n, err := nr.LoadFallbackFile(writeFallbackFile(t, fallbackNames))
+	if err != nil {
+		t.Fatalf("LoadFallbackFile: %v", err)
+	}
+	// The entry without a name is skipped
+	if n != 3 {
+		t.Errorf("loaded %d entries, want 3", n)
+	}
+
+	want := NameInfo{Type: "cluster", ID: "2001", Name: "prod-1", TenantID: "1001", TenantName: "acme"}
+	if info, err := nr.Resolve("2001"); err != nil || info != want {
+		t.Errorf("Resolve(2001) = %+v, %v, want %+v", info, err, want)
+	}
+	// Fallback entries do not expire
+	clock.Advance(365 * 24 * time.Hour)
+	if info, err := nr.Resolve("1001"); err != nil || info.Name != "acme" || info.Type != "tenant" {
+		t.Errorf("Resolve(1001) a year later = %+v, %v", info, err)
+	}
+	if info, _ := nr.Resolve("2009"); info.Name != "2009" {
+		t.Errorf("the skipped entry resolved to %+v, want its ID", info)
+	}
+}
+
+func TestFallbackFileReplacedByTiDB(t *testing.T) {
+	conn := openTestTiDB(t)
+	seedTestTiDB(t, conn)
+	nr := NewNameResolver()
+	defer nr.ClosePreparedStatements()
+
+	// Names already cached from TiDB are kept
+	if info, _ := nr.Resolve("1001"); info.Name != "acme" {
+		t.Fatalf("Resolve(1001) = %+v", info)
+	}
+	if _, err := conn.Exec(`UPDATE tenants SET tenant_name = 'acme-live' WHERE tenant_id = '1001'`); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := nr.LoadFallbackFile(writeFallbackFile(t, fallbackNames)); err != nil || n != 2 {
+		t.Fatalf("LoadFallbackFile = %d, %v, want 2 entries loaded", n, err)
+	}
+	if entry, _ := nr.cache.peek("1001"); entry.source == sourceFallback {
+		t.Error("a live entry was replaced by the fallback file")
+	}
+
+	// A fallback entry is replaced once TiDB resolves the ID
+	if info, err := nr.Resolve("2001"); err != nil || info.Name != "prod-east" {
+		t.Errorf("Resolve(2001) = %+v, %v, want prod-east from TiDB", info, err)
+	}
+	if entry, _ := nr.cache.peek("2001"); entry.source == sourceFallback {
+		t.Errorf("2001 is still served from the fallback file: %+v", entry)
+	}
+
+	// IDs TiDB does not know keep their fallback entry
+	for i := 0; i < 2; i++ {
+		if info, err := nr.Resolve("2005"); err != nil || info.Name != "dr-1" {
+			t.Errorf("Resolve(2005) = %+v, %v, want dr-1 from the fallback file", info, err)
+		}
+	}
+	if entry, _ := nr.cache.peek("2005"); entry.source != sourceFallback || entry.notFound {
+		t.Errorf("2005 entry = %+v, want the fallback entry", entry)
+	}
+}
+
+func TestFallbackFileErrors(t *testing.T) {
+	nr := NewNameResolver()
+	if _, err := nr.LoadFallbackFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing file loaded")
+	}
+	if _, err := nr.LoadFallbackFile(writeFallbackFile(t, `{"id": "2001"}`)); err == nil {
+		t.Error("file that is not a list loaded")
+	}
+	if nr.cache.len() != 0 {
+		t.Errorf("%d entries cached from invalid files", nr.cache.len())
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// TenantKindSubTenant is the TenantInfo.Kind of tenants that belong to a
+// parent tenant
+const TenantKindSubTenant = "sub_tenant"
+
+// maxTenantDepth bounds the parent chains followed by ResolveOrgForTenant
+const maxTenantDepth = 16
+
+// HierarchyInfo is the ownership chain of a cluster. Levels after the first
+// missing one are left empty.
+type HierarchyInfo struct {
+	Cluster ClusterInfo `json:"cluster"`
+	Tenant  TenantInfo  `json:"tenant"`
+	OrgID   string      `json:"orgId"`
+	OrgName string      `json:"orgName"`
+}
+
+// hierarchyCacheEntry is a cached HierarchyInfo
+type hierarchyCacheEntry struct {
+	info      HierarchyInfo
+	timestamp time.Time
+}
+
+// orgCacheEntry is the root org of a tenant, with the tenant IDs walked to
+// find it
+type orgCacheEntry struct {
+	orgID, orgName string
+	chain          []string
+	timestamp      time.Time
+}
+
+// ResolveHierarchy walks cluster -> tenant -> org for clusterID, using the
+// cluster and tenant detail caches where possible. It stops at the first level
+// that cannot be resolved; only a missing cluster is an error. Complete and
+// partial results are cached with the detail TTL.
+func (nr *NameResolver) ResolveHierarchy(clusterID string) (HierarchyInfo, error) {
+	nr.cacheMutex.RLock()
+	entry, ok := nr.hierarchyCache[clusterID]
+	nr.cacheMutex.RUnlock()
+	if ok && nr.now().Sub(entry.timestamp) < nr.detailTTL {
+		return entry.info, nil
+	}
+
+	var h HierarchyInfo
+
+	cluster, err := nr.ResolveCluster(clusterID)
+	if err != nil {
+		return h, err
+	}
+	h.Cluster = *cluster
+
+	if cluster.TenantID != "" {
+		tenant, err := nr.ResolveTenant(cluster.TenantID)
+		if err != nil && !errors.Is(err, ErrIDNotFound) {
+			return h, err
+		}
+		if tenant != nil {
+			h.Tenant = *tenant
+
+			// Clusters without an explicit org belong to their tenant's org
+			h.OrgID = cluster.OrgID
+			if h.OrgID == "" {
+				h.OrgID, h.OrgName = tenant.TenantID, tenant.TenantName
+				if orgID, orgName, err := nr.ResolveOrgForTenant(tenant.TenantID); err == nil {
+					h.OrgID, h.OrgName = orgID, orgName
+				}
+			} else if h.OrgID == tenant.TenantID {
+				h.OrgName = tenant.TenantName
+			} else if org, err := nr.Resolve(h.OrgID); err == nil && org.Name != h.OrgID {
+				h.OrgName = org.Name
+			}
+		}
+	}
+
+	nr.cacheMutex.Lock()
+	nr.hierarchyCache[clusterID] = hierarchyCacheEntry{info: h, timestamp: nr.now()}
+	nr.cacheMutex.Unlock()
+
+	return h, nil
+}
+
+// ResolveOrgForTenant returns the org-level tenant that tenantID belongs to,
+// following the parent_tenant_id of sub_tenants up to a tenant that is not
+// one. Other tenants are their own org. The result is cached with the detail
+// TTL for every tenant of the chain.
+func (nr *NameResolver) ResolveOrgForTenant(tenantID string) (orgID, orgName string, err error) {
+	if tenantID == "" {
+		return "", "", fmt.Errorf("empty tenant ID")
+	}
+
+	nr.cacheMutex.RLock()
+	entry, ok := nr.orgCache[tenantID]
+	nr.cacheMutex.RUnlock()
+	if ok && nr.now().Sub(entry.timestamp) < nr.detailTTL {
+		return entry.orgID, entry.orgName, nil
+	}
+
+	var chain []string
+	id := tenantID
+	for {
+		tenant, err := nr.ResolveTenant(id)
+		if err != nil {
+			if id != tenantID {
+				return "", "", fmt.Errorf("parent %s of tenant %s: %w", id, chain[len(chain)-1], err)
+			}
+			return "", "", err
+		}
+		chain = append(chain, id)
+		if tenant.Kind != TenantKindSubTenant || tenant.ParentTenantID == "" {
+			orgID, orgName = tenant.TenantID, tenant.TenantName
+			break
+		}
+		if len(chain) >= maxTenantDepth || slices.Contains(chain, tenant.ParentTenantID) {
+			return "", "", fmt.Errorf("tenant %s: parent chain loops or is deeper than %d levels", tenantID, maxTenantDepth)
+		}
+		id = tenant.ParentTenantID
+	}
+
+	// Every sub-tenant of the chain shares the org
+	now := nr.now()
+	nr.cacheMutex.Lock()
+	for i, id := range chain {
+		nr.orgCache[id] = orgCacheEntry{orgID: orgID, orgName: orgName, chain: chain[i:], timestamp: now}
+	}
+	nr.cacheMutex.Unlock()
+
+	return orgID, orgName, nil
+}
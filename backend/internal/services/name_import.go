@@ -0,0 +1,273 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ImportResult summarizes a bulk metadata import
+type ImportResult struct {
+	Inserted int              `json:"inserted"`
+	Updated  int              `json:"updated"`
+	Skipped  int              `json:"skipped"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// ImportRowError is a validation error for one input row. Row is 1-based and
+// does not count the CSV header.
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ParseImportRows reads a CMDB export as a JSON array of objects or as CSV with
+// a header row. Keys are snake_case column names such as cluster_id.
+func ParseImportRows(r io.Reader, format string) ([]map[string]string, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+		var raw []map[string]interface{}
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		rows := make([]map[string]string, 0, len(raw))
+		for _, obj := range raw {
+			row := make(map[string]string, len(obj))
+			for k, v := range obj {
+				if v != nil {
+					row[strings.ToLower(k)] = strings.TrimSpace(fmt.Sprint(v))
+				}
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+
+	case "csv":
+		records, err := csv.NewReader(r).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(records) == 0 {
+			return nil, nil
+		}
+		header := records[0]
+		rows := make([]map[string]string, 0, len(records)-1)
+		for _, record := range records[1:] {
+			row := make(map[string]string, len(header))
+			for i, col := range header {
+				if i < len(record) {
+					row[strings.ToLower(strings.TrimSpace(col))] = strings.TrimSpace(record[i])
+				}
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported import format %q, expected json or csv", format)
+	}
+}
+
+// ImportClusters validates rows, upserts them into cluster_metadata and loads
+// them into the cache. Rows missing cluster_id, cluster_name or tenant_id are
+// skipped and reported in the result.
+func (nr *NameResolver) ImportClusters(db *gorm.DB, rows []map[string]string) (ImportResult, error) {
+	result := ImportResult{Errors: []ImportRowError{}}
+	byID := make(map[string]models.ClusterMetadata)
+	var order []string
+
+	for i, row := range rows {
+		if missing := missingFields(row, "cluster_id", "cluster_name", "tenant_id"); len(missing) > 0 {
+			result.Skipped++
+			result.Errors = append(result.Errors, ImportRowError{Row: i + 1, Error: "missing " + strings.Join(missing, ", ")})
+			continue
+		}
+		id := row["cluster_id"]
+		if _, dup := byID[id]; dup {
+			// Later rows win
+			result.Skipped++
+		} else {
+			order = append(order, id)
+		}
+		byID[id] = models.ClusterMetadata{
+			ClusterID:   id,
+			ClusterName: row["cluster_name"],
+			TenantID:    row["tenant_id"],
+			TenantName:  row["tenant_name"],
+			DeployType:  row["deploy_type"],
+			Version:     row["version"],
+			Provider:    row["provider"],
+			Region:      row["region"],
+			ProjectID:   row["project_id"],
+			OrgID:       row["org_id"],
+			ClusterType: row["cluster_type"],
+			UpdatedAt:   time.Now(),
+		}
+	}
+
+	records := make([]models.ClusterMetadata, 0, len(order))
+	for _, id := range order {
+		records = append(records, byID[id])
+	}
+
+	existing, err := existingIDs(db, &models.ClusterMetadata{}, "cluster_id", order)
+	if err != nil {
+		return result, err
+	}
+	if len(records) > 0 {
+		if err := db.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(records, 500).Error; err != nil {
+			return result, fmt.Errorf("failed to import clusters: %w", err)
+		}
+	}
+
+	now := nr.now()
+	for _, rec := range records {
+		if existing[rec.ClusterID] {
+			result.Updated++
+		} else {
+			result.Inserted++
+		}
+
+		nr.setCacheEntry(rec.ClusterID, NameInfo{
+			Type:       "cluster",
+			ID:         rec.ClusterID,
+			Name:       rec.ClusterName,
+			TenantID:   rec.TenantID,
+			TenantName: rec.TenantName,
+			DeployType: rec.DeployType,
+		}, false, sourceImport)
+
+		nr.cacheMutex.Lock()
+		nr.clusterCache[rec.ClusterID] = clusterCacheEntry{info: ClusterInfo{
+			ClusterID:   rec.ClusterID,
+			ClusterName: rec.ClusterName,
+			TenantID:    rec.TenantID,
+			TenantName:  rec.TenantName,
+			DeployType:  rec.DeployType,
+			Version:     rec.Version,
+			Provider:    rec.Provider,
+			Region:      rec.Region,
+			ProjectID:   rec.ProjectID,
+			OrgID:       rec.OrgID,
+			ClusterType: rec.ClusterType,
+			UpdatedAt:   rec.UpdatedAt,
+		}, timestamp: now}
+		nr.cacheMutex.Unlock()
+	}
+
+	nr.logger.Info("Imported cluster metadata",
+		slog.Int("inserted", result.Inserted),
+		slog.Int("updated", result.Updated),
+		slog.Int("skipped", result.Skipped))
+	return result, nil
+}
+
+// ImportTenants validates rows, upserts them into tenant_metadata and loads
+// them into the cache. Rows missing tenant_id or tenant_name are skipped and
+// reported in the result.
+func (nr *NameResolver) ImportTenants(db *gorm.DB, rows []map[string]string) (ImportResult, error) {
+	result := ImportResult{Errors: []ImportRowError{}}
+	byID := make(map[string]models.TenantMetadata)
+	var order []string
+
+	for i, row := range rows {
+		if missing := missingFields(row, "tenant_id", "tenant_name"); len(missing) > 0 {
+			result.Skipped++
+			result.Errors = append(result.Errors, ImportRowError{Row: i + 1, Error: "missing " + strings.Join(missing, ", ")})
+			continue
+		}
+		id := row["tenant_id"]
+		if _, dup := byID[id]; dup {
+			result.Skipped++
+		} else {
+			order = append(order, id)
+		}
+		byID[id] = models.TenantMetadata{
+			TenantID:   id,
+			TenantName: row["tenant_name"],
+			Kind:       row["kind"],
+			UpdatedAt:  time.Now(),
+		}
+	}
+
+	records := make([]models.TenantMetadata, 0, len(order))
+	for _, id := range order {
+		records = append(records, byID[id])
+	}
+
+	existing, err := existingIDs(db, &models.TenantMetadata{}, "tenant_id", order)
+	if err != nil {
+		return result, err
+	}
+	if len(records) > 0 {
+		if err := db.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(records, 500).Error; err != nil {
+			return result, fmt.Errorf("failed to import tenants: %w", err)
+		}
+	}
+
+	now := nr.now()
+	for _, rec := range records {
+		if existing[rec.TenantID] {
+			result.Updated++
+		} else {
+			result.Inserted++
+		}
+
+		nr.setCacheEntry(rec.TenantID, NameInfo{
+			Type: "tenant",
+			ID:   rec.TenantID,
+			Name: rec.TenantName,
+		}, false, sourceImport)
+
+		nr.cacheMutex.Lock()
+		nr.tenantCache[rec.TenantID] = tenantCacheEntry{info: TenantInfo{
+			TenantID:   rec.TenantID,
+			TenantName: rec.TenantName,
+			Kind:       rec.Kind,
+			UpdatedAt:  rec.UpdatedAt,
+		}, timestamp: now}
+		nr.cacheMutex.Unlock()
+	}
+
+	nr.logger.Info("Imported tenant metadata",
+		slog.Int("inserted", result.Inserted),
+		slog.Int("updated", result.Updated),
+		slog.Int("skipped", result.Skipped))
+	return result, nil
+}
+
+func missingFields(row map[string]string, fields ...string) []string {
+	var missing []string
+	for _, f := range fields {
+		if row[f] == "" {
+			missing = append(missing, f)
+		}
+	}
+	return missing
+}
+
+// existingIDs returns which of ids already have a row in model's table
+func existingIDs(db *gorm.DB, model interface{}, column string, ids []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	for start := 0; start < len(ids); start += 500 {
+		end := min(start+500, len(ids))
+		var found []string
+		if err := db.Model(model).Where(column+" IN ?", ids[start:end]).Pluck(column, &found).Error; err != nil {
+			return nil, fmt.Errorf("failed to look up existing rows: %w", err)
+		}
+		for _, id := range found {
+			existing[id] = true
+		}
+	}
+	return existing, nil
+}
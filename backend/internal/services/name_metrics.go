@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// nameServiceRegistry holds the metrics of the shared resolver returned by GetNameResolver
+var nameServiceRegistry = prometheus.NewRegistry()
+
+// NameServiceRegistry returns the registry served at /metrics/name-service
+func NameServiceRegistry() *prometheus.Registry {
+	return nameServiceRegistry
+}
+
+// NameResolverMetrics holds the Prometheus collectors updated by NameResolver
+type NameResolverMetrics struct {
+	CacheHits        prometheus.Counter
+	CacheMisses      prometheus.Counter
+	DBLookups        prometheus.Counter
+	DBErrors         prometheus.Counter
+	DBLookupDuration prometheus.Histogram
+	CacheSize        prometheus.GaugeFunc
+}
+
+// WithMetrics registers the resolver's metrics with reg
+func WithMetrics(reg prometheus.Registerer) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.metricsRegisterer = reg
+	}
+}
+
+func newNameResolverMetrics(reg prometheus.Registerer, nr *NameResolver) *NameResolverMetrics {
+	m := &NameResolverMetrics{
+		CacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "name_resolver",
+			Name:      "cache_hits_total",
+			Help:      "Number of Resolve calls served from the cache.",
+		}),
+		CacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "name_resolver",
+			Name:      "cache_misses_total",
+			Help:      "Number of Resolve calls not served from the cache.",
+		}),
+		DBLookups: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "name_resolver",
+			Name:      "db_lookups_total",
+			Help:      "Number of name lookups issued against TiDB.",
+		}),
+		DBErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "name_resolver",
+			Name:      "db_errors_total",
+			Help:      "Number of TiDB name lookups that failed.",
+		}),
+		DBLookupDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "name_resolver",
+			Name:      "db_lookup_duration_seconds",
+			Help:      "Duration of TiDB name lookups.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		CacheSize: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: "name_resolver",
+			Name:      "cache_size",
+			Help:      "Number of entries currently held in the cache.",
+		}, func() float64 {
+			nr.cacheMutex.RLock()
+			defer nr.cacheMutex.RUnlock()
+			return float64(nr.cache.len())
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{m.CacheHits, m.CacheMisses, m.DBLookups, m.DBErrors, m.DBLookupDuration, m.CacheSize} {
+		if err := reg.Register(c); err != nil {
+			nr.logger.Warn("Failed to register name resolver metric", slog.Any("error", err))
+		}
+	}
+	return m
+}
+
+// The helpers below are no-ops when metrics are not enabled
+
+func (m *NameResolverMetrics) hit(n int) {
+	if m != nil && n > 0 {
+		m.CacheHits.Add(float64(n))
+	}
+}
+
+func (m *NameResolverMetrics) miss(n int) {
+	if m != nil && n > 0 {
+		m.CacheMisses.Add(float64(n))
+	}
+}
+
+// observeLookup records one database round-trip that started at start
+func (m *NameResolverMetrics) observeLookup(start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.DBLookups.Inc()
+	m.DBLookupDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.DBErrors.Inc()
+	}
+}
+
+// WritePrometheusMetrics writes the GetCacheStats counters in the Prometheus
+// text exposition format. The cache is locked only while it is counted.
+func (nr *NameResolver) WritePrometheusMetrics(w io.Writer) error {
+	stats := nr.snapshotCacheStats()
+	current := nr.breaker.currentState()
+
+	bw := bufio.NewWriter(w)
+	metric := func(name, help, typ string) {
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+
+	metric("name_resolver_cache_total", "Number of name cache entries by state.", "gauge")
+	for _, s := range []struct {
+		state string
+		n     int
+	}{{"found", stats.found}, {"not_found", stats.notFound}, {"expired", stats.expired}} {
+		fmt.Fprintf(bw, "name_resolver_cache_total{state=%q} %d\n", s.state, s.n)
+	}
+
+	metric("name_resolver_cache_source_total", "Number of name cache entries by the backend that served them.", "gauge")
+	for _, source := range sortedKeys(stats.bySource) {
+		fmt.Fprintf(bw, "name_resolver_cache_source_total{source=%q} %d\n", source, stats.bySource[source])
+	}
+
+	metric("name_resolver_cache_age_total", "Number of name cache entries by age.", "gauge")
+	for _, b := range cacheAgeBuckets {
+		fmt.Fprintf(bw, "name_resolver_cache_age_total{age=%q} %d\n", b.label, stats.ageHistogram[b.label])
+	}
+
+	metric("name_resolver_reverse_cache_total", "Number of cached name to ID mappings.", "gauge")
+	fmt.Fprintf(bw, "name_resolver_reverse_cache_total %d\n", stats.reverseTotal)
+
+	metric("name_resolver_lru_evictions_total", "Number of entries evicted from the full cache.", "counter")
+	fmt.Fprintf(bw, "name_resolver_lru_evictions_total %d\n", stats.evictions)
+
+	metric("name_resolver_hit_ratio", "Fraction of Resolve calls served from the cache since startup.", "gauge")
+	fmt.Fprintf(bw, "name_resolver_hit_ratio %g\n", nr.hitRate())
+
+	metric("name_resolver_circuit_breaker_state", "Current TiDB circuit breaker state (1 for the active state).", "gauge")
+	for _, state := range []breakerState{breakerClosed, breakerOpen, breakerHalfOpen} {
+		active := 0
+		if state == current {
+			active = 1
+		}
+		fmt.Fprintf(bw, "name_resolver_circuit_breaker_state{state=%q} %d\n", state.String(), active)
+	}
+
+	return bw.Flush()
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
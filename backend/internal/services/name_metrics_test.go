@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNameResolverMetrics(t *testing.T) {
+	seedTestTiDB(t, openTestTiDB(t))
+	failTiDBQueries("FROM projects", &mysql.MySQLError{Number: 1146, Message: "Table 'projects' doesn't exist"})
+	reg := prometheus.NewRegistry()
+	nr := NewNameResolver(WithMetrics(reg))
+	defer nr.ClosePreparedStatements()
+
+	// One lookup for 2001, which caches its tenant 1001 too, and six for the
+	// unknown 3999: clusters, tenants, both name fallbacks and both project lookups
+	for i := 0; i < 3; i++ {
+		nr.Resolve("2001")
+	}
+	nr.Resolve("3999")
+	nr.ResolveBatch([]string{"2001", "1001", "3999"})
+
+	// A failed cluster query is an error; the tenants are still looked up
+	failTiDBQueries("FROM clusters", errors.New("connection refused"))
+	nr.ResolveBatch([]string{"2002"})
+
+	want := map[string]float64{
+		"name_resolver_cache_hits_total":   5,
+		"name_resolver_cache_misses_total": 3,
+		"name_resolver_db_lookups_total":   9,
+		"name_resolver_db_errors_total":    1,
+	}
+	for name, n := range want {
+		if got := counterValue(t, reg, name); got != n {
+			t.Errorf("%s = %v, want %v", name, got, n)
+		}
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	for _, mf := range families {
+		switch mf.GetName() {
+		case "name_resolver_db_lookup_duration_seconds":
+			if n := mf.GetMetric()[0].GetHistogram().GetSampleCount(); n != 9 {
+				t.Errorf("lookup duration has %d samples, want 9", n)
+			}
+		case "name_resolver_cache_size":
+			// 2001, 1001 and the miss of 3999; 2002 is not cached after the failure
+			if n := mf.GetMetric()[0].GetGauge().GetValue(); n != 3 {
+				t.Errorf("cache size = %v, want 3", n)
+			}
+		}
+	}
+}
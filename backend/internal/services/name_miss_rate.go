@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMissRateInterval  = 60 * time.Second
+	defaultMissRateWindow    = 5 * time.Minute
+	defaultMissRateThreshold = 0.5
+	// defaultMissRateMinLookups keeps a handful of lookups on an idle
+	// instance from firing the alert
+	defaultMissRateMinLookups = 20
+
+	// HighMissRateAlertName is the alertname of the system alerts created by
+	// HighMissRateAlert
+	HighMissRateAlertName = "NameResolverHighMissRate"
+)
+
+// missRateSample is a reading of the cumulative cache_hits and cache_misses
+// counters of GetCacheStats
+type missRateSample struct {
+	at           time.Time
+	hits, misses int64
+}
+
+// MissRateMonitor samples the NameResolver cache counters every Interval and
+// computes the miss rate, cache_misses / (cache_hits + cache_misses), of the
+// lookups made over the last Window. When it exceeds Threshold it calls the
+// OnHighMissRate callback, once until the rate drops back to or below it.
+// Windows with fewer than MinLookups lookups are ignored.
+type MissRateMonitor struct {
+	Interval   time.Duration
+	Window     time.Duration
+	Threshold  float64
+	MinLookups int64
+
+	nr *NameResolver
+
+	mu             sync.Mutex
+	samples        []missRateSample // oldest first, spanning at most Window
+	firing         bool
+	onHighMissRate func(rate float64)
+}
+
+// NewMissRateMonitor returns a monitor of nr sampling every 60s over a 5m
+// window with a threshold of 0.5
+func NewMissRateMonitor(nr *NameResolver) *MissRateMonitor {
+	return &MissRateMonitor{
+		Interval:   defaultMissRateInterval,
+		Window:     defaultMissRateWindow,
+		Threshold:  defaultMissRateThreshold,
+		MinLookups: defaultMissRateMinLookups,
+		nr:         nr,
+	}
+}
+
+// NewMissRateMonitorFromEnv configures the monitor from
+// NAME_SERVICE_MISS_RATE_THRESHOLD (default 0.5) and
+// NAME_SERVICE_MISS_RATE_WINDOW (default 5m)
+func NewMissRateMonitorFromEnv(nr *NameResolver) *MissRateMonitor {
+	m := NewMissRateMonitor(nr)
+	if v, err := strconv.ParseFloat(os.Getenv("NAME_SERVICE_MISS_RATE_THRESHOLD"), 64); err == nil && v > 0 && v < 1 {
+		m.Threshold = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("NAME_SERVICE_MISS_RATE_WINDOW")); err == nil && v >= m.Interval {
+		m.Window = v
+	}
+	return m
+}
+
+// OnHighMissRate registers the callback run when the miss rate exceeds the
+// threshold. It runs on the monitor's goroutine.
+func (m *MissRateMonitor) OnHighMissRate(fn func(rate float64)) {
+	m.mu.Lock()
+	m.onHighMissRate = fn
+	m.mu.Unlock()
+}
+
+// Start samples the counters every Interval until ctx is cancelled
+func (m *MissRateMonitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.Interval)
+		defer ticker.Stop()
+
+		m.Sample(time.Now())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case t := <-ticker.C:
+				m.Sample(t)
+			}
+		}
+	}()
+}
+
+// Sample reads GetCacheStats, records the counters taken at the given time
+// and checks the miss rate over the window. It returns the rate and whether
+// the window had enough lookups to compute it.
+func (m *MissRateMonitor) Sample(at time.Time) (float64, bool) {
+	stats := m.nr.GetCacheStats()
+	hits, _ := stats["cache_hits"].(int64)
+	misses, _ := stats["cache_misses"].(int64)
+
+	m.mu.Lock()
+	m.samples = append(m.samples, missRateSample{at: at, hits: hits, misses: misses})
+	// Keep the newest sample at least Window old as the baseline
+	for len(m.samples) > 1 && !m.samples[1].at.After(at.Add(-m.Window)) {
+		m.samples = m.samples[1:]
+	}
+	rate, ok := m.missRate()
+	var fn func(float64)
+	switch {
+	case !ok:
+	case rate > m.Threshold:
+		if !m.firing {
+			m.firing = true
+			fn = m.onHighMissRate
+		}
+	default:
+		m.firing = false
+	}
+	m.mu.Unlock()
+
+	if fn != nil {
+		fn(rate)
+	}
+	return rate, ok
+}
+
+// missRate compares the newest sample with the oldest. Must be called with
+// m.mu held.
+func (m *MissRateMonitor) missRate() (float64, bool) {
+	if len(m.samples) < 2 {
+		return 0, false
+	}
+	first, last := m.samples[0], m.samples[len(m.samples)-1]
+	hits, misses := last.hits-first.hits, last.misses-first.misses
+	// The counters restart with the resolver
+	if hits < 0 || misses < 0 || hits+misses < m.MinLookups {
+		return 0, false
+	}
+	return float64(misses) / float64(hits+misses), true
+}
+
+// HighMissRateAlert returns an OnHighMissRate callback that stores a system
+// alert, severity warning and alertname NameResolverHighMissRate, in SQLite
+func (m *MissRateMonitor) HighMissRateAlert(sqlite *gorm.DB) func(rate float64) {
+	return func(rate float64) {
+		now := time.Now().UTC()
+		id := fmt.Sprintf("SYSTEM-%s-%d", HighMissRateAlertName, now.Unix())
+		labels, _ := json.Marshal([]string{"system", "alertname=" + HighMissRateAlertName})
+		alert := models.Issue{
+			ID:    id,
+			Title: fmt.Sprintf("Name resolver miss rate %.0f%% over the last %s (threshold %.0f%%)", rate*100, m.Window, m.Threshold*100),
+			Description: "The name service could not find a large share of the clusters and tenants it was asked for " +
+				"in its cache or in TiDB; dashboards show raw IDs instead of names.",
+			Created:         now.Format("2006-01-02 15:04:05") + " UTC",
+			UpdatedAt:       now.Format("2006-01-02 15:04:05") + " UTC",
+			Priority:        "warning",
+			Labels:          string(labels),
+			IsAlert:         true,
+			AlertSignature:  HighMissRateAlertName,
+			Status:          "open",
+			ComponentName:   "name-service",
+			SourceComponent: "alerts-platform",
+		}
+		err := sqlite.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&alert).Error; err != nil {
+				return err
+			}
+			return tx.Create([]models.AlertLabel{
+				{AlertID: id, Key: "alertname", Value: HighMissRateAlertName},
+				{AlertID: id, Key: "severity", Value: "warning"},
+			}).Error
+		})
+		if err != nil {
+			m.nr.logger.Warn("Failed to store name resolver miss rate alert", slog.Any("error", err))
+			return
+		}
+		m.nr.logger.Warn("Name resolver miss rate above threshold", slog.Float64("miss_rate", rate), slog.String("alert_id", id))
+	}
+}
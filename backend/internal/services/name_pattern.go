@@ -0,0 +1,144 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+// defaultPatternLimit caps ResolvePattern results to avoid runaway queries
+const defaultPatternLimit = 50
+
+// WithPatternLimit sets the max number of results returned by ResolvePattern.
+// n <= 0 keeps the default.
+func WithPatternLimit(n int) NameResolverOption {
+	return func(nr *NameResolver) {
+		if n > 0 {
+			nr.patternLimit = n
+		}
+	}
+}
+
+// ResolvePattern returns the clusters and tenants whose name matches a glob
+// pattern such as "prod-cluster-*" ("*" matches any run of characters, "?" a
+// single one). A pattern without wildcards matches names containing it.
+// Matching is case-insensitive, each match is cached individually and the
+// result is sorted by name.
+func (nr *NameResolver) ResolvePattern(pattern string) ([]NameInfo, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	if !db.TiDBReady() {
+		return nil, fmt.Errorf("TiDB not connected")
+	}
+
+	like := globToLike(pattern)
+	if !strings.ContainsAny(pattern, "*?") {
+		like = "%" + like + "%"
+	}
+
+	results, err := nr.lookupClustersByPattern(like)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) < nr.patternLimit {
+		tenants, err := nr.lookupTenantsByPattern(like, nr.patternLimit-len(results))
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, tenants...)
+	}
+
+	for _, info := range results {
+		nr.setCacheEntry(info.ID, info, false, sourcePrimary)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
+
+func (nr *NameResolver) lookupClustersByPattern(like string) ([]NameInfo, error) {
+	rows, err := db.TiDB.Query(`
+		SELECT c.cluster_id, c.cluster_name, c.tenant_id,
+		       COALESCE(NULLIF(c.tenant_name, ''), t.tenant_name, '') as tenant_name,
+		       COALESCE(c.deploy_type, '') as deploy_type
+		FROM clusters c
+		LEFT JOIN tenants t ON c.tenant_id = t.tenant_id
+		WHERE LOWER(c.cluster_name) LIKE LOWER(?)
+		ORDER BY c.cluster_name
+		LIMIT ?
+	`, like, nr.patternLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []NameInfo
+	for rows.Next() {
+		var clusterID, clusterName, tenantID, tenantName, deployType string
+		if err := rows.Scan(&clusterID, &clusterName, &tenantID, &tenantName, &deployType); err != nil {
+			return nil, err
+		}
+		results = append(results, NameInfo{
+			Type:       "cluster",
+			ID:         clusterID,
+			Name:       nr.clusterDisplayName(clusterID, clusterName, deployType),
+			TenantID:   tenantID,
+			TenantName: tenantName,
+			DeployType: deployType,
+		})
+	}
+	return results, rows.Err()
+}
+
+func (nr *NameResolver) lookupTenantsByPattern(like string, limit int) ([]NameInfo, error) {
+	rows, err := db.TiDB.Query(`
+		SELECT tenant_id, tenant_name FROM tenants
+		WHERE LOWER(tenant_name) LIKE LOWER(?)
+		ORDER BY tenant_name
+		LIMIT ?
+	`, like, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []NameInfo
+	for rows.Next() {
+		var tenantID, tenantName string
+		if err := rows.Scan(&tenantID, &tenantName); err != nil {
+			return nil, err
+		}
+		results = append(results, NameInfo{
+			Type: "tenant",
+			ID:   tenantID,
+			Name: tenantName,
+		})
+	}
+	return results, rows.Err()
+}
+
+// globToLike converts a glob pattern to a LIKE pattern, escaping any LIKE
+// wildcards already present in it
+func globToLike(pattern string) string {
+	var b strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteByte('%')
+		case '?':
+			b.WriteByte('_')
+		default:
+			b.WriteString(escapeLike(string(r)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer traces name resolution; spans are dropped unless tracing.Init
+// installed an exporter
+var tracer = otel.Tracer("github.com/nolouch/alerts-platform-v2/internal/services")
+
+// Backends a cache entry can be served from
+const (
+	sourcePrimary  = "primary"
+	sourceReplica  = "replica"
+	sourcePreload  = "preload"
+	sourceSQLite   = "sqlite"
+	sourceSnapshot = "snapshot"
+	sourceRedis    = "redis"
+	sourceImport   = "import"
+	sourceFallback = "fallback"
+	sourceSync     = "sync"
+)
+
+const (
+	primaryQueryTimeout = 500 * time.Millisecond // after this the replica is tried
+	replicaQueryTimeout = 2 * time.Second
+)
+
+// queryRow scans a single row from the primary TiDB with a short deadline. If the
+// primary fails or times out and a replica is configured, the query is retried
+// there. Lookups of preparedQueries run as prepared statements on the
+// primary. It returns the backend that produced the result. sql.ErrNoRows from
+// the primary is an answer, not a failure, and is not retried. Each attempt is
+// traced in a name_resolver.db_query span.
+func (nr *NameResolver) queryRow(ctx context.Context, query string, args []interface{}, dest ...interface{}) (string, error) {
+	err := nr.scanPrimary(ctx, query, args, dest)
+	if err == nil || err == sql.ErrNoRows || db.TiDBReplica == nil {
+		return sourcePrimary, err
+	}
+
+	nr.logger.Warn("Name service primary query failed, retrying on replica", slog.Any("error", err))
+	return sourceReplica, tracedScanRow(ctx, db.TiDBReplica, sourceReplica, replicaQueryTimeout, query, args, dest)
+}
+
+// queryRows is queryRow for queries returning several rows: scan reads all of
+// rows and is called again from scratch if the query is retried on the
+// replica
+func (nr *NameResolver) queryRows(ctx context.Context, query string, args []interface{}, scan func(rows *sql.Rows) error) (string, error) {
+	err := tracedQueryRows(ctx, db.TiDB, sourcePrimary, primaryQueryTimeout, query, args, scan)
+	if err == nil || db.TiDBReplica == nil {
+		return sourcePrimary, err
+	}
+
+	nr.logger.Warn("Name service primary query failed, retrying on replica", slog.Any("error", err))
+	return sourceReplica, tracedQueryRows(ctx, db.TiDBReplica, sourceReplica, replicaQueryTimeout, query, args, scan)
+}
+
+func tracedQueryRows(ctx context.Context, conn *sql.DB, source string, timeout time.Duration, query string, args []interface{}, scan func(rows *sql.Rows) error) error {
+	return traceScan(ctx, source, query, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		rows, err := conn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		if err := scan(rows); err != nil {
+			return err
+		}
+		return rows.Err()
+	})
+}
+
+func tracedScanRow(ctx context.Context, conn *sql.DB, source string, timeout time.Duration, query string, args []interface{}, dest []interface{}) error {
+	return traceScan(ctx, source, query, func(ctx context.Context) error {
+		return scanRowWithTimeout(ctx, conn, timeout, query, args, dest)
+	})
+}
+
+// tracedScanStmt is tracedScanRow for the prepared statement of query on the primary
+func tracedScanStmt(ctx context.Context, stmt *sql.Stmt, query string, args []interface{}, dest []interface{}) error {
+	return traceScan(ctx, sourcePrimary, query, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, primaryQueryTimeout)
+		defer cancel()
+		return stmt.QueryRowContext(ctx, args...).Scan(dest...)
+	})
+}
+
+// traceScan runs scan in a name_resolver.db_query span
+func traceScan(ctx context.Context, source string, query string, scan func(ctx context.Context) error) error {
+	ctx, span := tracer.Start(ctx, "name_resolver.db_query",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "tidb"),
+			attribute.String("db.statement", strings.Join(strings.Fields(query), " ")),
+			attribute.String("name_resolver.source", source),
+		))
+	defer span.End()
+
+	err := scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func scanRowWithTimeout(ctx context.Context, conn *sql.DB, timeout time.Duration, query string, args []interface{}, dest []interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return conn.QueryRowContext(ctx, query, args...).Scan(dest...)
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueryRowFallsBackToReplica(t *testing.T) {
+	openSlowTestTiDB(t)
+	seedTestTiDB(t, openTestReplica(t))
+	nr := NewNameResolver()
+	defer nr.ClosePreparedStatements()
+
+	start := time.Now()
+	info, source, err := nr.getCluster(context.Background(), "2001")
+	if err != nil || info == nil || info.ClusterName != "prod-east" {
+		t.Fatalf("getCluster = %+v, %v", info, err)
+	}
+	if source != sourceReplica {
+		t.Errorf("source = %q, want %q", source, sourceReplica)
+	}
+	if elapsed := time.Since(start); elapsed >= slowTiDBDelay {
+		t.Errorf("lookup took %v, the primary deadline did not fire", elapsed)
+	}
+}
+
+func TestBatchQueriesFallBackToReplica(t *testing.T) {
+	openSlowTestTiDB(t)
+	seedTestTiDB(t, openTestReplica(t))
+	nr := NewNameResolver()
+
+	clusters, source, err := nr.getClustersByIDs(context.Background(), []string{"2001", "2002"})
+	if err != nil || source != sourceReplica {
+		t.Fatalf("getClustersByIDs: source %q, error %v", source, err)
+	}
+	if len(clusters) != 1 || clusters["2001"].ClusterName != "prod-east" || clusters["2001"].TenantName != "acme" {
+		t.Errorf("clusters = %+v", clusters)
+	}
+
+	names, source, err := nr.getTenantNamesByIDs(context.Background(), []string{"1001"})
+	if err != nil || source != sourceReplica || names["1001"] != "acme" {
+		t.Errorf("getTenantNamesByIDs = %v, %q, %v", names, source, err)
+	}
+}
+
+func TestBatchQueriesWithoutReplica(t *testing.T) {
+	openSlowTestTiDB(t)
+	nr := NewNameResolver()
+
+	if _, source, err := nr.getClustersByIDs(context.Background(), []string{"2001"}); err == nil || source != sourcePrimary {
+		t.Errorf("slow primary without replica: source %q, error %v, want the deadline error", source, err)
+	}
+
+	seedTestTiDB(t, openTestTiDB(t))
+	results, errs := nr.ResolveBatch([]string{"2001", "1001", "9999"})
+	if results["2001"].Name == "" || results["2001"].TenantName != "acme" || results["1001"].Name != "acme" {
+		t.Errorf("ResolveBatch = %+v", results)
+	}
+	if len(errs) != 1 {
+		t.Errorf("errs = %v, want only 9999 not found", errs)
+	}
+	if e, ok := nr.cache.peek("1001"); !ok || e.source != sourcePrimary {
+		t.Errorf("tenant cache entry = %+v, %v", e, ok)
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import "context"
+
+// NameResolverRequestCache memoizes the lookups of one HTTP request in front
+// of a NameResolver, so resolving the same cluster for every alert of a page
+// takes the shared cache's lock once per ID. It is not safe for concurrent use
+// and must not outlive the request: entries never expire.
+type NameResolverRequestCache struct {
+	nr    *NameResolver
+	names map[string]requestCacheEntry
+}
+
+type requestCacheEntry struct {
+	info NameInfo
+	err  error
+}
+
+var _ NameService = (*NameResolverRequestCache)(nil)
+
+// NewRequestCache returns an empty request cache resolving misses with nr
+func NewRequestCache(nr *NameResolver) *NameResolverRequestCache {
+	return &NameResolverRequestCache{nr: nr, names: make(map[string]requestCacheEntry)}
+}
+
+// Resolve implements NameService
+func (rc *NameResolverRequestCache) Resolve(id string) (NameInfo, error) {
+	return rc.ResolveContext(context.Background(), id)
+}
+
+// ResolveContext implements NameService. Errors are remembered along with the
+// result, so an unknown ID is not looked up again either.
+func (rc *NameResolverRequestCache) ResolveContext(ctx context.Context, id string) (NameInfo, error) {
+	if entry, ok := rc.names[id]; ok {
+		return entry.info, entry.err
+	}
+	info, err := rc.nr.ResolveContext(ctx, id)
+	rc.names[id] = requestCacheEntry{info: info, err: err}
+	return info, err
+}
+
+// ResolveBatch implements NameService. Only the IDs not seen earlier in the
+// request are passed on to the NameResolver.
+func (rc *NameResolverRequestCache) ResolveBatch(ids []string) (map[string]NameInfo, []error) {
+	results := make(map[string]NameInfo, len(ids))
+	var missing []string
+	for _, id := range ids {
+		if entry, ok := rc.names[id]; ok && entry.err == nil {
+			results[id] = entry.info
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	resolved, errs := rc.nr.ResolveBatch(missing)
+	for id, info := range resolved {
+		rc.names[id] = requestCacheEntry{info: info}
+		results[id] = info
+	}
+	return results, errs
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRequestCache(t *testing.T) {
+	conn := openTestTiDB(t)
+	seedTestTiDB(t, conn)
+	nr := NewNameResolver()
+	defer nr.ClosePreparedStatements()
+	rc := NewRequestCache(nr)
+
+	// Each ID reaches the resolver once, whatever the number of lookups.
+	// Unknown IDs are remembered with their error.
+	_, notFound := rc.Resolve("3999")
+	if notFound == nil {
+		t.Fatal("Resolve(3999) found an unknown ID")
+	}
+	for i := 0; i < 200; i++ {
+		for id, name := range map[string]string{"2001": "prod-east", "1001": "acme"} {
+			if info, err := rc.Resolve(id); err != nil || info.Name != name {
+				t.Fatalf("Resolve(%s) = %+v, %v, want %s", id, info, err, name)
+			}
+		}
+		if info, err := rc.Resolve("3999"); err != notFound || info.Name != "3999" {
+			t.Fatalf("Resolve(3999) = %+v, %v, want the remembered %v", info, err, notFound)
+		}
+	}
+	if n := nr.requests.Load(); n != 3 {
+		t.Errorf("resolver got %d requests, want one per ID", n)
+	}
+
+	// ResolveBatch only passes on the IDs not seen in the request
+	if _, err := conn.Exec(`INSERT INTO clusters (cluster_id, cluster_name, tenant_id) VALUES ('2002', 'staging', '1001')`); err != nil {
+		t.Fatal(err)
+	}
+	lookups := countTiDBQueries("")
+	names, errs := rc.ResolveBatch([]string{"2001", "1001"})
+	if len(errs) > 0 || names["2001"].Name != "prod-east" || names["1001"].Name != "acme" {
+		t.Errorf("ResolveBatch of seen IDs = %v, %v", names, errs)
+	}
+	if n := countTiDBQueries("") - lookups; n != 0 {
+		t.Errorf("ResolveBatch of seen IDs ran %d queries", n)
+	}
+	names, errs = rc.ResolveBatch([]string{"2001", "2002"})
+	if len(errs) > 0 || names["2001"].Name != "prod-east" || names["2002"].Name != "staging" {
+		t.Errorf("ResolveBatch = %v, %v", names, errs)
+	}
+	// The batch passed on 2002 alone
+	if n := nr.requests.Load(); n != 4 {
+		t.Errorf("resolver got %d requests after the batch, want 4", n)
+	}
+	if info, err := rc.Resolve("2002"); err != nil || info.Name != "staging" {
+		t.Errorf("Resolve(2002) after the batch = %+v, %v", info, err)
+	}
+	if n := nr.requests.Load(); n != 4 {
+		t.Errorf("Resolve(2002) after the batch reached the resolver")
+	}
+
+	// A batch retries the IDs that failed earlier in the request
+	if _, err := conn.Exec(`INSERT INTO clusters (cluster_id, cluster_name, tenant_id) VALUES ('3999', 'late', '1001')`); err != nil {
+		t.Fatal(err)
+	}
+	nr.ClearCache()
+	if names, _ := rc.ResolveBatch([]string{"3999"}); names["3999"].Name != "late" {
+		t.Errorf("ResolveBatch of a failed ID = %v, want it looked up again", names)
+	}
+}
+
+// BenchmarkRequestCache resolves a page of 200 alerts spread over 5 clusters
+// through the shared cache and through a request cache per page
+func BenchmarkRequestCache(b *testing.B) {
+	seedTestTiDB(b, openTestTiDB(b))
+	nr := NewNameResolver()
+	defer nr.ClosePreparedStatements()
+	page := make([]string, 200)
+	for i := range page {
+		page[i] = fmt.Sprintf("%d", 2001+i%5)
+	}
+	for _, id := range page[:5] {
+		nr.Resolve(id)
+	}
+
+	b.Run("global", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, id := range page {
+				nr.Resolve(id)
+			}
+		}
+	})
+	b.Run("request", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			rc := NewRequestCache(nr)
+			for _, id := range page {
+				rc.Resolve(id)
+			}
+		}
+	})
+}
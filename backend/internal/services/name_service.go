@@ -1,15 +1,28 @@
 package services
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
 )
 
 type NameInfo struct {
@@ -18,6 +31,7 @@ type NameInfo struct {
 	Name       string `json:"name"`
 	TenantID   string `json:"tenantId"`
 	TenantName string `json:"tenantName"`
+	DeployType string `json:"deployType,omitempty"` // clusters only, empty when the entry was cached without it
 }
 
 type ClusterInfo struct {
@@ -40,27 +54,174 @@ type ClusterInfo struct {
 }
 
 type TenantInfo struct {
-	TenantID   string
-	TenantName string
-	Kind       string
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	TenantID       string
+	TenantName     string
+	Kind           string
+	ParentTenantID string // the tenant a sub_tenant belongs to
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
 
+// ProjectInfo is a row of the optional projects table
+type ProjectInfo struct {
+	ProjectID   string
+	ProjectName string
+	OrgID       string
+}
+
+// ErrIDNotFound is returned when an ID matches no cluster, tenant or project
+var ErrIDNotFound = errors.New("ID not found")
+
 // cacheEntry represents a cached item with expiration
 type cacheEntry struct {
 	info      NameInfo
 	notFound  bool      // true if this ID was not found in database
 	timestamp time.Time // when this entry was cached
+	source    string    // backend that served the entry: "primary", "replica", "preload", "sqlite", "snapshot", "redis", "import" or "fallback"
 }
 
 type NameResolver struct {
-	cache        map[string]cacheEntry
-	cacheMutex   sync.RWMutex
-	missLogger   *log.Logger
-	cacheTTL     time.Duration // TTL for cache entries
-	notFoundTTL  time.Duration // TTL for not-found entries (shorter to allow retry)
-	preloaded    bool          // true after preload is complete, cache miss means not found
+	// cache is safe for concurrent use and readers never block on it.
+	// cacheMutex guards the maps below and multi-step updates of cache.
+	cache       *shardedCache
+	cacheMutex  sync.RWMutex
+	logger      *slog.Logger
+	missLogger  *slog.Logger                         // writes unresolved IDs to the dedicated miss log
+	missJSON    bool                                 // miss log lines are {"ts","id","reason","ms_elapsed"} JSON
+	cacheTTL    time.Duration                        // TTL for cache entries
+	notFoundTTL time.Duration                        // TTL for not-found entries (shorter to allow retry)
+	typeTTL     map[string]time.Duration             // per-type TTL overrides ("cluster", "tenant", "notFound")
+	preloaded   atomic.Bool                          // true after preload is complete, cache miss means not found
+	maxSize     int                                  // max number of cache entries, 0 means unbounded
+	shardCount  int                                  // number of cache shards
+	now         func() time.Time                     // clock for cache timestamps and TTL checks
+	after       func(time.Duration) <-chan time.Time // waits for the next scheduled preload, time.After when nil
+
+	reverseCache map[string]reverseEntry // lowercased name -> ID, guarded by cacheMutex
+	reverseTTL   time.Duration           // TTL for reverse (name -> ID) entries
+	aliasCache   map[string]reverseEntry // "uuid:<id>"/"slug:<id>" -> cluster ID ("" if unknown), guarded by cacheMutex
+	patternLimit int                     // max results returned by ResolvePattern
+
+	snapshotMaxSize int64 // max decompressed size of a snapshot accepted by ImportSnapshot
+
+	clusterCache map[string]clusterCacheEntry // full cluster details for ResolveCluster, guarded by cacheMutex
+	tenantCache  map[string]tenantCacheEntry  // full tenant details for ResolveTenant, guarded by cacheMutex
+	detailTTL    time.Duration                // TTL for clusterCache, tenantCache, hierarchyCache and orgCache entries
+
+	hierarchyCache map[string]hierarchyCacheEntry // cluster ID -> ownership chain, guarded by cacheMutex
+	orgCache       map[string]orgCacheEntry       // tenant ID -> root org tenant, guarded by cacheMutex
+
+	lookupGroup singleflight.Group // deduplicates concurrent database lookups per ID
+	breaker     *circuitBreaker    // skips TiDB after repeated failures
+	backend     CacheBackend       // optional shared cache tier, nil when disabled
+
+	clusterView         atomic.Bool // getCluster reads v_cluster_names instead of joining clusters and tenants
+	clusterViewMissing  atomic.Bool // set once TiDB reports there is no v_cluster_names view
+	projectsMissing     atomic.Bool // set once TiDB reports there is no projects table
+	parentTenantMissing atomic.Bool // set once TiDB reports there is no tenants.parent_tenant_id column
+
+	hits     atomic.Int64 // IDs Resolve and ResolveBatch served from the in-memory cache
+	requests atomic.Int64 // IDs Resolve and ResolveBatch looked up in the cache
+
+	emitter   EventEmitter        // optional, notified when an entity is first resolved
+	seen      map[string]struct{} // IDs already announced to emitter
+	seenMutex sync.Mutex
+
+	stmts     map[string]preparedStmt // query -> prepared statement on the primary, guarded by stmtMutex
+	stmtMutex sync.Mutex
+
+	metrics           *NameResolverMetrics // nil when metrics are disabled
+	metricsRegisterer prometheus.Registerer
+}
+
+// reverseEntry is a cached name -> ID mapping
+type reverseEntry struct {
+	id        string
+	timestamp time.Time
+}
+
+// NameResolverOption configures a NameResolver at construction time
+type NameResolverOption func(*NameResolver)
+
+// WithTypeTTL sets an independent TTL for one entry type. Supported types are
+// "cluster", "tenant", "project" and "notFound"; other types fall back to the
+// global TTLs.
+func WithTypeTTL(entityType string, ttl time.Duration) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.typeTTL[entityType] = ttl
+	}
+}
+
+// WithMaxCacheSize bounds the cache to n entries, evicting the least recently
+// used entry when full. n <= 0 leaves the cache unbounded.
+func WithMaxCacheSize(n int) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.maxSize = n
+	}
+}
+
+// WithShardCount partitions the cache into n shards (default 64) so writers of
+// different IDs do not contend. n <= 1 keeps a single shard, which also makes
+// a bounded cache evict in strict LRU order.
+func WithShardCount(n int) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.shardCount = max(n, 1)
+	}
+}
+
+// WithLogger sets the logger used for the resolver's own messages. Defaults to slog.Default().
+func WithLogger(l *slog.Logger) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.logger = l
+	}
+}
+
+// WithMissLogger sets the logger unresolved IDs are written to, instead of the
+// file configured by NAME_SERVICE_MISS_LOG
+func WithMissLogger(l *slog.Logger) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.missLogger = l
+	}
+}
+
+// WithStructuredMissLog switches the miss log to one JSON object per line with
+// ts, id, reason and ms_elapsed keys, so log pipelines can parse it without a
+// custom parser. ms_elapsed is measured from the start of the Resolve call.
+func WithStructuredMissLog(enabled bool) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.missJSON = enabled
+	}
+}
+
+// WithSnapshotMaxSize sets the largest decompressed snapshot ImportSnapshot
+// accepts. Defaults to 256 MB.
+func WithSnapshotMaxSize(n int64) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.snapshotMaxSize = n
+	}
+}
+
+// WithClusterView makes getCluster read the v_cluster_names view instead of
+// joining clusters and tenants, see SetClusterView
+func WithClusterView(enabled bool) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.clusterView.Store(enabled)
+	}
+}
+
+// WithReverseTTL sets the TTL for name -> ID entries used by ResolveByName
+func WithReverseTTL(ttl time.Duration) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.reverseTTL = ttl
+	}
+}
+
+// WithClock makes the resolver read the time from now when it stamps and
+// expires cache entries. Tests use it to move past a TTL without sleeping.
+func WithClock(now func() time.Time) NameResolverOption {
+	return func(nr *NameResolver) {
+		nr.now = now
+	}
 }
 
 var (
@@ -68,14 +229,63 @@ var (
 	resolverOnce     sync.Once
 )
 
+// NewNameResolver creates a resolver with default TTLs and the given options applied
+func NewNameResolver(opts ...NameResolverOption) *NameResolver {
+	nr := &NameResolver{
+		cacheTTL:    24 * time.Hour, // Cache hits for 24 hours
+		notFoundTTL: 1 * time.Hour,  // Cache misses for 1 hour
+		typeTTL:     make(map[string]time.Duration),
+		shardCount:  defaultCacheShards,
+		now:         time.Now,
+
+		reverseCache: make(map[string]reverseEntry),
+		reverseTTL:   1 * time.Hour, // Names can be renamed, keep reverse lookups short
+		aliasCache:   make(map[string]reverseEntry),
+		patternLimit: defaultPatternLimit,
+
+		snapshotMaxSize: defaultSnapshotMaxSize,
+
+		clusterCache: make(map[string]clusterCacheEntry),
+		tenantCache:  make(map[string]tenantCacheEntry),
+
+		hierarchyCache: make(map[string]hierarchyCacheEntry),
+		orgCache:       make(map[string]orgCacheEntry),
+		detailTTL:      1 * time.Hour, // Version and lifecycle change more often than names
+
+		seen: make(map[string]struct{}),
+
+		breaker: newCircuitBreaker(breakerFailureThreshold, breakerCooldown),
+	}
+	for _, opt := range opts {
+		opt(nr)
+	}
+	if nr.logger == nil {
+		nr.logger = slog.Default()
+	}
+	nr.cache = newShardedCache(nr.shardCount, nr.maxSize)
+	if rc, ok := nr.backend.(*RedisCache); ok {
+		rc.attach(nr)
+	}
+	if nr.metricsRegisterer != nil {
+		nr.metrics = newNameResolverMetrics(nr.metricsRegisterer, nr)
+	}
+	return nr
+}
+
 func GetNameResolver() *NameResolver {
 	resolverOnce.Do(func() {
-		resolverInstance = &NameResolver{
-			cache:       make(map[string]cacheEntry),
-			cacheTTL:    24 * time.Hour,     // Cache hits for 24 hours
-			notFoundTTL: 1 * time.Hour,      // Cache misses for 1 hour
+		opts := append(nameResolverOptionsFromEnv(), WithMetrics(nameServiceRegistry))
+		resolverInstance = NewNameResolver(opts...)
+		if resolverInstance.missLogger == nil {
+			resolverInstance.initMissLogger()
+		}
+
+		// Static names for when TiDB is unreachable, e.g. in air-gapped environments
+		if path := os.Getenv("NAME_SERVICE_FALLBACK_FILE"); path != "" {
+			if _, err := resolverInstance.LoadFallbackFile(path); err != nil {
+				resolverInstance.logger.Warn("Name fallback file not loaded", slog.Any("error", err))
+			}
 		}
-		resolverInstance.initMissLogger()
 
 		// Preload is enabled by default, set NAME_SERVICE_PRELOAD=false to disable
 		if os.Getenv("NAME_SERVICE_PRELOAD") != "false" {
@@ -85,24 +295,139 @@ func GetNameResolver() *NameResolver {
 	return resolverInstance
 }
 
+// NameService resolves cluster and tenant IDs to names. *NameResolver is the
+// production implementation; code that only needs names should depend on this
+// interface so tests can inject nametest.FakeNameResolver.
+type NameService interface {
+	Resolve(id string) (NameInfo, error)
+	ResolveContext(ctx context.Context, id string) (NameInfo, error)
+	ResolveBatch(ids []string) (map[string]NameInfo, []error)
+}
+
+var _ NameService = (*NameResolver)(nil)
+
+var (
+	nameServiceMu       sync.RWMutex
+	nameServiceOverride NameService
+)
+
+// GetNameService returns the service installed by SetNameService, or the
+// GetNameResolver singleton
+func GetNameService() NameService {
+	nameServiceMu.RLock()
+	override := nameServiceOverride
+	nameServiceMu.RUnlock()
+	if override != nil {
+		return override
+	}
+	return GetNameResolver()
+}
+
+// SetNameService replaces the service returned by GetNameService, e.g. with a
+// fake in tests. Passing nil restores the GetNameResolver singleton.
+func SetNameService(svc NameService) {
+	nameServiceMu.Lock()
+	nameServiceOverride = svc
+	nameServiceMu.Unlock()
+}
+
+// nameResolverOptionsFromEnv builds resolver options from NAME_SERVICE_* environment variables
+func nameResolverOptionsFromEnv() []NameResolverOption {
+	var opts []NameResolverOption
+
+	typeTTLEnv := map[string]string{
+		"cluster":  "NAME_SERVICE_CLUSTER_TTL",
+		"tenant":   "NAME_SERVICE_TENANT_TTL",
+		"notFound": "NAME_SERVICE_NOT_FOUND_TTL",
+	}
+	for entityType, key := range typeTTLEnv {
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			slog.Warn("Invalid name service TTL, using default", slog.String("key", key), slog.String("value", value))
+			continue
+		}
+		opts = append(opts, WithTypeTTL(entityType, ttl))
+	}
+
+	if value := os.Getenv("NAME_SERVICE_CACHE_MAX_SIZE"); value != "" {
+		if maxSize, err := strconv.Atoi(value); err == nil {
+			opts = append(opts, WithMaxCacheSize(maxSize))
+		} else {
+			slog.Warn("Invalid NAME_SERVICE_CACHE_MAX_SIZE, cache stays unbounded", slog.String("value", value))
+		}
+	}
+
+	if value := os.Getenv("NAME_SERVICE_CACHE_SHARDS"); value != "" {
+		if shards, err := strconv.Atoi(value); err == nil && shards > 0 {
+			opts = append(opts, WithShardCount(shards))
+		} else {
+			slog.Warn("Invalid NAME_SERVICE_CACHE_SHARDS, using default", slog.String("value", value))
+		}
+	}
+
+	if value := os.Getenv("NAME_SERVICE_SNAPSHOT_MAX_BYTES"); value != "" {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil && n > 0 {
+			opts = append(opts, WithSnapshotMaxSize(n))
+		} else {
+			slog.Warn("Invalid NAME_SERVICE_SNAPSHOT_MAX_BYTES, using default", slog.String("value", value))
+		}
+	}
+
+	if value := os.Getenv("NAME_SERVICE_MISS_LOG_JSON"); value != "" {
+		if structured, err := strconv.ParseBool(value); err == nil {
+			opts = append(opts, WithStructuredMissLog(structured))
+		} else {
+			slog.Warn("Invalid NAME_SERVICE_MISS_LOG_JSON, using the default format", slog.String("value", value))
+		}
+	}
+
+	if value := os.Getenv("USE_CLUSTER_VIEW"); value != "" {
+		if enabled, err := strconv.ParseBool(value); err == nil {
+			opts = append(opts, WithClusterView(enabled))
+		} else {
+			slog.Warn("Invalid USE_CLUSTER_VIEW, joining clusters and tenants", slog.String("value", value))
+		}
+	}
+
+	if emitter := kafkaEmitterFromEnv(); emitter != nil {
+		opts = append(opts, WithEventEmitter(emitter))
+	}
+
+	if url := os.Getenv("NAME_SERVICE_REDIS_URL"); url != "" {
+		if rc, err := redisCacheFromEnv(url, os.Getenv("NAME_SERVICE_REDIS_PREFIX")); err == nil {
+			opts = append(opts, WithCacheBackend(rc))
+		} else {
+			slog.Warn("Invalid NAME_SERVICE_REDIS_URL, using in-memory cache only", slog.Any("error", err))
+		}
+	}
+
+	return opts
+}
+
 // preloadAll loads all clusters and tenants into cache at startup
 func (nr *NameResolver) preloadAll() {
-	if db.TiDB == nil {
-		log.Println("[WARN] Cannot preload name service: TiDB not connected")
+	if !db.TiDBReady() {
+		nr.logger.Warn("Cannot preload name service: TiDB not connected")
 		return
 	}
 
-	log.Println("[INFO] Starting name service preload...")
+	nr.logger.Info("Starting name service preload")
 	start := time.Now()
 
 	clustersLoaded := nr.preloadClusters()
 	tenantsLoaded := nr.preloadTenants()
 
-	log.Printf("[INFO] Name service preload completed in %v: %d clusters, %d tenants",
-		time.Since(start), clustersLoaded, tenantsLoaded)
+	nr.logger.Info("Name service preload completed",
+		slog.Duration("elapsed", time.Since(start)),
+		slog.Int("clusters", clustersLoaded),
+		slog.Int("tenants", tenantsLoaded))
 
-	nr.preloaded = true
-	log.Println("[INFO] Server preload finished, ready to serve requests")
+	nr.preloaded.Store(true)
+	nr.logger.Info("Server preload finished, ready to serve requests")
 }
 
 // preloadClusters loads all clusters into cache
@@ -115,75 +440,72 @@ func (nr *NameResolver) preloadClusters() int {
 		LEFT JOIN tenants t ON c.tenant_id = t.tenant_id
 	`)
 	if err != nil {
-		log.Printf("[ERROR] Failed to preload clusters: %v", err)
+		nr.logger.Error("Failed to preload clusters", slog.Any("error", err))
 		return 0
 	}
 	defer rows.Close()
 
-	count := 0
-	nr.cacheMutex.Lock()
-	defer nr.cacheMutex.Unlock()
-
+	entries := make(map[string]cacheEntry)
 	for rows.Next() {
 		var clusterID, clusterName, tenantID, tenantName, deployType string
 		if err := rows.Scan(&clusterID, &clusterName, &tenantID, &tenantName, &deployType); err != nil {
-			log.Printf("[WARN] Failed to scan cluster row: %v", err)
+			nr.logger.Warn("Failed to scan cluster row", slog.Any("error", err))
 			continue
 		}
 
-		nr.cache[clusterID] = cacheEntry{
+		entries[clusterID] = cacheEntry{
 			info: NameInfo{
 				Type:       "cluster",
 				ID:         clusterID,
 				Name:       clusterName,
 				TenantID:   tenantID,
 				TenantName: tenantName,
+				DeployType: deployType,
 			},
 			notFound:  false,
-			timestamp: time.Now(),
+			timestamp: nr.now(),
+			source:    sourcePreload,
 		}
-		count++
 	}
 
-	return count
+	nr.cache.setMany(entries)
+	return len(entries)
 }
 
 // preloadTenants loads all tenants into cache
 func (nr *NameResolver) preloadTenants() int {
 	rows, err := db.TiDB.Query(`SELECT tenant_id, tenant_name FROM tenants`)
 	if err != nil {
-		log.Printf("[ERROR] Failed to preload tenants: %v", err)
+		nr.logger.Error("Failed to preload tenants", slog.Any("error", err))
 		return 0
 	}
 	defer rows.Close()
 
-	count := 0
-	nr.cacheMutex.Lock()
-	defer nr.cacheMutex.Unlock()
-
+	entries := make(map[string]cacheEntry)
 	for rows.Next() {
 		var tenantID, tenantName string
 		if err := rows.Scan(&tenantID, &tenantName); err != nil {
-			log.Printf("[WARN] Failed to scan tenant row: %v", err)
+			nr.logger.Warn("Failed to scan tenant row", slog.Any("error", err))
 			continue
 		}
 
 		// Only add if not already in cache (clusters take priority)
-		if _, exists := nr.cache[tenantID]; !exists {
-			nr.cache[tenantID] = cacheEntry{
+		if _, exists := nr.cache.peek(tenantID); !exists {
+			entries[tenantID] = cacheEntry{
 				info: NameInfo{
 					Type: "tenant",
 					ID:   tenantID,
 					Name: tenantName,
 				},
 				notFound:  false,
-				timestamp: time.Now(),
+				timestamp: nr.now(),
+				source:    sourcePreload,
 			}
-			count++
 		}
 	}
 
-	return count
+	nr.cache.setMany(entries)
+	return len(entries)
 }
 
 // initMissLogger initializes a separate logger for cache misses
@@ -195,19 +517,95 @@ func (nr *NameResolver) initMissLogger() {
 
 	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
-		log.Printf("[WARN] Failed to create name service miss log file: %v, using stderr", err)
-		nr.missLogger = log.New(os.Stderr, "[NAME_MISS] ", log.LstdFlags)
+		nr.logger.Warn("Failed to create name service miss log file, using stderr", slog.String("path", logPath), slog.Any("error", err))
+		if nr.missJSON {
+			nr.missLogger = NewStructuredMissLogger(os.Stderr)
+		} else {
+			nr.missLogger = NewPlainMissLogger(os.Stderr, "[NAME_MISS] ")
+		}
 		return
 	}
-	nr.missLogger = log.New(file, "", log.LstdFlags)
-	log.Printf("[INFO] Name service miss log initialized: %s", logPath)
+	if nr.missJSON {
+		nr.missLogger = NewStructuredMissLogger(file)
+	} else {
+		nr.missLogger = NewPlainMissLogger(file, "")
+	}
+	nr.logger.Info("Name service miss log initialized", slog.String("path", logPath))
+}
+
+// NewPlainMissLogger returns a miss logger writing the default
+// "2006/01/02 15:04:05 ID=<id> reason=<reason>" lines to w, each starting
+// with prefix
+func NewPlainMissLogger(w io.Writer, prefix string) *slog.Logger {
+	return slog.New(&plainMissHandler{out: log.New(w, prefix, log.LstdFlags)})
 }
 
-// logMiss logs a cache miss to the dedicated log file
-func (nr *NameResolver) logMiss(id string, reason string) {
-	if nr.missLogger != nil {
-		nr.missLogger.Printf("ID=%s reason=%s", id, reason)
+// plainMissHandler formats the id and reason attributes of a record as one
+// log.Logger line; other attributes and the message are dropped
+type plainMissHandler struct {
+	out   *log.Logger
+	attrs []slog.Attr
+}
+
+func (h *plainMissHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *plainMissHandler) Handle(_ context.Context, r slog.Record) error {
+	var id, reason string
+	visit := func(a slog.Attr) bool {
+		switch a.Key {
+		case "id":
+			id = a.Value.String()
+		case "reason":
+			reason = a.Value.String()
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		visit(a)
 	}
+	r.Attrs(visit)
+	h.out.Printf("ID=%s reason=%s", id, reason)
+	return nil
+}
+
+func (h *plainMissHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &plainMissHandler{out: h.out, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...)}
+}
+
+func (h *plainMissHandler) WithGroup(string) slog.Handler { return h }
+
+// NewStructuredMissLogger returns a miss logger writing one
+// {"ts":...,"id":...,"reason":...,"ms_elapsed":...} object per line to w, with
+// ts in UTC RFC 3339
+func NewStructuredMissLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return a
+			}
+			switch a.Key {
+			case slog.TimeKey:
+				return slog.String("ts", a.Value.Time().UTC().Format(time.RFC3339))
+			case slog.LevelKey, slog.MessageKey:
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+// logMiss logs a cache miss to the dedicated log file. start is when the
+// Resolve or ResolveBatch call began.
+func (nr *NameResolver) logMiss(id string, reason string, start time.Time) {
+	if nr.missLogger == nil {
+		return
+	}
+	if nr.missJSON {
+		elapsed := float64(time.Since(start).Microseconds()) / 1000
+		nr.missLogger.Info("name not resolved", slog.String("id", id), slog.String("reason", reason), slog.Float64("ms_elapsed", elapsed))
+		return
+	}
+	nr.missLogger.Info("name not resolved", slog.String("id", id), slog.String("reason", reason))
 }
 
 func isNumeric(s string) bool {
@@ -221,90 +619,147 @@ func isNumeric(s string) bool {
 
 // isEntryValid checks if a cache entry is still valid
 func (nr *NameResolver) isEntryValid(entry cacheEntry) bool {
-	ttl := nr.cacheTTL
+	return nr.now().Sub(entry.timestamp) < nr.entryTTL(entry)
+}
+
+// entryTTL returns the TTL for an entry, preferring a type-specific TTL when configured
+func (nr *NameResolver) entryTTL(entry cacheEntry) time.Duration {
+	entityType := entry.info.Type
 	if entry.notFound {
-		ttl = nr.notFoundTTL
+		entityType = "notFound"
 	}
-	return time.Since(entry.timestamp) < ttl
+	if ttl, ok := nr.typeTTL[entityType]; ok {
+		return ttl
+	}
+
+	if entry.notFound {
+		return nr.notFoundTTL
+	}
+	return nr.cacheTTL
 }
 
 func (nr *NameResolver) Resolve(id string) (NameInfo, error) {
+	return nr.ResolveContext(context.Background(), id)
+}
+
+// ResolveContext is Resolve as part of the trace in ctx. The lookup is traced
+// in a NameResolver.Resolve span, with child spans for the cache lookup and
+// each database query.
+func (nr *NameResolver) ResolveContext(ctx context.Context, id string) (NameInfo, error) {
+	ctx, span := tracer.Start(ctx, "NameResolver.Resolve", trace.WithAttributes(attribute.String("name_resolver.id", id)))
+	defer span.End()
+
+	info, err := nr.resolve(ctx, id)
+	if err != nil && !errors.Is(err, ErrIDNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return info, err
+}
+
+func (nr *NameResolver) resolve(ctx context.Context, id string) (NameInfo, error) {
+	resolveStart := time.Now()
 	if id == "" {
 		return NameInfo{}, fmt.Errorf("empty id")
 	}
 
 	if !isNumeric(id) {
+		// Cluster UUIDs and slugs resolve as the numeric ID they stand for
+		if canonical, ok := nr.resolveAlias(ctx, id); ok {
+			return nr.resolve(ctx, canonical)
+		}
 		return NameInfo{ID: id, Name: id}, nil
 	}
 
 	// Check cache (including not-found entries)
-	nr.cacheMutex.RLock()
-	entry, ok := nr.cache[id]
+	_, cacheSpan := tracer.Start(ctx, "name_resolver.cache_lookup")
+	entry, ok := nr.cache.get(id)
 	isValid := ok && nr.isEntryValid(entry)
-	preloaded := nr.preloaded
-	nr.cacheMutex.RUnlock()
+	cacheSpan.SetAttributes(attribute.Bool("name_resolver.cache_hit", isValid))
+	cacheSpan.End()
+	preloaded := nr.isPreloadComplete()
 
+	nr.requests.Add(1)
 	if isValid {
+		nr.hits.Add(1)
+		nr.metrics.hit(1)
 		if entry.notFound {
 			return NameInfo{ID: id, Name: id}, nil
 		}
+		if entry.source == sourceFallback && !preloaded {
+			return nr.refreshFallback(ctx, id, entry, resolveStart), nil
+		}
 		return entry.info, nil
 	}
+	nr.metrics.miss(1)
+
+	// Another instance may already have resolved it
+	if nr.backend != nil {
+		if shared, ok := nr.backend.Get(id); ok && nr.isEntryValid(shared) {
+			nr.cache.set(id, shared)
+			if shared.notFound {
+				return NameInfo{ID: id, Name: id}, nil
+			}
+			return shared.info, nil
+		}
+	}
 
 	// If preloaded, cache miss means not found - return immediately without DB query
 	if preloaded {
-		nr.logMiss(id, "not_in_preloaded_cache")
+		nr.logMiss(id, "not_in_preloaded_cache", resolveStart)
 		return NameInfo{ID: id, Name: id}, nil
 	}
 
 	// Check if TiDB is available
-	if db.TiDB == nil {
-		nr.logMiss(id, "TiDB_not_connected")
+	if !db.TiDBReady() {
+		nr.logMiss(id, "TiDB_not_connected", resolveStart)
 		return NameInfo{ID: id, Name: id}, nil
 	}
 
-	// First try to find as cluster
-	if clusterInfo, err := nr.getCluster(id); err == nil && clusterInfo != nil {
-		clusterName := clusterInfo.ClusterName
-
-		// Special handling for nextgen-host clusters with empty names
-		if clusterInfo.DeployType == "nextgen-host" && (clusterName == "" || clusterName == id) {
-			if premiumNames, err := nr.getPremiumClusterNamesByParentID(id); err == nil && len(premiumNames) > 0 {
-				meaningfulNames := []string{}
-				for _, name := range premiumNames {
-					name = strings.TrimSpace(name)
-					if name != "" && name != id {
-						meaningfulNames = append(meaningfulNames, name)
-					}
-				}
-				if len(meaningfulNames) > 0 {
-					clusterName = strings.Join(meaningfulNames, ", ")
-				}
-			}
-		}
+	// TiDB keeps failing: fall back to the raw ID without logging each miss
+	if !nr.breaker.allow() {
+		return NameInfo{ID: id, Name: id}, nil
+	}
 
+	// Concurrent misses for the same ID share a single database round-trip. It
+	// is traced under the caller that started it and must outlive its cancellation.
+	result, err, _ := nr.lookupGroup.Do(id, func() (interface{}, error) {
+		return nr.resolveFromDB(context.WithoutCancel(ctx), id, resolveStart)
+	})
+	return result.(NameInfo), err
+}
+
+// resolveFromDB looks an ID up in TiDB and stores the result (or the miss) in
+// the cache. resolveStart is when the calling Resolve began.
+func (nr *NameResolver) resolveFromDB(ctx context.Context, id string, resolveStart time.Time) (NameInfo, error) {
+	// First try to find as cluster
+	start := time.Now()
+	clusterInfo, source, err := nr.getCluster(ctx, id)
+	nr.observeLookup(start, err)
+	if err == nil && clusterInfo != nil {
 		result := NameInfo{
 			Type:       "cluster",
 			ID:         id,
-			Name:       clusterName,
+			Name:       nr.clusterDisplayName(id, clusterInfo.ClusterName, clusterInfo.DeployType),
 			TenantID:   clusterInfo.TenantID,
 			TenantName: clusterInfo.TenantName,
+			DeployType: clusterInfo.DeployType,
 		}
 
-		// Update cache
+		// Update cache, keeping the full details for ResolveCluster too
+		nr.setCacheEntry(id, result, false, source)
 		nr.cacheMutex.Lock()
-		nr.cache[id] = cacheEntry{
-			info:      result,
-			notFound:  false,
-			timestamp: time.Now(),
-		}
+		nr.clusterCache[id] = clusterCacheEntry{info: *clusterInfo, timestamp: nr.now()}
 		nr.cacheMutex.Unlock()
 
 		return result, nil
 	}
 
 	// Then try to find as tenant
-	if tenantInfo, err := nr.getTenant(id); err == nil && tenantInfo != nil {
+	start = time.Now()
+	tenantInfo, source, err := nr.getTenant(ctx, id)
+	nr.observeLookup(start, err)
+	if err == nil && tenantInfo != nil {
 		result := NameInfo{
 			Type: "tenant",
 			ID:   id,
@@ -312,105 +767,566 @@ func (nr *NameResolver) Resolve(id string) (NameInfo, error) {
 		}
 
 		// Update cache
-		nr.cacheMutex.Lock()
-		nr.cache[id] = cacheEntry{
-			info:      result,
-			notFound:  false,
-			timestamp: time.Now(),
-		}
-		nr.cacheMutex.Unlock()
+		nr.setCacheEntry(id, result, false, source)
 
 		return result, nil
 	}
 
 	// Fallback: try simple tenant name
-	if tenantName, err := nr.getTenantName(id); err == nil && tenantName != "" {
+	start = time.Now()
+	tenantName, source, err := nr.getTenantName(ctx, id)
+	nr.observeLookup(start, err)
+	if err == nil && tenantName != "" {
 		result := NameInfo{
 			Type: "tenant",
 			ID:   id,
 			Name: tenantName,
 		}
 
-		nr.cacheMutex.Lock()
-		nr.cache[id] = cacheEntry{
-			info:      result,
-			notFound:  false,
-			timestamp: time.Now(),
-		}
-		nr.cacheMutex.Unlock()
+		nr.setCacheEntry(id, result, false, source)
 
 		return result, nil
 	}
 
 	// Fallback: try simple cluster name
-	if clusterName, err := nr.getClusterName(id); err == nil && clusterName != "" {
+	start = time.Now()
+	clusterName, source, err := nr.getClusterName(ctx, id)
+	nr.observeLookup(start, err)
+	if err == nil && clusterName != "" {
 		result := NameInfo{
 			Type: "cluster",
 			ID:   id,
 			Name: clusterName,
 		}
 
-		nr.cacheMutex.Lock()
-		nr.cache[id] = cacheEntry{
-			info:      result,
-			notFound:  false,
-			timestamp: time.Now(),
+		nr.setCacheEntry(id, result, false, source)
+
+		return result, nil
+	}
+
+	// Last resort: projects
+	start = time.Now()
+	projectInfo, source, err := nr.getProject(ctx, id)
+	nr.observeLookup(start, err)
+	if err == nil && projectInfo != nil {
+		result := NameInfo{
+			Type: "project",
+			ID:   id,
+			Name: projectInfo.ProjectName,
 		}
-		nr.cacheMutex.Unlock()
+
+		nr.setCacheEntry(id, result, false, source)
+
+		return result, nil
+	}
+
+	// Fallback: try simple project name
+	start = time.Now()
+	projectName, source, err := nr.getProjectName(ctx, id)
+	nr.observeLookup(start, err)
+	if err == nil && projectName != "" {
+		result := NameInfo{
+			Type: "project",
+			ID:   id,
+			Name: projectName,
+		}
+
+		nr.setCacheEntry(id, result, false, source)
 
 		return result, nil
 	}
 
 	// Not found - cache the miss and log it
-	nr.cacheMutex.Lock()
-	nr.cache[id] = cacheEntry{
-		info:      NameInfo{ID: id, Name: id},
-		notFound:  true,
-		timestamp: time.Now(),
+	nr.setCacheEntry(id, NameInfo{ID: id, Name: id}, true, source)
+
+	nr.logMiss(id, "not_found_in_database", resolveStart)
+
+	return NameInfo{ID: id, Name: id}, fmt.Errorf("%w: %s", ErrIDNotFound, id)
+}
+
+// ResolveBatch resolves multiple IDs at once. IDs that are already warm in the
+// cache are served directly; the rest are looked up with a single query against
+// clusters and a second one against tenants for whatever is left over. Cluster
+// UUIDs and slugs resolve as the numeric ID they stand for, as in Resolve.
+func (nr *NameResolver) ResolveBatch(ids []string) (map[string]NameInfo, []error) {
+	aliases := make(map[string]string) // alias -> canonical ID
+	batch := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != "" && !isNumeric(id) {
+			if canonical, ok := nr.resolveAlias(context.Background(), id); ok {
+				aliases[id] = canonical
+				batch = append(batch, canonical)
+				continue
+			}
+		}
+		batch = append(batch, id)
+	}
+	if len(aliases) == 0 {
+		return nr.resolveBatch(batch)
+	}
+
+	resolved, errs := nr.resolveBatch(batch)
+	results := make(map[string]NameInfo, len(ids))
+	for _, id := range ids {
+		if canonical, ok := aliases[id]; ok {
+			results[id] = resolved[canonical]
+		} else if info, ok := resolved[id]; ok {
+			results[id] = info
+		}
+	}
+	return results, errs
+}
+
+func (nr *NameResolver) resolveBatch(ids []string) (map[string]NameInfo, []error) {
+	resolveStart := time.Now()
+	results := make(map[string]NameInfo, len(ids))
+	var errs []error
+
+	// Deduplicate and serve warm entries from cache
+	var pending []string
+	seen := make(map[string]bool, len(ids))
+	hits := 0
+
+	preloaded := nr.isPreloadComplete()
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if id == "" {
+			errs = append(errs, fmt.Errorf("empty id"))
+			continue
+		}
+		if !isNumeric(id) {
+			results[id] = NameInfo{ID: id, Name: id}
+			continue
+		}
+
+		if entry, ok := nr.cache.get(id); ok && nr.isEntryValid(entry) {
+			hits++
+			if entry.notFound {
+				results[id] = NameInfo{ID: id, Name: id}
+			} else {
+				results[id] = entry.info
+			}
+			continue
+		}
+		pending = append(pending, id)
+	}
+
+	nr.requests.Add(int64(hits + len(pending)))
+	nr.hits.Add(int64(hits))
+	nr.metrics.hit(hits)
+	nr.metrics.miss(len(pending))
+	if len(pending) == 0 {
+		return results, errs
+	}
+
+	// Same short-circuits as Resolve: after preload or without TiDB a miss is final
+	if preloaded || !db.TiDBReady() {
+		reason := "not_in_preloaded_cache"
+		if !preloaded {
+			reason = "TiDB_not_connected"
+		}
+		for _, id := range pending {
+			nr.logMiss(id, reason, resolveStart)
+			results[id] = NameInfo{ID: id, Name: id}
+		}
+		return results, errs
+	}
+
+	if !nr.breaker.allow() {
+		for _, id := range pending {
+			results[id] = NameInfo{ID: id, Name: id}
+		}
+		return results, append(errs, ErrCircuitOpen)
+	}
+
+	// Look up clusters first
+	start := time.Now()
+	clusters, clusterSource, err := nr.getClustersByIDs(context.Background(), pending)
+	nr.observeLookup(start, err)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("batch cluster lookup failed: %w", err))
+	}
+
+	var remaining []string
+	for _, id := range pending {
+		clusterInfo, ok := clusters[id]
+		if !ok {
+			remaining = append(remaining, id)
+			continue
+		}
+
+		result := NameInfo{
+			Type:       "cluster",
+			ID:         id,
+			Name:       nr.clusterDisplayName(id, clusterInfo.ClusterName, clusterInfo.DeployType),
+			TenantID:   clusterInfo.TenantID,
+			TenantName: clusterInfo.TenantName,
+			DeployType: clusterInfo.DeployType,
+		}
+		nr.setCacheEntry(id, result, false, clusterSource)
+		results[id] = result
+	}
+
+	if len(remaining) == 0 {
+		return results, errs
+	}
+
+	// Then tenants for whatever is left
+	start = time.Now()
+	tenantNames, tenantSource, tenantErr := nr.getTenantNamesByIDs(context.Background(), remaining)
+	nr.observeLookup(start, tenantErr)
+	if tenantErr != nil {
+		errs = append(errs, fmt.Errorf("batch tenant lookup failed: %w", tenantErr))
+	}
+
+	for _, id := range remaining {
+		if tenantName, ok := tenantNames[id]; ok {
+			result := NameInfo{
+				Type: "tenant",
+				ID:   id,
+				Name: tenantName,
+			}
+			nr.setCacheEntry(id, result, false, tenantSource)
+			results[id] = result
+			continue
+		}
+
+		results[id] = NameInfo{ID: id, Name: id}
+
+		// Only cache the miss when both lookups actually succeeded
+		if err != nil || tenantErr != nil {
+			continue
+		}
+		nr.setCacheEntry(id, NameInfo{ID: id, Name: id}, true, tenantSource)
+		nr.logMiss(id, "not_found_in_database", resolveStart)
+		errs = append(errs, fmt.Errorf("%w: %s", ErrIDNotFound, id))
+	}
+
+	return results, errs
+}
+
+// ResolveByName finds the entity whose cluster or tenant name matches name
+// (case-insensitive). Clusters are tried before tenants, and a LIKE match is
+// used as a fallback when there is no exact match.
+func (nr *NameResolver) ResolveByName(name string) (NameInfo, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return NameInfo{}, fmt.Errorf("empty name")
+	}
+	key := strings.ToLower(name)
+
+	nr.cacheMutex.RLock()
+	rev, ok := nr.reverseCache[key]
+	nr.cacheMutex.RUnlock()
+
+	if ok && nr.now().Sub(rev.timestamp) < nr.reverseTTL {
+		return nr.Resolve(rev.id)
+	}
+
+	if !db.TiDBReady() {
+		return NameInfo{}, fmt.Errorf("TiDB not connected")
+	}
+
+	id, err := nr.lookupIDByName(name)
+	if err != nil {
+		return NameInfo{}, err
+	}
+	if id == "" {
+		return NameInfo{}, fmt.Errorf("name not found: %s", name)
 	}
+
+	nr.cacheMutex.Lock()
+	nr.reverseCache[key] = reverseEntry{id: id, timestamp: nr.now()}
 	nr.cacheMutex.Unlock()
 
-	nr.logMiss(id, "not_found_in_database")
+	return nr.Resolve(id)
+}
+
+// lookupIDByName tries exact cluster, exact tenant, then LIKE cluster and LIKE tenant matches
+func (nr *NameResolver) lookupIDByName(name string) (string, error) {
+	pattern := "%" + escapeLike(name) + "%"
+	lookups := []struct {
+		query string
+		arg   string
+	}{
+		{`SELECT cluster_id FROM clusters WHERE LOWER(cluster_name) = LOWER(?) LIMIT 1`, name},
+		{`SELECT tenant_id FROM tenants WHERE LOWER(tenant_name) = LOWER(?) LIMIT 1`, name},
+		{`SELECT cluster_id FROM clusters WHERE LOWER(cluster_name) LIKE LOWER(?) ORDER BY cluster_name LIMIT 1`, pattern},
+		{`SELECT tenant_id FROM tenants WHERE LOWER(tenant_name) LIKE LOWER(?) ORDER BY tenant_name LIMIT 1`, pattern},
+	}
+
+	for _, lookup := range lookups {
+		var id string
+		err := db.TiDB.QueryRow(lookup.query, lookup.arg).Scan(&id)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return id, nil
+	}
+	return "", nil
+}
 
-	return NameInfo{ID: id, Name: id}, fmt.Errorf("ID not found: %s", id)
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// setCacheEntry stores a resolved (or not-found) entry in the cache and the
+// shared backend, if any
+func (nr *NameResolver) setCacheEntry(id string, info NameInfo, notFound bool, source string) {
+	entry := cacheEntry{
+		info:      info,
+		notFound:  notFound,
+		timestamp: nr.now(),
+		source:    source,
+	}
+	nr.cache.set(id, entry)
+
+	if nr.backend != nil {
+		nr.backend.Set(id, entry)
+	}
+	if nr.emitter != nil && !notFound {
+		nr.emitDiscovered(info)
+	}
+}
+
+// isPreloadComplete reports whether a cache miss can be treated as not found.
+// Once the LRU has evicted anything the preloaded data is no longer complete.
+func (nr *NameResolver) isPreloadComplete() bool {
+	return nr.preloaded.Load() && nr.cache.evicted() == 0
+}
+
+// clusterDisplayName returns the name to show for a cluster. nextgen-host clusters
+// usually have no name of their own, so the names of their premium clusters are used.
+func (nr *NameResolver) clusterDisplayName(id, clusterName, deployType string) string {
+	if deployType != "nextgen-host" || (clusterName != "" && clusterName != id) {
+		return clusterName
+	}
+
+	premiumNames, err := nr.getPremiumClusterNamesByParentID(id)
+	if err != nil || len(premiumNames) == 0 {
+		return clusterName
+	}
+
+	meaningfulNames := []string{}
+	for _, name := range premiumNames {
+		name = strings.TrimSpace(name)
+		if name != "" && name != id {
+			meaningfulNames = append(meaningfulNames, name)
+		}
+	}
+	if len(meaningfulNames) > 0 {
+		return strings.Join(meaningfulNames, ", ")
+	}
+	return clusterName
 }
 
 // GetCacheStats returns cache statistics
 func (nr *NameResolver) GetCacheStats() map[string]interface{} {
+	stats := nr.snapshotCacheStats()
+
+	typeTTL := make(map[string]string, len(nr.typeTTL))
+	for entityType, ttl := range nr.typeTTL {
+		typeTTL[entityType] = ttl.String()
+	}
+
+	return map[string]interface{}{
+		"total":         stats.total,
+		"found":         stats.found,
+		"not_found":     stats.notFound,
+		"expired":       stats.expired,
+		"cache_ttl":     nr.cacheTTL.String(),
+		"not_found_ttl": nr.notFoundTTL.String(),
+		"type_ttl":      typeTTL,
+		"max_size":      nr.maxSize,
+		"lru_evictions": stats.evictions,
+		"reverse_total": stats.reverseTotal,
+		"reverse_ttl":   nr.reverseTTL.String(),
+		"alias_total":   stats.aliasTotal,
+		"by_source":     stats.bySource,
+		"age_histogram": stats.ageHistogram,
+		"hit_rate":      nr.hitRate(),
+		"cache_hits":    nr.hits.Load(),
+		"cache_misses":  nr.requests.Load() - nr.hits.Load(),
+		"active_region": db.ActiveRegion(),
+
+		"circuit_breaker_state": nr.breaker.currentState().String(),
+	}
+}
+
+// cacheStats is a point-in-time copy of the cache counters
+type cacheStats struct {
+	total, found, notFound, expired int
+	reverseTotal, aliasTotal        int
+	evictions                       int64
+	bySource                        map[string]int
+	ageHistogram                    map[string]int
+}
+
+// snapshotCacheStats counts the cache entries, holding the read lock only while counting
+func (nr *NameResolver) snapshotCacheStats() cacheStats {
+	stats := cacheStats{
+		bySource:     make(map[string]int),
+		ageHistogram: make(map[string]int, len(cacheAgeBuckets)),
+	}
+	for _, b := range cacheAgeBuckets {
+		stats.ageHistogram[b.label] = 0
+	}
+
+	now := nr.now()
 	nr.cacheMutex.RLock()
 	defer nr.cacheMutex.RUnlock()
 
-	total := len(nr.cache)
-	found := 0
-	notFound := 0
-	expired := 0
-
-	for _, entry := range nr.cache {
+	stats.total = nr.cache.len()
+	stats.evictions = nr.cache.evicted()
+	stats.reverseTotal = len(nr.reverseCache)
+	stats.aliasTotal = len(nr.aliasCache)
+	nr.cache.each(func(_ string, entry cacheEntry) {
+		stats.ageHistogram[cacheAgeBucket(now.Sub(entry.timestamp))]++
 		if !nr.isEntryValid(entry) {
-			expired++
+			stats.expired++
 		} else if entry.notFound {
-			notFound++
+			stats.notFound++
 		} else {
-			found++
+			stats.found++
+		}
+		stats.bySource[entry.source]++
+	})
+	return stats
+}
+
+// cacheAgeBuckets are the upper bounds of the age_histogram buckets reported by
+// GetCacheStats; older entries fall in ">24h"
+var cacheAgeBuckets = []struct {
+	label string
+	max   time.Duration
+}{
+	{"<1m", time.Minute},
+	{"1m-1h", time.Hour},
+	{"1h-6h", 6 * time.Hour},
+	{"6h-24h", 24 * time.Hour},
+	{">24h", 0},
+}
+
+// cacheAgeBucket returns the age_histogram bucket of an entry of the given age
+func cacheAgeBucket(age time.Duration) string {
+	for _, b := range cacheAgeBuckets {
+		if b.max == 0 || age < b.max {
+			return b.label
 		}
 	}
+	return ">24h"
+}
 
-	return map[string]interface{}{
-		"total":          total,
-		"found":          found,
-		"not_found":      notFound,
-		"expired":        expired,
-		"cache_ttl":      nr.cacheTTL.String(),
-		"not_found_ttl":  nr.notFoundTTL.String(),
+// hitRate is the fraction of Resolve calls served from the cache since startup
+func (nr *NameResolver) hitRate() float64 {
+	requests := nr.requests.Load()
+	if requests == 0 {
+		return 0
 	}
+	return float64(nr.hits.Load()) / float64(requests)
+}
+
+// ResolveAll returns a page of the valid cached names sorted by ID, and the
+// total number of valid entries. The read lock is held only while the entries
+// are copied out.
+func (nr *NameResolver) ResolveAll(offset, limit int) ([]NameInfo, int, error) {
+	if offset < 0 || limit < 0 {
+		return nil, 0, fmt.Errorf("offset and limit must not be negative")
+	}
+
+	nr.cacheMutex.RLock()
+	infos := make([]NameInfo, 0, nr.cache.len())
+	nr.cache.each(func(_ string, entry cacheEntry) {
+		if !entry.notFound && nr.isEntryValid(entry) {
+			infos = append(infos, entry.info)
+		}
+	})
+	nr.cacheMutex.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+
+	total := len(infos)
+	if offset >= total {
+		return []NameInfo{}, total, nil
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	return infos[offset:end], total, nil
 }
 
 // ClearCache clears all cache entries
 func (nr *NameResolver) ClearCache() {
 	nr.cacheMutex.Lock()
 	defer nr.cacheMutex.Unlock()
-	nr.cache = make(map[string]cacheEntry)
-	log.Println("[INFO] Name resolver cache cleared")
+	nr.cache.clear()
+	nr.reverseCache = make(map[string]reverseEntry)
+	nr.aliasCache = make(map[string]reverseEntry)
+	nr.clusterCache = make(map[string]clusterCacheEntry)
+	nr.tenantCache = make(map[string]tenantCacheEntry)
+	nr.hierarchyCache = make(map[string]hierarchyCacheEntry)
+	nr.orgCache = make(map[string]orgCacheEntry)
+	nr.logger.Info("Name resolver cache cleared")
+}
+
+// Invalidate evicts the given IDs, and any name -> ID entries pointing at them,
+// so the next Resolve goes back to the database. It returns the number of IDs
+// that were cached.
+func (nr *NameResolver) Invalidate(ids []string) int {
+	nr.cacheMutex.Lock()
+	evict := make(map[string]bool, len(ids))
+	evicted := 0
+	for _, id := range ids {
+		evict[id] = true
+		if nr.cache.delete(id) {
+			evicted++
+		}
+		delete(nr.clusterCache, id)
+		delete(nr.tenantCache, id)
+	}
+
+	for name, rev := range nr.reverseCache {
+		if evict[rev.id] {
+			delete(nr.reverseCache, name)
+		}
+	}
+	for key, alias := range nr.aliasCache {
+		if evict[alias.id] {
+			delete(nr.aliasCache, key)
+		}
+	}
+	for clusterID, h := range nr.hierarchyCache {
+		if evict[clusterID] || evict[h.info.Tenant.TenantID] || evict[h.info.OrgID] {
+			delete(nr.hierarchyCache, clusterID)
+		}
+	}
+	for tenantID, org := range nr.orgCache {
+		for _, id := range org.chain {
+			if evict[id] {
+				delete(nr.orgCache, tenantID)
+				break
+			}
+		}
+	}
+	nr.cacheMutex.Unlock()
+
+	// Other instances would otherwise read the stale entry back from the shared tier
+	if d, ok := nr.backend.(cacheBackendDeleter); ok {
+		for _, id := range ids {
+			d.Delete(id)
+		}
+	}
+
+	return evicted
 }
 
 // CleanExpiredCache removes expired entries from cache
@@ -419,103 +1335,267 @@ func (nr *NameResolver) CleanExpiredCache() int {
 	defer nr.cacheMutex.Unlock()
 
 	cleaned := 0
-	for id, entry := range nr.cache {
+	nr.cache.each(func(id string, entry cacheEntry) {
 		if !nr.isEntryValid(entry) {
-			delete(nr.cache, id)
+			nr.cache.delete(id)
+			cleaned++
+		}
+	})
+	for name, rev := range nr.reverseCache {
+		if nr.now().Sub(rev.timestamp) >= nr.reverseTTL {
+			delete(nr.reverseCache, name)
+			cleaned++
+		}
+	}
+	for key, alias := range nr.aliasCache {
+		if nr.now().Sub(alias.timestamp) >= nr.aliasTTL(alias) {
+			delete(nr.aliasCache, key)
+			cleaned++
+		}
+	}
+	for id, entry := range nr.clusterCache {
+		if nr.now().Sub(entry.timestamp) >= nr.detailTTL {
+			delete(nr.clusterCache, id)
+			cleaned++
+		}
+	}
+	for id, entry := range nr.tenantCache {
+		if nr.now().Sub(entry.timestamp) >= nr.detailTTL {
+			delete(nr.tenantCache, id)
+			cleaned++
+		}
+	}
+	for id, entry := range nr.hierarchyCache {
+		if nr.now().Sub(entry.timestamp) >= nr.detailTTL {
+			delete(nr.hierarchyCache, id)
+			cleaned++
+		}
+	}
+	for id, entry := range nr.orgCache {
+		if nr.now().Sub(entry.timestamp) >= nr.detailTTL {
+			delete(nr.orgCache, id)
 			cleaned++
 		}
 	}
 	if cleaned > 0 {
-		log.Printf("[INFO] Cleaned %d expired cache entries", cleaned)
+		nr.logger.Info("Cleaned expired cache entries", slog.Int("count", cleaned))
 	}
 	return cleaned
 }
 
-// getCluster retrieves cluster info from database
-func (nr *NameResolver) getCluster(clusterID string) (*ClusterInfo, error) {
-	row := db.TiDB.QueryRow(`
-		SELECT c.cluster_id, c.cluster_name, c.tenant_id,
-		       COALESCE(NULLIF(c.tenant_name, ''), t.tenant_name, '') as tenant_name,
-		       COALESCE(c.deploy_type, '') as deploy_type,
-		       COALESCE(c.version, '') as version,
-		       COALESCE(c.cluster_lifecycle, '') as cluster_lifecycle,
-		       COALESCE(c.creation_duration, '') as creation_duration,
-		       COALESCE(c.tenant_plan, '') as tenant_plan,
-		       COALESCE(c.provider, '') as provider,
-		       COALESCE(c.region, '') as region,
-		       COALESCE(c.project_id, '') as project_id,
-		       COALESCE(c.org_id, '') as org_id,
-		       COALESCE(c.cluster_type, '') as cluster_type,
-		       c.created_at, c.updated_at
-		FROM clusters c
-		LEFT JOIN tenants t ON c.tenant_id = t.tenant_id
-		WHERE c.cluster_id = ?
-	`, clusterID)
-
+// getCluster retrieves cluster info from database, from v_cluster_names when
+// the cluster view is enabled and exists. The tenant joined in is cached too,
+// so resolving it next needs no query of its own.
+func (nr *NameResolver) getCluster(ctx context.Context, clusterID string) (*ClusterInfo, string, error) {
 	var info ClusterInfo
-	err := row.Scan(&info.ClusterID, &info.ClusterName, &info.TenantID, &info.TenantName,
+	var joinedTenantName sql.NullString
+	dest := []interface{}{
+		&info.ClusterID, &info.ClusterName, &info.TenantID, &info.TenantName,
 		&info.DeployType, &info.Version, &info.ClusterLifecycle, &info.CreationDuration,
 		&info.TenantPlan, &info.Provider, &info.Region, &info.ProjectID, &info.OrgID, &info.ClusterType,
-		&info.CreatedAt, &info.UpdatedAt)
+		&info.CreatedAt, &info.UpdatedAt, &joinedTenantName,
+	}
+	query := clusterQuery
+	if nr.useClusterView() {
+		query = clusterViewQuery
+	}
+	source, err := nr.queryRow(ctx, query, []interface{}{clusterID}, dest...)
+	var mysqlErr *mysql.MySQLError
+	if query == clusterViewQuery && errors.As(err, &mysqlErr) && mysqlErr.Number == 1146 {
+		if !nr.clusterViewMissing.Swap(true) {
+			nr.logger.Warn("No v_cluster_names view in TiDB, joining clusters and tenants until the view is enabled again")
+		}
+		source, err = nr.queryRow(ctx, clusterQuery, []interface{}{clusterID}, dest...)
+	}
 	if err == sql.ErrNoRows {
-		return nil, nil
+		return nil, source, nil
 	}
 	if err != nil {
-		return nil, err
+		return nil, source, err
+	}
+	if info.TenantID != "" && joinedTenantName.Valid && joinedTenantName.String != "" {
+		nr.prefetchTenant(info.TenantID, joinedTenantName.String, source)
 	}
-	return &info, nil
+	return &info, source, nil
 }
 
-// getTenant retrieves tenant info from database
-func (nr *NameResolver) getTenant(tenantID string) (*TenantInfo, error) {
-	row := db.TiDB.QueryRow(`
-		SELECT tenant_id, tenant_name, kind, created_at, updated_at
-		FROM tenants WHERE tenant_id = ?
-	`, tenantID)
+// prefetchTenant caches the name of a tenant read along with one of its
+// clusters, unless a valid entry for it is cached already. The entry gets the
+// same TTL as one cached by Resolve.
+func (nr *NameResolver) prefetchTenant(tenantID, tenantName, source string) {
+	if existing, ok := nr.cache.peek(tenantID); ok && !existing.notFound && nr.isEntryValid(existing) {
+		return
+	}
+	nr.setCacheEntry(tenantID, NameInfo{
+		Type: "tenant",
+		ID:   tenantID,
+		Name: tenantName,
+	}, false, source)
+}
+
+// getClustersByIDs retrieves basic cluster info for a set of IDs in one query.
+// It returns the backend that answered.
+func (nr *NameResolver) getClustersByIDs(ctx context.Context, clusterIDs []string) (map[string]*ClusterInfo, string, error) {
+	placeholders, args := inClause(clusterIDs)
+	var clusters map[string]*ClusterInfo
+	source, err := nr.queryRows(ctx, `
+		SELECT c.cluster_id, c.cluster_name, c.tenant_id,
+		       COALESCE(NULLIF(c.tenant_name, ''), t.tenant_name, '') as tenant_name,
+		       COALESCE(c.deploy_type, '') as deploy_type
+		FROM clusters c
+		LEFT JOIN tenants t ON c.tenant_id = t.tenant_id
+		WHERE c.cluster_id IN (`+placeholders+`)
+	`, args, func(rows *sql.Rows) error {
+		clusters = make(map[string]*ClusterInfo, len(clusterIDs))
+		for rows.Next() {
+			var info ClusterInfo
+			if err := rows.Scan(&info.ClusterID, &info.ClusterName, &info.TenantID, &info.TenantName, &info.DeployType); err != nil {
+				return err
+			}
+			clusters[info.ClusterID] = &info
+		}
+		return nil
+	})
+	return clusters, source, err
+}
 
+// getTenant retrieves tenant info from database. Deployments whose tenants
+// table has no parent_tenant_id column are queried without it.
+func (nr *NameResolver) getTenant(ctx context.Context, tenantID string) (*TenantInfo, string, error) {
 	var info TenantInfo
-	err := row.Scan(&info.TenantID, &info.TenantName, &info.Kind, &info.CreatedAt, &info.UpdatedAt)
+	var source string
+	var err error
+	if !nr.parentTenantMissing.Load() {
+		source, err = nr.queryRow(ctx, tenantQuery, []interface{}{tenantID},
+			&info.TenantID, &info.TenantName, &info.Kind, &info.ParentTenantID, &info.CreatedAt, &info.UpdatedAt)
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1054 {
+			nr.parentTenantMissing.Store(true)
+			nr.logger.Info("No parent_tenant_id column in TiDB tenants table, tenant hierarchy disabled")
+		}
+	}
+	if nr.parentTenantMissing.Load() {
+		source, err = nr.queryRow(ctx, tenantQueryNoParent, []interface{}{tenantID},
+			&info.TenantID, &info.TenantName, &info.Kind, &info.CreatedAt, &info.UpdatedAt)
+	}
 	if err == sql.ErrNoRows {
-		return nil, nil
+		return nil, source, nil
 	}
 	if err != nil {
-		return nil, err
+		return nil, source, err
 	}
-	return &info, nil
+	return &info, source, nil
 }
 
 // getClusterName retrieves cluster name by ID
-func (nr *NameResolver) getClusterName(clusterID string) (string, error) {
-	row := db.TiDB.QueryRow(`
-		SELECT cluster_name FROM clusters WHERE cluster_id = ?
-	`, clusterID)
-
+func (nr *NameResolver) getClusterName(ctx context.Context, clusterID string) (string, string, error) {
 	var name string
-	err := row.Scan(&name)
+	source, err := nr.queryRow(ctx, clusterNameQuery, []interface{}{clusterID}, &name)
 	if err == sql.ErrNoRows {
-		return "", nil
+		return "", source, nil
 	}
 	if err != nil {
-		return "", err
+		return "", source, err
 	}
-	return name, nil
+	return name, source, nil
 }
 
 // getTenantName retrieves tenant name by ID
-func (nr *NameResolver) getTenantName(tenantID string) (string, error) {
-	row := db.TiDB.QueryRow(`
-		SELECT tenant_name FROM tenants WHERE tenant_id = ?
-	`, tenantID)
+func (nr *NameResolver) getTenantName(ctx context.Context, tenantID string) (string, string, error) {
+	var name string
+	source, err := nr.queryRow(ctx, tenantNameQuery, []interface{}{tenantID}, &name)
+	if err == sql.ErrNoRows {
+		return "", source, nil
+	}
+	if err != nil {
+		return "", source, err
+	}
+	return name, source, nil
+}
+
+// getProject retrieves project info from database. Deployments without a
+// projects table are treated as having no projects.
+func (nr *NameResolver) getProject(ctx context.Context, projectID string) (*ProjectInfo, string, error) {
+	if projectID == "" || nr.projectsMissing.Load() {
+		return nil, sourcePrimary, nil
+	}
+
+	var info ProjectInfo
+	source, err := nr.queryRow(ctx, `
+		SELECT project_id, COALESCE(project_name, ''), COALESCE(org_id, '')
+		FROM projects WHERE project_id = ?
+	`, []interface{}{projectID}, &info.ProjectID, &info.ProjectName, &info.OrgID)
+	if err == sql.ErrNoRows {
+		return nil, source, nil
+	}
+	if err != nil {
+		return nil, source, nr.checkProjectsTable(err)
+	}
+	return &info, source, nil
+}
+
+// getProjectName retrieves project name from database
+func (nr *NameResolver) getProjectName(ctx context.Context, projectID string) (string, string, error) {
+	if projectID == "" || nr.projectsMissing.Load() {
+		return "", sourcePrimary, nil
+	}
 
 	var name string
-	err := row.Scan(&name)
+	source, err := nr.queryRow(ctx, `
+		SELECT project_name FROM projects WHERE project_id = ?
+	`, []interface{}{projectID}, &name)
 	if err == sql.ErrNoRows {
-		return "", nil
+		return "", source, nil
 	}
 	if err != nil {
-		return "", err
+		return "", source, nr.checkProjectsTable(err)
+	}
+	return name, source, nil
+}
+
+// checkProjectsTable swallows "table doesn't exist" errors for the optional
+// projects table and stops querying it afterwards
+func (nr *NameResolver) checkProjectsTable(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1146 {
+		if !nr.projectsMissing.Swap(true) {
+			nr.logger.Info("No projects table in TiDB, project lookups disabled")
+		}
+		return nil
+	}
+	return err
+}
+
+// getTenantNamesByIDs retrieves tenant names for a set of IDs in one query.
+// It returns the backend that answered.
+func (nr *NameResolver) getTenantNamesByIDs(ctx context.Context, tenantIDs []string) (map[string]string, string, error) {
+	placeholders, args := inClause(tenantIDs)
+	var names map[string]string
+	source, err := nr.queryRows(ctx, `
+		SELECT tenant_id, tenant_name FROM tenants WHERE tenant_id IN (`+placeholders+`)
+	`, args, func(rows *sql.Rows) error {
+		names = make(map[string]string, len(tenantIDs))
+		for rows.Next() {
+			var tenantID, tenantName string
+			if err := rows.Scan(&tenantID, &tenantName); err != nil {
+				return err
+			}
+			names[tenantID] = tenantName
+		}
+		return nil
+	})
+	return names, source, err
+}
+
+// inClause builds the placeholder list and arguments for an IN (...) query
+func inClause(ids []string) (string, []interface{}) {
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
 	}
-	return name, nil
+	return strings.Join(placeholders, ", "), args
 }
 
 // getPremiumClusterNamesByParentID retrieves premium cluster names by parent ID
@@ -0,0 +1,447 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestPlainMissLog(t *testing.T) {
+	var buf bytes.Buffer
+	nr := NewNameResolver(WithMissLogger(NewPlainMissLogger(&buf, "")))
+	nr.logMiss("10086", "not found in database", time.Now())
+
+	line := regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} ID=10086 reason=not found in database\n$`)
+	if !line.Match(buf.Bytes()) {
+		t.Errorf("miss log = %q, want the plain ID=... reason=... format", buf.String())
+	}
+}
+
+func TestStructuredMissLog(t *testing.T) {
+	var buf bytes.Buffer
+	nr := NewNameResolver(WithMissLogger(NewStructuredMissLogger(&buf)), WithStructuredMissLog(true))
+	nr.logMiss("10086", "query error", time.Now().Add(-5*time.Millisecond))
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("miss log %q is not JSON: %v", buf.String(), err)
+	}
+	if len(entry) != 4 {
+		t.Errorf("keys = %v, want ts, id, reason and ms_elapsed", entry)
+	}
+	if entry["id"] != "10086" || entry["reason"] != "query error" {
+		t.Errorf("entry = %v", entry)
+	}
+	if ts, _ := entry["ts"].(string); ts == "" || ts[len(ts)-1] != 'Z' {
+		t.Errorf("ts = %v, want UTC RFC 3339", entry["ts"])
+	}
+	if ms, _ := entry["ms_elapsed"].(float64); ms < 5 {
+		t.Errorf("ms_elapsed = %v, want at least 5", entry["ms_elapsed"])
+	}
+}
+
+func TestMissLogFile(t *testing.T) {
+	seedTestTiDB(t, openTestTiDB(t))
+	delayTiDBQueries("clusters", 10*time.Millisecond)
+	path := filepath.Join(t.TempDir(), "miss.log")
+	t.Setenv("NAME_SERVICE_MISS_LOG", path)
+
+	// Both formats append to the same NAME_SERVICE_MISS_LOG file
+	for _, structured := range []bool{false, true} {
+		nr := NewNameResolver(WithStructuredMissLog(structured))
+		nr.initMissLogger()
+		if info, _ := nr.Resolve("3999"); info.Name != "3999" {
+			t.Fatalf("Resolve(3999) = %+v, want the ID", info)
+		}
+		nr.ClosePreparedStatements()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read miss log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("miss log = %q, want one line per format", data)
+	}
+	if !strings.HasSuffix(lines[0], " ID=3999 reason=not_found_in_database") {
+		t.Errorf("plain line = %q", lines[0])
+	}
+	var entry struct {
+		TS        time.Time `json:"ts"`
+		ID        string    `json:"id"`
+		Reason    string    `json:"reason"`
+		MsElapsed float64   `json:"ms_elapsed"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("structured line %q: %v", lines[1], err)
+	}
+	if entry.ID != "3999" || entry.Reason != "not_found_in_database" || time.Since(entry.TS) > time.Minute {
+		t.Errorf("structured entry = %+v", entry)
+	}
+	// The elapsed time includes the database lookup of the Resolve call
+	if entry.MsElapsed < 10 {
+		t.Errorf("ms_elapsed = %v, want at least the 10ms lookup", entry.MsElapsed)
+	}
+}
+
+func TestClusterLookupCachesTenant(t *testing.T) {
+	conn := openTestTiDB(t)
+	seedTestTiDB(t, conn)
+	if _, err := conn.Exec(`INSERT INTO clusters (cluster_id, cluster_name, tenant_id) VALUES ('2002', 'staging', '1001'), ('2003', 'orphan', '1009')`); err != nil {
+		t.Fatal(err)
+	}
+	clock := newTestClock()
+	nr := NewNameResolver(WithClock(clock.Now))
+	defer nr.ClosePreparedStatements()
+
+	if info, err := nr.Resolve("2001"); err != nil || info.TenantName != "acme" {
+		t.Fatalf("Resolve(2001) = %+v, %v", info, err)
+	}
+	entry, ok := nr.cache.peek("1001")
+	want := NameInfo{Type: "tenant", ID: "1001", Name: "acme"}
+	if !ok || entry.info != want || entry.notFound || !entry.timestamp.Equal(clock.Now()) {
+		t.Fatalf("tenant entry after the cluster lookup = %+v, %v", entry, ok)
+	}
+
+	// The tenant is then served from the cache
+	hits := nr.hits.Load()
+	if info, err := nr.Resolve("1001"); err != nil || info != want {
+		t.Errorf("Resolve(1001) = %+v, %v, want %+v", info, err, want)
+	}
+	if nr.hits.Load() != hits+1 {
+		t.Error("Resolve(1001) missed the cache")
+	}
+
+	// A valid cached tenant is kept, the tenant of another cluster refreshes
+	// an expired one
+	clock.Advance(time.Hour)
+	nr.Resolve("2002")
+	if entry, _ := nr.cache.peek("1001"); !entry.timestamp.Equal(clock.Now().Add(-time.Hour)) {
+		t.Errorf("valid tenant entry was replaced at %v", entry.timestamp)
+	}
+	clock.Advance(24 * time.Hour)
+	nr.cache.delete("2002")
+	nr.Resolve("2002")
+	if entry, _ := nr.cache.peek("1001"); !entry.timestamp.Equal(clock.Now()) {
+		t.Errorf("expired tenant entry from %v was not refreshed", entry.timestamp)
+	}
+
+	// Tenants missing from the tenants table are not cached
+	nr.Resolve("2003")
+	if _, ok := nr.cache.peek("1009"); ok {
+		t.Error("tenant 1009 without a tenants row was cached")
+	}
+}
+
+func TestClusterViewMissingIsLatched(t *testing.T) {
+	seedTestTiDB(t, openTestTiDB(t))
+	failTiDBQueries("v_cluster_names", &mysql.MySQLError{Number: 1146, Message: "Table 'v_cluster_names' doesn't exist"})
+
+	nr := NewNameResolver(WithClusterView(true))
+	defer nr.ClosePreparedStatements()
+	for i := 0; i < 3; i++ {
+		info, _, err := nr.getCluster(context.Background(), "2001")
+		if err != nil || info == nil || info.ClusterName != "prod-east" {
+			t.Fatalf("getCluster = %+v, %v", info, err)
+		}
+	}
+	if n := countTiDBQueries("v_cluster_names"); n != 1 {
+		t.Errorf("missing view queried %d times, want once", n)
+	}
+	if !nr.ClusterView() {
+		t.Error("the missing view switched the setting off")
+	}
+
+	// Enabling the view again retries it
+	nr.SetClusterView(true)
+	nr.getCluster(context.Background(), "2001")
+	if n := countTiDBQueries("v_cluster_names"); n != 2 {
+		t.Errorf("view queried %d times after re-enabling it, want 2", n)
+	}
+}
+
+// testClock is a resolver clock that only moves when advanced
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func TestEntryTTL(t *testing.T) {
+	nr := NewNameResolver(
+		WithTypeTTL("cluster", 2*time.Hour),
+		WithTypeTTL("tenant", 48*time.Hour),
+		WithTypeTTL("notFound", 5*time.Minute),
+	)
+	defaults := NewNameResolver()
+
+	for _, tc := range []struct {
+		name  string
+		nr    *NameResolver
+		entry cacheEntry
+		want  time.Duration
+	}{
+		{"cluster", nr, cacheEntry{info: NameInfo{Type: "cluster"}}, 2 * time.Hour},
+		{"tenant", nr, cacheEntry{info: NameInfo{Type: "tenant"}}, 48 * time.Hour},
+		{"not found", nr, cacheEntry{info: NameInfo{Type: "cluster"}, notFound: true}, 5 * time.Minute},
+		{"no override", nr, cacheEntry{info: NameInfo{Type: "project"}}, 24 * time.Hour},
+		{"default", defaults, cacheEntry{info: NameInfo{Type: "cluster"}}, 24 * time.Hour},
+		{"default not found", defaults, cacheEntry{notFound: true}, time.Hour},
+	} {
+		if got := tc.nr.entryTTL(tc.entry); got != tc.want {
+			t.Errorf("%s: TTL = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTypeTTLExpiry(t *testing.T) {
+	seedTestTiDB(t, openTestTiDB(t))
+	clock := newTestClock()
+	nr := NewNameResolver(
+		WithClock(clock.Now),
+		WithTypeTTL("cluster", 2*time.Hour),
+		WithTypeTTL("tenant", 48*time.Hour),
+		WithTypeTTL("notFound", 5*time.Minute),
+	)
+	defer nr.ClosePreparedStatements()
+	for _, id := range []string{"2001", "1001", "3999"} {
+		nr.Resolve(id)
+	}
+
+	valid := func(id string) bool {
+		entry, ok := nr.cache.peek(id)
+		return ok && nr.isEntryValid(entry)
+	}
+	for _, step := range []struct {
+		advance               time.Duration
+		cluster, tenant, miss bool
+	}{
+		{0, true, true, true},
+		{5 * time.Minute, true, true, false},
+		{2 * time.Hour, false, true, false},
+		// Longer than the global TTL of 24h
+		{30 * time.Hour, false, true, false},
+		{48 * time.Hour, false, false, false},
+	} {
+		clock.Advance(step.advance)
+		if valid("2001") != step.cluster || valid("1001") != step.tenant || valid("3999") != step.miss {
+			t.Errorf("after %v: cluster %v, tenant %v, miss %v, want %v %v %v", step.advance,
+				valid("2001"), valid("1001"), valid("3999"), step.cluster, step.tenant, step.miss)
+		}
+	}
+}
+
+func TestTypeTTLFromEnv(t *testing.T) {
+	t.Setenv("NAME_SERVICE_CLUSTER_TTL", "30m")
+	t.Setenv("NAME_SERVICE_TENANT_TTL", "168h")
+	t.Setenv("NAME_SERVICE_NOT_FOUND_TTL", "soon")
+
+	nr := NewNameResolver(nameResolverOptionsFromEnv()...)
+	if nr.typeTTL["cluster"] != 30*time.Minute || nr.typeTTL["tenant"] != 168*time.Hour {
+		t.Errorf("type TTLs = %v, want cluster 30m and tenant 168h", nr.typeTTL)
+	}
+	if _, ok := nr.typeTTL["notFound"]; ok {
+		t.Errorf("invalid NAME_SERVICE_NOT_FOUND_TTL was applied: %v", nr.typeTTL)
+	}
+}
+
+// counterValue returns the value of the counter name gathered from reg
+func counterValue(t testing.TB, reg prometheus.Gatherer, name string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() == name {
+			return mf.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	t.Fatalf("no metric %s", name)
+	return 0
+}
+
+// resolveConcurrently resolves id from n goroutines started together
+func resolveConcurrently(nr *NameResolver, id string, n int) []NameInfo {
+	results := make([]NameInfo, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			results[i], _ = nr.Resolve(id)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return results
+}
+
+func TestResolveSharesConcurrentLookups(t *testing.T) {
+	seedTestTiDB(t, openTestTiDB(t))
+	// Slow enough for every goroutine to miss the cache before the first lookup ends
+	delayTiDBQueries("FROM clusters c", 50*time.Millisecond)
+
+	reg := prometheus.NewRegistry()
+	nr := NewNameResolver(WithMetrics(reg))
+	defer nr.ClosePreparedStatements()
+
+	for i, info := range resolveConcurrently(nr, "2001", 100) {
+		if info.Name != "prod-east" {
+			t.Fatalf("goroutine %d resolved %+v", i, info)
+		}
+	}
+	if n := counterValue(t, reg, "name_resolver_db_lookups_total"); n != 1 {
+		t.Errorf("%v database lookups, want 1", n)
+	}
+	if n := counterValue(t, reg, "name_resolver_cache_misses_total"); n != 100 {
+		t.Errorf("%v cache misses, want 100", n)
+	}
+}
+
+// BenchmarkResolveColdID resolves one uncached ID from 100 goroutines per
+// iteration and reports the database lookups it took
+func BenchmarkResolveColdID(b *testing.B) {
+	seedTestTiDB(b, openTestTiDB(b))
+	delayTiDBQueries("FROM clusters c", time.Millisecond)
+
+	var lookups float64
+	for i := 0; i < b.N; i++ {
+		reg := prometheus.NewRegistry()
+		nr := NewNameResolver(WithMetrics(reg))
+		resolveConcurrently(nr, "2001", 100)
+		lookups += counterValue(b, reg, "name_resolver_db_lookups_total")
+		nr.ClosePreparedStatements()
+	}
+	b.ReportMetric(lookups/float64(b.N), "db_lookups/op")
+	if lookups != float64(b.N) {
+		b.Errorf("%v database lookups for %d cold IDs, want one each", lookups, b.N)
+	}
+}
+
+func TestCacheStatsAgeHistogram(t *testing.T) {
+	clock := newTestClock()
+	nr := NewNameResolver(WithClock(clock.Now), WithTypeTTL("cluster", 7*24*time.Hour))
+	now := clock.Now()
+	for i, age := range []time.Duration{
+		0, 59 * time.Second, // <1m
+		time.Minute, 30 * time.Minute, // 1m-1h
+		time.Hour, 5*time.Hour + 59*time.Minute, // 1h-6h
+		6 * time.Hour, 23 * time.Hour, 24*time.Hour - time.Second, // 6h-24h
+		24 * time.Hour, 72 * time.Hour, // >24h
+	} {
+		id := strconv.Itoa(2000 + i)
+		nr.cache.set(id, cacheEntry{info: NameInfo{Type: "cluster", ID: id, Name: "c" + id}, timestamp: now.Add(-age)})
+	}
+
+	stats := nr.GetCacheStats()
+	want := map[string]int{"<1m": 2, "1m-1h": 2, "1h-6h": 2, "6h-24h": 3, ">24h": 2}
+	if got := stats["age_histogram"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("age_histogram = %v, want %v", got, want)
+	}
+	if stats["hit_rate"] != 0.0 {
+		t.Errorf("hit_rate before any Resolve = %v, want 0", stats["hit_rate"])
+	}
+
+	// Three hits and a miss, which fails without TiDB
+	for _, id := range []string{"2000", "2001", "2000", "9999"} {
+		nr.Resolve(id)
+	}
+	stats = nr.GetCacheStats()
+	if stats["hit_rate"] != 0.75 || stats["cache_hits"] != int64(3) || stats["cache_misses"] != int64(1) {
+		t.Errorf("hit_rate %v, hits %v, misses %v, want 0.75, 3 and 1", stats["hit_rate"], stats["cache_hits"], stats["cache_misses"])
+	}
+
+	// Ages move with the clock
+	clock.Advance(24 * time.Hour)
+	if got := nr.GetCacheStats()["age_histogram"].(map[string]int); got[">24h"] != 11 {
+		t.Errorf("a day later: %v, want every entry over 24h", got)
+	}
+}
+
+func TestResolveBatchAliases(t *testing.T) {
+	conn := openTestTiDB(t)
+	seedTestTiDB(t, conn)
+	for _, q := range []string{
+		`ALTER TABLE clusters ADD COLUMN cluster_uuid TEXT`,
+		`ALTER TABLE clusters ADD COLUMN cluster_slug TEXT`,
+		`UPDATE clusters SET cluster_uuid = '3f2504e0-4f89-11d3-9a0c-0305e82c3301', cluster_slug = 'prod-east' WHERE cluster_id = '2001'`,
+	} {
+		if _, err := conn.Exec(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nr := NewNameResolver()
+	defer nr.ClosePreparedStatements()
+
+	ids := []string{"3F2504E0-4F89-11D3-9A0C-0305E82C3301", "prod-east", "1001", "not a slug"}
+	results, errs := nr.ResolveBatch(ids)
+	if len(errs) != 0 {
+		t.Fatalf("ResolveBatch errors: %v", errs)
+	}
+	if len(results) != len(ids) {
+		t.Errorf("results for %d IDs, want one per requested ID: %+v", len(results), results)
+	}
+	for _, alias := range ids[:2] {
+		if info := results[alias]; info.ID != "2001" || info.Type != "cluster" || info.TenantName != "acme" {
+			t.Errorf("results[%s] = %+v, want cluster 2001", alias, info)
+		}
+	}
+	if info := results["1001"]; info.Type != "tenant" || info.Name != "acme" {
+		t.Errorf("results[1001] = %+v, want tenant acme", info)
+	}
+	if info := results["not a slug"]; info.Name != "not a slug" {
+		t.Errorf("results[not a slug] = %+v, want the ID as its name", info)
+	}
+
+}
+
+func TestResolveBatchCountsHits(t *testing.T) {
+	seedTestTiDB(t, openTestTiDB(t))
+	nr := NewNameResolver()
+	defer nr.ClosePreparedStatements()
+
+	// Batch lookups count towards the hit rate like those of Resolve, once per ID
+	ids := []string{"2001", "1001", "2001"}
+	nr.ResolveBatch(ids)
+	if hits, requests := nr.hits.Load(), nr.requests.Load(); hits != 0 || requests != 2 {
+		t.Errorf("after a cold batch: %d hits of %d requests, want 0 of 2", hits, requests)
+	}
+	nr.ResolveBatch(ids)
+	if hits, requests := nr.hits.Load(), nr.requests.Load(); hits != 2 || requests != 4 {
+		t.Errorf("after a warm batch: %d hits of %d requests, want 2 of 4", hits, requests)
+	}
+	if rate := nr.GetCacheStats()["hit_rate"]; rate != 0.5 {
+		t.Errorf("hit_rate = %v, want 0.5", rate)
+	}
+}
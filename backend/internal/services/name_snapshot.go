@@ -0,0 +1,121 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+const (
+	snapshotVersion = 1
+
+	// snapshotMinRemainingTTL skips imported entries that would expire almost immediately
+	snapshotMinRemainingTTL = time.Minute
+
+	defaultSnapshotMaxSize = 256 << 20
+)
+
+// ErrSnapshotTooLarge is returned by ImportSnapshot for snapshots that
+// decompress to more than the size set by WithSnapshotMaxSize
+var ErrSnapshotTooLarge = errors.New("cache snapshot too large")
+
+// cacheSnapshot is the versioned format exchanged between processes during a
+// blue-green deploy
+type cacheSnapshot struct {
+	Version    int                  `json:"version"`
+	ExportedAt time.Time            `json:"exported_at"`
+	Entries    []cacheSnapshotEntry `json:"entries"`
+}
+
+type cacheSnapshotEntry struct {
+	NameInfo
+	NotFound bool      `json:"not_found"`
+	CachedAt time.Time `json:"cached_at"`
+}
+
+// ExportSnapshot serializes the valid cache entries to gzipped JSON
+func (nr *NameResolver) ExportSnapshot() ([]byte, error) {
+	snapshot := cacheSnapshot{Version: snapshotVersion, ExportedAt: time.Now()}
+
+	nr.cacheMutex.RLock()
+	nr.cache.each(func(_ string, entry cacheEntry) {
+		if !nr.isEntryValid(entry) || entry.source == sourceFallback {
+			return
+		}
+		snapshot.Entries = append(snapshot.Entries, cacheSnapshotEntry{
+			NameInfo: entry.info,
+			NotFound: entry.notFound,
+			CachedAt: entry.timestamp,
+		})
+	})
+	nr.cacheMutex.RUnlock()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(snapshot); err != nil {
+		return nil, fmt.Errorf("failed to encode cache snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress cache snapshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ImportSnapshot loads a snapshot produced by ExportSnapshot. Entries that
+// would expire within the next minute, or that are older than what is already
+// cached, are skipped. Snapshots decompressing to more than the configured
+// maximum are rejected with ErrSnapshotTooLarge.
+func (nr *NameResolver) ImportSnapshot(data []byte) error {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid cache snapshot: %w", err)
+	}
+	defer zr.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(zr, nr.snapshotMaxSize+1))
+	if err != nil {
+		return fmt.Errorf("invalid cache snapshot: %w", err)
+	}
+	if int64(len(raw)) > nr.snapshotMaxSize {
+		return fmt.Errorf("%w: more than %d bytes decompressed", ErrSnapshotTooLarge, nr.snapshotMaxSize)
+	}
+
+	var snapshot cacheSnapshot
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return fmt.Errorf("invalid cache snapshot: %w", err)
+	}
+	if snapshot.Version != snapshotVersion {
+		return fmt.Errorf("unsupported cache snapshot version %d", snapshot.Version)
+	}
+
+	imported := make(map[string]cacheEntry, len(snapshot.Entries))
+	nr.cacheMutex.Lock()
+	for _, e := range snapshot.Entries {
+		if e.ID == "" {
+			continue
+		}
+		entry := cacheEntry{
+			info:      e.NameInfo,
+			notFound:  e.NotFound,
+			timestamp: e.CachedAt,
+			source:    sourceSnapshot,
+		}
+		if nr.now().Sub(entry.timestamp) >= nr.entryTTL(entry)-snapshotMinRemainingTTL {
+			continue
+		}
+		if existing, ok := nr.cache.peek(e.ID); ok && !existing.timestamp.Before(entry.timestamp) {
+			continue
+		}
+		imported[e.ID] = entry
+	}
+	nr.cache.setMany(imported)
+	nr.cacheMutex.Unlock()
+
+	nr.logger.Info("Imported name cache snapshot", slog.Int("imported", len(imported)), slog.Int("total", len(snapshot.Entries)))
+	return nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	src := NewNameResolver()
+	now := time.Now()
+	src.cache.setMany(map[string]cacheEntry{
+		"c1":      {info: NameInfo{Type: "cluster", ID: "c1", Name: "prod-east"}, timestamp: now, source: sourcePrimary},
+		"missing": {info: NameInfo{ID: "missing", Name: "missing"}, notFound: true, timestamp: now, source: sourcePrimary},
+		"stale":   {info: NameInfo{ID: "stale", Name: "old"}, timestamp: now.Add(-48 * time.Hour), source: sourcePrimary},
+		"static":  {info: NameInfo{ID: "static", Name: "static"}, timestamp: now, source: sourceFallback},
+	})
+
+	data, err := src.ExportSnapshot()
+	if err != nil {
+		t.Fatalf("ExportSnapshot: %v", err)
+	}
+
+	dst := NewNameResolver()
+	if err := dst.ImportSnapshot(data); err != nil {
+		t.Fatalf("ImportSnapshot: %v", err)
+	}
+	if e, ok := dst.cache.peek("c1"); !ok || e.info.Name != "prod-east" || e.source != sourceSnapshot {
+		t.Errorf("c1 = %+v, %v", e, ok)
+	}
+	if e, ok := dst.cache.peek("missing"); !ok || !e.notFound {
+		t.Errorf("not-found entry = %+v, %v", e, ok)
+	}
+	for _, id := range []string{"stale", "static"} {
+		if _, ok := dst.cache.peek(id); ok {
+			t.Errorf("%s was imported", id)
+		}
+	}
+}
+
+func TestImportSnapshotTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(append([]byte(`{"version":1,"entries":[],"pad":"`), bytes.Repeat([]byte("x"), 4096)...))
+	zw.Write([]byte(`"}`))
+	zw.Close()
+
+	nr := NewNameResolver(WithSnapshotMaxSize(1024))
+	if err := nr.ImportSnapshot(buf.Bytes()); !errors.Is(err, ErrSnapshotTooLarge) {
+		t.Errorf("got %v, want ErrSnapshotTooLarge", err)
+	}
+	if err := NewNameResolver().ImportSnapshot(buf.Bytes()); err != nil {
+		t.Errorf("the same snapshot under the default limit: %v", err)
+	}
+}
+
+func TestImportSnapshotInvalid(t *testing.T) {
+	nr := NewNameResolver()
+	if err := nr.ImportSnapshot([]byte("not gzip")); err == nil {
+		t.Error("accepted data that is not gzip")
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(`{"version":99}`))
+	zw.Close()
+	if err := nr.ImportSnapshot(buf.Bytes()); err == nil {
+		t.Error("accepted an unknown snapshot version")
+	}
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+// Lookups run as prepared statements on the primary TiDB, so they are parsed
+// and planned once rather than on every cache miss
+const (
+	clusterQuery = `
+		SELECT c.cluster_id, c.cluster_name, c.tenant_id,
+		       COALESCE(NULLIF(c.tenant_name, ''), t.tenant_name, '') as tenant_name,
+		       COALESCE(c.deploy_type, '') as deploy_type,
+		       COALESCE(c.version, '') as version,
+		       COALESCE(c.cluster_lifecycle, '') as cluster_lifecycle,
+		       COALESCE(c.creation_duration, '') as creation_duration,
+		       COALESCE(c.tenant_plan, '') as tenant_plan,
+		       COALESCE(c.provider, '') as provider,
+		       COALESCE(c.region, '') as region,
+		       COALESCE(c.project_id, '') as project_id,
+		       COALESCE(c.org_id, '') as org_id,
+		       COALESCE(c.cluster_type, '') as cluster_type,
+		       c.created_at, c.updated_at,
+		       t.tenant_name
+		FROM clusters c
+		LEFT JOIN tenants t ON c.tenant_id = t.tenant_id
+		WHERE c.cluster_id = ?
+	`
+	// clusterViewQuery is clusterQuery against v_cluster_names, a view (or
+	// table) holding the join precomputed, see SetClusterView
+	clusterViewQuery = `
+		SELECT cluster_id, cluster_name, tenant_id, tenant_name, deploy_type, version,
+		       cluster_lifecycle, creation_duration, tenant_plan, provider, region,
+		       project_id, org_id, cluster_type, created_at, updated_at, joined_tenant_name
+		FROM v_cluster_names
+		WHERE cluster_id = ?
+	`
+	tenantQuery = `
+		SELECT tenant_id, tenant_name, kind, COALESCE(parent_tenant_id, ''), created_at, updated_at
+		FROM tenants WHERE tenant_id = ?
+	`
+	// tenantQueryNoParent is tenantQuery for tenants tables without parent_tenant_id
+	tenantQueryNoParent = `
+		SELECT tenant_id, tenant_name, kind, created_at, updated_at
+		FROM tenants WHERE tenant_id = ?
+	`
+	clusterNameQuery = `SELECT cluster_name FROM clusters WHERE cluster_id = ?`
+	tenantNameQuery  = `SELECT tenant_name FROM tenants WHERE tenant_id = ?`
+)
+
+// preparedQueries are the queries queryRow runs as prepared statements
+var preparedQueries = map[string]bool{
+	clusterQuery:        true,
+	clusterViewQuery:    true,
+	tenantQuery:         true,
+	tenantQueryNoParent: true,
+	clusterNameQuery:    true,
+	tenantNameQuery:     true,
+}
+
+// MySQL errors of a statement handle the server no longer knows, e.g. after
+// a TiDB failover or restart
+const (
+	errUnknownStmtHandler = 1243 // ER_UNKNOWN_STMT_HANDLER
+	errNeedReprepare      = 1615 // ER_NEED_REPREPARE
+)
+
+// preparedStmt is a statement prepared on the connection pool conn
+type preparedStmt struct {
+	conn *sql.DB
+	stmt *sql.Stmt
+}
+
+// PrepareStatements prepares the cluster and tenant lookups on the primary
+// TiDB. Lookups prepare their statement on first use anyway; calling it at
+// startup moves that cost out of the first requests and surfaces SQL errors
+// early.
+func (nr *NameResolver) PrepareStatements(ctx context.Context) error {
+	if !db.TiDBReady() {
+		return fmt.Errorf("TiDB not connected")
+	}
+	var errs []error
+	for query := range preparedQueries {
+		if query == tenantQuery && nr.parentTenantMissing.Load() {
+			continue
+		}
+		if query == clusterViewQuery && !nr.useClusterView() {
+			continue
+		}
+		if _, err := nr.preparedStmt(ctx, query); err != nil {
+			var mysqlErr *mysql.MySQLError
+			if query == tenantQuery && errors.As(err, &mysqlErr) && mysqlErr.Number == 1054 {
+				// No parent_tenant_id column, getTenant falls back on its first call
+				continue
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SetClusterView switches getCluster between the v_cluster_names view, which
+// holds the join of clusters and tenants precomputed, and joining the two
+// tables on every lookup. It takes effect on the next lookup; cached entries
+// are kept. Enabling it also retries a view found missing earlier.
+func (nr *NameResolver) SetClusterView(enabled bool) {
+	if enabled {
+		nr.clusterViewMissing.Store(false)
+	}
+	if nr.clusterView.Swap(enabled) != enabled {
+		nr.logger.Info("Name service cluster view switched", slog.Bool("enabled", enabled))
+	}
+}
+
+// useClusterView reports whether getCluster should try the v_cluster_names
+// view: it is enabled and TiDB has not reported it missing
+func (nr *NameResolver) useClusterView() bool {
+	return nr.clusterView.Load() && !nr.clusterViewMissing.Load()
+}
+
+// ClusterView reports whether getCluster reads the v_cluster_names view
+func (nr *NameResolver) ClusterView() bool {
+	return nr.clusterView.Load()
+}
+
+// ClosePreparedStatements closes the prepared statements. Later lookups
+// prepare them again, so it is safe to call before TiDB is closed on shutdown.
+func (nr *NameResolver) ClosePreparedStatements() {
+	nr.stmtMutex.Lock()
+	defer nr.stmtMutex.Unlock()
+	for query, ps := range nr.stmts {
+		if err := ps.stmt.Close(); err != nil {
+			nr.logger.Warn("Failed to close prepared statement", slog.Any("error", err))
+		}
+		delete(nr.stmts, query)
+	}
+}
+
+// preparedStmt returns query prepared on the current primary TiDB pool. A
+// statement prepared on a pool that has since been replaced, e.g. by a
+// reconnect, is closed and prepared again.
+func (nr *NameResolver) preparedStmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	conn := db.TiDB
+	if conn == nil {
+		return nil, fmt.Errorf("TiDB not connected")
+	}
+
+	nr.stmtMutex.Lock()
+	defer nr.stmtMutex.Unlock()
+	if ps, ok := nr.stmts[query]; ok {
+		if ps.conn == conn {
+			return ps.stmt, nil
+		}
+		ps.stmt.Close()
+		delete(nr.stmts, query)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, primaryQueryTimeout)
+	defer cancel()
+	stmt, err := conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if nr.stmts == nil {
+		nr.stmts = make(map[string]preparedStmt)
+	}
+	nr.stmts[query] = preparedStmt{conn: conn, stmt: stmt}
+	return stmt, nil
+}
+
+// dropStmt forgets stmt if it is still the prepared statement of query
+func (nr *NameResolver) dropStmt(query string, stmt *sql.Stmt) {
+	nr.stmtMutex.Lock()
+	defer nr.stmtMutex.Unlock()
+	if ps, ok := nr.stmts[query]; ok && ps.stmt == stmt {
+		stmt.Close()
+		delete(nr.stmts, query)
+	}
+}
+
+// isStaleStmtError reports whether err means the statement must be prepared
+// again before it can run
+func isStaleStmtError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == errUnknownStmtHandler || mysqlErr.Number == errNeedReprepare
+	}
+	// The statement was closed by ClosePreparedStatements while in use
+	return err != nil && err.Error() == "sql: statement is closed"
+}
+
+// scanPrimary scans a single row of query from the primary TiDB, using its
+// prepared statement if query is one of preparedQueries. A statement
+// invalidated by a failover is prepared again and the query retried once.
+func (nr *NameResolver) scanPrimary(ctx context.Context, query string, args []interface{}, dest []interface{}) error {
+	if !preparedQueries[query] {
+		return tracedScanRow(ctx, db.TiDB, sourcePrimary, primaryQueryTimeout, query, args, dest)
+	}
+
+	stmt, err := nr.preparedStmt(ctx, query)
+	if err != nil {
+		return err
+	}
+	err = tracedScanStmt(ctx, stmt, query, args, dest)
+	if !isStaleStmtError(err) {
+		return err
+	}
+
+	nr.logger.Info("Prepared statement invalidated, preparing it again", slog.Any("error", err))
+	nr.dropStmt(query, stmt)
+	if stmt, err = nr.preparedStmt(ctx, query); err != nil {
+		return err
+	}
+	return tracedScanStmt(ctx, stmt, query, args, dest)
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+func TestPreparedStatementsAreReused(t *testing.T) {
+	seedTestTiDB(t, openTestTiDB(t))
+	nr := NewNameResolver()
+	defer nr.ClosePreparedStatements()
+
+	for i := 0; i < 3; i++ {
+		info, _, err := nr.getCluster(context.Background(), "2001")
+		if err != nil || info == nil || info.ClusterName != "prod-east" {
+			t.Fatalf("getCluster = %+v, %v", info, err)
+		}
+	}
+	if n := countTiDBQueries("FROM clusters c"); n != 1 {
+		t.Errorf("cluster query prepared %d times, want once", n)
+	}
+
+	// A new pool, e.g. after a reconnect, gets the statement prepared again
+	seedTestTiDB(t, openTestTiDB(t))
+	if _, _, err := nr.getCluster(context.Background(), "2001"); err != nil {
+		t.Fatalf("getCluster on the new pool: %v", err)
+	}
+	if n := countTiDBQueries("FROM clusters c"); n != 2 {
+		t.Errorf("cluster query prepared %d times in total, want once per pool", n)
+	}
+}
+
+func TestPrepareStatements(t *testing.T) {
+	prev, prevReady := db.TiDB, db.TiDBReady()
+	db.SetTiDB(nil)
+	if err := NewNameResolver().PrepareStatements(context.Background()); err == nil {
+		t.Error("PrepareStatements without TiDB succeeded")
+	}
+	if prevReady {
+		db.SetTiDB(prev)
+	}
+
+	openTestTiDB(t)
+	nr := NewNameResolver()
+	defer nr.ClosePreparedStatements()
+	if err := nr.PrepareStatements(context.Background()); err != nil {
+		t.Fatalf("PrepareStatements: %v", err)
+	}
+	if countTiDBQueries("v_cluster_names") != 0 {
+		t.Error("the view query was prepared although the view is disabled")
+	}
+}
+
+func TestIsStaleStmtError(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{&mysql.MySQLError{Number: errUnknownStmtHandler}, true},
+		{fmt.Errorf("scan: %w", &mysql.MySQLError{Number: errNeedReprepare}), true},
+		{errors.New("sql: statement is closed"), true},
+		{&mysql.MySQLError{Number: 1146}, false},
+		{sql.ErrNoRows, false},
+		{nil, false},
+	} {
+		if got := isStaleStmtError(tc.err); got != tc.want {
+			t.Errorf("isStaleStmtError(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+// BenchmarkClusterLookup compares the cluster query run as a prepared
+// statement with the same query parsed on every call
+func BenchmarkClusterLookup(b *testing.B) {
+	seedTestTiDB(b, openTestTiDB(b))
+	nr := NewNameResolver()
+	defer nr.ClosePreparedStatements()
+	ctx := context.Background()
+
+	scan := func(b *testing.B, lookup func(dest []interface{}) error) {
+		var info ClusterInfo
+		var joined sql.NullString
+		dest := []interface{}{
+			&info.ClusterID, &info.ClusterName, &info.TenantID, &info.TenantName,
+			&info.DeployType, &info.Version, &info.ClusterLifecycle, &info.CreationDuration,
+			&info.TenantPlan, &info.Provider, &info.Region, &info.ProjectID, &info.OrgID, &info.ClusterType,
+			&info.CreatedAt, &info.UpdatedAt, &joined,
+		}
+		for i := 0; i < b.N; i++ {
+			if err := lookup(dest); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("prepared", func(b *testing.B) {
+		scan(b, func(dest []interface{}) error {
+			return nr.scanPrimary(ctx, clusterQuery, []interface{}{"2001"}, dest)
+		})
+	})
+	b.Run("unprepared", func(b *testing.B) {
+		scan(b, func(dest []interface{}) error {
+			return tracedScanRow(ctx, db.TiDB, sourcePrimary, primaryQueryTimeout, clusterQuery, []interface{}{"2001"}, dest)
+		})
+	})
+}
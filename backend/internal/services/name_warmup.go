@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// warmUpBatchSize caps the number of IDs sent in a single IN (...) query
+const warmUpBatchSize = 500
+
+// WarmUpStrategy selects the clusters warmed by WarmUp
+type WarmUpStrategy int
+
+const (
+	// WarmUpFrequency warms the clusters that alerted most in the last 24 hours
+	WarmUpFrequency WarmUpStrategy = iota
+	// WarmUpRecency warms every cluster that alerted within the window,
+	// so new clusters are warm before they become noisy
+	WarmUpRecency
+	// WarmUpCombined warms the clusters of both strategies
+	WarmUpCombined
+)
+
+func (s WarmUpStrategy) String() string {
+	switch s {
+	case WarmUpFrequency:
+		return "frequency"
+	case WarmUpRecency:
+		return "recency"
+	case WarmUpCombined:
+		return "combined"
+	}
+	return fmt.Sprintf("WarmUpStrategy(%d)", int(s))
+}
+
+// ParseWarmUpStrategy parses "frequency", "recency" or "combined"
+func ParseWarmUpStrategy(s string) (WarmUpStrategy, error) {
+	for _, strategy := range []WarmUpStrategy{WarmUpFrequency, WarmUpRecency, WarmUpCombined} {
+		if strings.EqualFold(strings.TrimSpace(s), strategy.String()) {
+			return strategy, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid warm-up strategy %q, expected frequency, recency or combined", s)
+}
+
+// WarmUpOptions are the parameters of the warm-up strategies
+type WarmUpOptions struct {
+	Limit  int           // number of clusters warmed by frequency
+	Window time.Duration // alerts fired within it are warmed by recency
+}
+
+// WarmUp warms the cache with the clusters selected by strategy from the alert
+// history in db
+func (nr *NameResolver) WarmUp(strategy WarmUpStrategy, db *gorm.DB, opts WarmUpOptions) error {
+	switch strategy {
+	case WarmUpFrequency:
+		return nr.WarmUpFromAlertHistory(db, opts.Limit)
+	case WarmUpRecency:
+		return nr.WarmUpByRecency(db, opts.Window)
+	case WarmUpCombined:
+		frequent, err := frequentClusterIDs(db, opts.Limit)
+		if err != nil {
+			return err
+		}
+		recent, err := recentClusterIDs(db, opts.Window)
+		if err != nil {
+			return err
+		}
+		// Frequent clusters first, recent ones not already among them after
+		seen := make(map[string]bool, len(frequent)+len(recent))
+		ids := make([]string, 0, len(frequent)+len(recent))
+		for _, id := range append(frequent, recent...) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return nr.WarmUpIDs(ids)
+	}
+	return fmt.Errorf("unknown warm-up strategy %v", strategy)
+}
+
+// WarmUpIDs resolves a list of high-priority IDs through the batch path so they
+// are cached before the first request needs them
+func (nr *NameResolver) WarmUpIDs(ids []string) error {
+	start := time.Now()
+	resolved, notFound := 0, 0
+	var lookupErrs []error
+
+	for i := 0; i < len(ids); i += warmUpBatchSize {
+		end := i + warmUpBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		results, errs := nr.ResolveBatch(ids[i:end])
+		for _, info := range results {
+			if info.Type != "" {
+				resolved++
+			}
+		}
+		for _, err := range errs {
+			if errors.Is(err, ErrIDNotFound) {
+				notFound++
+			} else {
+				lookupErrs = append(lookupErrs, err)
+			}
+		}
+	}
+
+	nr.logger.Info("Name service warm-up completed",
+		slog.Duration("elapsed", time.Since(start)),
+		slog.Int("ids", len(ids)),
+		slog.Int("resolved", resolved),
+		slog.Int("not_found", notFound),
+		slog.Int("errors", len(lookupErrs)))
+
+	if len(lookupErrs) > 0 {
+		return fmt.Errorf("warm-up finished with %d errors: %w", len(lookupErrs), lookupErrs[0])
+	}
+	return nil
+}
+
+// WarmUpFromAlertHistory warms the cache with the limit most frequent cluster IDs
+// among alerts created in the last 24 hours
+func (nr *NameResolver) WarmUpFromAlertHistory(db *gorm.DB, limit int) error {
+	clusterIDs, err := frequentClusterIDs(db, limit)
+	if err != nil {
+		return err
+	}
+	return nr.WarmUpIDs(clusterIDs)
+}
+
+// WarmUpByRecency warms the cache with every cluster ID among alerts created
+// within window, however few alerts it has
+func (nr *NameResolver) WarmUpByRecency(db *gorm.DB, window time.Duration) error {
+	clusterIDs, err := recentClusterIDs(db, window)
+	if err != nil {
+		return err
+	}
+	return nr.WarmUpIDs(clusterIDs)
+}
+
+func frequentClusterIDs(db *gorm.DB, limit int) ([]string, error) {
+	since := time.Now().UTC().Add(-24 * time.Hour).Format("2006-01-02 15:04:05")
+
+	var clusterIDs []string
+	err := db.Raw(`
+		SELECT cluster_id
+		FROM issues
+		WHERE is_alert = 1 AND REPLACE(created, ' UTC', '') >= ?
+		AND cluster_id != '' AND cluster_id IS NOT NULL
+		GROUP BY cluster_id
+		ORDER BY COUNT(*) DESC
+		LIMIT ?
+	`, since, limit).Scan(&clusterIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert history: %w", err)
+	}
+	return clusterIDs, nil
+}
+
+// recentClusterIDs returns the clusters that alerted within window, most
+// recent first
+func recentClusterIDs(db *gorm.DB, window time.Duration) ([]string, error) {
+	since := time.Now().UTC().Add(-window).Format("2006-01-02 15:04:05")
+
+	var clusterIDs []string
+	err := db.Raw(`
+		SELECT cluster_id
+		FROM issues
+		WHERE is_alert = 1 AND REPLACE(created, ' UTC', '') >= ?
+		AND cluster_id != '' AND cluster_id IS NOT NULL
+		GROUP BY cluster_id
+		ORDER BY MAX(created) DESC
+	`, since).Scan(&clusterIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert history: %w", err)
+	}
+	return clusterIDs, nil
+}
+
+// StartPreloadCron runs WarmUp with strategy on the given 5-field cron
+// schedule (e.g. "*/30 * * * *") until ctx is cancelled
+func (nr *NameResolver) StartPreloadCron(ctx context.Context, db *gorm.DB, spec string, strategy WarmUpStrategy, opts WarmUpOptions) error {
+	schedule, err := parseCron(spec)
+	if err != nil {
+		return err
+	}
+
+	after := nr.after
+	if after == nil {
+		after = time.After
+	}
+
+	go func() {
+		for {
+			now := nr.now()
+			next := schedule.next(now)
+			if next.IsZero() {
+				nr.logger.Warn("Preload cron schedule never fires", slog.String("spec", spec))
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-after(next.Sub(now)):
+			}
+
+			if err := nr.WarmUp(strategy, db, opts); err != nil {
+				nr.logger.Warn("Scheduled name service preload failed", slog.Any("error", err))
+			}
+		}
+	}()
+
+	nr.logger.Info("Name service preload cron started",
+		slog.String("spec", spec),
+		slog.String("strategy", strategy.String()),
+		slog.Int("top_n", opts.Limit),
+		slog.Duration("window", opts.Window))
+	return nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// fakeTimer is a wait requested through NameResolver.after
+type fakeTimer struct {
+	d time.Duration
+	c chan time.Time
+}
+
+func TestPreloadCronFiresOnSchedule(t *testing.T) {
+	seedTestTiDB(t, openTestTiDB(t))
+	sqliteDB := openTestDB(t)
+	created := time.Now().UTC().Add(-time.Hour).Format("2006-01-02 15:04:05") + " UTC"
+	if err := sqliteDB.Create(&models.Issue{ID: "A-1", IsAlert: true, ClusterID: "2001", Created: created}).Error; err != nil {
+		t.Fatalf("seed issue: %v", err)
+	}
+
+	clock := newTestClock()
+	clock.Advance(7*time.Minute + 30*time.Second)
+	reg := prometheus.NewRegistry()
+	nr := NewNameResolver(WithClock(clock.Now), WithMetrics(reg))
+	defer nr.ClosePreparedStatements()
+	timers := make(chan fakeTimer)
+	nr.after = func(d time.Duration) <-chan time.Time {
+		c := make(chan time.Time, 1)
+		timers <- fakeTimer{d, c}
+		return c
+	}
+	nextTimer := func() fakeTimer {
+		t.Helper()
+		select {
+		case timer := <-timers:
+			return timer
+		case <-time.After(5 * time.Second):
+			t.Fatal("the preload cron did not wait for its next run")
+			return fakeTimer{}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := nr.StartPreloadCron(ctx, sqliteDB, "*/15 * * * *", WarmUpFrequency, WarmUpOptions{Limit: 10}); err != nil {
+		t.Fatalf("StartPreloadCron: %v", err)
+	}
+
+	// Started at 12:07:30, the runs are at 12:15, 12:30 and 12:45
+	for i, want := range []time.Duration{7*time.Minute + 30*time.Second, 15 * time.Minute, 15 * time.Minute} {
+		timer := nextTimer()
+		if timer.d != want {
+			t.Errorf("wait %d = %v, want %v", i, timer.d, want)
+		}
+		if n := counterValue(t, reg, "name_resolver_db_lookups_total"); n != float64(i) {
+			t.Errorf("%v lookups before run %d, want %d", n, i, i)
+		}
+		nr.ClearCache()
+		clock.Advance(timer.d)
+		timer.c <- clock.Now()
+	}
+	// The next wait is only requested once the third run has finished
+	nextTimer()
+	if n := counterValue(t, reg, "name_resolver_db_lookups_total"); n != 3 {
+		t.Errorf("%v lookups after three runs, want 3", n)
+	}
+	if entry, ok := nr.cache.peek("2001"); !ok || entry.info.Name != "prod-east" {
+		t.Errorf("cluster 2001 after the preload = %+v, %v", entry, ok)
+	}
+
+	if err := nr.StartPreloadCron(ctx, sqliteDB, "* * *", WarmUpFrequency, WarmUpOptions{}); err == nil {
+		t.Error("StartPreloadCron accepted an invalid spec")
+	}
+}
+
+func TestPreloadCronStopsOnCancel(t *testing.T) {
+	sqliteDB := openTestDB(t)
+	nr := NewNameResolver(WithClock(newTestClock().Now))
+	timers := make(chan fakeTimer, 1)
+	nr.after = func(d time.Duration) <-chan time.Time {
+		c := make(chan time.Time, 1)
+		timers <- fakeTimer{d, c}
+		return c
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := nr.StartPreloadCron(ctx, sqliteDB, "0 * * * *", WarmUpFrequency, WarmUpOptions{Limit: 10}); err != nil {
+		t.Fatalf("StartPreloadCron: %v", err)
+	}
+	timer := <-timers
+	cancel()
+	// Give the goroutine time to see the cancellation before the timer fires
+	time.Sleep(20 * time.Millisecond)
+	timer.c <- time.Time{}
+
+	select {
+	case <-timers:
+		t.Error("the preload cron ran after its context was cancelled")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
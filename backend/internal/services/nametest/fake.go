@@ -0,0 +1,111 @@
+// Package nametest provides an in-memory services.NameService for tests that
+// must not depend on the NameResolver singleton or a TiDB connection.
+package nametest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+// FakeNameResolver resolves the names registered with RegisterName and counts
+// every call. Unknown IDs resolve to themselves, like a miss of the real
+// resolver: Resolve returns services.ErrIDNotFound and ResolveBatch no error.
+// It is safe for concurrent use.
+type FakeNameResolver struct {
+	mu           sync.Mutex
+	names        map[string]services.NameInfo
+	resolveCalls int
+	batchCalls   int
+	lookups      map[string]int
+}
+
+var _ services.NameService = (*FakeNameResolver)(nil)
+
+// NewFakeNameResolver returns a fake with no names registered
+func NewFakeNameResolver() *FakeNameResolver {
+	return &FakeNameResolver{
+		names:   make(map[string]services.NameInfo),
+		lookups: make(map[string]int),
+	}
+}
+
+// RegisterName makes id resolve to info. info.ID defaults to id.
+func (f *FakeNameResolver) RegisterName(id string, info services.NameInfo) {
+	if info.ID == "" {
+		info.ID = id
+	}
+	f.mu.Lock()
+	f.names[id] = info
+	f.mu.Unlock()
+}
+
+// Resolve implements services.NameService
+func (f *FakeNameResolver) Resolve(id string) (services.NameInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.resolveCalls++
+	f.lookups[id]++
+
+	if id == "" {
+		return services.NameInfo{}, fmt.Errorf("empty id")
+	}
+	if info, ok := f.names[id]; ok {
+		return info, nil
+	}
+	return services.NameInfo{ID: id, Name: id}, fmt.Errorf("%w: %s", services.ErrIDNotFound, id)
+}
+
+// ResolveContext implements services.NameService
+func (f *FakeNameResolver) ResolveContext(_ context.Context, id string) (services.NameInfo, error) {
+	return f.Resolve(id)
+}
+
+// ResolveBatch implements services.NameService
+func (f *FakeNameResolver) ResolveBatch(ids []string) (map[string]services.NameInfo, []error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batchCalls++
+
+	results := make(map[string]services.NameInfo, len(ids))
+	var errs []error
+	for _, id := range ids {
+		if _, done := results[id]; done {
+			continue
+		}
+		f.lookups[id]++
+		if id == "" {
+			errs = append(errs, fmt.Errorf("empty id"))
+			continue
+		}
+		if info, ok := f.names[id]; ok {
+			results[id] = info
+		} else {
+			results[id] = services.NameInfo{ID: id, Name: id}
+		}
+	}
+	return results, errs
+}
+
+// ResolveCalls returns the number of Resolve calls
+func (f *FakeNameResolver) ResolveCalls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.resolveCalls
+}
+
+// ResolveBatchCalls returns the number of ResolveBatch calls
+func (f *FakeNameResolver) ResolveBatchCalls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.batchCalls
+}
+
+// Lookups returns how many times id was looked up by either method
+func (f *FakeNameResolver) Lookups(id string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lookups[id]
+}
@@ -0,0 +1,8 @@
+// Package nametest_integration runs the NameResolver against a real MySQL
+// server started with testcontainers-go. Its tests are behind the integration
+// build tag and need a Docker daemon:
+//
+//	go test -tags integration ./internal/services/nametest_integration/
+//
+// Without a reachable daemon the tests are skipped.
+package nametest_integration
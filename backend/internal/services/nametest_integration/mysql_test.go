@@ -0,0 +1,159 @@
+//go:build integration
+
+package nametest_integration
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/mysql"
+)
+
+const mysqlImage = "mysql:8.0.36"
+
+// The container is started by the first test that needs it and shared by all
+var (
+	mysqlOnce      sync.Once
+	mysqlContainer *mysql.MySQLContainer
+	mysqlDSN       string
+	mysqlErr       error
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if mysqlContainer != nil {
+		if err := testcontainers.TerminateContainer(mysqlContainer); err != nil {
+			log.Printf("failed to terminate MySQL container: %v", err)
+		}
+	}
+	os.Exit(code)
+}
+
+// fixtures are the clusters and tenants every test starts with. Tenant 1001
+// is a sub-tenant of the org 1000; cluster 2003 belongs to a tenant that does
+// not exist.
+const fixtures = `
+DROP VIEW IF EXISTS v_cluster_names;
+DROP TABLE IF EXISTS clusters;
+DROP TABLE IF EXISTS tenants;
+CREATE TABLE tenants (
+	tenant_id VARCHAR(32) PRIMARY KEY,
+	tenant_name VARCHAR(255),
+	kind VARCHAR(32) DEFAULT 'tenant',
+	parent_tenant_id VARCHAR(32),
+	created_at DATETIME DEFAULT '2026-01-01 00:00:00',
+	updated_at DATETIME DEFAULT '2026-01-01 00:00:00'
+);
+CREATE TABLE clusters (
+	cluster_id VARCHAR(32) PRIMARY KEY,
+	cluster_name VARCHAR(255),
+	tenant_id VARCHAR(32),
+	tenant_name VARCHAR(255),
+	deploy_type VARCHAR(32),
+	version VARCHAR(32),
+	cluster_lifecycle VARCHAR(32),
+	creation_duration VARCHAR(32),
+	tenant_plan VARCHAR(32),
+	provider VARCHAR(32),
+	region VARCHAR(64),
+	project_id VARCHAR(32),
+	org_id VARCHAR(32),
+	cluster_type VARCHAR(32),
+	created_at DATETIME DEFAULT '2026-01-01 00:00:00',
+	updated_at DATETIME DEFAULT '2026-01-01 00:00:00'
+);
+INSERT INTO tenants (tenant_id, tenant_name, kind, parent_tenant_id) VALUES
+	('1000', 'acme', 'tenant', NULL),
+	('1001', 'acme-eu', 'sub_tenant', '1000'),
+	('1002', 'globex', 'tenant', NULL);
+INSERT INTO clusters (cluster_id, cluster_name, tenant_id, tenant_name, deploy_type, version, provider, region) VALUES
+	('2001', 'prod-east', '1001', NULL, 'dedicated', 'v8.5.0', 'aws', 'us-east-1'),
+	('2002', 'staging', '1002', 'globex', 'dedicated', 'v7.5.2', 'gcp', 'us-central1'),
+	('2003', 'orphan', '1999', NULL, 'dedicated', 'v8.1.0', 'aws', 'eu-west-1');
+`
+
+// openMySQL seeds the fixtures into the shared MySQL container and installs
+// it as the name service's TiDB for the duration of the test. The test is
+// skipped when there is no Docker daemon to run the container.
+//
+// NAMETEST_MYSQL_DSN runs the tests against an existing server instead, e.g.
+// a CI service container. The DSN must set multiStatements and parseTime; the
+// clusters and tenants tables of its database are recreated by every test.
+func openMySQL(t *testing.T) *sql.DB {
+	t.Helper()
+	if dsn := os.Getenv("NAMETEST_MYSQL_DSN"); dsn != "" {
+		mysqlDSN = dsn
+	} else {
+		startMySQL(t)
+	}
+
+	conn, err := sql.Open("mysql", mysqlDSN)
+	if err != nil {
+		t.Fatalf("open MySQL: %v", err)
+	}
+	mustExec(t, conn, fixtures)
+
+	db.SetTiDB(conn)
+	t.Cleanup(func() {
+		db.SetTiDB(nil)
+		conn.Close()
+	})
+	return conn
+}
+
+// startMySQL starts the container shared by the tests on first use
+func startMySQL(t *testing.T) {
+	t.Helper()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	mysqlOnce.Do(func() {
+		ctx := context.Background()
+		mysqlContainer, mysqlErr = mysql.Run(ctx, mysqlImage, mysql.WithDatabase("names"))
+		if mysqlErr != nil {
+			return
+		}
+		mysqlDSN, mysqlErr = mysqlContainer.ConnectionString(ctx, "parseTime=true", "multiStatements=true")
+	})
+	if mysqlErr != nil {
+		t.Fatalf("start MySQL container: %v", mysqlErr)
+	}
+}
+
+func mustExec(t *testing.T, conn *sql.DB, stmts ...string) {
+	t.Helper()
+	for _, stmt := range stmts {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("exec %q: %v", stmt, err)
+		}
+	}
+}
+
+// fakeClock is a NameResolver clock that only moves when advanced
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
@@ -0,0 +1,236 @@
+//go:build integration
+
+package nametest_integration
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/services"
+)
+
+const (
+	clusterTTL  = time.Hour
+	notFoundTTL = 5 * time.Minute
+)
+
+func newResolver(clock *fakeClock, opts ...services.NameResolverOption) *services.NameResolver {
+	opts = append([]services.NameResolverOption{
+		services.WithClock(clock.Now),
+		services.WithTypeTTL("cluster", clusterTTL),
+		services.WithTypeTTL("tenant", clusterTTL),
+		services.WithTypeTTL("notFound", notFoundTTL),
+	}, opts...)
+	return services.NewNameResolver(opts...)
+}
+
+func TestResolve(t *testing.T) {
+	conn := openMySQL(t)
+	clock := newFakeClock()
+	nr := newResolver(clock)
+
+	info, err := nr.Resolve("2001")
+	if err != nil {
+		t.Fatalf("Resolve(2001): %v", err)
+	}
+	want := services.NameInfo{Type: "cluster", ID: "2001", Name: "prod-east", TenantID: "1001", TenantName: "acme-eu", DeployType: "dedicated"}
+	if info != want {
+		t.Errorf("Resolve(2001) = %+v, want %+v", info, want)
+	}
+	if info, err := nr.Resolve("1002"); err != nil || info.Type != "tenant" || info.Name != "globex" {
+		t.Errorf("Resolve(1002) = %+v, %v, want tenant globex", info, err)
+	}
+
+	// Renames are not seen while the entries are cached, including the tenant
+	// cached along with its cluster
+	mustExec(t, conn,
+		`UPDATE clusters SET cluster_name = 'prod-east-2' WHERE cluster_id = '2001'`,
+		`UPDATE tenants SET tenant_name = 'acme-europe' WHERE tenant_id = '1001'`,
+	)
+	clock.Advance(clusterTTL - time.Minute)
+	if info, _ := nr.Resolve("2001"); info.Name != "prod-east" {
+		t.Errorf("cached cluster name = %q, want prod-east", info.Name)
+	}
+	if info, _ := nr.Resolve("1001"); info.Name != "acme-eu" {
+		t.Errorf("prefetched tenant name = %q, want acme-eu", info.Name)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if info, _ := nr.Resolve("2001"); info.Name != "prod-east-2" || info.TenantName != "acme-europe" {
+		t.Errorf("after the TTL: %+v, want prod-east-2 of acme-europe", info)
+	}
+	if info, _ := nr.Resolve("1001"); info.Name != "acme-europe" {
+		t.Errorf("tenant after the TTL = %q, want acme-europe", info.Name)
+	}
+}
+
+func TestResolveNotFound(t *testing.T) {
+	conn := openMySQL(t)
+	clock := newFakeClock()
+	nr := newResolver(clock)
+
+	info, err := nr.Resolve("3001")
+	if !errors.Is(err, services.ErrIDNotFound) {
+		t.Fatalf("Resolve(3001) error = %v, want ErrIDNotFound", err)
+	}
+	if info.ID != "3001" || info.Name != "3001" {
+		t.Errorf("Resolve(3001) = %+v, want the ID as the name", info)
+	}
+
+	// The miss is cached for the not-found TTL
+	mustExec(t, conn, `INSERT INTO clusters (cluster_id, cluster_name, tenant_id, deploy_type) VALUES ('3001', 'late', '1002', 'dedicated')`)
+	if info, err := nr.Resolve("3001"); err != nil || info.Name != "3001" {
+		t.Errorf("cached miss = %+v, %v, want the ID and no error", info, err)
+	}
+	if names, _ := nr.ResolveBatch([]string{"3001"}); names["3001"].Name != "3001" {
+		t.Errorf("batch of a cached miss = %+v, want the ID", names["3001"])
+	}
+
+	clock.Advance(notFoundTTL)
+	if info, err := nr.Resolve("3001"); err != nil || info.Name != "late" {
+		t.Errorf("after the not-found TTL: %+v, %v, want late", info, err)
+	}
+
+	if _, err := nr.ResolveCluster("3999"); !errors.Is(err, services.ErrIDNotFound) {
+		t.Errorf("ResolveCluster(3999) error = %v, want ErrIDNotFound", err)
+	}
+}
+
+func TestResolveBatch(t *testing.T) {
+	conn := openMySQL(t)
+	clock := newFakeClock()
+	nr := newResolver(clock)
+
+	names, errs := nr.ResolveBatch([]string{"2001", "2002", "1000", "3999", "2001"})
+	if len(errs) != 1 || !errors.Is(errs[0], services.ErrIDNotFound) {
+		t.Errorf("errs = %v, want one ErrIDNotFound", errs)
+	}
+	want := map[string]string{"2001": "prod-east", "2002": "staging", "1000": "acme", "3999": "3999"}
+	for id, name := range want {
+		if names[id].Name != name {
+			t.Errorf("%s = %+v, want %s", id, names[id], name)
+		}
+	}
+	if names["2001"].TenantName != "acme-eu" || names["1000"].Type != "tenant" {
+		t.Errorf("2001 = %+v, 1000 = %+v", names["2001"], names["1000"])
+	}
+
+	// Batch results fill the cache that Resolve reads
+	mustExec(t, conn, `UPDATE clusters SET cluster_name = 'staging-2' WHERE cluster_id = '2002'`)
+	if info, _ := nr.Resolve("2002"); info.Name != "staging" {
+		t.Errorf("Resolve after ResolveBatch = %q, want the cached staging", info.Name)
+	}
+	if names, errs := nr.ResolveBatch([]string{"2002", "3999"}); len(errs) != 0 || names["2002"].Name != "staging" {
+		t.Errorf("cached batch = %+v, %v", names, errs)
+	}
+
+	clock.Advance(clusterTTL)
+	if names, _ := nr.ResolveBatch([]string{"2002"}); names["2002"].Name != "staging-2" {
+		t.Errorf("batch after the TTL = %+v, want staging-2", names["2002"])
+	}
+}
+
+func TestResolveHierarchy(t *testing.T) {
+	conn := openMySQL(t)
+	clock := newFakeClock()
+	nr := newResolver(clock)
+
+	h, err := nr.ResolveHierarchy("2001")
+	if err != nil {
+		t.Fatalf("ResolveHierarchy(2001): %v", err)
+	}
+	if h.Cluster.ClusterName != "prod-east" || h.Cluster.Region != "us-east-1" || h.Cluster.Version != "v8.5.0" {
+		t.Errorf("cluster = %+v", h.Cluster)
+	}
+	if h.Tenant.TenantID != "1001" || h.Tenant.Kind != services.TenantKindSubTenant || h.Tenant.ParentTenantID != "1000" {
+		t.Errorf("tenant = %+v, want the sub-tenant 1001 of 1000", h.Tenant)
+	}
+	if h.OrgID != "1000" || h.OrgName != "acme" {
+		t.Errorf("org = %s %s, want 1000 acme", h.OrgID, h.OrgName)
+	}
+
+	// A tenant of its own is its org; a missing tenant ends the chain
+	if h, err := nr.ResolveHierarchy("2002"); err != nil || h.OrgID != "1002" || h.OrgName != "globex" {
+		t.Errorf("ResolveHierarchy(2002) = %+v, %v, want org 1002 globex", h, err)
+	}
+	if h, err := nr.ResolveHierarchy("2003"); err != nil || h.Tenant.TenantID != "" || h.OrgID != "" {
+		t.Errorf("ResolveHierarchy(2003) = %+v, %v, want no tenant or org", h, err)
+	}
+	if _, err := nr.ResolveHierarchy("3999"); !errors.Is(err, services.ErrIDNotFound) {
+		t.Errorf("ResolveHierarchy(3999) error = %v, want ErrIDNotFound", err)
+	}
+
+	// Hierarchies are cached with the detail TTL, an hour by default
+	mustExec(t, conn, `UPDATE tenants SET tenant_name = 'acme-corp' WHERE tenant_id = '1000'`)
+	clock.Advance(30 * time.Minute)
+	if h, _ := nr.ResolveHierarchy("2001"); h.OrgName != "acme" {
+		t.Errorf("cached org name = %q, want acme", h.OrgName)
+	}
+	clock.Advance(time.Hour)
+	if h, _ := nr.ResolveHierarchy("2001"); h.OrgName != "acme-corp" {
+		t.Errorf("org name after the TTL = %q, want acme-corp", h.OrgName)
+	}
+}
+
+// clusterViewDDL is the v_cluster_names view documented in the README
+const clusterViewDDL = `
+CREATE OR REPLACE VIEW v_cluster_names AS
+SELECT c.cluster_id, c.cluster_name, c.tenant_id,
+       COALESCE(NULLIF(c.tenant_name, ''), t.tenant_name, '') AS tenant_name,
+       COALESCE(c.deploy_type, '') AS deploy_type,
+       COALESCE(c.version, '') AS version,
+       COALESCE(c.cluster_lifecycle, '') AS cluster_lifecycle,
+       COALESCE(c.creation_duration, '') AS creation_duration,
+       COALESCE(c.tenant_plan, '') AS tenant_plan,
+       COALESCE(c.provider, '') AS provider,
+       COALESCE(c.region, '') AS region,
+       COALESCE(c.project_id, '') AS project_id,
+       COALESCE(c.org_id, '') AS org_id,
+       COALESCE(c.cluster_type, '') AS cluster_type,
+       c.created_at, c.updated_at,
+       t.tenant_name AS joined_tenant_name
+FROM clusters c
+LEFT JOIN tenants t ON c.tenant_id = t.tenant_id
+`
+
+func TestClusterView(t *testing.T) {
+	conn := openMySQL(t)
+	clock := newFakeClock()
+
+	// Without the view, lookups fall back to the join
+	viewless := newResolver(clock, services.WithClusterView(true))
+	if info, err := viewless.Resolve("2001"); err != nil || info.Name != "prod-east" {
+		t.Fatalf("Resolve without the view = %+v, %v", info, err)
+	}
+
+	mustExec(t, conn, clusterViewDDL)
+	joined := newResolver(clock)
+	viewed := newResolver(clock, services.WithClusterView(true))
+	if !viewed.ClusterView() {
+		t.Fatal("cluster view is not enabled")
+	}
+
+	for _, id := range []string{"2001", "2002", "2003", "1001", "3999"} {
+		want, wantErr := joined.Resolve(id)
+		got, err := viewed.Resolve(id)
+		if got != want || errors.Is(err, services.ErrIDNotFound) != errors.Is(wantErr, services.ErrIDNotFound) {
+			t.Errorf("Resolve(%s) through the view = %+v, %v, want %+v, %v", id, got, err, want, wantErr)
+		}
+	}
+	for _, id := range []string{"2001", "2002", "2003"} {
+		want, err := joined.ResolveCluster(id)
+		if err != nil {
+			t.Fatalf("ResolveCluster(%s): %v", id, err)
+		}
+		got, err := viewed.ResolveCluster(id)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ResolveCluster(%s) through the view = %+v, %v, want %+v", id, got, err, want)
+		}
+	}
+	// The tenant read through the view is cached like the joined one
+	if info, err := viewed.Resolve("1001"); err != nil || info.Name != "acme-eu" {
+		t.Errorf("tenant prefetched from the view = %+v, %v", info, err)
+	}
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// NotificationService manages webhook notification channels and sends new
+// alerts to every enabled channel whose label filter matches. Channels are
+// held in memory and reloaded whenever they change.
+type NotificationService struct {
+	DB *gorm.DB
+
+	mu       sync.RWMutex
+	channels []notificationTarget
+}
+
+// Notifier delivers an alert to one channel
+type Notifier interface {
+	Send(alert Alert) error
+}
+
+// notificationTarget is a loaded channel with its parsed filter
+type notificationTarget struct {
+	channel  models.NotificationChannel
+	filter   []Matcher
+	notifier Notifier
+}
+
+var (
+	notificationInstance *NotificationService
+	notificationOnce     sync.Once
+)
+
+// GetNotificationService returns the shared service, loading the channels from
+// SQLite on first use
+func GetNotificationService() *NotificationService {
+	notificationOnce.Do(func() {
+		notificationInstance = &NotificationService{DB: db.DB}
+		if err := notificationInstance.Load(); err != nil {
+			log.Printf("[WARN] Failed to load notification channels: %v", err)
+		}
+	})
+	return notificationInstance
+}
+
+// ValidateNotificationChannel checks the fields required to send to channel
+// and fills in defaults
+func ValidateNotificationChannel(channel *models.NotificationChannel) error {
+	if channel.Name == "" {
+		return errors.New("name is required")
+	}
+	switch channel.Type {
+	case "":
+		channel.Type = ChannelWebhook
+	case ChannelWebhook:
+	case ChannelSlack:
+		if _, err := NewSlackNotifier(channel.URL, channel.Template); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported type %q, expected %s or %s", channel.Type, ChannelWebhook, ChannelSlack)
+	}
+	if !strings.HasPrefix(channel.URL, "http://") && !strings.HasPrefix(channel.URL, "https://") {
+		return errors.New("url must be an http(s) URL")
+	}
+	channel.Method = strings.ToUpper(channel.Method)
+	switch channel.Method {
+	case "":
+		channel.Method = http.MethodPost
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return fmt.Errorf("unsupported method %q, expected POST, PUT or PATCH", channel.Method)
+	}
+	if channel.MaxRetries < 0 || channel.TimeoutSeconds < 0 {
+		return errors.New("max_retries and timeout_seconds must not be negative")
+	}
+	if channel.MaxRetries > MaxWebhookRetries {
+		return fmt.Errorf("max_retries must be at most %d", MaxWebhookRetries)
+	}
+	if len(channel.Headers) > 0 && string(channel.Headers) != "null" {
+		var headers map[string]string
+		if err := json.Unmarshal(channel.Headers, &headers); err != nil {
+			return errors.New("headers must be a JSON object of strings")
+		}
+	}
+	if hasLabelFilter(channel.LabelFilter) {
+		if _, err := ParseMatchers(channel.LabelFilter); err != nil {
+			return fmt.Errorf("label_filter: %w", err)
+		}
+	}
+	return nil
+}
+
+func hasLabelFilter(raw json.RawMessage) bool {
+	s := strings.TrimSpace(string(raw))
+	return s != "" && s != "null" && s != "[]"
+}
+
+// Load replaces the in-memory channels with the enabled ones from the database
+func (s *NotificationService) Load() error {
+	var channels []models.NotificationChannel
+	if err := s.DB.Where("enabled = ?", true).Order("id asc").Find(&channels).Error; err != nil {
+		return err
+	}
+
+	targets := make([]notificationTarget, 0, len(channels))
+	for _, ch := range channels {
+		notifier, err := newNotifier(ch)
+		if err != nil {
+			log.Printf("[WARN] Skipping notification channel %d: %v", ch.ID, err)
+			continue
+		}
+		target := notificationTarget{channel: ch, notifier: notifier}
+		if hasLabelFilter(ch.LabelFilter) {
+			filter, err := ParseMatchers(ch.LabelFilter)
+			if err != nil {
+				log.Printf("[WARN] Skipping notification channel %d: invalid label_filter: %v", ch.ID, err)
+				continue
+			}
+			target.filter = filter
+		}
+		targets = append(targets, target)
+	}
+
+	s.mu.Lock()
+	s.channels = targets
+	s.mu.Unlock()
+	return nil
+}
+
+// newNotifier builds the notifier for channel's type
+func newNotifier(ch models.NotificationChannel) (Notifier, error) {
+	if ch.Type == ChannelSlack {
+		return NewSlackNotifier(ch.URL, ch.Template)
+	}
+	webhook := &WebhookNotifier{
+		URL:        ch.URL,
+		Method:     ch.Method,
+		MaxRetries: ch.MaxRetries,
+		Timeout:    time.Duration(ch.TimeoutSeconds) * time.Second,
+	}
+	if len(ch.Headers) > 0 {
+		json.Unmarshal(ch.Headers, &webhook.Headers)
+	}
+	return webhook, nil
+}
+
+// SendTest sends a sample alert to the channel with id, whether or not it is
+// enabled. Returns gorm.ErrRecordNotFound if it does not exist.
+func (s *NotificationService) SendTest(id uint) error {
+	return s.SendToChannel(id, TestAlert())
+}
+
+// SendToChannel sends alert to the channel with id regardless of its label
+// filter and whether it is enabled, so disabled channels can serve as
+// escalation targets only. Returns gorm.ErrRecordNotFound if it does not exist.
+func (s *NotificationService) SendToChannel(id uint, alert Alert) error {
+	var ch models.NotificationChannel
+	if err := s.DB.First(&ch, id).Error; err != nil {
+		return err
+	}
+	notifier, err := newNotifier(ch)
+	if err != nil {
+		return err
+	}
+	return notifier.Send(alert)
+}
+
+// TestAlert returns the sample alert sent by notification tests
+func TestAlert() Alert {
+	return Alert{
+		ID:       "TEST-1",
+		Title:    "Test notification from the alerts dashboard",
+		Severity: "Warning",
+		Status:   "Created",
+		Created:  time.Now().UTC().Format("2006-01-02 15:04:05") + " UTC",
+		Labels:   map[string]string{"alertname": "TestNotification"},
+	}
+}
+
+// ListChannels returns all channels, including disabled ones
+func (s *NotificationService) ListChannels() ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	if err := s.DB.Order("id asc").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+func (s *NotificationService) CreateChannel(channel *models.NotificationChannel) error {
+	if err := ValidateNotificationChannel(channel); err != nil {
+		return err
+	}
+	if err := s.DB.Create(channel).Error; err != nil {
+		return err
+	}
+	return s.Load()
+}
+
+// UpdateChannel replaces the channel with channel.ID. Returns gorm.ErrRecordNotFound if it does not exist.
+func (s *NotificationService) UpdateChannel(channel *models.NotificationChannel) error {
+	if err := ValidateNotificationChannel(channel); err != nil {
+		return err
+	}
+	var existing models.NotificationChannel
+	if err := s.DB.First(&existing, channel.ID).Error; err != nil {
+		return err
+	}
+	channel.CreatedAt = existing.CreatedAt
+	channel.Headers = restoreRedactedHeaders(channel.Headers, existing.Headers)
+	if err := s.DB.Save(channel).Error; err != nil {
+		return err
+	}
+	return s.Load()
+}
+
+// DeleteChannel removes a channel. Returns gorm.ErrRecordNotFound if it does not exist.
+func (s *NotificationService) DeleteChannel(id uint) error {
+	result := s.DB.Delete(&models.NotificationChannel{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return s.Load()
+}
+
+// RedactedHeaderValue replaces header values in channels returned by the API.
+// Sending it back in an update keeps the stored value.
+const RedactedHeaderValue = "[REDACTED]"
+
+// RedactChannel returns channel with every header value replaced by
+// RedactedHeaderValue, since headers usually carry credentials
+func RedactChannel(channel models.NotificationChannel) models.NotificationChannel {
+	var headers map[string]string
+	if len(channel.Headers) == 0 || json.Unmarshal(channel.Headers, &headers) != nil || headers == nil {
+		return channel
+	}
+	for name := range headers {
+		headers[name] = RedactedHeaderValue
+	}
+	channel.Headers, _ = json.Marshal(headers)
+	return channel
+}
+
+// restoreRedactedHeaders replaces the RedactedHeaderValue values of updated
+// with the stored ones, so a redacted channel can be sent back unchanged
+func restoreRedactedHeaders(updated, stored json.RawMessage) json.RawMessage {
+	var headers, old map[string]string
+	if json.Unmarshal(updated, &headers) != nil || headers == nil {
+		return updated
+	}
+	json.Unmarshal(stored, &old)
+	for name, value := range headers {
+		if value != RedactedHeaderValue {
+			continue
+		}
+		if prev, ok := old[name]; ok {
+			headers[name] = prev
+		} else {
+			delete(headers, name)
+		}
+	}
+	merged, err := json.Marshal(headers)
+	if err != nil {
+		return updated
+	}
+	return merged
+}
+
+// NotifyAlert sends a new alert to every matching channel. Failures are
+// logged per channel and returned joined.
+func (s *NotificationService) NotifyAlert(data *IssueData) error {
+	s.mu.RLock()
+	channels := s.channels
+	s.mu.RUnlock()
+	if len(channels) == 0 {
+		return nil
+	}
+
+	alert := alertFromData(data)
+
+	var errs []error
+	for _, target := range channels {
+		if target.filter != nil && !matchAll(target.filter, alert.Labels) {
+			continue
+		}
+		if err := target.notifier.Send(alert); err != nil {
+			errs = append(errs, fmt.Errorf("channel %q: %w", target.channel.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotifySpike sends a meta-alert about a spike in alert volume reported by an
+// AnomalyDetector to every channel whose label filter matches it
+func (s *NotificationService) NotifySpike(factor float64, recentAlerts []Alert) error {
+	now := time.Now().UTC()
+	clusters := make(map[string]bool)
+	for _, a := range recentAlerts {
+		if a.ClusterID != "" {
+			clusters[a.ClusterID] = true
+		}
+	}
+
+	return s.NotifyAlert(&IssueData{
+		ID:             fmt.Sprintf("alert-spike-%d", now.Unix()),
+		Title:          fmt.Sprintf("Alert volume spike: %.1fx the recent rate (%d alerts, %d clusters)", factor, len(recentAlerts), len(clusters)),
+		Created:        now.Format("2006-01-02 15:04:05") + " UTC",
+		Priority:       "Critical",
+		Labels:         "[]",
+		IsAlert:        true,
+		AlertSignature: "AlertVolumeSpike",
+		Status:         "Created",
+	})
+}
+
+// NotifyAutoResolved sends a summary of the alerts a StaleAlertReaper resolved
+// because they were still open staleAfter after they were created
+func (s *NotificationService) NotifyAutoResolved(staleAfter time.Duration, resolved []models.Issue) error {
+	now := time.Now().UTC()
+	clusters := make(map[string]bool)
+	for _, issue := range resolved {
+		if issue.ClusterID != "" {
+			clusters[issue.ClusterID] = true
+		}
+	}
+
+	return s.NotifyAlert(&IssueData{
+		ID:             fmt.Sprintf("stale-alerts-%d", now.Unix()),
+		Title:          fmt.Sprintf("Auto-resolved %d alerts open for more than %s (%d clusters)", len(resolved), staleAfter, len(clusters)),
+		Created:        now.Format("2006-01-02 15:04:05") + " UTC",
+		Priority:       "Minor",
+		Labels:         "[]",
+		IsAlert:        true,
+		AlertSignature: "StaleAlertsAutoResolved",
+		Status:         "Resolved",
+	})
+}
+
+// alertFromData builds the notification payload of a stored alert
+func alertFromData(data *IssueData) Alert {
+	return Alert{
+		ID:        data.ID,
+		Title:     data.Title,
+		Severity:  data.Priority,
+		Status:    data.Status,
+		ClusterID: data.ClusterID,
+		TenantID:  data.TenantID,
+		Created:   data.Created,
+		Labels:    AlertLabels(issueFromData(data)),
+	}
+}
+
+// issueFromData builds the fields of an Issue that AlertLabels reads
+func issueFromData(data *IssueData) *models.Issue {
+	return &models.Issue{
+		ID:              data.ID,
+		Priority:        data.Priority,
+		Labels:          data.Labels,
+		AlertSignature:  data.AlertSignature,
+		ClusterID:       data.ClusterID,
+		TenantID:        data.TenantID,
+		BizType:         data.BizType,
+		Status:          data.Status,
+		ComponentName:   data.ComponentName,
+		SourceComponent: data.SourceComponent,
+		AlertGroup:      data.AlertGroup,
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+func TestValidateNotificationChannelMaxRetries(t *testing.T) {
+	channel := models.NotificationChannel{Name: "ops", URL: "https://hooks.example.com", MaxRetries: MaxWebhookRetries}
+	if err := ValidateNotificationChannel(&channel); err != nil {
+		t.Errorf("max_retries %d: %v", MaxWebhookRetries, err)
+	}
+	channel.MaxRetries = MaxWebhookRetries + 1
+	if err := ValidateNotificationChannel(&channel); err == nil {
+		t.Errorf("max_retries %d was accepted", channel.MaxRetries)
+	}
+}
+
+func TestRedactChannel(t *testing.T) {
+	channel := models.NotificationChannel{Name: "ops", Headers: json.RawMessage(`{"Authorization":"Bearer secret","X-Team":"sre"}`)}
+	redacted := RedactChannel(channel)
+
+	var headers map[string]string
+	if err := json.Unmarshal(redacted.Headers, &headers); err != nil {
+		t.Fatal(err)
+	}
+	if len(headers) != 2 || headers["Authorization"] != RedactedHeaderValue || headers["X-Team"] != RedactedHeaderValue {
+		t.Errorf("redacted headers = %v", headers)
+	}
+	if string(channel.Headers) != `{"Authorization":"Bearer secret","X-Team":"sre"}` {
+		t.Errorf("RedactChannel modified its argument: %s", channel.Headers)
+	}
+	if got := RedactChannel(models.NotificationChannel{}); len(got.Headers) != 0 {
+		t.Errorf("channel without headers got %s", got.Headers)
+	}
+}
+
+func TestUpdateChannelKeepsRedactedHeaders(t *testing.T) {
+	svc := &NotificationService{DB: openTestDB(t)}
+	channel := models.NotificationChannel{
+		Name:    "ops",
+		URL:     "https://hooks.example.com",
+		Headers: json.RawMessage(`{"Authorization":"Bearer secret","X-Team":"sre"}`),
+		Enabled: true,
+	}
+	if err := svc.CreateChannel(&channel); err != nil {
+		t.Fatal(err)
+	}
+
+	// The client sends back what it was given, changing one header and adding one
+	update := RedactChannel(channel)
+	update.Headers = json.RawMessage(`{"Authorization":"` + RedactedHeaderValue + `","X-Team":"dba","X-New":"1","X-Unknown":"` + RedactedHeaderValue + `"}`)
+	if err := svc.UpdateChannel(&update); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored models.NotificationChannel
+	if err := svc.DB.First(&stored, channel.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	var headers map[string]string
+	json.Unmarshal(stored.Headers, &headers)
+	want := map[string]string{"Authorization": "Bearer secret", "X-Team": "dba", "X-New": "1"}
+	if len(headers) != len(want) {
+		t.Fatalf("stored headers = %v, want %v", headers, want)
+	}
+	for k, v := range want {
+		if headers[k] != v {
+			t.Errorf("header %s = %q, want %q", k, headers[k], v)
+		}
+	}
+}
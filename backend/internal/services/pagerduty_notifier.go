@@ -0,0 +1,147 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// PagerDutyEventsURL is the PagerDuty Events API v2 endpoint
+const PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerDutySeverities maps alert priorities to PagerDuty severities
+var pagerDutySeverities = map[string]string{
+	"critical": "critical",
+	"high":     "error",
+	"major":    "error",
+	"medium":   "warning",
+	"warning":  "warning",
+	"low":      "info",
+}
+
+// PagerDutySeverity returns the PagerDuty severity for an alert priority,
+// defaulting to "warning"
+func PagerDutySeverity(priority string) string {
+	if s, ok := pagerDutySeverities[strings.ToLower(priority)]; ok {
+		return s
+	}
+	return "warning"
+}
+
+// PagerDutyNotifier triggers and resolves PagerDuty incidents through the
+// Events API v2
+type PagerDutyNotifier struct {
+	RoutingKey string // defaults to PAGERDUTY_ROUTING_KEY
+	EventsURL  string // defaults to PagerDutyEventsURL
+	Client     *http.Client
+}
+
+func NewPagerDutyNotifier(routingKey string) *PagerDutyNotifier {
+	if routingKey == "" {
+		routingKey = os.Getenv("PAGERDUTY_ROUTING_KEY")
+	}
+	return &PagerDutyNotifier{
+		RoutingKey: routingKey,
+		EventsURL:  PagerDutyEventsURL,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key,omitempty"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp,omitempty"`
+	Component     string            `json:"component,omitempty"`
+	Group         string            `json:"group,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+type pagerDutyResponse struct {
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	DedupKey string `json:"dedup_key"`
+}
+
+// Trigger opens (or updates) the incident for alert and returns its incident
+// key. The alert's deduplication key is used as the PagerDuty dedup_key so
+// duplicates of the same alert land on one incident.
+func (p *PagerDutyNotifier) Trigger(alert *IssueData) (string, error) {
+	dedupKey := alert.DedupKey
+	if dedupKey == "" {
+		dedupKey = alert.ID
+	}
+
+	source := alert.ClusterID
+	if source == "" {
+		source = "alerts-dashboard"
+	}
+	var timestamp string
+	if t, err := time.Parse("2006-01-02 15:04:05 MST", alert.Created); err == nil {
+		timestamp = t.Format(time.RFC3339)
+	}
+
+	return p.enqueue(pagerDutyEvent{
+		EventAction: "trigger",
+		DedupKey:    dedupKey,
+		Payload: &pagerDutyPayload{
+			Summary:   fmt.Sprintf("[%s] %s", alert.Priority, alert.Title),
+			Source:    source,
+			Severity:  PagerDutySeverity(alert.Priority),
+			Timestamp: timestamp,
+			Component: alert.ComponentName,
+			Group:     alert.AlertGroup,
+			CustomDetails: map[string]string{
+				"issue":           alert.ID,
+				"alert_signature": alert.AlertSignature,
+				"cluster_id":      alert.ClusterID,
+				"tenant_id":       alert.TenantID,
+			},
+		},
+	})
+}
+
+// Resolve resolves the incident with incidentKey
+func (p *PagerDutyNotifier) Resolve(incidentKey string) error {
+	_, err := p.enqueue(pagerDutyEvent{EventAction: "resolve", DedupKey: incidentKey})
+	return err
+}
+
+func (p *PagerDutyNotifier) enqueue(event pagerDutyEvent) (string, error) {
+	if p.RoutingKey == "" {
+		return "", errors.New("no PagerDuty routing key configured")
+	}
+	event.RoutingKey = p.RoutingKey
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	resp, err := p.Client.Post(p.EventsURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to notify pagerduty: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result pagerDutyResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != http.StatusAccepted {
+		return "", fmt.Errorf("pagerduty %s returned status %d: %s", event.EventAction, resp.StatusCode, result.Message)
+	}
+	if result.DedupKey == "" {
+		result.DedupKey = event.DedupKey
+	}
+	return result.DedupKey, nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// maxRemoteWriteSize bounds the decompressed size of a remote write request
+const maxRemoteWriteSize = 32 << 20
+
+// ErrRemoteWriteTooLarge is returned by DecodeWriteRequest for requests that
+// decompress to more than 32 MB
+var ErrRemoteWriteTooLarge = errors.New("remote write request too large")
+
+// DecodeWriteRequest decodes the snappy-compressed prompb.WriteRequest sent by
+// Prometheus remote write (protocol 1.0) and returns its time series
+func DecodeWriteRequest(body []byte) ([]prompb.TimeSeries, error) {
+	size, err := snappy.DecodedLen(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy body: %w", err)
+	}
+	if size > maxRemoteWriteSize {
+		return nil, ErrRemoteWriteTooLarge
+	}
+	raw, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy body: %w", err)
+	}
+
+	var req prompb.WriteRequest
+	if err := req.Unmarshal(raw); err != nil {
+		return nil, fmt.Errorf("invalid WriteRequest: %w", err)
+	}
+	return req.Timeseries, nil
+}
+
+// AlertsFromTimeSeries converts the series of Prometheus' ALERTS metric that
+// are firing (alertstate="firing") into alerts. Series without alertname are
+// ignored. Labels are normalized; __name__ and alertstate are dropped. The ID
+// of each alert is "PROM-" and a hash of its labels, the same for every write
+// of the alert. Created is the time of its latest sample.
+func AlertsFromTimeSeries(series []prompb.TimeSeries) []Alert {
+	var alerts []Alert
+	for _, ts := range series {
+		labels := make(map[string]string, len(ts.Labels))
+		for _, l := range ts.Labels {
+			labels[l.Name] = l.Value
+		}
+		if labels["alertname"] == "" || labels["alertstate"] != "firing" {
+			continue
+		}
+		delete(labels, "__name__")
+		delete(labels, "alertstate")
+		labels = Normalize(GetLabelNormalizer(), labels)
+
+		var at time.Time
+		for _, s := range ts.Samples {
+			if t := time.UnixMilli(s.Timestamp); s.Timestamp > 0 && t.After(at) {
+				at = t
+			}
+		}
+		if at.IsZero() {
+			at = time.Now()
+		}
+
+		clusterID := labels["tidb_cluster_id"]
+		if clusterID == "" {
+			clusterID = labels["cluster_id"]
+		}
+		alerts = append(alerts, Alert{
+			ID:        "PROM-" + promLabelsHash(labels),
+			Title:     labels["alertname"],
+			Severity:  labels["severity"],
+			Status:    "firing",
+			ClusterID: clusterID,
+			TenantID:  labels["o11y_tenant_id"],
+			Created:   at.UTC().Format("2006-01-02 15:04:05") + " UTC",
+			Labels:    labels,
+		})
+	}
+	return alerts
+}
+
+// promLabelsHash returns the first 16 hex digits of the SHA-256 of the sorted
+// name=value pairs of labels
+func promLabelsHash(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(labels[name])
+		b.WriteByte(0)
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// encodeWriteRequest encodes series as a snappy-compressed prompb.WriteRequest
+func encodeWriteRequest(t *testing.T, series []prompb.TimeSeries) []byte {
+	t.Helper()
+	raw, err := (&prompb.WriteRequest{Timeseries: series}).Marshal()
+	if err != nil {
+		t.Fatalf("marshal WriteRequest: %v", err)
+	}
+	return snappy.Encode(nil, raw)
+}
+
+func alertSeries(state, cluster string, ts int64) prompb.TimeSeries {
+	return prompb.TimeSeries{
+		Labels: []prompb.Label{
+			{Name: "__name__", Value: "ALERTS"},
+			{Name: "alertname", Value: "TiKVDown"},
+			{Name: "alertstate", Value: state},
+			{Name: "severity", Value: "critical"},
+			{Name: "cluster_id", Value: cluster},
+		},
+		Samples: []prompb.Sample{{Value: 1, Timestamp: ts - 1000}, {Value: 1, Timestamp: ts}},
+	}
+}
+
+func TestDecodeWriteRequest(t *testing.T) {
+	in := []prompb.TimeSeries{alertSeries("firing", "c1", 1767225600000), alertSeries("pending", "c2", 1767225600000)}
+	series, err := DecodeWriteRequest(encodeWriteRequest(t, in))
+	if err != nil {
+		t.Fatalf("DecodeWriteRequest: %v", err)
+	}
+	if len(series) != 2 {
+		t.Fatalf("got %d series, want 2", len(series))
+	}
+	for i := range in {
+		if len(series[i].Labels) != len(in[i].Labels) || len(series[i].Samples) != 2 {
+			t.Fatalf("series %d = %+v, want %+v", i, series[i], in[i])
+		}
+		for j, l := range in[i].Labels {
+			if series[i].Labels[j].Name != l.Name || series[i].Labels[j].Value != l.Value {
+				t.Errorf("series %d label %d = %+v, want %+v", i, j, series[i].Labels[j], l)
+			}
+		}
+		if got := series[i].Samples[1]; got.Value != in[i].Samples[1].Value || got.Timestamp != in[i].Samples[1].Timestamp {
+			t.Errorf("series %d sample = %+v, want %+v", i, series[i].Samples[1], in[i].Samples[1])
+		}
+	}
+}
+
+func TestDecodeWriteRequestInvalid(t *testing.T) {
+	if _, err := DecodeWriteRequest([]byte("not snappy")); err == nil {
+		t.Error("DecodeWriteRequest accepted a body that is not snappy")
+	}
+	if _, err := DecodeWriteRequest(snappy.Encode(nil, []byte{0x0a, 0xff})); err == nil {
+		t.Error("DecodeWriteRequest accepted a truncated protobuf")
+	}
+
+	huge := snappy.Encode(nil, make([]byte, maxRemoteWriteSize+1))
+	if _, err := DecodeWriteRequest(huge); !errors.Is(err, ErrRemoteWriteTooLarge) {
+		t.Errorf("DecodeWriteRequest of a body over the limit: got %v, want ErrRemoteWriteTooLarge", err)
+	}
+}
+
+func TestAlertsFromTimeSeries(t *testing.T) {
+	series := []prompb.TimeSeries{
+		alertSeries("firing", "c1", 1767225600000),
+		alertSeries("pending", "c2", 1767225600000),
+		{Labels: []prompb.Label{{Name: "alertstate", Value: "firing"}}},
+	}
+	alerts := AlertsFromTimeSeries(series)
+	if len(alerts) != 1 {
+		t.Fatalf("got %d alerts, want only the firing one: %+v", len(alerts), alerts)
+	}
+
+	a := alerts[0]
+	if !strings.HasPrefix(a.ID, "PROM-") || len(a.ID) != len("PROM-")+16 {
+		t.Errorf("ID = %q, want PROM- and 16 hex digits", a.ID)
+	}
+	if a.Title != "TiKVDown" || a.Severity != "critical" || a.ClusterID != "c1" || a.Status != "firing" {
+		t.Errorf("alert = %+v", a)
+	}
+	if a.Created != "2026-01-01 00:00:00 UTC" {
+		t.Errorf("Created = %q, want the time of the latest sample", a.Created)
+	}
+	if _, ok := a.Labels["__name__"]; ok {
+		t.Error("__name__ label was kept")
+	}
+	if _, ok := a.Labels["alertstate"]; ok {
+		t.Error("alertstate label was kept")
+	}
+
+	// The ID only depends on the labels, so every write of an alert maps to the same issue
+	later := AlertsFromTimeSeries([]prompb.TimeSeries{alertSeries("firing", "c1", 1767229200000)})
+	if later[0].ID != a.ID {
+		t.Errorf("ID changed between writes: %q, %q", a.ID, later[0].ID)
+	}
+	other := AlertsFromTimeSeries([]prompb.TimeSeries{alertSeries("firing", "c3", 1767225600000)})
+	if other[0].ID == a.ID {
+		t.Error("alerts of different clusters got the same ID")
+	}
+}
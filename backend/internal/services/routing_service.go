@@ -0,0 +1,264 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// Supported RoutingRule channels
+const (
+	ChannelSlack     = "slack"
+	ChannelWebhook   = "webhook"
+	ChannelPagerDuty = "pagerduty"
+	ChannelEmail     = "email"
+)
+
+// RoutingService manages routing rules and delivers alert notifications to the
+// channel of the first rule matching the alert's tenant plan and severity
+type RoutingService struct {
+	DB         *gorm.DB
+	HTTPClient *http.Client
+}
+
+func NewRoutingService(db *gorm.DB) *RoutingService {
+	return &RoutingService{
+		DB:         db,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// channelConfig holds the fields read from RoutingRule.ChannelConfig
+type channelConfig struct {
+	URL             string `json:"url"`
+	RoutingKey      string `json:"routing_key"` // pagerduty only, overrides PAGERDUTY_ROUTING_KEY
+	EmailRecipients        // email only
+}
+
+// ValidateRoutingRule checks the fields required to route with rule
+func ValidateRoutingRule(rule *models.RoutingRule) error {
+	if rule.TenantPlan == "" {
+		return errors.New("tenant_plan is required (use * to match any plan)")
+	}
+	if rule.Severity == "" {
+		return errors.New("severity is required (use * to match any severity)")
+	}
+	switch rule.Channel {
+	case ChannelSlack, ChannelWebhook, ChannelPagerDuty, ChannelEmail:
+	default:
+		return fmt.Errorf("unsupported channel %q, expected %s, %s, %s or %s", rule.Channel, ChannelSlack, ChannelWebhook, ChannelPagerDuty, ChannelEmail)
+	}
+	if rule.Channel == ChannelPagerDuty && len(rule.ChannelConfig) == 0 {
+		return nil
+	}
+	var cfg channelConfig
+	if len(rule.ChannelConfig) == 0 || json.Unmarshal(rule.ChannelConfig, &cfg) != nil {
+		return errors.New("channel_config must be a JSON object")
+	}
+	switch rule.Channel {
+	case ChannelPagerDuty:
+	case ChannelEmail:
+		if len(cfg.To) == 0 {
+			return errors.New("channel_config.to is required")
+		}
+	default:
+		if cfg.URL == "" {
+			return errors.New("channel_config.url is required")
+		}
+	}
+	return nil
+}
+
+// ListRules returns all rules in evaluation order
+func (s *RoutingService) ListRules() ([]models.RoutingRule, error) {
+	var rules []models.RoutingRule
+	if err := s.DB.Order("priority asc, id asc").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (s *RoutingService) CreateRule(rule *models.RoutingRule) error {
+	if err := ValidateRoutingRule(rule); err != nil {
+		return err
+	}
+	return s.DB.Create(rule).Error
+}
+
+// UpdateRule replaces the rule with rule.ID. Returns gorm.ErrRecordNotFound if it does not exist.
+func (s *RoutingService) UpdateRule(rule *models.RoutingRule) error {
+	if err := ValidateRoutingRule(rule); err != nil {
+		return err
+	}
+	var existing models.RoutingRule
+	if err := s.DB.First(&existing, rule.ID).Error; err != nil {
+		return err
+	}
+	rule.CreatedAt = existing.CreatedAt
+	return s.DB.Save(rule).Error
+}
+
+// DeleteRule removes a rule. Returns gorm.ErrRecordNotFound if it does not exist.
+func (s *RoutingService) DeleteRule(id uint) error {
+	result := s.DB.Delete(&models.RoutingRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// MatchRoutingRule returns the enabled rule with the lowest priority whose
+// tenant plan and severity match, treating "*" as a wildcard. Matching is
+// case-insensitive. Returns nil if no rule matches.
+func MatchRoutingRule(rules []models.RoutingRule, tenantPlan, severity string) *models.RoutingRule {
+	sorted := make([]models.RoutingRule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+
+	for i := range sorted {
+		rule := &sorted[i]
+		if !rule.Enabled {
+			continue
+		}
+		if matchesRoutingValue(rule.TenantPlan, tenantPlan) && matchesRoutingValue(rule.Severity, severity) {
+			return rule
+		}
+	}
+	return nil
+}
+
+func matchesRoutingValue(pattern, value string) bool {
+	return pattern == "*" || strings.EqualFold(pattern, value)
+}
+
+// RouteAlert looks up the tenant plan of the alert's cluster, picks the matching
+// rule and sends the notification. Alerts without a matching rule are ignored.
+func (s *RoutingService) RouteAlert(alert *IssueData) error {
+	plan := ""
+	if alert.ClusterID != "" {
+		if info, err := GetNameResolver().ResolveCluster(alert.ClusterID); err == nil {
+			plan = info.TenantPlan
+		}
+	}
+
+	rule, err := s.matchAlert(alert, plan)
+	if err != nil || rule == nil {
+		return err
+	}
+	return s.send(rule, alert, plan)
+}
+
+// ResolveAlert resolves the PagerDuty incident opened for a resolved alert,
+// using the routing key of the pagerduty rule the alert matches, if any
+func (s *RoutingService) ResolveAlert(alert *IssueData, incidentKey string) error {
+	plan := ""
+	if alert.ClusterID != "" {
+		if info, err := GetNameResolver().ResolveCluster(alert.ClusterID); err == nil {
+			plan = info.TenantPlan
+		}
+	}
+
+	rule, err := s.matchAlert(alert, plan)
+	if err != nil {
+		return err
+	}
+	return s.pagerDuty(rule).Resolve(incidentKey)
+}
+
+func (s *RoutingService) matchAlert(alert *IssueData, plan string) (*models.RoutingRule, error) {
+	rules, err := s.ListRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routing rules: %w", err)
+	}
+	return MatchRoutingRule(rules, plan, alert.Priority), nil
+}
+
+// pagerDuty returns a notifier using rule's routing key, or the default key
+// when rule is nil, not a pagerduty rule or has no override
+func (s *RoutingService) pagerDuty(rule *models.RoutingRule) *PagerDutyNotifier {
+	var cfg channelConfig
+	if rule != nil && rule.Channel == ChannelPagerDuty && len(rule.ChannelConfig) > 0 {
+		json.Unmarshal(rule.ChannelConfig, &cfg)
+	}
+	pd := NewPagerDutyNotifier(cfg.RoutingKey)
+	pd.Client = s.HTTPClient
+	return pd
+}
+
+// triggerPagerDuty opens an incident for alert and records its incident key
+func (s *RoutingService) triggerPagerDuty(rule *models.RoutingRule, alert *IssueData) error {
+	incidentKey, err := s.pagerDuty(rule).Trigger(alert)
+	if err != nil {
+		return err
+	}
+	return s.DB.Model(&models.Issue{}).Where("id = ?", alert.ID).Update("pd_incident_key", incidentKey).Error
+}
+
+// send delivers alert to the rule's channel
+func (s *RoutingService) send(rule *models.RoutingRule, alert *IssueData, plan string) error {
+	if rule.Channel == ChannelPagerDuty {
+		return s.triggerPagerDuty(rule, alert)
+	}
+
+	var cfg channelConfig
+	if err := json.Unmarshal(rule.ChannelConfig, &cfg); err != nil {
+		return fmt.Errorf("routing rule %d has an invalid channel_config", rule.ID)
+	}
+	if rule.Channel == ChannelEmail {
+		smtpNotifier := GetSMTPNotifier()
+		if smtpNotifier == nil {
+			return fmt.Errorf("routing rule %d uses email but SMTP_HOST is not set", rule.ID)
+		}
+		return smtpNotifier.Send(alertFromData(alert), cfg.EmailRecipients)
+	}
+	if cfg.URL == "" {
+		return fmt.Errorf("routing rule %d has an invalid channel_config", rule.ID)
+	}
+
+	var payload interface{}
+	switch rule.Channel {
+	case ChannelSlack:
+		payload = map[string]string{
+			"text": fmt.Sprintf("[%s] %s (cluster %s, plan %s) %s", alert.Priority, alert.Title, alert.ClusterID, plan, alert.ID),
+		}
+	case ChannelWebhook:
+		payload = map[string]string{
+			"id":          alert.ID,
+			"title":       alert.Title,
+			"severity":    alert.Priority,
+			"cluster_id":  alert.ClusterID,
+			"tenant_id":   alert.TenantID,
+			"tenant_plan": plan,
+			"created":     alert.Created,
+		}
+	default:
+		return fmt.Errorf("routing rule %d has unsupported channel %q", rule.ID, rule.Channel)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := s.HTTPClient.Post(cfg.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to notify %s: %w", rule.Channel, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s notification returned status %d", rule.Channel, resp.StatusCode)
+	}
+	return nil
+}
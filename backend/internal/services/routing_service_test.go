@@ -0,0 +1,99 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+func TestMatchRoutingRule(t *testing.T) {
+	rules := []models.RoutingRule{
+		{ID: 1, Priority: 100, TenantPlan: "*", Severity: "*", Enabled: true},
+		{ID: 2, Priority: 10, TenantPlan: "enterprise", Severity: "Critical", Enabled: true},
+		{ID: 3, Priority: 20, TenantPlan: "enterprise", Severity: "*", Enabled: true},
+		{ID: 4, Priority: 5, TenantPlan: "free", Severity: "*", Enabled: false},
+		{ID: 5, Priority: 30, TenantPlan: "*", Severity: "Critical", Enabled: true},
+		// Same priority as 5: the earlier rule wins
+		{ID: 6, Priority: 30, TenantPlan: "developer", Severity: "Critical", Enabled: true},
+	}
+
+	for _, tc := range []struct {
+		plan, severity string
+		want           uint
+	}{
+		{"enterprise", "Critical", 2},
+		{"Enterprise", "critical", 2},
+		{"enterprise", "Warning", 3},
+		{"developer", "Critical", 5},
+		{"free", "Major", 1},
+		{"", "", 1},
+	} {
+		got := MatchRoutingRule(rules, tc.plan, tc.severity)
+		if got == nil || got.ID != tc.want {
+			t.Errorf("MatchRoutingRule(%q, %q) = %+v, want rule %d", tc.plan, tc.severity, got, tc.want)
+		}
+	}
+
+	if got := MatchRoutingRule(rules[1:4], "free", "Major"); got != nil {
+		t.Errorf("only a disabled rule matches, got %+v", got)
+	}
+	if got := MatchRoutingRule(nil, "free", "Major"); got != nil {
+		t.Errorf("no rules, got %+v", got)
+	}
+	// The rules are matched on a copy and keep their order
+	if rules[0].ID != 1 || rules[1].ID != 2 {
+		t.Errorf("rules were reordered: %+v", rules)
+	}
+}
+
+func TestRouteAlertByTenantPlan(t *testing.T) {
+	conn := openTestTiDB(t)
+	seedTestTiDB(t, conn)
+	if _, err := conn.Exec(`UPDATE clusters SET tenant_plan = 'enterprise' WHERE cluster_id = '2001'`); err != nil {
+		t.Fatalf("set tenant plan: %v", err)
+	}
+	useNameResolver(t)
+	sqliteDB := openTestDB(t)
+
+	got := make(chan map[string]string, 2)
+	hook := func(name string) string {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload map[string]string
+			json.NewDecoder(r.Body).Decode(&payload)
+			payload["hook"] = name
+			got <- payload
+		}))
+		t.Cleanup(srv.Close)
+		return `{"url": "` + srv.URL + `"}`
+	}
+	s := NewRoutingService(sqliteDB)
+	for _, rule := range []models.RoutingRule{
+		{Priority: 10, TenantPlan: "enterprise", Severity: "Critical", Channel: ChannelWebhook, ChannelConfig: json.RawMessage(hook("enterprise")), Enabled: true},
+		{Priority: 20, TenantPlan: "*", Severity: "*", Channel: ChannelWebhook, ChannelConfig: json.RawMessage(hook("default")), Enabled: true},
+	} {
+		if err := s.CreateRule(&rule); err != nil {
+			t.Fatalf("CreateRule: %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		cluster, severity, hook, plan string
+	}{
+		{"2001", "Critical", "enterprise", "enterprise"},
+		{"2001", "Major", "default", "enterprise"},
+		// Unknown clusters have no plan and only match the wildcard
+		{"9999", "Critical", "default", ""},
+	} {
+		alert := &IssueData{ID: "A-1", Title: "TiKV down", Priority: tc.severity, ClusterID: tc.cluster}
+		if err := s.RouteAlert(alert); err != nil {
+			t.Fatalf("RouteAlert(%s, %s): %v", tc.cluster, tc.severity, err)
+		}
+		payload := <-got
+		if payload["hook"] != tc.hook || payload["tenant_plan"] != tc.plan || payload["severity"] != tc.severity {
+			t.Errorf("%s %s routed as %v, want the %s hook with plan %q", tc.cluster, tc.severity, payload, tc.hook, tc.plan)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownSeverity is the severity of alerts whose severity is not in the map
+const UnknownSeverity = "unknown"
+
+// SeverityMapper maps the severities of the different monitoring systems (P1,
+// CRITICAL, sev1, ...) to the canonical ones. Matching is case-insensitive, and
+// a canonical severity maps to itself.
+type SeverityMapper struct {
+	path    string
+	entries map[string]string // lowercased severity -> canonical severity
+
+	unknownSeen sync.Map // unknown severities already logged
+}
+
+// NewSeverityMapper builds a mapper from custom severity -> canonical severity
+func NewSeverityMapper(mapping map[string]string) (*SeverityMapper, error) {
+	m := &SeverityMapper{entries: make(map[string]string, 2*len(mapping))}
+	for from, to := range mapping {
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if from == "" || to == "" {
+			return nil, fmt.Errorf("invalid severity mapping %q: %q", from, to)
+		}
+		key := strings.ToLower(from)
+		if existing, ok := m.entries[key]; ok && existing != to {
+			return nil, fmt.Errorf("severity %q is mapped to both %q and %q", from, existing, to)
+		}
+		m.entries[key] = to
+	}
+	for _, to := range mapping {
+		to = strings.TrimSpace(to)
+		if _, ok := m.entries[strings.ToLower(to)]; !ok {
+			m.entries[strings.ToLower(to)] = to
+		}
+	}
+	return m, nil
+}
+
+// LoadSeverityMapFile reads the mapping from a YAML file of the form
+//
+//	P1: Critical
+//	sev1: Critical
+//	P2: Major
+func LoadSeverityMapFile(path string) (*SeverityMapper, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read severity map config: %w", err)
+	}
+	var mapping map[string]string
+	if err := yaml.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse severity map config %s: %w", path, err)
+	}
+	m, err := NewSeverityMapper(mapping)
+	if err != nil {
+		return nil, fmt.Errorf("invalid severity map config %s: %w", path, err)
+	}
+	m.path = path
+	return m, nil
+}
+
+// Map returns the canonical severity of severity, or UnknownSeverity when it is
+// not in the map. Empty severities are kept, as are all severities when no map
+// is configured.
+func (m *SeverityMapper) Map(severity string) string {
+	if m == nil || len(m.entries) == 0 || severity == "" {
+		return severity
+	}
+	if canonical, ok := m.entries[strings.ToLower(strings.TrimSpace(severity))]; ok {
+		return canonical
+	}
+	if _, logged := m.unknownSeen.LoadOrStore(severity, struct{}{}); !logged {
+		log.Printf("[WARN] Unknown alert severity %q, normalized to %q\n", severity, UnknownSeverity)
+	}
+	return UnknownSeverity
+}
+
+// Path returns the file the mapper was loaded from
+func (m *SeverityMapper) Path() string {
+	if m == nil {
+		return ""
+	}
+	return m.path
+}
+
+// Len returns the number of severities the mapper knows, canonical ones included
+func (m *SeverityMapper) Len() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.entries)
+}
+
+var (
+	severityMapperOnce     sync.Once
+	severityMapperInstance atomic.Pointer[SeverityMapper]
+)
+
+// GetSeverityMapper returns the mapper configured by the YAML file in
+// SEVERITY_MAP_CONFIG, or config/severity_map.yaml if present. Without a
+// config severities are kept as is.
+func GetSeverityMapper() *SeverityMapper {
+	severityMapperOnce.Do(func() {
+		m, err := loadSeverityMapper()
+		if err != nil {
+			log.Printf("⚠️  %v", err)
+		} else if m != nil {
+			log.Printf("✅ Loaded %d severity mappings from %s", m.Len(), m.path)
+		}
+		severityMapperInstance.Store(m)
+	})
+	return severityMapperInstance.Load()
+}
+
+// ReloadSeverityMapper reads the severity map config again and swaps it in. The
+// current mapper is kept when the config is invalid.
+func ReloadSeverityMapper() (*SeverityMapper, error) {
+	GetSeverityMapper()
+	m, err := loadSeverityMapper()
+	if err != nil {
+		return severityMapperInstance.Load(), err
+	}
+	severityMapperInstance.Store(m)
+	if m != nil {
+		log.Printf("✅ Reloaded %d severity mappings from %s", m.Len(), m.path)
+	}
+	return m, nil
+}
+
+// loadSeverityMapper returns nil without error when no config exists
+func loadSeverityMapper() (*SeverityMapper, error) {
+	paths := []string{"../config/severity_map.yaml", "../../config/severity_map.yaml", "config/severity_map.yaml"}
+	if p := os.Getenv("SEVERITY_MAP_CONFIG"); p != "" {
+		paths = []string{p}
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		return LoadSeverityMapFile(path)
+	}
+	return nil, nil
+}
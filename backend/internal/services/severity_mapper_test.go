@@ -0,0 +1,103 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSeverityMapper(t *testing.T) {
+	m, err := NewSeverityMapper(map[string]string{"P1": "critical", "sev1": "critical", " P2 ": "major", "WARN": "warning"})
+	if err != nil {
+		t.Fatalf("NewSeverityMapper: %v", err)
+	}
+	for in, want := range map[string]string{
+		"P1": "critical", "p1": "critical", "SEV1": "critical", " Sev1 ": "critical",
+		"p2": "major", "warn": "warning",
+		// Canonical severities map to themselves, whatever their case
+		"critical": "critical", "CRITICAL": "critical", "Major": "major",
+		// Unknown severities are normalized, empty ones kept
+		"P5": UnknownSeverity, "info": UnknownSeverity, "": "",
+	} {
+		if got := m.Map(in); got != want {
+			t.Errorf("Map(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if m.Len() != 7 {
+		t.Errorf("Len = %d, want 4 mappings and 3 canonical severities", m.Len())
+	}
+
+	// Without a map severities are kept as is
+	var none *SeverityMapper
+	if got := none.Map("P1"); got != "P1" {
+		t.Errorf("nil mapper mapped P1 to %q", got)
+	}
+	empty, _ := NewSeverityMapper(nil)
+	if got := empty.Map("P1"); got != "P1" {
+		t.Errorf("empty mapper mapped P1 to %q", got)
+	}
+}
+
+func TestNewSeverityMapperErrors(t *testing.T) {
+	for _, mapping := range []map[string]string{
+		{"": "critical"},
+		{"P1": " "},
+		{"P1": "critical", "p1": "major"},
+	} {
+		if _, err := NewSeverityMapper(mapping); err == nil {
+			t.Errorf("mapping %v accepted", mapping)
+		}
+	}
+	// The same severity in different cases may map to the same target
+	if _, err := NewSeverityMapper(map[string]string{"P1": "critical", "p1": "critical"}); err != nil {
+		t.Errorf("duplicate mapping to the same severity: %v", err)
+	}
+}
+
+func TestReloadSeverityMapper(t *testing.T) {
+	prev := GetSeverityMapper()
+	t.Cleanup(func() { severityMapperInstance.Store(prev) })
+
+	path := filepath.Join(t.TempDir(), "severity_map.yaml")
+	t.Setenv("SEVERITY_MAP_CONFIG", path)
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("P1: critical\nP2: major\n")
+	m, err := ReloadSeverityMapper()
+	if err != nil || m.Path() != path {
+		t.Fatalf("ReloadSeverityMapper = %v, %v", m, err)
+	}
+	if got := GetSeverityMapper().Map("p2"); got != "major" {
+		t.Errorf("p2 = %q, want major", got)
+	}
+
+	// The new map is used by the running process
+	write("P1: critical\nP2: warning\nsev3: info\n")
+	if _, err := ReloadSeverityMapper(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := GetSeverityMapper().Map("P2"); got != "warning" {
+		t.Errorf("P2 after reload = %q, want warning", got)
+	}
+	if got := GetSeverityMapper().Map("SEV3"); got != "info" {
+		t.Errorf("SEV3 after reload = %q, want info", got)
+	}
+
+	// An invalid config is rejected and the current map kept
+	write("P1: critical\np1: major\n")
+	if m, err := ReloadSeverityMapper(); err == nil || m != GetSeverityMapper() {
+		t.Errorf("invalid config: %v, %v, want an error and the current mapper", m, err)
+	}
+	if got := GetSeverityMapper().Map("P2"); got != "warning" {
+		t.Errorf("P2 after a failed reload = %q, want warning", got)
+	}
+	write("- P1\n")
+	if _, err := ReloadSeverityMapper(); err == nil {
+		t.Error("config that is not a map reloaded")
+	}
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+// Matcher is one Alertmanager style silence matcher. Regex values are anchored
+// at both ends; IsEqual false negates the match and defaults to true.
+type Matcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual *bool  `json:"isEqual,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// Matches reports whether labels satisfy the matcher. A missing label is
+// treated as an empty value, as in Alertmanager.
+func (m *Matcher) Matches(labels map[string]string) bool {
+	value := labels[m.Name]
+	var matched bool
+	if m.IsRegex {
+		matched = m.re != nil && m.re.MatchString(value)
+	} else {
+		matched = value == m.Value
+	}
+	if m.IsEqual != nil && !*m.IsEqual {
+		return !matched
+	}
+	return matched
+}
+
+// ParseMatchers decodes and validates the matchers of a silence
+func ParseMatchers(raw json.RawMessage) ([]Matcher, error) {
+	var matchers []Matcher
+	if err := json.Unmarshal(raw, &matchers); err != nil {
+		return nil, fmt.Errorf("matchers must be a JSON array: %w", err)
+	}
+	if len(matchers) == 0 {
+		return nil, errors.New("at least one matcher is required")
+	}
+	for i := range matchers {
+		m := &matchers[i]
+		if m.Name == "" {
+			return nil, fmt.Errorf("matcher %d: name is required", i)
+		}
+		if m.IsRegex {
+			re, err := regexp.Compile("^(?:" + m.Value + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("matcher %d: invalid regex: %w", i, err)
+			}
+			m.re = re
+		}
+	}
+	return matchers, nil
+}
+
+// AlertLabels returns the label set silences are matched against: the alert's
+// own fields plus any "key=value" or "key:value" JIRA labels
+func AlertLabels(issue *models.Issue) map[string]string {
+	labels := map[string]string{
+		"alertname":        issue.AlertSignature,
+		"severity":         issue.Priority,
+		"status":           issue.Status,
+		"cluster_id":       issue.ClusterID,
+		"tenant_id":        issue.TenantID,
+		"biz_type":         issue.BizType,
+		"component":        issue.ComponentName,
+		"source_component": issue.SourceComponent,
+		"alertgroup":       issue.AlertGroup,
+	}
+
+	var jiraLabels []string
+	if json.Unmarshal([]byte(issue.Labels), &jiraLabels) == nil {
+		for _, l := range jiraLabels {
+			if k, v, ok := strings.Cut(l, "="); ok {
+				labels[k] = v
+			} else if k, v, ok := strings.Cut(l, ":"); ok {
+				labels[k] = v
+			}
+		}
+	}
+	return labels
+}
+
+// SilenceService manages silences and evaluates them against alerts
+type SilenceService struct {
+	DB *gorm.DB
+}
+
+func NewSilenceService(db *gorm.DB) *SilenceService {
+	return &SilenceService{DB: db}
+}
+
+// ListSilences returns all silences, newest first
+func (s *SilenceService) ListSilences() ([]models.Silence, error) {
+	var silences []models.Silence
+	if err := s.DB.Order("id desc").Find(&silences).Error; err != nil {
+		return nil, err
+	}
+	return silences, nil
+}
+
+// CreateSilence validates and stores silence. StartsAt defaults to now.
+func (s *SilenceService) CreateSilence(silence *models.Silence) error {
+	if _, err := ParseMatchers(silence.Matchers); err != nil {
+		return err
+	}
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = time.Now().UTC()
+	}
+	if !silence.EndsAt.After(silence.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	return s.DB.Create(silence).Error
+}
+
+// ExpireSilence ends a silence now. Returns gorm.ErrRecordNotFound if it does not exist.
+func (s *SilenceService) ExpireSilence(id uint) (*models.Silence, error) {
+	var silence models.Silence
+	if err := s.DB.First(&silence, id).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if silence.EndsAt.After(now) {
+		silence.EndsAt = now
+		if silence.StartsAt.After(now) {
+			silence.StartsAt = now
+		}
+		if err := s.DB.Save(&silence).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &silence, nil
+}
+
+// activeSilence is a silence active at the time it was loaded with its parsed matchers
+type activeSilence struct {
+	silence  models.Silence
+	matchers []Matcher
+}
+
+// activeSilences returns every silence active at now. Silences whose matchers
+// no longer parse are skipped.
+func (s *SilenceService) activeSilences(now time.Time) ([]activeSilence, error) {
+	var silences []models.Silence
+	if err := s.DB.Where("starts_at <= ? AND ends_at > ?", now.UTC(), now.UTC()).Order("id asc").Find(&silences).Error; err != nil {
+		return nil, err
+	}
+	active := make([]activeSilence, 0, len(silences))
+	for _, silence := range silences {
+		if matchers, err := ParseMatchers(silence.Matchers); err == nil {
+			active = append(active, activeSilence{silence: silence, matchers: matchers})
+		}
+	}
+	return active, nil
+}
+
+// SilencedBy returns the first silence active at now matching labels, or nil
+func (s *SilenceService) SilencedBy(labels map[string]string, now time.Time) (*models.Silence, error) {
+	active, err := s.activeSilences(now)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range active {
+		if matchAll(a.matchers, labels) {
+			return &a.silence, nil
+		}
+	}
+	return nil, nil
+}
+
+// MarkSilenced sets Silenced on each issue matched by a silence active at now
+func (s *SilenceService) MarkSilenced(issues []models.Issue, now time.Time) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	active, err := s.activeSilences(now)
+	if err != nil {
+		return err
+	}
+	if len(active) == 0 {
+		return nil
+	}
+
+	for i := range issues {
+		labels := AlertLabels(&issues[i])
+		for _, a := range active {
+			if matchAll(a.matchers, labels) {
+				issues[i].Silenced = true
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// MatchingAlerts returns the unresolved alerts created since since that
+// silence matches
+func (s *SilenceService) MatchingAlerts(silence *models.Silence, since time.Time) ([]models.Issue, error) {
+	matchers, err := ParseMatchers(silence.Matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []models.Issue
+	err = s.DB.Where("is_alert = 1 AND deleted_at IS NULL AND REPLACE(created, ' UTC', '') >= ?", since.UTC().Format("2006-01-02 15:04:05")).
+		Find(&issues).Error
+	if err != nil {
+		return nil, err
+	}
+
+	matching := issues[:0]
+	for i := range issues {
+		if !isResolvedStatus(issues[i].Status) && matchAll(matchers, AlertLabels(&issues[i])) {
+			matching = append(matching, issues[i])
+		}
+	}
+	return matching, nil
+}
+
+func matchAll(matchers []Matcher, labels map[string]string) bool {
+	for i := range matchers {
+		if !matchers[i].Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+func TestMatcherSemantics(t *testing.T) {
+	labels := map[string]string{"alertname": "TiKVDown", "severity": "critical", "cluster_id": "10001"}
+	tests := []struct {
+		name     string
+		matchers string
+		want     bool
+	}{
+		{"= matches", `[{"name":"severity","value":"critical"}]`, true},
+		{"= is exact", `[{"name":"severity","value":"crit"}]`, false},
+		{"= is case sensitive", `[{"name":"severity","value":"Critical"}]`, false},
+		{"!= excludes the value", `[{"name":"severity","value":"critical","isEqual":false}]`, false},
+		{"!= matches other values", `[{"name":"severity","value":"warning","isEqual":false}]`, true},
+		{"=~ matches", `[{"name":"alertname","value":"TiKV.*","isRegex":true}]`, true},
+		{"=~ is anchored", `[{"name":"alertname","value":"KV","isRegex":true}]`, false},
+		{"=~ alternatives are anchored", `[{"name":"alertname","value":"TiDBDown|TiKV","isRegex":true}]`, false},
+		{"!~ negates the regex", `[{"name":"alertname","value":"TiKV.*","isRegex":true,"isEqual":false}]`, false},
+		{"missing labels are empty", `[{"name":"region","value":""}]`, true},
+		{"missing labels match !=", `[{"name":"region","value":"us-east-1","isEqual":false}]`, true},
+		{"every matcher must match", `[{"name":"severity","value":"critical"},{"name":"cluster_id","value":"10002"}]`, false},
+		{"all matchers match", `[{"name":"severity","value":"critical"},{"name":"cluster_id","value":"100.*","isRegex":true}]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matchers, err := ParseMatchers(json.RawMessage(tt.matchers))
+			if err != nil {
+				t.Fatalf("ParseMatchers: %v", err)
+			}
+			if got := matchAll(matchers, labels); got != tt.want {
+				t.Errorf("%s against %v = %v, want %v", tt.matchers, labels, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMatchersInvalid(t *testing.T) {
+	for _, raw := range []string{
+		`{"name":"severity"}`,
+		`[]`,
+		`[{"value":"critical"}]`,
+		`[{"name":"alertname","value":"(","isRegex":true}]`,
+	} {
+		if _, err := ParseMatchers(json.RawMessage(raw)); err == nil {
+			t.Errorf("ParseMatchers(%s) succeeded", raw)
+		}
+	}
+}
+
+func createSilence(t *testing.T, s *SilenceService, matchers string, start, end time.Time) *models.Silence {
+	t.Helper()
+	silence := &models.Silence{Matchers: json.RawMessage(matchers), StartsAt: start, EndsAt: end}
+	if err := s.CreateSilence(silence); err != nil {
+		t.Fatalf("CreateSilence: %v", err)
+	}
+	return silence
+}
+
+func TestSilenceExpiry(t *testing.T) {
+	s := NewSilenceService(openTestDB(t))
+	now := time.Now().UTC()
+	labels := map[string]string{"alertname": "TiKVDown", "cluster_id": "10001"}
+
+	active := createSilence(t, s, `[{"name":"cluster_id","value":"10001"}]`, now.Add(-time.Hour), now.Add(time.Hour))
+	next := createSilence(t, s, `[{"name":"alertname","value":"TiKV.*","isRegex":true}]`, now.Add(time.Hour), now.Add(2*time.Hour))
+
+	for _, tt := range []struct {
+		name string
+		at   time.Time
+		want uint // ID of the silence, 0 for none
+	}{
+		{"before any silence", now.Add(-2 * time.Hour), 0},
+		{"while active", now, active.ID},
+		{"at the end", now.Add(time.Hour), next.ID}, // ends_at is exclusive, the next one starts
+		{"after both", now.Add(3 * time.Hour), 0},
+	} {
+		silence, err := s.SilencedBy(labels, tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got uint
+		if silence != nil {
+			got = silence.ID
+		}
+		if got != tt.want {
+			t.Errorf("%s: silenced by %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	// Expiring a silence ends it now
+	expired, err := s.ExpireSilence(active.ID)
+	if err != nil || expired.EndsAt.After(time.Now().UTC()) {
+		t.Fatalf("ExpireSilence = %+v, %v", expired, err)
+	}
+	if silence, _ := s.SilencedBy(labels, time.Now().UTC()); silence != nil {
+		t.Errorf("silenced by %d after expiring", silence.ID)
+	}
+	if _, err := s.ExpireSilence(999); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("ExpireSilence of a missing silence: %v", err)
+	}
+
+	if err := s.CreateSilence(&models.Silence{Matchers: json.RawMessage(`[{"name":"a","value":"b"}]`), StartsAt: now, EndsAt: now}); err == nil {
+		t.Error("created a silence ending when it starts")
+	}
+}
+
+func TestMarkSilenced(t *testing.T) {
+	sqlite := openTestDB(t)
+	s := NewSilenceService(sqlite)
+	now := time.Now().UTC()
+	createSilence(t, s, `[{"name":"severity","value":"Critical"},{"name":"team","value":"storage"}]`, now.Add(-time.Hour), now.Add(time.Hour))
+
+	issues := []models.Issue{
+		seedAlert(t, sqlite, "A-1", time.Minute, func(i *models.Issue) { i.Labels = `["team=storage"]` }),
+		seedAlert(t, sqlite, "A-2", time.Minute, func(i *models.Issue) { i.Labels = `["team:storage"]` }),
+		seedAlert(t, sqlite, "A-3", time.Minute, func(i *models.Issue) { i.Labels = `["team=compute"]` }),
+		seedAlert(t, sqlite, "A-4", time.Minute, func(i *models.Issue) { i.Priority = "Major"; i.Labels = `["team=storage"]` }),
+	}
+	if err := s.MarkSilenced(issues, now); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{true, true, false, false} {
+		if issues[i].Silenced != want {
+			t.Errorf("%s silenced = %v, want %v", issues[i].ID, issues[i].Silenced, want)
+		}
+	}
+
+	// Once the silence has ended nothing is silenced
+	issues[0].Silenced = false
+	if err := s.MarkSilenced(issues[:1], now.Add(2*time.Hour)); err != nil || issues[0].Silenced {
+		t.Errorf("silenced after the silence ended: %v, %v", issues[0].Silenced, err)
+	}
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+// DefaultSlackTemplate is used when a Slack channel has no template
+const DefaultSlackTemplate = `*[{{.Severity}}] {{.Alert.Title}}*
+Cluster: {{if .NameInfo.Name}}{{.NameInfo.Name}}{{else}}{{.Alert.ClusterID}}{{end}}{{if .NameInfo.TenantName}} (tenant {{.NameInfo.TenantName}}){{end}}
+{{- if .SilencedBy}}
+Silenced by #{{.SilencedBy.ID}}{{if .SilencedBy.Comment}}: {{.SilencedBy.Comment}}{{end}}
+{{- end}}
+{{- if .DashboardURL}}
+<{{.DashboardURL}}|Open in dashboard>
+{{- end}}`
+
+// SlackMessageData is the data passed to a SlackNotifier template
+type SlackMessageData struct {
+	Alert        Alert
+	NameInfo     NameInfo // resolved cluster (or tenant) name of the alert
+	Severity     string
+	SilencedBy   *models.Silence // active silence matching the alert, nil if none
+	DashboardURL string
+}
+
+// slackMentions maps severities to the mention prepended to the message
+var slackMentions = map[string]string{
+	"Critical": "<!channel>",
+	"Major":    "<!here>",
+}
+
+// slackLimiter paces messages to all Slack webhooks at one per second
+var slackLimiter = newTokenBucket(1, time.Second)
+
+// slackRequestTimeout bounds a single post to a Slack webhook
+const slackRequestTimeout = 30 * time.Second
+
+// SlackNotifier posts alerts to a Slack Incoming Webhook, formatting the
+// message with a text/template
+type SlackNotifier struct {
+	WebhookURL string
+	Template   string // defaults to DefaultSlackTemplate
+
+	Client *http.Client
+
+	tmpl *template.Template
+}
+
+// NewSlackNotifier parses tmpl and returns a notifier for webhookURL
+func NewSlackNotifier(webhookURL, tmpl string) (*SlackNotifier, error) {
+	if tmpl == "" {
+		tmpl = DefaultSlackTemplate
+	}
+	parsed, err := template.New("slack").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid slack template: %w", err)
+	}
+	return &SlackNotifier{
+		WebhookURL: webhookURL,
+		Template:   tmpl,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		tmpl:       parsed,
+	}, nil
+}
+
+// Send renders the template for alert and posts it to Slack
+func (s *SlackNotifier) Send(alert Alert) error {
+	text, err := s.Render(slackMessageData(alert))
+	if err != nil {
+		return err
+	}
+	if mention := slackMentions[alert.Severity]; mention != "" {
+		text = mention + " " + text
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	// Waiting for a turn behind a burst of messages does not count against
+	// the request timeout
+	if err := slackLimiter.wait(context.Background()); err != nil {
+		return fmt.Errorf("slack rate limit: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), slackRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to notify slack: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack notification returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Render executes the notifier's template with data
+func (s *SlackNotifier) Render(data SlackMessageData) (string, error) {
+	var buf bytes.Buffer
+	if err := s.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render slack template: %w", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// slackMessageData resolves the names, silence and dashboard link of alert.
+// Lookup failures leave the corresponding fields empty.
+func slackMessageData(alert Alert) SlackMessageData {
+	data := SlackMessageData{Alert: alert, Severity: alert.Severity}
+
+	id := alert.ClusterID
+	if id == "" {
+		id = alert.TenantID
+	}
+	if id != "" {
+		if info, err := GetNameService().Resolve(id); err == nil {
+			data.NameInfo = info
+		}
+	}
+
+	if silence, err := NewSilenceService(db.DB).SilencedBy(alert.Labels, time.Now()); err == nil {
+		data.SilencedBy = silence
+	}
+
+	data.DashboardURL = alertDashboardURL(alert.ID)
+	return data
+}
+
+// alertDashboardURL links to the alert in the dashboard at DASHBOARD_URL, or
+// returns "" when it is not configured
+func alertDashboardURL(id string) string {
+	base := os.Getenv("DASHBOARD_URL")
+	if base == "" || id == "" {
+		return ""
+	}
+	return strings.TrimRight(base, "/") + "/?alert=" + url.QueryEscape(id)
+}
+
+// tokenBucket is a minimal token bucket holding at most capacity tokens,
+// refilled one token per interval
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity int
+	tokens   int
+	interval time.Duration
+	last     time.Time
+}
+
+func newTokenBucket(capacity int, interval time.Duration) *tokenBucket {
+	return &tokenBucket{capacity: capacity, tokens: capacity, interval: interval, last: time.Now()}
+}
+
+// wait blocks until a token is available or ctx is done
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		if refill := int(now.Sub(b.last) / b.interval); refill > 0 {
+			b.tokens = min(b.capacity, b.tokens+refill)
+			b.last = b.last.Add(time.Duration(refill) * b.interval)
+			if b.tokens == b.capacity {
+				b.last = now
+			}
+		}
+		if b.tokens > 0 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		delay := b.interval - now.Sub(b.last)
+		b.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlackNotifierSend(t *testing.T) {
+	openTestDB(t)
+	useNameService(t, stubNames{"c1": {Type: "cluster", ID: "c1", Name: "prod-east", TenantName: "acme"}})
+
+	var got map[string]string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode slack payload: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	n, err := NewSlackNotifier(srv.URL, "")
+	if err != nil {
+		t.Fatalf("NewSlackNotifier: %v", err)
+	}
+	alert := Alert{ID: "A-1", Title: "TiKV down", Severity: "Critical", ClusterID: "c1"}
+	if err := n.Send(alert); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	want := "<!channel> *[Critical] TiKV down*\nCluster: prod-east (tenant acme)"
+	if got["text"] != want {
+		t.Errorf("text = %q, want %q", got["text"], want)
+	}
+
+	status = http.StatusInternalServerError
+	if err := n.Send(alert); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Send to a failing webhook: got %v, want the status in the error", err)
+	}
+}
+
+func TestSlackNotifierRender(t *testing.T) {
+	if _, err := NewSlackNotifier("http://slack.invalid", "{{.Alert.Title"); err == nil {
+		t.Fatal("NewSlackNotifier accepted an invalid template")
+	}
+
+	n, err := NewSlackNotifier("http://slack.invalid", "{{.Severity}}: {{.Alert.Title}} on {{.NameInfo.Name}}")
+	if err != nil {
+		t.Fatalf("NewSlackNotifier: %v", err)
+	}
+	text, err := n.Render(SlackMessageData{
+		Alert:    Alert{Title: "PD leader changed"},
+		NameInfo: NameInfo{Name: "prod-west"},
+		Severity: "Major",
+	})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if text != "Major: PD leader changed on prod-west" {
+		t.Errorf("Render = %q", text)
+	}
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	b := newTokenBucket(1, 50*time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	if err := b.wait(ctx); err != nil {
+		t.Fatalf("first wait: %v", err)
+	}
+	if err := b.wait(ctx); err != nil {
+		t.Fatalf("second wait: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("second token after %v, want about one interval", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := b.wait(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait with an empty bucket and a cancelled context: got %v", err)
+	}
+}
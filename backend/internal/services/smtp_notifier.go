@@ -0,0 +1,268 @@
+package services
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultEmailTemplate is the HTML body of alert emails
+const DefaultEmailTemplate = `<html><body style="font-family: sans-serif">
+<h2>[{{.Severity}}] {{.Alert.Title}}</h2>
+<table cellpadding="4">
+<tr><td><b>Cluster</b></td><td>{{if .ClusterName}}{{.ClusterName}} ({{.Alert.ClusterID}}){{else}}{{.Alert.ClusterID}}{{end}}</td></tr>
+<tr><td><b>Tenant</b></td><td>{{if .TenantName}}{{.TenantName}} ({{.Alert.TenantID}}){{else}}{{.Alert.TenantID}}{{end}}</td></tr>
+<tr><td><b>Severity</b></td><td>{{.Severity}}</td></tr>
+<tr><td><b>Created</b></td><td>{{.Alert.Created}}</td></tr>
+</table>
+{{if .Labels}}<h3>Labels</h3>
+<ul>{{range .Labels}}<li><code>{{.Name}}={{.Value}}</code></li>{{end}}</ul>{{end}}
+{{if .DashboardURL}}<p><a href="{{.DashboardURL}}">View alert {{.Alert.ID}} in the dashboard</a></p>{{end}}
+</body></html>`
+
+var defaultEmailTemplate = template.Must(template.New("email").Parse(DefaultEmailTemplate))
+
+// EmailLabel is one alert label shown in an email
+type EmailLabel struct {
+	Name  string
+	Value string
+}
+
+// EmailData is the data passed to the email template
+type EmailData struct {
+	Alert        Alert
+	ClusterName  string
+	TenantName   string
+	Severity     string
+	Labels       []EmailLabel // non-empty labels sorted by name
+	DashboardURL string
+}
+
+// EmailRecipients are the addresses an alert email is sent to
+type EmailRecipients struct {
+	To  []string `json:"to"`
+	CC  []string `json:"cc"`
+	BCC []string `json:"bcc"`
+}
+
+// SMTPNotifier sends alert emails over a single persistent SMTP connection,
+// reconnecting when it fails. It is safe for concurrent use.
+type SMTPNotifier struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	TLS      bool // implicit TLS (e.g. port 465); otherwise STARTTLS is used when offered
+
+	mu     sync.Mutex
+	client *smtp.Client
+}
+
+var (
+	smtpInstance *SMTPNotifier
+	smtpOnce     sync.Once
+)
+
+// GetSMTPNotifier returns the notifier configured by the SMTP_* environment
+// variables, or nil if SMTP_HOST is not set
+func GetSMTPNotifier() *SMTPNotifier {
+	smtpOnce.Do(func() {
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return
+		}
+		port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if port == 0 {
+			port = 587
+		}
+		smtpInstance = &SMTPNotifier{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+			TLS:      os.Getenv("SMTP_TLS") == "true",
+		}
+	})
+	return smtpInstance
+}
+
+// Send renders the default template for alert and mails it to recipients
+func (n *SMTPNotifier) Send(alert Alert, recipients EmailRecipients) error {
+	if len(recipients.To) == 0 {
+		return errors.New("at least one recipient is required")
+	}
+	var body bytes.Buffer
+	if err := defaultEmailTemplate.Execute(&body, emailData(alert)); err != nil {
+		return fmt.Errorf("failed to render email: %w", err)
+	}
+	subject := fmt.Sprintf("[%s] %s", alert.Severity, alert.Title)
+	return n.sendMail(recipients, subject, body.Bytes())
+}
+
+func (n *SMTPNotifier) sendMail(recipients EmailRecipients, subject string, html []byte) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(recipients.To, ", "))
+	if len(recipients.CC) > 0 {
+		fmt.Fprintf(&msg, "Cc: %s\r\n", strings.Join(recipients.CC, ", "))
+	}
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n")
+	msg.Write(html)
+
+	// BCC recipients get the message but are not listed in the headers
+	rcpts := make([]string, 0, len(recipients.To)+len(recipients.CC)+len(recipients.BCC))
+	rcpts = append(rcpts, recipients.To...)
+	rcpts = append(rcpts, recipients.CC...)
+	rcpts = append(rcpts, recipients.BCC...)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// A pooled connection may have been closed by the server; retry once on a new one
+	err := n.deliver(rcpts, msg.Bytes())
+	if err != nil && n.client != nil {
+		n.reset()
+		err = n.deliver(rcpts, msg.Bytes())
+	}
+	if err != nil {
+		n.reset()
+	}
+	return err
+}
+
+// deliver sends one message on the persistent connection, dialling it first if needed
+func (n *SMTPNotifier) deliver(rcpts []string, msg []byte) error {
+	if n.client == nil {
+		client, err := n.dial()
+		if err != nil {
+			return err
+		}
+		n.client = client
+	}
+
+	c := n.client
+	if err := c.Reset(); err != nil {
+		return err
+	}
+	if err := c.Mail(n.From); err != nil {
+		return err
+	}
+	for _, rcpt := range rcpts {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (n *SMTPNotifier) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+	tlsConfig := &tls.Config{ServerName: n.Host}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if n.TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server %s: %w", addr, err)
+	}
+
+	c, err := smtp.NewClient(conn, n.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !n.TLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				c.Close()
+				return nil, err
+			}
+		}
+	}
+	if n.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.Username, n.Password, n.Host)); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// reset drops the persistent connection
+func (n *SMTPNotifier) reset() {
+	if n.client != nil {
+		n.client.Close()
+		n.client = nil
+	}
+}
+
+// Close ends the persistent connection
+func (n *SMTPNotifier) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.client == nil {
+		return nil
+	}
+	err := n.client.Quit()
+	n.client = nil
+	return err
+}
+
+// emailData resolves the cluster and tenant names of alert. Lookup failures
+// leave the names empty.
+func emailData(alert Alert) EmailData {
+	data := EmailData{
+		Alert:        alert,
+		Severity:     alert.Severity,
+		DashboardURL: alertDashboardURL(alert.ID),
+	}
+
+	resolver := GetNameService()
+	if alert.ClusterID != "" {
+		if info, err := resolver.Resolve(alert.ClusterID); err == nil {
+			data.ClusterName = info.Name
+			data.TenantName = info.TenantName
+		}
+	}
+	if data.TenantName == "" && alert.TenantID != "" {
+		if info, err := resolver.Resolve(alert.TenantID); err == nil {
+			data.TenantName = info.Name
+		}
+	}
+
+	for name, value := range alert.Labels {
+		if value != "" {
+			data.Labels = append(data.Labels, EmailLabel{Name: name, Value: value})
+		}
+	}
+	sort.Slice(data.Labels, func(i, j int) bool { return data.Labels[i].Name < data.Labels[j].Name })
+	return data
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultStaleAfter     = 24 * time.Hour
+	defaultReaperInterval = 10 * time.Minute
+)
+
+// StaleAlertReaperStatus summarizes the runs of a StaleAlertReaper
+type StaleAlertReaperStatus struct {
+	Running       bool       `json:"running"`
+	StaleAfter    string     `json:"stale_after"`
+	Interval      string     `json:"interval"`
+	LastRun       *time.Time `json:"last_run,omitempty"`
+	LastResolved  int        `json:"last_resolved"`
+	TotalResolved int        `json:"total_resolved"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// StaleAlertReaper periodically resolves alerts that are still unresolved
+// StaleAfter after they were created and flags them auto_resolved
+type StaleAlertReaper struct {
+	StaleAfter time.Duration
+	Interval   time.Duration
+	// Notify sends a summary of every non-empty run to the notification channels
+	Notify bool
+
+	mu            sync.Mutex
+	running       bool
+	lastRun       time.Time
+	lastResolved  int
+	totalResolved int
+	lastErr       error
+}
+
+var (
+	staleReaperInstance *StaleAlertReaper
+	staleReaperOnce     sync.Once
+)
+
+// GetStaleAlertReaper returns the shared reaper, configured from
+// STALE_ALERT_AFTER (default 24h), STALE_REAPER_INTERVAL (default 10m) and
+// STALE_REAPER_NOTIFY
+func GetStaleAlertReaper() *StaleAlertReaper {
+	staleReaperOnce.Do(func() {
+		r := &StaleAlertReaper{StaleAfter: defaultStaleAfter, Interval: defaultReaperInterval}
+		if d, err := time.ParseDuration(os.Getenv("STALE_ALERT_AFTER")); err == nil && d > 0 {
+			r.StaleAfter = d
+		}
+		if d, err := time.ParseDuration(os.Getenv("STALE_REAPER_INTERVAL")); err == nil && d > 0 {
+			r.Interval = d
+		}
+		r.Notify, _ = strconv.ParseBool(os.Getenv("STALE_REAPER_NOTIFY"))
+		staleReaperInstance = r
+	})
+	return staleReaperInstance
+}
+
+// RunOnce resolves the alerts that went stale and returns them
+func (r *StaleAlertReaper) RunOnce(db *gorm.DB) ([]models.Issue, error) {
+	cutoff := time.Now().UTC().Add(-r.StaleAfter).Format("2006-01-02 15:04:05")
+
+	var stale []models.Issue
+	err := db.Where("is_alert = 1 AND deleted_at IS NULL AND resolved_at IS NULL").
+		Where("LOWER(status) NOT IN ?", resolvedStatuses).
+		Where("REPLACE(created, ' UTC', '') < ?", cutoff).
+		Find(&stale).Error
+	if err != nil {
+		r.record(0, err)
+		return nil, err
+	}
+	if len(stale) == 0 {
+		r.record(0, nil)
+		return nil, nil
+	}
+
+	ids := make([]string, len(stale))
+	for i := range stale {
+		ids[i] = stale[i].ID
+	}
+	now := time.Now().UTC()
+	err = db.Model(&models.Issue{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":        "Resolved",
+		"resolved_at":   now,
+		"auto_resolved": true,
+	}).Error
+	if err != nil {
+		r.record(0, err)
+		return nil, err
+	}
+
+	broadcaster := GetAlertBroadcaster()
+	for i := range stale {
+		stale[i].Status = "Resolved"
+		stale[i].ResolvedAt = &now
+		stale[i].AutoResolved = true
+		log.Printf("[INFO] Auto-resolved stale alert %s (created %s)\n", stale[i].ID, stale[i].Created)
+		broadcaster.Publish(AlertEventResolved, AlertFromIssue(&stale[i]))
+	}
+	r.record(len(stale), nil)
+	return stale, nil
+}
+
+// Start runs RunOnce every Interval until ctx is cancelled
+func (r *StaleAlertReaper) Start(ctx context.Context, db *gorm.DB) {
+	r.mu.Lock()
+	r.running = true
+	r.mu.Unlock()
+
+	go func() {
+		defer func() {
+			r.mu.Lock()
+			r.running = false
+			r.mu.Unlock()
+		}()
+
+		ticker := time.NewTicker(r.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			resolved, err := r.RunOnce(db)
+			if err != nil {
+				log.Printf("[WARN] Failed to auto-resolve stale alerts: %v\n", err)
+				continue
+			}
+			if len(resolved) > 0 && r.Notify {
+				if err := GetNotificationService().NotifyAutoResolved(r.StaleAfter, resolved); err != nil {
+					log.Printf("[WARN] Failed to send auto-resolve notification: %v\n", err)
+				}
+			}
+		}
+	}()
+}
+
+func (r *StaleAlertReaper) record(resolved int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastRun = time.Now().UTC()
+	r.lastResolved = resolved
+	r.totalResolved += resolved
+	r.lastErr = err
+}
+
+// Status returns the reaper's configuration and the outcome of its runs
+func (r *StaleAlertReaper) Status() StaleAlertReaperStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := StaleAlertReaperStatus{
+		Running:       r.running,
+		StaleAfter:    r.StaleAfter.String(),
+		Interval:      r.Interval.String(),
+		LastResolved:  r.lastResolved,
+		TotalResolved: r.totalResolved,
+	}
+	if !r.lastRun.IsZero() {
+		lastRun := r.lastRun
+		status.LastRun = &lastRun
+	}
+	if r.lastErr != nil {
+		status.LastError = r.lastErr.Error()
+	}
+	return status
+}
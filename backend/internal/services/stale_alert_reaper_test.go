@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+func TestStaleAlertReaperResolves(t *testing.T) {
+	sqlite := openTestDB(t)
+	seedAlert(t, sqlite, "STALE", 25*time.Hour)
+	seedAlert(t, sqlite, "FRESH", 23*time.Hour)
+	seedAlert(t, sqlite, "CLOSED", 25*time.Hour, func(i *models.Issue) { i.Status = "Closed" })
+	seedAlert(t, sqlite, "TICKET", 25*time.Hour, func(i *models.Issue) { i.IsAlert = false })
+
+	events, unsubscribe := GetAlertBroadcaster().Subscribe(10)
+	defer unsubscribe()
+
+	r := &StaleAlertReaper{StaleAfter: 24 * time.Hour}
+	resolved, err := r.RunOnce(sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resolved) != 1 || resolved[0].ID != "STALE" {
+		t.Fatalf("resolved %+v, want STALE only", resolved)
+	}
+
+	var issues []models.Issue
+	if err := sqlite.Order("id").Find(&issues).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, issue := range issues {
+		reaped := issue.ID == "STALE"
+		if issue.AutoResolved != reaped || (issue.ResolvedAt != nil) != reaped {
+			t.Errorf("%s: auto_resolved = %v, resolved_at = %v, want reaped %v", issue.ID, issue.AutoResolved, issue.ResolvedAt, reaped)
+		}
+		if reaped && issue.Status != "Resolved" {
+			t.Errorf("%s: status = %q, want Resolved", issue.ID, issue.Status)
+		}
+	}
+
+	select {
+	case event := <-events:
+		if event.Type != AlertEventResolved || event.Alert.ID != "STALE" {
+			t.Errorf("published %s of %s, want resolved of STALE", event.Type, event.Alert.ID)
+		}
+	default:
+		t.Error("no event published for the resolved alert")
+	}
+
+	// A second run finds nothing left to resolve
+	if resolved, err := r.RunOnce(sqlite); err != nil || len(resolved) != 0 {
+		t.Errorf("second run resolved %d, %v, want none", len(resolved), err)
+	}
+	if s := r.Status(); s.LastRun == nil || s.LastResolved != 0 || s.TotalResolved != 1 || s.LastError != "" {
+		t.Errorf("status = %+v, want 1 resolved in total and none in the last run", s)
+	}
+}
+
+func TestStaleAlertReaperNotifies(t *testing.T) {
+	sqlite := openTestDB(t)
+	rcv := useEscalation(t, sqlite)
+	notifications := GetNotificationService()
+	if err := notifications.Load(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		notifications.mu.Lock()
+		notifications.channels = nil
+		notifications.mu.Unlock()
+	})
+
+	seedAlert(t, sqlite, "STALE-1", 2*time.Hour)
+	seedAlert(t, sqlite, "STALE-2", 2*time.Hour, func(i *models.Issue) { i.ClusterID = "10002" })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &StaleAlertReaper{StaleAfter: time.Hour, Interval: 10 * time.Millisecond, Notify: true}
+	r.Start(ctx, sqlite)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(rcv.received()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Let later runs, which resolve nothing, go by
+	time.Sleep(50 * time.Millisecond)
+
+	received := rcv.received()
+	if len(received) != 1 || !strings.HasPrefix(received[0], "stale-alerts-") {
+		t.Fatalf("notified %v, want a single stale alerts summary", received)
+	}
+	if s := r.Status(); !s.Running || s.TotalResolved != 2 {
+		t.Errorf("status = %+v, want running with 2 resolved", s)
+	}
+}
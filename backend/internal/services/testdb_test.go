@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+	"github.com/nolouch/alerts-platform-v2/internal/db/migrations"
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB opens a migrated SQLite database in a temporary directory and
+// installs it as db.DB for the duration of the test
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	sqliteDB, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "alerts.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := migrations.Up(sqliteDB); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	prev := db.DB
+	db.DB = sqliteDB
+	t.Cleanup(func() {
+		db.DB = prev
+		if sqlDB, err := sqliteDB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return sqliteDB
+}
+
+// seedAlert inserts an open alert created ago before now into sqlite, after
+// applying edits to it
+func seedAlert(t *testing.T, sqlite *gorm.DB, id string, ago time.Duration, edits ...func(*models.Issue)) models.Issue {
+	t.Helper()
+	issue := models.Issue{
+		ID:             id,
+		Title:          "alert " + id,
+		Created:        time.Now().UTC().Add(-ago).Format("2006-01-02 15:04:05") + " UTC",
+		Priority:       "Critical",
+		IsAlert:        true,
+		AlertSignature: "TiKVDown",
+		ClusterID:      "10001",
+		Status:         "Created",
+		Labels:         "[]",
+	}
+	for _, edit := range edits {
+		edit(&issue)
+	}
+	if err := sqlite.Create(&issue).Error; err != nil {
+		t.Fatalf("seed alert %s: %v", id, err)
+	}
+	return issue
+}
+
+// stubNames is a NameService resolving the names it holds and failing for
+// any other ID
+type stubNames map[string]NameInfo
+
+func (s stubNames) Resolve(id string) (NameInfo, error) {
+	if info, ok := s[id]; ok {
+		return info, nil
+	}
+	return NameInfo{ID: id, Name: id}, ErrIDNotFound
+}
+
+func (s stubNames) ResolveContext(_ context.Context, id string) (NameInfo, error) {
+	return s.Resolve(id)
+}
+
+func (s stubNames) ResolveBatch(ids []string) (map[string]NameInfo, []error) {
+	results := make(map[string]NameInfo, len(ids))
+	for _, id := range ids {
+		results[id], _ = s.Resolve(id)
+	}
+	return results, nil
+}
+
+// useNameService installs svc as the NameService for the duration of the test
+func useNameService(t *testing.T, svc NameService) {
+	t.Helper()
+	SetNameService(svc)
+	t.Cleanup(func() { SetNameService(nil) })
+}
+
+// useNameResolver returns the shared NameResolver, without preloading and
+// with an empty cache, and installs it as the NameService for the duration of
+// the test
+func useNameResolver(t *testing.T) *NameResolver {
+	t.Helper()
+	t.Setenv("NAME_SERVICE_PRELOAD", "false")
+	nr := GetNameResolver()
+	nr.ClearCache()
+	t.Cleanup(nr.ClearCache)
+	useNameService(t, nr)
+	return nr
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/nolouch/alerts-platform-v2/internal/db"
+)
+
+// The test TiDB is SQLite behind a driver that fails the queries registered
+// with failTiDBQueries and slows down those registered with delayTiDBQueries,
+// so tests can replay MySQL errors such as 1146 and overlapping lookups
+var (
+	registerTestTiDB sync.Once
+	tidbFailuresMu   sync.Mutex
+	tidbFailures     = map[string]error{}         // query substring -> error
+	tidbDelays       = map[string]time.Duration{} // query substring -> time to prepare
+	tidbQueries      []string                     // every statement prepared, in order
+)
+
+const testTiDBSchema = `
+CREATE TABLE tenants (
+	tenant_id TEXT PRIMARY KEY,
+	tenant_name TEXT,
+	kind TEXT DEFAULT 'tenant',
+	parent_tenant_id TEXT,
+	created_at DATETIME DEFAULT '2026-01-01 00:00:00',
+	updated_at DATETIME DEFAULT '2026-01-01 00:00:00'
+);
+CREATE TABLE clusters (
+	cluster_id TEXT PRIMARY KEY,
+	cluster_name TEXT,
+	tenant_id TEXT,
+	tenant_name TEXT,
+	deploy_type TEXT,
+	version TEXT,
+	cluster_lifecycle TEXT,
+	creation_duration TEXT,
+	tenant_plan TEXT,
+	provider TEXT,
+	region TEXT,
+	project_id TEXT,
+	org_id TEXT,
+	cluster_type TEXT,
+	created_at DATETIME DEFAULT '2026-01-01 00:00:00',
+	updated_at DATETIME DEFAULT '2026-01-01 00:00:00'
+);
+`
+
+// slowTiDBDelay is how long SELECTs of a slow test TiDB take to prepare,
+// longer than primaryQueryTimeout
+const slowTiDBDelay = 2 * time.Second
+
+type testTiDBDriver struct {
+	sqlite3.SQLiteDriver
+	delay time.Duration
+}
+
+func (d *testTiDBDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &testTiDBConn{Conn: conn, delay: d.delay}, nil
+}
+
+// testTiDBConn only implements Prepare and PrepareContext, so every query
+// goes through them
+type testTiDBConn struct {
+	driver.Conn
+	delay time.Duration
+}
+
+func (c *testTiDBConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if c.delay > 0 && strings.HasPrefix(strings.TrimSpace(query), "SELECT") {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.delay):
+		}
+	}
+	return c.Prepare(query)
+}
+
+func (c *testTiDBConn) Prepare(query string) (driver.Stmt, error) {
+	tidbFailuresMu.Lock()
+	tidbQueries = append(tidbQueries, query)
+	for substr, err := range tidbFailures {
+		if strings.Contains(query, substr) {
+			tidbFailuresMu.Unlock()
+			return nil, err
+		}
+	}
+	var delay time.Duration
+	for substr, d := range tidbDelays {
+		if strings.Contains(query, substr) {
+			delay = d
+		}
+	}
+	tidbFailuresMu.Unlock()
+	time.Sleep(delay)
+	return c.Conn.Prepare(query)
+}
+
+// openTestTiDB opens an empty clusters and tenants schema and installs it as
+// the name service's TiDB for the duration of the test
+func openTestTiDB(t testing.TB) *sql.DB {
+	t.Helper()
+	conn := openTestTiDBConn(t, "sqlite3_tidb")
+	prev, prevReady := db.TiDB, db.TiDBReady()
+	db.SetTiDB(conn)
+	t.Cleanup(func() {
+		if prevReady {
+			db.SetTiDB(prev)
+		} else {
+			db.SetTiDB(nil)
+		}
+		tidbFailuresMu.Lock()
+		clear(tidbFailures)
+		clear(tidbDelays)
+		tidbQueries = nil
+		tidbFailuresMu.Unlock()
+	})
+	return conn
+}
+
+// openSlowTestTiDB is openTestTiDB for a primary whose queries all take
+// slowTiDBDelay
+func openSlowTestTiDB(t testing.TB) *sql.DB {
+	t.Helper()
+	openTestTiDB(t)
+	slow := openTestTiDBConn(t, "sqlite3_tidb_slow")
+	db.SetTiDB(slow)
+	return slow
+}
+
+// openTestReplica opens an empty schema and installs it as db.TiDBReplica for
+// the duration of the test
+func openTestReplica(t testing.TB) *sql.DB {
+	t.Helper()
+	conn := openTestTiDBConn(t, "sqlite3_tidb")
+	prev := db.TiDBReplica
+	db.TiDBReplica = conn
+	t.Cleanup(func() { db.TiDBReplica = prev })
+	return conn
+}
+
+func openTestTiDBConn(t testing.TB, driverName string) *sql.DB {
+	t.Helper()
+	registerTestTiDB.Do(func() {
+		sql.Register("sqlite3_tidb", &testTiDBDriver{})
+		sql.Register("sqlite3_tidb_slow", &testTiDBDriver{delay: slowTiDBDelay})
+	})
+
+	conn, err := sql.Open(driverName, filepath.Join(t.TempDir(), "tidb.db"))
+	if err != nil {
+		t.Fatalf("open test TiDB: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	for _, stmt := range strings.Split(testTiDBSchema, ";") {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("create test TiDB schema: %v", err)
+		}
+	}
+	return conn
+}
+
+// failTiDBQueries makes every statement containing substr fail with err
+func failTiDBQueries(substr string, err error) {
+	tidbFailuresMu.Lock()
+	tidbFailures[substr] = err
+	tidbFailuresMu.Unlock()
+}
+
+// delayTiDBQueries makes every statement containing substr take d to prepare
+func delayTiDBQueries(substr string, d time.Duration) {
+	tidbFailuresMu.Lock()
+	tidbDelays[substr] = d
+	tidbFailuresMu.Unlock()
+}
+
+// countTiDBQueries returns how many statements containing substr were prepared
+func countTiDBQueries(substr string) int {
+	tidbFailuresMu.Lock()
+	defer tidbFailuresMu.Unlock()
+	n := 0
+	for _, q := range tidbQueries {
+		if strings.Contains(q, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+// seedTestTiDB adds tenant 1001 "acme" and its dedicated cluster 2001 "prod-east"
+func seedTestTiDB(t testing.TB, conn *sql.DB) {
+	t.Helper()
+	for _, q := range []string{
+		`INSERT INTO tenants (tenant_id, tenant_name) VALUES ('1001', 'acme')`,
+		`INSERT INTO clusters (cluster_id, cluster_name, tenant_id, deploy_type) VALUES ('2001', 'prod-east', '1001', 'dedicated')`,
+	} {
+		if _, err := conn.Exec(q); err != nil {
+			t.Fatalf("seed test TiDB: %v", err)
+		}
+	}
+}
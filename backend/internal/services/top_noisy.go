@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrUnsupportedNoisyGroup is returned by TopNoisyAlerts for groupings other
+// than alertname, fingerprint and cluster_id
+var ErrUnsupportedNoisyGroup = errors.New("unsupported group_by, expected alertname, fingerprint or cluster_id")
+
+// noisyGroupColumns maps the supported groupings to their issues column
+var noisyGroupColumns = map[string]string{
+	"alertname":   "alert_signature",
+	"fingerprint": "fingerprint",
+	"cluster_id":  "cluster_id",
+}
+
+// NoisyQuery selects the page of groups returned by TopNoisyAlerts
+type NoisyQuery struct {
+	Window  time.Duration
+	GroupBy string // alertname, fingerprint or cluster_id
+	Limit   int
+	Offset  int
+
+	TenantID string // only alerts of this tenant, when set
+}
+
+// NoisyAlert is one group of alerts ranked by TopNoisyAlerts. The alert and
+// cluster fields describe the most recent alert of the group.
+type NoisyAlert struct {
+	Key           string  `json:"key"`
+	Occurrences   int64   `json:"occurrences"` // sum of occurrence_count, so folded repeats are counted
+	Alerts        int64   `json:"alerts"`      // stored alert rows
+	Percentage    float64 `json:"percentage"`  // share of all occurrences in the window
+	LatestAlertID string  `json:"latest_alert_id"`
+	LatestCreated string  `json:"latest_created"`
+	AlertName     string  `json:"alertname"`
+	ClusterID     string  `json:"cluster_id"`
+	ClusterName   string  `json:"cluster_name"`
+	TenantID      string  `json:"tenant_id"`
+	TenantName    string  `json:"tenant_name"`
+}
+
+// NoisyResult is a page of TopNoisyAlerts with the totals over all groups
+type NoisyResult struct {
+	Groups           []NoisyAlert `json:"groups"`
+	TotalGroups      int64        `json:"total_groups"`
+	TotalOccurrences int64        `json:"total_occurrences"`
+}
+
+// TopNoisyAlerts ranks the alert groups of the window ending now by their
+// summed occurrence count, noisiest first. Totals and the most recent alert of
+// each group come from window functions in the same query, so the page and the
+// percentages are computed over one consistent snapshot. Alerts without a
+// value for the grouping column are left out.
+func TopNoisyAlerts(db *gorm.DB, q NoisyQuery) (*NoisyResult, error) {
+	column, ok := noisyGroupColumns[q.GroupBy]
+	if !ok {
+		return nil, ErrUnsupportedNoisyGroup
+	}
+	start := time.Now().UTC().Add(-q.Window).Format("2006-01-02 15:04:05")
+
+	var rows []struct {
+		GroupKey         string
+		Occurrences      int64
+		Alerts           int64
+		ID               string
+		Created          string
+		AlertSignature   string
+		ClusterID        string
+		TenantID         string
+		TotalGroups      int64
+		TotalOccurrences int64
+	}
+	err := db.Raw(`
+		WITH ranked AS (
+			SELECT `+column+` AS group_key, id, created, alert_signature, cluster_id, tenant_id,
+				SUM(COALESCE(occurrence_count, 1)) OVER (PARTITION BY `+column+`) AS occurrences,
+				COUNT(*) OVER (PARTITION BY `+column+`) AS alerts,
+				ROW_NUMBER() OVER (PARTITION BY `+column+` ORDER BY REPLACE(created, ' UTC', '') DESC, id DESC) AS rn
+			FROM issues
+			WHERE is_alert = 1 AND deleted_at IS NULL AND `+column+` != '' AND `+column+` IS NOT NULL
+				AND REPLACE(created, ' UTC', '') >= ? AND (? = '' OR tenant_id = ?)
+		)
+		SELECT group_key, occurrences, alerts, id, created, alert_signature, cluster_id, tenant_id,
+			COUNT(*) OVER () AS total_groups, SUM(occurrences) OVER () AS total_occurrences
+		FROM ranked
+		WHERE rn = 1
+		ORDER BY occurrences DESC, group_key
+		LIMIT ? OFFSET ?
+	`, start, q.TenantID, q.TenantID, q.Limit, q.Offset).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := &NoisyResult{Groups: make([]NoisyAlert, 0, len(rows))}
+	resolver := GetNameResolver()
+	for _, row := range rows {
+		result.TotalGroups, result.TotalOccurrences = row.TotalGroups, row.TotalOccurrences
+		group := NoisyAlert{
+			Key:           row.GroupKey,
+			Occurrences:   row.Occurrences,
+			Alerts:        row.Alerts,
+			LatestAlertID: row.ID,
+			LatestCreated: row.Created,
+			AlertName:     row.AlertSignature,
+			ClusterID:     row.ClusterID,
+			ClusterName:   row.ClusterID,
+			TenantID:      row.TenantID,
+			TenantName:    row.TenantID,
+		}
+		if row.TotalOccurrences > 0 {
+			group.Percentage = math.Round(float64(row.Occurrences)*10000/float64(row.TotalOccurrences)) / 100
+		}
+		if row.ClusterID != "" {
+			if info, err := resolver.ResolveCluster(row.ClusterID); err == nil {
+				group.ClusterName = info.ClusterName
+			}
+		}
+		if row.TenantID != "" {
+			if info, err := resolver.ResolveTenant(row.TenantID); err == nil {
+				group.TenantName = info.TenantName
+			}
+		}
+		result.Groups = append(result.Groups, group)
+	}
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nolouch/alerts-platform-v2/internal/models"
+)
+
+func TestTopNoisyAlerts(t *testing.T) {
+	seedTestTiDB(t, openTestTiDB(t))
+	useNameResolver(t)
+	sqliteDB := openTestDB(t)
+
+	now := time.Now().UTC()
+	deleted := now
+	for _, issue := range []models.Issue{
+		{ID: "A-1", AlertSignature: "TiKVDown", Fingerprint: "fp1", ClusterID: "2001", TenantID: "1001", OccurrenceCount: 5},
+		{ID: "A-2", AlertSignature: "TiKVDown", Fingerprint: "fp1", ClusterID: "2002", TenantID: "1001", OccurrenceCount: 3},
+		{ID: "A-3", AlertSignature: "PDLeader", Fingerprint: "fp2", ClusterID: "2001", TenantID: "1001", OccurrenceCount: 1},
+		{ID: "A-4", AlertSignature: "PDLeader", ClusterID: "2001", TenantID: "1001", OccurrenceCount: 1},
+		{ID: "A-6", ClusterID: "2002", TenantID: "1002", OccurrenceCount: 2},
+		// Outside the window, deleted or not an alert
+		{ID: "A-5", AlertSignature: "DiskFull", ClusterID: "2002", TenantID: "1002", OccurrenceCount: 10},
+		{ID: "A-7", AlertSignature: "DiskFull", ClusterID: "2002", TenantID: "1002", OccurrenceCount: 10, DeletedAt: &deleted},
+		{ID: "T-1", AlertSignature: "DiskFull", ClusterID: "2002", TenantID: "1002", OccurrenceCount: 10},
+	} {
+		ago := map[string]time.Duration{"A-1": time.Hour, "A-2": 2 * time.Hour, "A-3": 30 * time.Minute, "A-4": 3 * time.Hour, "A-5": 8 * 24 * time.Hour}[issue.ID]
+		if ago == 0 {
+			ago = time.Hour
+		}
+		issue.Created = now.Add(-ago).Format("2006-01-02 15:04:05") + " UTC"
+		issue.IsAlert = issue.ID != "T-1"
+		if err := sqliteDB.Create(&issue).Error; err != nil {
+			t.Fatalf("seed %s: %v", issue.ID, err)
+		}
+	}
+
+	type group struct {
+		key, latest, clusterName, tenantName string
+		occurrences, alerts                  int64
+		percentage                           float64
+	}
+	check := func(q NoisyQuery, totalGroups, totalOccurrences int64, want ...group) {
+		t.Helper()
+		q.Window = 7 * 24 * time.Hour
+		result, err := TopNoisyAlerts(sqliteDB, q)
+		if err != nil {
+			t.Fatalf("TopNoisyAlerts(%+v): %v", q, err)
+		}
+		if result.TotalGroups != totalGroups || result.TotalOccurrences != totalOccurrences {
+			t.Errorf("%+v: totals %d groups, %d occurrences, want %d and %d", q, result.TotalGroups, result.TotalOccurrences, totalGroups, totalOccurrences)
+		}
+		if len(result.Groups) != len(want) {
+			t.Fatalf("%+v: groups = %+v, want %d", q, result.Groups, len(want))
+		}
+		for i, w := range want {
+			g := result.Groups[i]
+			got := group{g.Key, g.LatestAlertID, g.ClusterName, g.TenantName, g.Occurrences, g.Alerts, g.Percentage}
+			if got != w {
+				t.Errorf("%+v: group %d = %+v, want %+v", q, i, got, w)
+			}
+		}
+	}
+
+	// Names are those of the most recent alert of the group; 2002 and 1002
+	// are unknown and keep their IDs
+	check(NoisyQuery{GroupBy: "alertname", Limit: 10}, 2, 10,
+		group{"TiKVDown", "A-1", "prod-east", "acme", 8, 2, 80},
+		group{"PDLeader", "A-3", "prod-east", "acme", 2, 2, 20},
+	)
+	check(NoisyQuery{GroupBy: "cluster_id", Limit: 10}, 2, 12,
+		group{"2001", "A-3", "prod-east", "acme", 7, 3, 58.33},
+		group{"2002", "A-6", "2002", "1002", 5, 2, 41.67},
+	)
+	check(NoisyQuery{GroupBy: "fingerprint", Limit: 10}, 2, 9,
+		group{"fp1", "A-1", "prod-east", "acme", 8, 2, 88.89},
+		group{"fp2", "A-3", "prod-east", "acme", 1, 1, 11.11},
+	)
+
+	// Pages keep the totals of every group
+	check(NoisyQuery{GroupBy: "alertname", Limit: 1, Offset: 1}, 2, 10,
+		group{"PDLeader", "A-3", "prod-east", "acme", 2, 2, 20},
+	)
+	check(NoisyQuery{GroupBy: "cluster_id", Limit: 10, TenantID: "1002"}, 1, 2,
+		group{"2002", "A-6", "2002", "1002", 2, 1, 100},
+	)
+
+	if _, err := TopNoisyAlerts(sqliteDB, NoisyQuery{GroupBy: "tenant_id", Limit: 10}); !errors.Is(err, ErrUnsupportedNoisyGroup) {
+		t.Errorf("group_by=tenant_id error = %v, want ErrUnsupportedNoisyGroup", err)
+	}
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Suppression reasons stored in issues.suppression_reason
+const (
+	SuppressionLifecycle     = "lifecycle"
+	SuppressionVersionFilter = "version_filter"
+)
+
+// VersionFilter suppresses the alerts whose alertname matches AlertName on
+// clusters running a version in [MinVersion, MaxVersion). Either bound may be
+// empty to leave the range open on that side.
+type VersionFilter struct {
+	AlertName  string `yaml:"alert_name" json:"alert_name"`
+	MinVersion string `yaml:"min_version,omitempty" json:"min_version,omitempty"`
+	MaxVersion string `yaml:"max_version,omitempty" json:"max_version,omitempty"`
+	Comment    string `yaml:"comment,omitempty" json:"comment,omitempty"`
+
+	re       *regexp.Regexp
+	min, max *semver
+}
+
+// VersionFilters is the set of version filters applied on ingestion
+type VersionFilters struct {
+	Filters []VersionFilter `yaml:"filters" json:"filters"`
+	Path    string          `yaml:"-" json:"path,omitempty"`
+
+	Resolver *NameResolver `yaml:"-" json:"-"`
+}
+
+// NewVersionFilters validates and compiles filters
+func NewVersionFilters(filters []VersionFilter, resolver *NameResolver) (*VersionFilters, error) {
+	f := &VersionFilters{Filters: make([]VersionFilter, len(filters)), Resolver: resolver}
+	for i, filter := range filters {
+		if filter.AlertName == "" {
+			return nil, fmt.Errorf("filter %d: alert_name is required", i+1)
+		}
+		if filter.MinVersion == "" && filter.MaxVersion == "" {
+			return nil, fmt.Errorf("filter %d: min_version or max_version is required", i+1)
+		}
+		re, err := regexp.Compile(filter.AlertName)
+		if err != nil {
+			return nil, fmt.Errorf("filter %d: invalid alert_name pattern %q: %w", i+1, filter.AlertName, err)
+		}
+		filter.re = re
+		if filter.MinVersion != "" {
+			if filter.min, err = parseSemver(filter.MinVersion); err != nil {
+				return nil, fmt.Errorf("filter %d: invalid min_version: %w", i+1, err)
+			}
+		}
+		if filter.MaxVersion != "" {
+			if filter.max, err = parseSemver(filter.MaxVersion); err != nil {
+				return nil, fmt.Errorf("filter %d: invalid max_version: %w", i+1, err)
+			}
+		}
+		if filter.min != nil && filter.max != nil && filter.min.compare(filter.max) >= 0 {
+			return nil, fmt.Errorf("filter %d: min_version %s is not below max_version %s", i+1, filter.MinVersion, filter.MaxVersion)
+		}
+		f.Filters[i] = filter
+	}
+	return f, nil
+}
+
+// LoadVersionFiltersFile reads filters from a YAML file of the form
+//
+//	filters:
+//	  - alert_name: "^TiKV_raftstore_thread_cpu_seconds_total$"
+//	    min_version: v7.1.0
+//	    max_version: v7.1.2
+func LoadVersionFiltersFile(path string, resolver *NameResolver) (*VersionFilters, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version filter config: %w", err)
+	}
+	var config VersionFilters
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse version filter config %s: %w", path, err)
+	}
+	filters, err := NewVersionFilters(config.Filters, resolver)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version filter config %s: %w", path, err)
+	}
+	filters.Path = path
+	return filters, nil
+}
+
+// Matches reports whether a filter applies to alertName on a cluster running
+// version
+func (f *VersionFilters) Matches(alertName, version string) bool {
+	if f == nil || len(f.Filters) == 0 || alertName == "" {
+		return false
+	}
+	v, err := parseSemver(version)
+	if err != nil {
+		return false
+	}
+	for _, filter := range f.Filters {
+		if !filter.re.MatchString(alertName) {
+			continue
+		}
+		if filter.min != nil && v.compare(filter.min) < 0 {
+			continue
+		}
+		if filter.max != nil && v.compare(filter.max) >= 0 {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// ShouldSuppress reports whether alertName on clusterID is a known false
+// positive for the cluster's version. Clusters that cannot be resolved or have
+// no version never are.
+func (f *VersionFilters) ShouldSuppress(clusterID, alertName string) bool {
+	if f == nil || len(f.Filters) == 0 || clusterID == "" || f.Resolver == nil {
+		return false
+	}
+	info, err := f.Resolver.ResolveCluster(clusterID)
+	if err != nil || info.Version == "" {
+		return false
+	}
+	return f.Matches(alertName, info.Version)
+}
+
+// semver is a parsed major.minor.patch[-prerelease] version
+type semver struct {
+	parts      [3]int
+	prerelease string
+}
+
+// parseSemver parses versions like v7.5.1, 8.1.0-alpha or 6.5; build metadata
+// is ignored and missing parts are 0
+func parseSemver(s string) (*semver, error) {
+	raw := s
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	v := &semver{}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		s, v.prerelease = s[:i], s[i+1:]
+	}
+	fields := strings.Split(s, ".")
+	if len(fields) > 3 || s == "" {
+		return nil, fmt.Errorf("invalid version %q", raw)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", raw)
+		}
+		v.parts[i] = n
+	}
+	return v, nil
+}
+
+// compare returns -1, 0 or 1. A prerelease sorts before its release.
+func (v *semver) compare(o *semver) int {
+	for i := range v.parts {
+		if v.parts[i] != o.parts[i] {
+			if v.parts[i] < o.parts[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case v.prerelease == o.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case o.prerelease == "":
+		return -1
+	}
+	return strings.Compare(v.prerelease, o.prerelease)
+}
+
+var (
+	versionFiltersOnce     sync.Once
+	versionFiltersInstance atomic.Pointer[VersionFilters]
+)
+
+// GetVersionFilters returns the filters configured by the YAML file in
+// VERSION_FILTER_CONFIG, or config/version_filters.yaml if present. Without a
+// config no alert is suppressed by version.
+func GetVersionFilters() *VersionFilters {
+	versionFiltersOnce.Do(func() {
+		filters, err := loadVersionFilters()
+		if err != nil {
+			log.Printf("⚠️  %v", err)
+		} else if filters.Path != "" {
+			log.Printf("✅ Loaded %d version filters from %s", len(filters.Filters), filters.Path)
+		}
+		if filters == nil {
+			filters = &VersionFilters{Resolver: GetNameResolver()}
+		}
+		versionFiltersInstance.Store(filters)
+	})
+	return versionFiltersInstance.Load()
+}
+
+// ReloadVersionFilters reads the version filter config again and swaps it in.
+// The current filters are kept when the config is invalid.
+func ReloadVersionFilters() (*VersionFilters, error) {
+	GetVersionFilters()
+	filters, err := loadVersionFilters()
+	if err != nil {
+		return versionFiltersInstance.Load(), err
+	}
+	versionFiltersInstance.Store(filters)
+	if filters.Path != "" {
+		log.Printf("✅ Reloaded %d version filters from %s", len(filters.Filters), filters.Path)
+	}
+	return filters, nil
+}
+
+// loadVersionFilters returns empty filters without error when no config exists
+func loadVersionFilters() (*VersionFilters, error) {
+	paths := []string{"../config/version_filters.yaml", "../../config/version_filters.yaml", "config/version_filters.yaml"}
+	if p := os.Getenv("VERSION_FILTER_CONFIG"); p != "" {
+		paths = []string{p}
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		return LoadVersionFiltersFile(path, GetNameResolver())
+	}
+	return &VersionFilters{Resolver: GetNameResolver()}, nil
+}
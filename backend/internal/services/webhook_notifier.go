@@ -0,0 +1,108 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Alert is the payload WebhookNotifier sends for a new alert
+type Alert struct {
+	ID        string            `json:"id"`
+	Title     string            `json:"title"`
+	Severity  string            `json:"severity"`
+	Status    string            `json:"status"`
+	ClusterID string            `json:"cluster_id"`
+	TenantID  string            `json:"tenant_id"`
+	Created   string            `json:"created"`
+	Labels    map[string]string `json:"labels"`
+}
+
+// WebhookNotifier sends alerts to an external URL as JSON
+type WebhookNotifier struct {
+	URL        string
+	Method     string // defaults to POST
+	Headers    map[string]string
+	MaxRetries int           // retries after the first attempt, at most MaxWebhookRetries
+	Timeout    time.Duration // per attempt, defaults to 10s
+
+	Client *http.Client
+	sleep  func(time.Duration) // waits between attempts, time.Sleep when nil
+}
+
+const (
+	// MaxWebhookRetries bounds the retries of a webhook channel
+	MaxWebhookRetries = 5
+
+	// webhookInitialBackoff is the wait before the first retry; it doubles
+	// after each attempt up to webhookMaxBackoff
+	webhookInitialBackoff = 500 * time.Millisecond
+	webhookMaxBackoff     = 5 * time.Second
+)
+
+// Send delivers alert, retrying failed attempts and non-2xx responses with
+// capped exponential backoff
+func (w *WebhookNotifier) Send(alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	sleep := w.sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+	retries := min(w.MaxRetries, MaxWebhookRetries)
+	backoff := webhookInitialBackoff
+	for attempt := 0; ; attempt++ {
+		err = w.send(body)
+		if err == nil {
+			return nil
+		}
+		if attempt >= retries {
+			return fmt.Errorf("webhook %s failed after %d attempts: %w", w.URL, attempt+1, err)
+		}
+		sleep(backoff)
+		backoff = min(backoff*2, webhookMaxBackoff)
+	}
+}
+
+func (w *WebhookNotifier) send(body []byte) error {
+	method := w.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
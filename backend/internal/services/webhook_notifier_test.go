@@ -0,0 +1,68 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookRetriesAreCapped(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	var waits []time.Duration
+	w := &WebhookNotifier{
+		URL:        srv.URL,
+		MaxRetries: 1000,
+		sleep:      func(d time.Duration) { waits = append(waits, d) },
+	}
+	if err := w.Send(TestAlert()); err == nil {
+		t.Fatal("Send to a failing webhook succeeded")
+	}
+
+	if got := attempts.Load(); got != MaxWebhookRetries+1 {
+		t.Errorf("made %d attempts, want %d", got, MaxWebhookRetries+1)
+	}
+	want := []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	if len(waits) != len(want) {
+		t.Fatalf("waits = %v, want %v", waits, want)
+	}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Errorf("wait %d = %v, want %v", i, waits[i], want[i])
+		}
+	}
+}
+
+func TestWebhookSucceedsAfterRetry(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+	}))
+	defer srv.Close()
+
+	w := &WebhookNotifier{
+		URL:        srv.URL,
+		Headers:    map[string]string{"Authorization": "Bearer secret"},
+		MaxRetries: 3,
+		sleep:      func(time.Duration) {},
+	}
+	if err := w.Send(TestAlert()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("made %d attempts, want 3", got)
+	}
+}
@@ -0,0 +1,13 @@
+-- Projects table read by the name service (NameResolver) in TiDB.
+-- Project IDs are resolved after clusters and tenants; the table is optional
+-- and lookups are skipped when it does not exist.
+CREATE TABLE IF NOT EXISTS projects (
+    project_id   VARCHAR(64)  NOT NULL,
+    project_name VARCHAR(255) NOT NULL DEFAULT '',
+    org_id       VARCHAR(64)  NOT NULL DEFAULT '',
+    created_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (project_id),
+    KEY idx_projects_org_id (org_id),
+    KEY idx_projects_updated_at (updated_at)
+);
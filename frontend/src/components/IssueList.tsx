@@ -2,7 +2,7 @@ import { useQuery } from '@tanstack/react-query';
 import axios from 'axios';
 import { API_BASE_URL } from '../config/api';
 import { clsx } from 'clsx';
-import { ExternalLink, Clock, AlertCircle, Info } from 'lucide-react';
+import { ExternalLink, Clock, AlertCircle, Info, CheckCircle2 } from 'lucide-react';
 
 interface IssueListProps {
     title: string;
@@ -25,6 +25,7 @@ interface Issue {
     alert_signature: string;
     components: string;
     tenant_id?: string;
+    acknowledged?: boolean;
 }
 
 export const IssueList = ({
@@ -103,7 +104,10 @@ export const IssueList = ({
                                 </thead>
                                 <tbody className="divide-y divide-gray-100 bg-white">
                                     {issuesData.map((issue) => (
-                                        <tr key={issue.id} className="hover:bg-blue-50/50 transition-colors">
+                                        <tr key={issue.id} className={clsx(
+                                            "hover:bg-blue-50/50 transition-colors",
+                                            issue.acknowledged && "bg-gray-50 text-gray-500"
+                                        )}>
                                             <td className="px-4 py-3 font-medium">
                                                 <a
                                                     href={`https://tidb.atlassian.net/browse/${issue.id}`}
@@ -134,6 +138,12 @@ export const IssueList = ({
                                                 )}>
                                                     {issue.status}
                                                 </span>
+                                                {issue.acknowledged && (
+                                                    <span className="ml-1.5 inline-flex items-center gap-1 px-2 py-0.5 rounded text-xs font-medium border bg-emerald-50 text-emerald-700 border-emerald-200">
+                                                        <CheckCircle2 className="w-3 h-3" />
+                                                        Ack
+                                                    </span>
+                                                )}
                                             </td>
                                             <td className="px-4 py-3">
                                                 <div className="flex flex-col gap-0.5">